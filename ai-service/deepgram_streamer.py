@@ -30,7 +30,8 @@ class DeepgramStreamer:
         room_name: str,
         speaker_name: str,
         on_transcript: Optional[Callable[[str, str, bool], None]] = None,
-        api_key: Optional[str] = None
+        api_key: Optional[str] = None,
+        language: str = "en"
     ):
         """
         Initialize Deepgram streamer.
@@ -40,10 +41,12 @@ class DeepgramStreamer:
             speaker_name: Speaker name for transcript attribution
             on_transcript: Callback(speaker, text, is_final) for transcript events
             api_key: Deepgram API key (defaults to env var)
+            language: Transcription language code
         """
         self.room_name = room_name
         self.speaker_name = speaker_name
         self.on_transcript = on_transcript
+        self.language = language
         self.api_key = api_key or os.getenv("DEEPGRAM_API_KEY")
 
         if not self.api_key:
@@ -67,7 +70,7 @@ class DeepgramStreamer:
             # Configure live transcription options
             options = LiveOptions(
                 model="nova-2",
-                language="en",
+                language=self.language,
                 smart_format=True,
                 punctuate=True,
                 interim_results=True,
@@ -200,10 +203,12 @@ class DeepgramStreamerManager:
     def __init__(
         self,
         room_name: str,
-        on_transcript: Optional[Callable[[str, str, bool], None]] = None
+        on_transcript: Optional[Callable[[str, str, bool], None]] = None,
+        language: str = "en"
     ):
         self.room_name = room_name
         self.on_transcript = on_transcript
+        self.language = language
         self._streamers: dict[str, DeepgramStreamer] = {}
         self._lock = asyncio.Lock()
 
@@ -214,7 +219,8 @@ class DeepgramStreamerManager:
                 streamer = DeepgramStreamer(
                     room_name=self.room_name,
                     speaker_name=speaker_name,
-                    on_transcript=self.on_transcript
+                    on_transcript=self.on_transcript,
+                    language=self.language
                 )
                 if await streamer.connect():
                     self._streamers[speaker_id] = streamer
@@ -49,7 +49,9 @@ class TranscriptionAgent:
     def __init__(
         self,
         room_name: str,
-        on_transcript_broadcast: Optional[Callable[[str, dict], None]] = None
+        on_transcript_broadcast: Optional[Callable[[str, dict], None]] = None,
+        language: str = "en",
+        on_speakers_changed: Optional[Callable[[str, list], None]] = None
     ):
         """
         Initialize transcription agent.
@@ -57,9 +59,13 @@ class TranscriptionAgent:
         Args:
             room_name: LiveKit room to join
             on_transcript_broadcast: Callback(room_name, transcript_dict) for broadcasting
+            language: Transcription language code passed to Deepgram
+            on_speakers_changed: Callback(room_name, speakers) with the current active speakers
         """
         self.room_name = room_name
+        self.language = language
         self.on_transcript_broadcast = on_transcript_broadcast
+        self.on_speakers_changed = on_speakers_changed
 
         self._room: Optional[rtc.Room] = None
         self._deepgram_manager: Optional[DeepgramStreamerManager] = None
@@ -88,12 +94,14 @@ class TranscriptionAgent:
             self._room.on("participant_disconnected", self._on_participant_disconnected)
             self._room.on("track_subscribed", self._on_track_subscribed)
             self._room.on("track_unsubscribed", self._on_track_unsubscribed)
+            self._room.on("active_speakers_changed", self._on_active_speakers_changed)
             self._room.on("disconnected", self._on_disconnected)
 
             # Initialize Deepgram manager
             self._deepgram_manager = DeepgramStreamerManager(
                 room_name=self.room_name,
-                on_transcript=self._handle_transcript
+                on_transcript=self._handle_transcript,
+                language=self.language
             )
 
             # Connect to room
@@ -290,6 +298,17 @@ class TranscriptionAgent:
         """Called when unsubscribed from a track."""
         logger.debug(f"[{self.room_name}] Track unsubscribed from {participant.identity}")
 
+    def _on_active_speakers_changed(self, speakers: list[rtc.Participant]):
+        """Called when LiveKit reports a change in who is speaking."""
+        if not self.on_speakers_changed:
+            return
+        active = [
+            {"identity": p.identity, "name": p.name or p.identity}
+            for p in speakers
+            if p.identity != self._room.local_participant.identity
+        ]
+        self.on_speakers_changed(self.room_name, active)
+
     def _on_disconnected(self):
         """Called when disconnected from room."""
         self._is_connected = False
@@ -307,13 +326,15 @@ class TranscriptionAgentManager:
 
     def __init__(
         self,
-        on_transcript_broadcast: Optional[Callable[[str, dict], None]] = None
+        on_transcript_broadcast: Optional[Callable[[str, dict], None]] = None,
+        on_speakers_changed: Optional[Callable[[str, list], None]] = None
     ):
         self.on_transcript_broadcast = on_transcript_broadcast
+        self.on_speakers_changed = on_speakers_changed
         self._agents: dict[str, TranscriptionAgent] = {}
         self._lock = asyncio.Lock()
 
-    async def join_room(self, room_name: str) -> bool:
+    async def join_room(self, room_name: str, language: str = "en") -> bool:
         """Create and join a room with a transcription agent."""
         async with self._lock:
             if room_name in self._agents:
@@ -322,7 +343,9 @@ class TranscriptionAgentManager:
 
             agent = TranscriptionAgent(
                 room_name=room_name,
-                on_transcript_broadcast=self.on_transcript_broadcast
+                on_transcript_broadcast=self.on_transcript_broadcast,
+                language=language,
+                on_speakers_changed=self.on_speakers_changed
             )
 
             if await agent.join():
@@ -22,6 +22,9 @@ logger = logging.getLogger("ai-service")
 
 # Configuration
 BACKEND_API_URL = os.getenv("BACKEND_API_URL", "http://localhost:8080")
+# Shared with the backend, which refuses /api/internal/* calls without it
+INTERNAL_API_SECRET = os.getenv("INTERNAL_API_SECRET", "")
+INTERNAL_HEADERS = {"X-Internal-Secret": INTERNAL_API_SECRET}
 
 
 def validate_env():
@@ -32,6 +35,7 @@ def validate_env():
         "LIVEKIT_URL",
         "LIVEKIT_API_KEY",
         "LIVEKIT_API_SECRET",
+        "INTERNAL_API_SECRET",
     ]
     missing = [k for k in required if not os.getenv(k)]
     if missing:
@@ -50,7 +54,8 @@ async def broadcast_transcript(room_name: str, transcript_data: dict):
                     "text": transcript_data["text"],
                     "is_final": transcript_data["is_final"],
                     "timestamp": transcript_data["timestamp"],
-                }
+                },
+                headers=INTERNAL_HEADERS,
             ) as resp:
                 if resp.status != 200:
                     logger.warning(f"Failed to broadcast transcript: {resp.status}")
@@ -58,6 +63,24 @@ async def broadcast_transcript(room_name: str, transcript_data: dict):
         logger.error(f"Error broadcasting transcript: {e}")
 
 
+async def broadcast_speakers(room_name: str, speakers: list):
+    """Send the room's active speakers to backend for WebSocket broadcast."""
+    try:
+        async with aiohttp.ClientSession() as session:
+            async with session.post(
+                f"{BACKEND_API_URL}/api/internal/speakers",
+                json={
+                    "room_name": room_name,
+                    "speakers": speakers,
+                },
+                headers=INTERNAL_HEADERS,
+            ) as resp:
+                if resp.status != 200:
+                    logger.warning(f"Failed to broadcast active speakers: {resp.status}")
+    except Exception as e:
+        logger.error(f"Error broadcasting active speakers: {e}")
+
+
 async def save_notes_to_backend(room_name: str, markdown: str, usage: dict):
     """Save generated notes to backend."""
     try:
@@ -85,6 +108,9 @@ def run_service():
     agent_manager = TranscriptionAgentManager(
         on_transcript_broadcast=lambda room, data: asyncio.create_task(
             broadcast_transcript(room, data)
+        ),
+        on_speakers_changed=lambda room, speakers: asyncio.create_task(
+            broadcast_speakers(room, speakers)
         )
     )
 
@@ -104,12 +130,14 @@ def run_service():
 
         Expected payload:
         {
-            "room_name": "room-xxx"
+            "room_name": "room-xxx",
+            "language": "en"  # optional
         }
         """
         try:
             data = await request.json()
             room_name = data.get("room_name")
+            language = data.get("language") or "en"
 
             if not room_name:
                 return web.json_response(
@@ -125,8 +153,8 @@ def run_service():
                 })
 
             # Join the room
-            logger.info(f"Joining room: {room_name}")
-            success = await agent_manager.join_room(room_name)
+            logger.info(f"Joining room: {room_name} ({language})")
+            success = await agent_manager.join_room(room_name, language)
 
             if success:
                 return web.json_response({
@@ -215,6 +243,46 @@ def run_service():
                 status=500
             )
 
+    async def summarize(request):
+        """
+        Generate a running summary from a transcript without leaving the room
+        or saving anything. Used by the backend for interim draft notes.
+
+        Expected payload:
+        {
+            "room_name": "room-xxx",
+            "transcript": "[HH:MM:SS] Speaker: text\n..."
+        }
+        """
+        try:
+            data = await request.json()
+            room_name = data.get("room_name")
+            transcript = data.get("transcript", "")
+
+            if not room_name:
+                return web.json_response(
+                    {"error": "room_name required"},
+                    status=400
+                )
+
+            logger.info(f"Generating draft notes for room {room_name} ({len(transcript)} chars)")
+            result = await generate_notes_from_text(transcript)
+
+            return web.json_response({
+                "status": "completed",
+                "room_name": room_name,
+                "markdown": result["markdown"],
+                "model": result["model"],
+                "usage": result["usage"],
+            })
+
+        except Exception as e:
+            logger.error(f"Error generating draft notes: {e}")
+            return web.json_response(
+                {"error": str(e)},
+                status=500
+            )
+
     async def get_rooms(request):
         """List all active transcription rooms."""
         active_rooms = await agent_manager.get_active_rooms()
@@ -236,6 +304,7 @@ def run_service():
     app.router.add_get("/health", health)
     app.router.add_post("/join", join_room)
     app.router.add_post("/leave", leave_room)
+    app.router.add_post("/summarize", summarize)
     app.router.add_get("/rooms", get_rooms)
 
     logger.info("Starting real-time transcription service on port 8081")
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Acknowledgement records that a participant was shown the recording notice
+// and accepted it before joining
+type Acknowledgement struct {
+	ParticipantIdentity string    `json:"participantIdentity"`
+	AcknowledgedAt      time.Time `json:"acknowledgedAt"`
+	IPAddress           string    `json:"ipAddress,omitempty"`
+}
+
+// SetMeetingRecordingLocked turns the join-time recording acknowledgement on
+// or off for a meeting. It reports false if the meeting does not exist.
+func SetMeetingRecordingLocked(ctx context.Context, roomName string, locked bool) (bool, error) {
+	result, err := execWrite(ctx, "UPDATE meetings SET recording_locked = ? WHERE room_name = ?", locked, roomName)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// RecordAcknowledgement stores a participant's acknowledgement of the
+// recording notice, refreshing the time and address if they acknowledge again
+func RecordAcknowledgement(ctx context.Context, roomName, identity, ipAddress string) error {
+	now := time.Now().UTC()
+	_, err := execWrite(ctx,
+		`INSERT INTO acknowledgements (room_name, participant_identity, acknowledged_at, ip_address) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(room_name, participant_identity) DO UPDATE SET acknowledged_at = ?, ip_address = ?`,
+		roomName, identity, now, ipAddress, now, ipAddress,
+	)
+	return err
+}
+
+// HasAcknowledged reports whether identity has acknowledged the recording notice for a room
+func HasAcknowledged(ctx context.Context, roomName, identity string) (bool, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var count int
+	err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM acknowledgements WHERE room_name = ? AND participant_identity = ?",
+		roomName, identity,
+	).Scan(&count)
+	return count > 0, err
+}
+
+// ListAcknowledgements returns everyone who acknowledged the recording notice
+// for a room, in the order they did so
+func ListAcknowledgements(ctx context.Context, roomName string) ([]Acknowledgement, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT participant_identity, acknowledged_at, ip_address FROM acknowledgements WHERE room_name = ? ORDER BY acknowledged_at, id",
+		roomName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	acks := []Acknowledgement{}
+	for rows.Next() {
+		var a Acknowledgement
+		var ip sql.NullString
+		if err := rows.Scan(&a.ParticipantIdentity, &a.AcknowledgedAt, &ip); err != nil {
+			return nil, err
+		}
+		a.IPAddress = ip.String
+		acks = append(acks, a)
+	}
+	return acks, rows.Err()
+}
+
+// acknowledgementRequired reports whether identity must acknowledge the
+// recording notice before it may join a room
+func (s *server) acknowledgementRequired(ctx context.Context, roomName, identity string) (bool, error) {
+	meeting, err := s.store.GetMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !meeting.RecordingLocked {
+		return false, nil
+	}
+	acknowledged, err := s.store.HasAcknowledged(ctx, roomName, identity)
+	if err != nil {
+		return false, err
+	}
+	return !acknowledged, nil
+}
+
+// Acknowledgement handlers
+
+// AcknowledgeRequest identifies who acknowledged. The identity is the
+// participantName the client will then request a token with.
+type AcknowledgeRequest struct {
+	Identity string `json:"identity"`
+}
+
+func (r *AcknowledgeRequest) validate(errs *fieldErrors) {
+	errs.require("identity", r.Identity)
+}
+
+type AcknowledgeResponse struct {
+	Status   string `json:"status"` // acknowledged
+	Identity string `json:"identity"`
+}
+
+func (s *server) acknowledgeHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+
+	var req AcknowledgeRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	if err := s.store.RecordAcknowledgement(ctx, room, req.Identity, c.IP()); err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	return c.JSON(AcknowledgeResponse{Status: "acknowledged", Identity: req.Identity})
+}
+
+type AcknowledgementsResponse struct {
+	Acknowledgements []Acknowledgement `json:"acknowledgements"`
+	Count            int               `json:"count"`
+}
+
+func (s *server) listAcknowledgementsHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	acks, err := s.store.ListAcknowledgements(ctx, room)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	return c.JSON(AcknowledgementsResponse{Acknowledgements: acks, Count: len(acks)})
+}
+
+type RecordingLockResponse struct {
+	RoomName        string `json:"roomName"`
+	RecordingLocked bool   `json:"recordingLocked"`
+}
+
+func (s *server) recordingLockHandler(locked bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		room := roomParam(c)
+		if !s.canManageRoom(c, room) {
+			return respondError(c, 403, "Not your meeting")
+		}
+
+		found, err := s.store.SetMeetingRecordingLocked(ctx, room, locked)
+		if err != nil {
+			return respondError(c, 500, err.Error())
+		}
+		if !found {
+			return respondError(c, 404, "Meeting not found")
+		}
+
+		action := "meeting.recording_unlock"
+		if locked {
+			action = "meeting.recording_lock"
+		}
+		recordAudit(c, action, room, "")
+
+		return c.JSON(RecordingLockResponse{RoomName: room, RecordingLocked: locked})
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The AI service's own /health is checked at most once per aiHealthMaxAge
+// and the verdict shared: admins see it, and starting a transcription is
+// refused with 503 up front while the service is down instead of waiting on
+// a join that will fail.
+
+const (
+	aiHealthMaxAge  = 10 * time.Second
+	aiHealthTimeout = 5 * time.Second
+
+	// maxAIHealthBody bounds how much of the AI service's health response is
+	// read and passed through
+	maxAIHealthBody = 64 << 10
+)
+
+// AIServiceHealthResponse is the body of /api/ai-service/health
+type AIServiceHealthResponse struct {
+	Available  bool   `json:"available"`
+	Error      string `json:"error,omitempty"`
+	StatusCode int    `json:"statusCode,omitempty"` // of the AI service's response
+	LatencyMS  *int64 `json:"latencyMs,omitempty"`
+	// Service is the AI service's own health response, such as its
+	// available models and queue depth, passed through as JSON
+	Service       json.RawMessage `json:"service,omitempty"`
+	LastCheckedAt time.Time       `json:"lastCheckedAt"`
+	CheckInterval string          `json:"checkInterval"` // how long a check is reused, e.g. 10s
+}
+
+// aiHealthCache holds the last AI service health check
+type aiHealthCache struct {
+	mu     sync.Mutex
+	health *AIServiceHealthResponse
+}
+
+// aiServiceHealth returns the AI service's health, checking it if the last
+// check is older than aiHealthMaxAge
+func (s *server) aiServiceHealth(ctx context.Context) AIServiceHealthResponse {
+	cache := s.aiHealth
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.health != nil && time.Since(cache.health.LastCheckedAt) < aiHealthMaxAge {
+		return *cache.health
+	}
+
+	// A caller that gives up must not leave a failed check cached for everyone
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), aiHealthTimeout)
+	defer cancel()
+	health := checkAIService(ctx)
+	cache.health = &health
+	return health
+}
+
+// aiServiceAvailable reports whether the AI service passed its last health check
+func (s *server) aiServiceAvailable(ctx context.Context) bool {
+	return s.aiServiceHealth(ctx).Available
+}
+
+func checkAIService(ctx context.Context) AIServiceHealthResponse {
+	health := AIServiceHealthResponse{LastCheckedAt: time.Now().UTC(), CheckInterval: aiHealthMaxAge.String()}
+	if config.AIServiceURL == "" {
+		health.Error = "AI service is not configured"
+		return health
+	}
+
+	start := time.Now()
+	resp, err := getAIService(ctx, "health", "/health")
+	if err != nil {
+		ctxLogger(ctx).Warn("AI service health check failed", "error", err)
+		health.Error = err.Error()
+		return health
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAIHealthBody))
+	latencyMS := time.Since(start).Milliseconds()
+	health.LatencyMS = &latencyMS
+	health.StatusCode = resp.StatusCode
+	if err == nil && json.Valid(body) {
+		health.Service = body
+	}
+
+	switch {
+	case resp.StatusCode != 200:
+		health.Error = fmt.Sprintf("AI service health check returned %d", resp.StatusCode)
+	case err != nil:
+		health.Error = "Failed to read AI service health: " + err.Error()
+	default:
+		health.Available = true
+	}
+	if !health.Available {
+		ctxLogger(ctx).Warn("AI service unhealthy", "status", resp.StatusCode, "error", health.Error)
+	}
+	return health
+}
+
+// aiServiceHealthHandler reports the AI service's health; 503 with the same
+// body when it is down
+func (s *server) aiServiceHealthHandler(c *fiber.Ctx) error {
+	health := s.aiServiceHealth(c.UserContext())
+	status := 200
+	if !health.Available {
+		status = 503
+	}
+	return c.Status(status).JSON(health)
+}
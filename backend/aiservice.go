@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// callAIService posts a JSON payload to an AI service endpoint, recording
+// its latency by method and outcome. Transport failures and 5xx responses
+// count as errors; 4xx responses are answers (e.g. a room that is not active).
+func callAIService(ctx context.Context, method, path string, payload []byte) (*http.Response, error) {
+	return timeAIService(method, func() (*http.Response, error) {
+		return postJSON(ctx, config.AIServiceURL+path, payload)
+	})
+}
+
+// getAIService is callAIService for GET endpoints
+func getAIService(ctx context.Context, method, path string) (*http.Response, error) {
+	return timeAIService(method, func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, config.AIServiceURL+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		setRequestIDHeader(ctx, req)
+		return http.DefaultClient.Do(req)
+	})
+}
+
+func timeAIService(method string, call func() (*http.Response, error)) (*http.Response, error) {
+	aiServiceInFlight.Inc()
+	defer aiServiceInFlight.Dec()
+
+	start := time.Now()
+	resp, err := call()
+	status := "success"
+	if err != nil || resp.StatusCode >= 500 {
+		status = "error"
+	}
+	aiServiceDuration.WithLabelValues(method, status).Observe(time.Since(start).Seconds())
+	return resp, err
+}
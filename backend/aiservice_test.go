@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// aiServiceObservations returns how many calls the histogram has recorded
+// for method and status
+func aiServiceObservations(t *testing.T, method, status string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := aiServiceDuration.WithLabelValues(method, status).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestAIServiceCallsAreTimed(t *testing.T) {
+	useTestConfig(t)
+	var inFlight []float64
+	ai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight = append(inFlight, testutil.ToFloat64(aiServiceInFlight))
+		if r.URL.Path == "/leave" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(ai.Close)
+	config.AIServiceURL = ai.URL
+
+	calls := []struct{ method, path, status string }{
+		{"join", "/join", "success"},
+		{"leave", "/leave", "error"},
+		{"transcribe_recording", "/transcribe-recording", "success"},
+	}
+	before := make([]uint64, len(calls))
+	for i, c := range calls {
+		before[i] = aiServiceObservations(t, c.method, c.status)
+	}
+
+	for _, c := range calls {
+		resp, err := callAIService(context.Background(), c.method, c.path, []byte(`{}`))
+		if err != nil {
+			t.Fatalf("%s: %v", c.method, err)
+		}
+		resp.Body.Close()
+	}
+
+	for i, c := range calls {
+		if got := aiServiceObservations(t, c.method, c.status) - before[i]; got != 1 {
+			t.Errorf("%s %s: got %d observations, want 1", c.method, c.status, got)
+		}
+	}
+	for i, n := range inFlight {
+		if n != 1 {
+			t.Errorf("call %d: boom_ai_service_in_flight was %v during the call, want 1", i, n)
+		}
+	}
+	if n := testutil.ToFloat64(aiServiceInFlight); n != 0 {
+		t.Errorf("boom_ai_service_in_flight after the calls: got %v, want 0", n)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Every error response has the same shape:
+//
+//	{"error": {"code": "not_found", "message": "Meeting not found"}}
+//
+// code is derived from the status unless a handler names a more specific
+// one. Bodies and query parameters that fail validation get a 422 with code
+// validation_failed and a fields list naming each bad field, so clients can
+// show the message next to the right input. Some errors add details, such as
+// the current etag on a notes conflict.
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError describes what went wrong
+type APIError struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+	Details fiber.Map    `json:"details,omitempty"`
+}
+
+// FieldError is a problem with one field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// codeValidationFailed is the code of 422 responses listing field errors
+const codeValidationFailed = "validation_failed"
+
+// errorCode derives an error code from an HTTP status, e.g. not_found for 404
+func errorCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(utils.StatusMessage(status)), " ", "_")
+}
+
+// respondError writes an error response with the code for status
+func respondError(c *fiber.Ctx, status int, message string) error {
+	return respondAPIError(c, status, APIError{Message: message})
+}
+
+// respondAPIError writes e, filling in the code for status if it has none
+func respondAPIError(c *fiber.Ctx, status int, e APIError) error {
+	if e.Code == "" {
+		e.Code = errorCode(status)
+	}
+	return c.Status(status).JSON(ErrorResponse{Error: e})
+}
+
+// requestError is an error response that can travel as an error: handlers
+// either respond with it directly or return it for errorHandler to write
+type requestError struct {
+	status int
+	body   APIError
+}
+
+func (e *requestError) Error() string {
+	return e.body.Message
+}
+
+// detail adds a key to the error's details
+func (e *requestError) detail(key string, value any) {
+	if e.body.Details == nil {
+		e.body.Details = fiber.Map{}
+	}
+	e.body.Details[key] = value
+}
+
+func (e *requestError) respond(c *fiber.Ctx) error {
+	return respondAPIError(c, e.status, e.body)
+}
+
+// fieldErrors collects validation failures for a request
+type fieldErrors []FieldError
+
+// add records a problem with field. message stands on its own, naming the
+// field as the client sent it, e.g. "email is required".
+func (f *fieldErrors) add(field, message string) {
+	*f = append(*f, FieldError{Field: field, Message: message})
+}
+
+// require records field as missing if value is blank
+func (f *fieldErrors) require(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		f.add(field, field+" is required")
+	}
+}
+
+// email records an optional field as invalid if it is not an email address
+func (f *fieldErrors) email(field, value string) {
+	if value != "" && !isValidEmail(value) {
+		f.add(field, field+" is not a valid email address")
+	}
+}
+
+// timestamp parses an optional RFC 3339 field, recording it as invalid if
+// it does not parse. It returns the zero time for an empty or bad value.
+func (f *fieldErrors) timestamp(field, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		f.add(field, field+" must be an ISO 8601 timestamp such as 2026-01-02T15:04:05Z")
+	}
+	return t
+}
+
+// queryInt reads an optional integer query parameter, recording it as
+// invalid if it is not a whole number
+func (f *fieldErrors) queryInt(c *fiber.Ctx, key string, def int) int {
+	v := c.Query(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		f.add(key, key+" must be a whole number")
+		return def
+	}
+	return n
+}
+
+// err returns a 422 listing the failures, or nil if there are none
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return f.requestError()
+}
+
+func (f fieldErrors) requestError() *requestError {
+	messages := make([]string, len(f))
+	for i, fe := range f {
+		messages[i] = fe.Message
+	}
+	return &requestError{422, APIError{
+		Code:    codeValidationFailed,
+		Message: strings.Join(messages, "; "),
+		Fields:  f,
+	}}
+}
+
+// invalidField is the 422 for a single bad field, for checks that need more
+// than the request itself
+func invalidField(field, message string) *requestError {
+	return fieldErrors{{Field: field, Message: message}}.requestError()
+}
+
+// validatable is implemented by request types that check their own fields
+type validatable interface {
+	validate(errs *fieldErrors)
+}
+
+// parseBody decodes the request body into dst and validates it. Its errors
+// are ready-made 400 and 422 responses for handlers to return as they are.
+func parseBody(c *fiber.Ctx, dst any) error {
+	if err := c.BodyParser(dst); err != nil {
+		return invalidBody()
+	}
+	return validateRequest(dst)
+}
+
+// invalidBody is the 400 for a body that is not valid JSON or form data
+func invalidBody() *requestError {
+	return &requestError{400, APIError{Code: "invalid_body", Message: "Request body could not be parsed"}}
+}
+
+// validateRequest runs v's own checks, if it has any
+func validateRequest(v any) error {
+	if v, ok := v.(validatable); ok {
+		var errs fieldErrors
+		v.validate(&errs)
+		return errs.err()
+	}
+	return nil
+}
+
+// asRequestError reports whether err carries its own error response
+func asRequestError(err error) (*requestError, bool) {
+	var re *requestError
+	ok := errors.As(err, &re)
+	return re, ok
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestInvalidPayloadsListFieldErrors sends each route that takes a body one
+// representative bad payload and checks the 422 names the offending fields
+func TestInvalidPayloadsListFieldErrors(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	config.N8NCallbackSecret = "callback-secret"
+	app := newTestApp(t, newTestServer(t, sqlStore{}))
+
+	host := testUser(t, testAdminEmail)
+	at := time.Now().Add(time.Hour)
+	meeting, err := CreateScheduledMeeting(context.Background(), "client-call", host.ID, "Client", "client@example.com", at, at.Add(time.Hour), "client-call", "")
+	if err != nil {
+		t.Fatalf("CreateScheduledMeeting: %v", err)
+	}
+	admin := bearer(t, testAdminEmail)
+	userID := testUser(t, testUserEmail).ID
+	n8nBody, _ := json.Marshal(map[string]any{"status": "bounced", "bounceType": "medium"})
+
+	routes := []struct {
+		method, path string
+		body         any
+		header       []string
+		fields       []string
+	}{
+		{"POST", "/api/auth/login", map[string]any{}, nil, []string{"email", "password"}},
+		{"POST", "/api/auth/verify", map[string]any{}, internalSecret(), []string{"token"}},
+		{"POST", "/api/token", map[string]any{"roomName": "standup"}, nil, []string{"participantName"}},
+		{"POST", "/api/scheduled-meetings", map[string]any{"clientEmail": "not an address", "slug": "x", "joinPin": "12"}, admin, []string{"clientEmail", "joinPin", "scheduledAt", "slug"}},
+		{"POST", "/api/scheduled-meetings", map[string]any{"scheduledAt": "2026-10-16T12:00:00Z", "linkExpiresAt": "2026-10-16T11:00:00Z"}, admin, []string{"linkExpiresAt"}},
+		{"PATCH", fmt.Sprintf("/api/scheduled-meetings/%d", meeting.ID), map[string]any{"scheduledAt": "tomorrow"}, admin, []string{"scheduledAt"}},
+		{"POST", "/api/scheduled-meetings/bulk-cancel", map[string]any{"from": "2026-10-16T12:00:00Z", "to": "2026-10-15T12:00:00Z", "status": "active"}, admin, []string{"status", "to"}},
+		{"POST", fmt.Sprintf("/api/scheduled-meetings/%d/transfer", meeting.ID), map[string]any{}, admin, []string{"newOwnerEmail"}},
+		{"POST", "/api/meetings/client-call/breakout", map[string]any{"rooms": []map[string]any{{"participants": []string{"a"}}, {"name": "b", "participants": []string{"a"}}}}, admin, []string{"rooms[0].name", "rooms[1].participants"}},
+		{"POST", "/api/meetings/client-call/consent", map[string]any{"consentTimestamp": "yesterday"}, nil, []string{"consentGiven", "consentTimestamp", "participantIdentity"}},
+		{"POST", "/api/meetings/client-call/acknowledge", map[string]any{}, nil, []string{"identity"}},
+		{"POST", "/api/meetings/client-call/notes", map[string]any{"inputTokens": -1, "outputTokens": -1}, nil, []string{"inputTokens", "outputTokens"}},
+		{"POST", "/api/meetings/client-call/subscribe-email", map[string]any{}, nil, []string{"email"}},
+		{"DELETE", "/api/meetings/client-call/unsubscribe-email", map[string]any{"email": "ada@"}, nil, []string{"email"}},
+		{"POST", "/api/internal/email-bounce", map[string]any{"type": "lost"}, internalSecret(), []string{"email", "type"}},
+		{"POST", "/api/internal/transcript", map[string]any{}, internalSecret(), []string{"room_name"}},
+		{"POST", "/api/internal/speakers", map[string]any{}, internalSecret(), []string{"room_name"}},
+		{"POST", "/api/meetings/client-call/transcript/mirror", map[string]any{"targetRooms": []string{" "}}, admin, []string{"targetRooms[0]"}},
+		{"POST", "/api/meetings/client-call/speaker-map", map[string]any{}, admin, []string{"speakers"}},
+		{"POST", fmt.Sprintf("/api/admin/users/%d/active", userID), map[string]any{}, admin, []string{"active"}},
+		{"POST", fmt.Sprintf("/api/admin/users/%d/namespace", userID), map[string]any{"namespace": "Not Valid!"}, admin, []string{"namespace"}},
+		{"POST", "/api/admin/meetings/client-call/api-key", map[string]any{"permissions": []string{"fly"}, "expiresAt": "2000-01-01T00:00:00Z"}, admin, []string{"expiresAt", "permissions"}},
+		{"POST", "/api/webhooks/n8n/callback", n8nBody, []string{"X-N8N-Signature", signN8N(config.N8NCallbackSecret, n8nBody)}, []string{"bounceType", "email", "roomName"}},
+	}
+
+	for _, r := range routes {
+		resp := doRequest(t, app, r.method, r.path, r.body, r.header...)
+		if resp.Status != http.StatusUnprocessableEntity {
+			t.Errorf("%s %s: got %d, want 422: %s", r.method, r.path, resp.Status, resp.Body)
+			continue
+		}
+		e := resp.apiError(t)
+		if e.Code != codeValidationFailed || e.Message == "" {
+			t.Errorf("%s %s: got code %q message %q, want %s", r.method, r.path, e.Code, e.Message, codeValidationFailed)
+		}
+		var got []string
+		for _, f := range e.Fields {
+			if f.Message == "" {
+				t.Errorf("%s %s: field %s has no message", r.method, r.path, f.Field)
+			}
+			if len(got) == 0 || got[len(got)-1] != f.Field {
+				got = append(got, f.Field)
+			}
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(r.fields, ",") {
+			t.Errorf("%s %s: fields %v, want %v", r.method, r.path, got, r.fields)
+		}
+	}
+}
+
+func TestMalformedBodyIsInvalidBody(t *testing.T) {
+	useTestConfig(t)
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	resp := doRequest(t, app, http.MethodPost, "/api/auth/login", "{not json", "Content-Type", "application/json")
+	if resp.Status != http.StatusBadRequest {
+		t.Fatalf("malformed JSON: got %d, want 400", resp.Status)
+	}
+	if e := resp.apiError(t); e.Code != "invalid_body" || len(e.Fields) != 0 {
+		t.Errorf("malformed JSON: got %+v, want invalid_body without fields", e)
+	}
+}
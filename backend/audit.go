@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditEntry is one recorded sensitive action
+type AuditEntry struct {
+	ID          int64     `json:"id"`
+	ActorUserID int64     `json:"actorUserId,omitempty"`
+	Action      string    `json:"action"`
+	Target      string    `json:"target"`
+	Details     string    `json:"details,omitempty"`
+	IPAddress   string    `json:"ipAddress,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// LogAudit writes an audit_log entry. actorUserID is 0 for system actions.
+func LogAudit(ctx context.Context, actorUserID int64, action, target, details, ip string) error {
+	actor := sql.NullInt64{Int64: actorUserID, Valid: actorUserID != 0}
+	_, err := execWrite(ctx,
+		"INSERT INTO audit_log (actor_user_id, action, target, details, ip_address) VALUES (?, ?, ?, ?, ?)",
+		actor, action, target, details, ip,
+	)
+	return err
+}
+
+// recordAudit logs an action taken by the requesting user. Failures are logged
+// but never fail the request.
+func recordAudit(c *fiber.Ctx, action, target, details string) {
+	userID, _ := c.Locals("userID").(int64)
+	recordAuditAs(c, userID, action, target, details)
+}
+
+// recordAuditAs is recordAudit for requests whose actor is not the
+// authenticated user, such as a login
+func recordAuditAs(c *fiber.Ctx, actorUserID int64, action, target, details string) {
+	if err := LogAudit(c.UserContext(), actorUserID, action, target, details, c.IP()); err != nil {
+		ctxLogger(c.UserContext()).Error("Failed to write audit log", "action", action, "target", target, "error", err)
+	}
+}
+
+// AuditFilter narrows an audit log query. Zero values match everything.
+type AuditFilter struct {
+	Action      string
+	Target      string
+	ActorUserID int64
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+	Offset      int
+}
+
+// ListAudit returns a page of audit entries matching filter, newest first,
+// along with the total number of matches
+func ListAudit(ctx context.Context, filter AuditFilter) ([]AuditEntry, int, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var where []string
+	var args []interface{}
+	if filter.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.Target != "" {
+		where = append(where, "target = ?")
+		args = append(args, filter.Target)
+	}
+	if filter.ActorUserID != 0 {
+		where = append(where, "actor_user_id = ?")
+		args = append(args, filter.ActorUserID)
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, filter.Until.UTC())
+	}
+	clause := ""
+	if len(where) > 0 {
+		clause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+clause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, actor_user_id, action, target, details, ip_address, created_at FROM audit_log"+clause+" ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?",
+		append(args, filter.Limit, filter.Offset)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, total, rows.Err()
+}
+
+// ListAuditByTarget returns audit entries for a target (e.g. a room name), oldest first
+func ListAuditByTarget(ctx context.Context, target string) ([]AuditEntry, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, actor_user_id, action, target, details, ip_address, created_at FROM audit_log WHERE target = ? ORDER BY created_at, id",
+		target,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+func scanAuditEntry(rows *sql.Rows) (*AuditEntry, error) {
+	var e AuditEntry
+	var actor sql.NullInt64
+	var target, details, ip sql.NullString
+	if err := rows.Scan(&e.ID, &actor, &e.Action, &target, &details, &ip, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	e.ActorUserID = actor.Int64
+	e.Target = target.String
+	e.Details = details.String
+	e.IPAddress = ip.String
+	return &e, nil
+}
+
+// AuditPage is one page of audit entries matching a filter
+type AuditPage struct {
+	Entries []AuditEntry `json:"entries"`
+	Total   int          `json:"total"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+}
+
+// Audit log page size bounds
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+func listAuditHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	var errs fieldErrors
+	filter := AuditFilter{
+		Action: c.Query("action"),
+		Target: c.Query("target"),
+		Limit:  errs.queryInt(c, "limit", defaultAuditPageSize),
+		Offset: errs.queryInt(c, "offset", 0),
+		Since:  errs.timestamp("since", c.Query("since")),
+		Until:  errs.timestamp("until", c.Query("until")),
+	}
+	if filter.Limit < 1 || filter.Limit > maxAuditPageSize {
+		errs.add("limit", fmt.Sprintf("limit must be between 1 and %d", maxAuditPageSize))
+	}
+	if filter.Offset < 0 {
+		errs.add("offset", "offset must not be negative")
+	}
+	if actor := c.Query("actor"); actor != "" {
+		if _, err := fmt.Sscanf(actor, "%d", &filter.ActorUserID); err != nil {
+			errs.add("actor", "actor must be a user ID")
+		}
+	}
+	if err := errs.err(); err != nil {
+		return err
+	}
+
+	entries, total, err := ListAudit(ctx, filter)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if entries == nil {
+		entries = []AuditEntry{}
+	}
+
+	return c.JSON(AuditPage{Entries: entries, Total: total, Limit: filter.Limit, Offset: filter.Offset})
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -21,40 +24,61 @@ type User struct {
 	Email        string    `json:"email"`
 	Name         string    `json:"name"`
 	PasswordHash string    `json:"-"`
+	Active       bool      `json:"active"`
+	Namespace    string    `json:"namespace,omitempty"` // prefix for the rooms this user hosts
 	CreatedAt    time.Time `json:"createdAt"`
 }
 
+// UserSummary is the public part of a user returned alongside other data
+type UserSummary struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
 // JWT claims
 type JWTClaims struct {
-	UserID int64  `json:"user_id"`
-	Email  string `json:"email"`
-	Name   string `json:"name"`
-	Exp    int64  `json:"exp"`
+	UserID       int64  `json:"user_id"`
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	Exp          int64  `json:"exp"`
+	AuthTime     int64  `json:"auth_time"`
+	LastActivity int64  `json:"last_activity"`
 }
 
-var jwtSecret []byte
+// defaultSessionMaxAge is how long a session lasts when SESSION_MAX_AGE is
+// not set. A session never outlives SESSION_MAX_AGE from login; when
+// SESSION_IDLE_TIMEOUT is set it also ends after that long without an
+// authenticated request.
+const defaultSessionMaxAge = 24 * time.Hour
+
+// clockFunc is the time source for issuing and checking session tokens, so
+// expiry can be exercised without waiting on the wall clock
+var clockFunc = time.Now
+
+// refreshedTokenHeader carries a renewed token back to the client when the
+// current one is close to its idle expiry
+const refreshedTokenHeader = "X-Refreshed-Token"
+
+// adminEmails holds the users allowed to call admin endpoints. When empty,
+// nobody is an admin.
+var adminEmails = map[string]bool{}
 
 func initAuth() {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "boom-dev-secret-change-in-production"
-		log.Println("WARNING: JWT_SECRET not set, using default (insecure)")
+	for _, email := range config.AdminEmails {
+		adminEmails[email] = true
+	}
+	if len(adminEmails) == 0 {
+		slog.Warn("BOOM_ADMIN_EMAILS is empty; admin endpoints will refuse every user")
 	}
-	jwtSecret = []byte(secret)
 
-	seedUsers()
+	seedUsers(context.Background())
 }
 
-func seedUsers() {
-	password := os.Getenv("BOOM_ADMIN_PASSWORD")
-	if password == "" {
-		password = "boom2026"
-		log.Println("WARNING: BOOM_ADMIN_PASSWORD not set, using default")
-	}
-
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func seedUsers(ctx context.Context) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(config.AdminPassword), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("Failed to hash password: %v", err)
+		slog.Error("Failed to hash password", "error", err)
 		return
 	}
 
@@ -67,33 +91,140 @@ func seedUsers() {
 		{"justinnevins@protonmail.com", "Justin N"},
 	}
 
+	// Existing accounts are left untouched so seeding never re-activates a
+	// user an admin has disabled
 	for _, u := range users {
-		_, err := db.Exec(
+		_, err := execWrite(ctx,
 			"INSERT INTO users (email, password_hash, name) VALUES (?, ?, ?) ON CONFLICT(email) DO NOTHING",
 			u.email, string(hash), u.name,
 		)
 		if err != nil {
-			log.Printf("Failed to seed user %s: %v", u.email, err)
+			slog.Error("Failed to seed user", "email", u.email, "error", err)
+		}
+	}
+	slog.Info("Users seeded")
+}
+
+// GetUserByEmail looks up a user by email address
+func GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var user User
+	var namespace sql.NullString
+	err := db.QueryRowContext(ctx,
+		"SELECT id, email, password_hash, name, active, namespace, created_at FROM users WHERE email = ?",
+		email,
+	).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Active, &namespace, &user.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	user.Namespace = namespace.String
+	return &user, nil
+}
+
+// ListUsers returns every account, oldest first
+func ListUsers(ctx context.Context) ([]User, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "SELECT id, email, name, active, namespace, created_at FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		var namespace sql.NullString
+		if err := rows.Scan(&u.ID, &u.Email, &u.Name, &u.Active, &namespace, &u.CreatedAt); err != nil {
+			return nil, err
 		}
+		u.Namespace = namespace.String
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// SetUserActive enables or disables an account. Accounts whose data was
+// erased count as missing.
+func SetUserActive(ctx context.Context, id int64, active bool) (bool, error) {
+	result, err := execWrite(ctx, "UPDATE users SET active = ? WHERE id = ? AND deleted_at IS NULL", active, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// IsUserActive reports whether an account exists and is enabled
+func IsUserActive(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var active bool
+	err := db.QueryRowContext(ctx, "SELECT active FROM users WHERE id = ?", id).Scan(&active)
+	if err == sql.ErrNoRows {
+		return false, nil
 	}
-	log.Println("Users seeded")
+	return active, err
 }
 
-// generateJWT creates a signed JWT token
+// generateJWT creates a signed JWT token for a new session
 func generateJWT(user *User) (string, error) {
-	claims := JWTClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Name:   user.Name,
-		Exp:    time.Now().Add(24 * time.Hour).Unix(),
+	now := clockFunc()
+	return signJWT(newSessionClaims(user.ID, user.Email, user.Name, now, now))
+}
+
+// newSessionClaims builds claims for a session that started at authTime and
+// was last active at lastActivity
+func newSessionClaims(userID int64, email, name string, authTime, lastActivity time.Time) JWTClaims {
+	exp := authTime.Add(config.SessionMaxAge)
+	if config.SessionIdleTimeout > 0 {
+		if idle := lastActivity.Add(config.SessionIdleTimeout); idle.Before(exp) {
+			exp = idle
+		}
+	}
+	return JWTClaims{
+		UserID:       userID,
+		Email:        email,
+		Name:         name,
+		Exp:          exp.Unix(),
+		AuthTime:     authTime.Unix(),
+		LastActivity: lastActivity.Unix(),
+	}
+}
+
+// refreshJWT issues a token with the session's idle window restarted, or
+// returns "" if the current token does not need renewing yet
+func refreshJWT(claims *JWTClaims) (string, error) {
+	if config.SessionIdleTimeout <= 0 || claims.AuthTime == 0 {
+		return "", nil
+	}
+	now := clockFunc()
+	if time.Unix(claims.Exp, 0).Sub(now) > config.SessionIdleTimeout/2 {
+		return "", nil
+	}
+	refreshed := newSessionClaims(claims.UserID, claims.Email, claims.Name, time.Unix(claims.AuthTime, 0), now)
+	if refreshed.Exp <= claims.Exp {
+		// Already capped by the absolute max age
+		return "", nil
 	}
+	return signJWT(refreshed)
+}
 
+// signJWT encodes and signs a set of claims
+func signJWT(claims JWTClaims) (string, error) {
 	header := base64URLEncode([]byte(`{"alg":"HS256","typ":"JWT"}`))
 	payload, _ := json.Marshal(claims)
 	payloadB64 := base64URLEncode(payload)
 
 	signingInput := header + "." + payloadB64
-	mac := hmac.New(sha256.New, jwtSecret)
+	mac := hmac.New(sha256.New, []byte(config.JWTSecret))
 	mac.Write([]byte(signingInput))
 	signature := base64URLEncode(mac.Sum(nil))
 
@@ -109,7 +240,7 @@ func validateJWT(tokenStr string) (*JWTClaims, error) {
 
 	// Verify signature
 	signingInput := parts[0] + "." + parts[1]
-	mac := hmac.New(sha256.New, jwtSecret)
+	mac := hmac.New(sha256.New, []byte(config.JWTSecret))
 	mac.Write([]byte(signingInput))
 	expectedSig := base64URLEncode(mac.Sum(nil))
 
@@ -128,9 +259,17 @@ func validateJWT(tokenStr string) (*JWTClaims, error) {
 		return nil, fmt.Errorf("invalid claims: %w", err)
 	}
 
-	if time.Now().Unix() > claims.Exp {
+	now := clockFunc()
+	// exp is the first second the token is no longer valid
+	if now.Unix() >= claims.Exp {
 		return nil, fmt.Errorf("token expired")
 	}
+	if claims.AuthTime != 0 && now.After(time.Unix(claims.AuthTime, 0).Add(config.SessionMaxAge)) {
+		return nil, fmt.Errorf("session expired")
+	}
+	if config.SessionIdleTimeout > 0 && claims.LastActivity != 0 && now.After(time.Unix(claims.LastActivity, 0).Add(config.SessionIdleTimeout)) {
+		return nil, fmt.Errorf("session inactive")
+	}
 
 	return &claims, nil
 }
@@ -155,69 +294,266 @@ func authRequired() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+			return respondError(c, 401, "Unauthorized")
 		}
 
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		claims, err := validateJWT(token)
 		if err != nil {
-			return c.Status(401).JSON(fiber.Map{"error": "Invalid token"})
+			return respondError(c, 401, "Invalid token")
+		}
+
+		// Disabling a user also ends any session they already have
+		active, err := IsUserActive(c.UserContext(), claims.UserID)
+		if err != nil {
+			return respondError(c, 500, "Failed to check account status")
+		}
+		if !active {
+			return respondError(c, 403, "Account is disabled")
 		}
 
 		c.Locals("userID", claims.UserID)
 		c.Locals("userEmail", claims.Email)
 		c.Locals("userName", claims.Name)
+		c.SetUserContext(withLogAttrs(c.UserContext(), "user_id", claims.UserID))
+
+		// Sliding expiration: hand back a renewed token once the current one
+		// is past half of its idle window
+		if refreshed, err := refreshJWT(claims); err != nil {
+			ctxLogger(c.UserContext()).Error("Failed to refresh token", "error", err)
+		} else if refreshed != "" {
+			c.Set(refreshedTokenHeader, refreshed)
+		}
 		return c.Next()
 	}
 }
 
+// authOptional is Fiber middleware that signs the caller in when the request
+// carries a JWT, which must then be valid, and otherwise lets it through
+func authOptional() fiber.Handler {
+	required := authRequired()
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") == "" {
+			return c.Next()
+		}
+		return required(c)
+	}
+}
+
+// isAdmin reports whether the given user email has admin access
+func isAdmin(email string) bool {
+	return adminEmails[strings.ToLower(email)]
+}
+
+// adminRequired is Fiber middleware that restricts a route to admins.
+// It must run after authRequired.
+func adminRequired() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		email, _ := c.Locals("userEmail").(string)
+		if !isAdmin(email) {
+			return respondError(c, 403, "Admin access required")
+		}
+		return c.Next()
+	}
+}
+
+// canManageRoom reports whether the authenticated user is an admin, the
+// user who created the room, or the host of the scheduled meeting for it. A
+// room API key qualifies if it is for this room and has the manage
+// permission.
+func (s *server) canManageRoom(c *fiber.Ctx, roomName string) bool {
+	ctx := c.UserContext()
+	if _, scoped := c.Locals("room_scope").(string); scoped {
+		return roomScopeAllows(c, roomName, roomPermManage)
+	}
+	email, _ := c.Locals("userEmail").(string)
+	if isAdmin(email) {
+		return true
+	}
+	userID, _ := c.Locals("userID").(int64)
+	if userID == 0 {
+		return false
+	}
+	if meeting, err := s.store.GetMeetingByRoom(ctx, roomName); err == nil && meeting.HostUserID == userID {
+		return true
+	}
+	scheduled, err := s.store.GetScheduledMeetingByRoom(ctx, roomName)
+	return err == nil && scheduled.HostUserID == userID
+}
+
 // Login handler
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
-func loginHandler(c *fiber.Ctx) error {
+func (r *LoginRequest) validate(errs *fieldErrors) {
+	errs.require("email", r.Email)
+	errs.require("password", r.Password)
+}
+
+type LoginResponse struct {
+	Token string      `json:"token"`
+	User  UserSummary `json:"user"`
+}
+
+func (s *server) loginHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
 	var req LoginRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
 	// Find user by email
-	var user User
-	err := db.QueryRow(
-		"SELECT id, email, password_hash, name, created_at FROM users WHERE email = ?",
-		req.Email,
-	).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.CreatedAt)
+	user, err := s.store.GetUserByEmail(ctx, req.Email)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return respondError(c, 500, "Failed to look up user")
+	}
 	if err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Invalid credentials"})
+		recordAuditAs(c, 0, "auth.login_failed", req.Email, "unknown user")
+		return respondError(c, 401, "Invalid credentials")
 	}
 
 	// Check password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Invalid credentials"})
+		recordAuditAs(c, user.ID, "auth.login_failed", user.Email, "wrong password")
+		return respondError(c, 401, "Invalid credentials")
+	}
+
+	if !user.Active {
+		recordAuditAs(c, user.ID, "auth.login_failed", user.Email, "account disabled")
+		return respondError(c, 403, "Account is disabled")
 	}
 
 	// Generate token
-	token, err := generateJWT(&user)
+	token, err := generateJWT(user)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate token"})
+		return respondError(c, 500, "Failed to generate token")
 	}
 
-	return c.JSON(fiber.Map{
-		"token": token,
-		"user": fiber.Map{
-			"id":    user.ID,
-			"email": user.Email,
-			"name":  user.Name,
-		},
+	recordAuditAs(c, user.ID, "auth.login", user.Email, "")
+
+	return c.JSON(LoginResponse{
+		Token: token,
+		User:  UserSummary{ID: user.ID, Email: user.Email, Name: user.Name},
 	})
 }
 
+// User admin handlers
+
+func (s *server) listUsersHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	users, err := s.store.ListUsers(ctx)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if users == nil {
+		users = []User{}
+	}
+	return c.JSON(users)
+}
+
+type SetUserActiveRequest struct {
+	Active *bool `json:"active"`
+}
+
+func (r *SetUserActiveRequest) validate(errs *fieldErrors) {
+	if r.Active == nil {
+		errs.add("active", "active is required")
+	}
+}
+
+type SetUserActiveResponse struct {
+	ID     int64 `json:"id"`
+	Active bool  `json:"active"`
+}
+
+func (s *server) setUserActiveHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	var id int64
+	if _, err := fmt.Sscanf(c.Params("id"), "%d", &id); err != nil {
+		return respondError(c, 400, "Invalid ID")
+	}
+
+	var req SetUserActiveRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	if !*req.Active && id == c.Locals("userID").(int64) {
+		return respondError(c, 400, "You cannot disable your own account")
+	}
+
+	found, err := s.store.SetUserActive(ctx, id, *req.Active)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if !found {
+		return respondError(c, 404, "User not found")
+	}
+
+	action := "user.disable"
+	if *req.Active {
+		action = "user.enable"
+	}
+	recordAudit(c, action, fmt.Sprintf("user:%d", id), "")
+
+	return c.JSON(SetUserActiveResponse{ID: id, Active: *req.Active})
+}
+
+// internalSecretRequired is Fiber middleware that restricts a route to
+// trusted services presenting INTERNAL_API_SECRET in X-Internal-Secret
+func internalSecretRequired() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		secret := config.InternalAPISecret
+		if secret == "" {
+			return respondError(c, 503, "Internal API is not configured")
+		}
+		if subtle.ConstantTimeCompare([]byte(c.Get("X-Internal-Secret")), []byte(secret)) != 1 {
+			return respondError(c, 401, "Unauthorized")
+		}
+		return c.Next()
+	}
+}
+
+type VerifyTokenRequest struct {
+	Token string `json:"token"`
+}
+
+func (r *VerifyTokenRequest) validate(errs *fieldErrors) {
+	errs.require("token", r.Token)
+}
+
+type VerifyTokenResponse struct {
+	Valid  bool       `json:"valid"`
+	Claims *JWTClaims `json:"claims"`
+}
+
+// verifyTokenHandler lets a trusted service check a token issued by this
+// backend without holding the JWT secret itself
+func verifyTokenHandler(c *fiber.Ctx) error {
+	var req VerifyTokenRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	claims, err := validateJWT(strings.TrimPrefix(req.Token, "Bearer "))
+	if err != nil {
+		return respondError(c, 401, err.Error())
+	}
+	active, err := IsUserActive(c.UserContext(), claims.UserID)
+	if err != nil {
+		return respondError(c, 500, "Failed to check account status")
+	}
+	if !active {
+		return respondError(c, 401, "account is disabled")
+	}
+
+	return c.JSON(VerifyTokenResponse{Valid: true, Claims: claims})
+}
+
 func meHandler(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{
-		"id":    c.Locals("userID"),
-		"email": c.Locals("userEmail"),
-		"name":  c.Locals("userName"),
-	})
+	id, _ := c.Locals("userID").(int64)
+	email, _ := c.Locals("userEmail").(string)
+	name, _ := c.Locals("userName").(string)
+	return c.JSON(UserSummary{ID: id, Email: email, Name: name})
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestInternalRoutesRequireSecret(t *testing.T) {
+	useTestConfig(t)
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	routes := []struct {
+		path string
+		body any
+	}{
+		{"/api/internal/email-bounce", EmailBounceRequest{Email: "ada@example.com", Type: "hard"}},
+		{"/api/internal/transcript", TranscriptMessage{RoomName: "room", Speaker: "Ada", Text: "hello", IsFinal: true}},
+		{"/api/internal/speakers", map[string]any{"room_name": "room"}},
+	}
+	for _, r := range routes {
+		if resp := doRequest(t, app, http.MethodPost, r.path, r.body); resp.Status != http.StatusUnauthorized {
+			t.Errorf("%s without a secret: got %d, want 401", r.path, resp.Status)
+		}
+		if resp := doRequest(t, app, http.MethodPost, r.path, r.body, "X-Internal-Secret", "wrong"); resp.Status != http.StatusUnauthorized {
+			t.Errorf("%s with the wrong secret: got %d, want 401", r.path, resp.Status)
+		}
+	}
+
+	config.InternalAPISecret = ""
+	for _, r := range routes {
+		if resp := doRequest(t, app, http.MethodPost, r.path, r.body, internalSecret()...); resp.Status != http.StatusServiceUnavailable {
+			t.Errorf("%s with no secret configured: got %d, want 503", r.path, resp.Status)
+		}
+	}
+}
+
+func TestAdminDeniedWhenNoAdminsConfigured(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	config.AdminEmails = nil
+	adminEmails = map[string]bool{}
+	if isAdmin(testAdminEmail) {
+		t.Errorf("isAdmin(%q) with no admins configured: got true", testAdminEmail)
+	}
+	if resp := doRequest(t, app, http.MethodGet, "/api/admin/suppressions", nil, bearer(t, testAdminEmail)...); resp.Status != http.StatusForbidden {
+		t.Errorf("admin route with no admins configured: got %d, want 403", resp.Status)
+	}
+}
+
+// useTestClock fixes the session token clock at now for the rest of the test
+func useTestClock(t *testing.T, now time.Time) *time.Time {
+	t.Helper()
+	prev := clockFunc
+	clock := now
+	clockFunc = func() time.Time { return clock }
+	t.Cleanup(func() { clockFunc = prev })
+	return &clock
+}
+
+func TestValidateJWTExpiryEdges(t *testing.T) {
+	useTestConfig(t)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	useTestClock(t, now)
+
+	for _, tc := range []struct {
+		name  string
+		exp   time.Time
+		valid bool
+	}{
+		{"expired one second ago", now.Add(-time.Second), false},
+		{"expiring now", now, false},
+		{"expiring in one second", now.Add(time.Second), true},
+	} {
+		token, err := signJWT(JWTClaims{UserID: 1, Email: testUserEmail, Exp: tc.exp.Unix()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = validateJWT(token)
+		if tc.valid && err != nil {
+			t.Errorf("%s: got %v, want valid", tc.name, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%s: got valid, want an error", tc.name)
+		}
+	}
+}
+
+func TestJWTRoundTripWithClock(t *testing.T) {
+	useTestConfig(t)
+	config.SessionMaxAge = time.Hour
+	config.SessionIdleTimeout = 0
+	issued := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock := useTestClock(t, issued)
+
+	token, err := generateJWT(&User{ID: 7, Email: testUserEmail, Name: "Burt"})
+	if err != nil {
+		t.Fatalf("generateJWT: %v", err)
+	}
+	claims, err := validateJWT(token)
+	if err != nil {
+		t.Fatalf("validateJWT at issue time: %v", err)
+	}
+	if claims.UserID != 7 || claims.Email != testUserEmail || claims.Exp != issued.Add(time.Hour).Unix() {
+		t.Errorf("claims: got %+v", claims)
+	}
+
+	*clock = issued.Add(time.Hour - time.Second)
+	if _, err := validateJWT(token); err != nil {
+		t.Errorf("one second before expiry: got %v, want valid", err)
+	}
+	*clock = issued.Add(time.Hour)
+	if _, err := validateJWT(token); err == nil {
+		t.Error("at expiry: got valid, want an error")
+	}
+}
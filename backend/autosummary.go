@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// autoSummaryDelay gives the AI service time to save final notes after a room
+// finishes before the summary email goes out
+const autoSummaryDelay = 30 * time.Second
+
+// SetMeetingAutoSummary turns the automatic summary email on or off for a
+// meeting. It reports false if the meeting does not exist.
+func SetMeetingAutoSummary(ctx context.Context, roomName string, enabled bool) (bool, error) {
+	result, err := execWrite(ctx, "UPDATE meetings SET auto_send_summary = ? WHERE room_name = ?", enabled, roomName)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// scheduleAutoSummary emails a finished meeting's notes to its subscribers
+// after autoSummaryDelay, unless the host has turned auto-send off. Whether
+// the email was sent or skipped is recorded in the audit log.
+func (s *server) scheduleAutoSummary(parent context.Context, roomName string) {
+	time.AfterFunc(autoSummaryDelay, func() {
+		s.jobs.Submit(Job{Name: "auto summary " + roomName, Origin: parent, Execute: func(ctx context.Context) error {
+			s.sendAutoSummary(ctx, roomName)
+			return nil
+		}})
+	})
+}
+
+func (s *server) sendAutoSummary(ctx context.Context, roomName string) {
+	// Checked after the delay so turning auto-send off during it still counts
+	meeting, err := s.store.GetMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		// Rooms that never saved anything have no meeting row
+		return
+	}
+	if err != nil {
+		ctxLogger(ctx).Error("Auto summary failed", "error", err)
+		return
+	}
+	if !meeting.AutoSendSummary {
+		logAutoSummary(ctx, "summary.auto_skipped", roomName, "auto-send disabled")
+		return
+	}
+
+	notes, err := s.store.GetNotesByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		logAutoSummary(ctx, "summary.auto_skipped", roomName, "no notes")
+		return
+	}
+	if err != nil {
+		ctxLogger(ctx).Error("Auto summary failed", "error", err)
+		return
+	}
+
+	if err := TriggerEmailWorkflow(ctx, roomName, notes.Markdown); err != nil {
+		ctxLogger(ctx).Error("Auto summary failed", "error", err)
+		logAutoSummary(ctx, "summary.auto_failed", roomName, err.Error())
+		return
+	}
+	logAutoSummary(ctx, "summary.auto_sent", roomName, "")
+}
+
+func logAutoSummary(ctx context.Context, action, roomName, details string) {
+	ctxLogger(ctx).Info("Auto summary", "action", action, "details", details)
+	if err := LogAudit(ctx, 0, action, roomName, details, ""); err != nil {
+		ctxLogger(ctx).Error("Failed to write audit log", "action", action, "target", roomName, "error", err)
+	}
+}
+
+// autoSummaryHandler returns a handler that turns the automatic summary
+// email on or off for a meeting
+type AutoSummaryResponse struct {
+	RoomName        string `json:"roomName"`
+	AutoSendSummary bool   `json:"autoSendSummary"`
+}
+
+func (s *server) autoSummaryHandler(enabled bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		room := roomParam(c)
+		if !s.canManageRoom(c, room) {
+			return respondError(c, 403, "Not your meeting")
+		}
+
+		found, err := s.store.SetMeetingAutoSummary(ctx, room, enabled)
+		if err != nil {
+			return respondError(c, 500, err.Error())
+		}
+		if !found {
+			return respondError(c, 404, "Meeting not found")
+		}
+
+		action := "meeting.auto_summary_disable"
+		if enabled {
+			action = "meeting.auto_summary_enable"
+		}
+		recordAudit(c, action, room, "")
+
+		return c.JSON(AutoSummaryResponse{RoomName: room, AutoSendSummary: enabled})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupHandlerStatus(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	store := newFakeStore()
+	app := newTestApp(t, newTestServer(t, store))
+	admin := bearer(t, testAdminEmail)
+
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{nil, 200},
+		{errBackupRunning, 409},
+		{errBackupUnsupported, 501},
+		{errors.New("disk full"), 500},
+	} {
+		store.backupErr = tc.err
+		if resp := doRequest(t, app, "POST", "/api/admin/backup", nil, admin...); resp.Status != tc.want {
+			t.Errorf("backup error %v: got %d, want %d", tc.err, resp.Status, tc.want)
+		}
+	}
+}
+
+func TestBackupDatabaseRotates(t *testing.T) {
+	c := useTestConfig(t)
+	c.BackupDir = t.TempDir()
+	c.BackupKeep = 2
+	useTestDBAt(t, filepath.Join(t.TempDir(), "boom.db"))
+
+	// Two stale snapshots, older than any the backup will write
+	for _, name := range []string{"boom-20000101T000000Z.db", "boom-20000102T000000Z.db"} {
+		if err := os.WriteFile(filepath.Join(c.BackupDir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	result, err := BackupDatabase(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SizeBytes == 0 || len(result.SHA256) != 64 {
+		t.Errorf("got %+v, want a non-empty snapshot with a checksum", result)
+	}
+	if len(result.Rotated) != 1 || filepath.Base(result.Rotated[0]) != "boom-20000101T000000Z.db" {
+		t.Errorf("rotated %v, want only the oldest snapshot", result.Rotated)
+	}
+	matches, _ := filepath.Glob(filepath.Join(c.BackupDir, "*"))
+	if len(matches) != 2 {
+		t.Errorf("backup directory holds %v, want 2 snapshots and no partial file", matches)
+	}
+}
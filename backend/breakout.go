@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/livekit/protocol/livekit"
+)
+
+// Breakout rooms are ordinary LiveKit rooms named after their parent. The
+// SDK has no way to move a participant between rooms, so each participant is
+// sent a data message on breakoutTopic carrying a token for the room to
+// switch to, and the client reconnects with it.
+const (
+	breakoutTopic      = "breakout"
+	maxBreakoutRooms   = 26
+	breakoutCloseDelay = 30 * time.Second // lets clients leave before child rooms are deleted
+)
+
+// BreakoutRoom is a child room split off from a meeting
+type BreakoutRoom struct {
+	ParentRoom string    `json:"parentRoom"`
+	ChildRoom  string    `json:"roomName"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// breakoutMessage is the data message that tells a client to switch rooms
+type breakoutMessage struct {
+	Type  string `json:"type"` // breakout.move, breakout.return
+	Room  string `json:"room"`
+	Name  string `json:"name,omitempty"`
+	Token string `json:"token"`
+}
+
+// breakoutRoomName returns the LiveKit room for the i-th breakout group
+func breakoutRoomName(parent string, i int) string {
+	return fmt.Sprintf("%s-breakout-%c", parent, 'a'+i)
+}
+
+// CreateBreakoutRooms records the child rooms of a breakout
+func CreateBreakoutRooms(ctx context.Context, rooms []BreakoutRoom) error {
+	return withTx(ctx, func(tx *storeTx) error {
+		for _, r := range rooms {
+			if _, err := tx.ExecContext(ctx,
+				"INSERT INTO breakout_rooms (parent_room, child_room, name) VALUES (?, ?, ?)",
+				r.ParentRoom, r.ChildRoom, r.Name,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListBreakoutRooms returns the active breakout rooms of a meeting
+func ListBreakoutRooms(ctx context.Context, parentRoom string) ([]BreakoutRoom, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT parent_room, child_room, name, created_at FROM breakout_rooms WHERE parent_room = ? ORDER BY child_room",
+		parentRoom,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []BreakoutRoom
+	for rows.Next() {
+		var r BreakoutRoom
+		if err := rows.Scan(&r.ParentRoom, &r.ChildRoom, &r.Name, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, r)
+	}
+	return rooms, rows.Err()
+}
+
+// DeleteBreakoutRooms forgets a meeting's breakout rooms once it has ended
+func DeleteBreakoutRooms(ctx context.Context, parentRoom string) error {
+	_, err := execWrite(ctx, "DELETE FROM breakout_rooms WHERE parent_room = ?", parentRoom)
+	return err
+}
+
+// sendRoomSwitch tells one participant of fromRoom to reconnect to toRoom
+func (s *server) sendRoomSwitch(ctx context.Context, fromRoom string, p *livekit.ParticipantInfo, msgType, toRoom, label string) error {
+	token, err := joinToken(toRoom, p.Identity, p.Name)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(breakoutMessage{Type: msgType, Room: toRoom, Name: label, Token: token})
+	if err != nil {
+		return err
+	}
+	topic := breakoutTopic
+	_, err = s.rooms.SendData(ctx, &livekit.SendDataRequest{
+		Room:                  fromRoom,
+		Data:                  data,
+		Kind:                  livekit.DataPacket_RELIABLE,
+		DestinationIdentities: []string{p.Identity},
+		Topic:                 &topic,
+	})
+	return err
+}
+
+// Breakout handlers
+
+type BreakoutGroup struct {
+	Name         string   `json:"name"`
+	Participants []string `json:"participants"` // LiveKit identities
+}
+
+type StartBreakoutRequest struct {
+	Rooms []BreakoutGroup `json:"rooms"`
+}
+
+func (r *StartBreakoutRequest) validate(errs *fieldErrors) {
+	if len(r.Rooms) == 0 || len(r.Rooms) > maxBreakoutRooms {
+		errs.add("rooms", fmt.Sprintf("rooms must list between 1 and %d groups", maxBreakoutRooms))
+	}
+	assigned := map[string]bool{}
+	for i, g := range r.Rooms {
+		errs.require(fmt.Sprintf("rooms[%d].name", i), g.Name)
+		for _, identity := range g.Participants {
+			if assigned[identity] {
+				errs.add(fmt.Sprintf("rooms[%d].participants", i), fmt.Sprintf("%s is assigned to more than one room", identity))
+			}
+			assigned[identity] = true
+		}
+	}
+}
+
+type StartBreakoutResponse struct {
+	ParentRoom string         `json:"parentRoom"`
+	Rooms      []BreakoutRoom `json:"rooms"`
+	Failed     []string       `json:"failed"` // identities that could not be moved
+}
+
+func (s *server) startBreakoutHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	parent := roomParam(c)
+	if !s.canManageRoom(c, parent) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	var req StartBreakoutRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	existing, err := s.store.ListBreakoutRooms(ctx, parent)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if len(existing) > 0 {
+		return respondError(c, 409, "Breakout rooms are already open for this meeting")
+	}
+
+	resp, err := s.rooms.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: parent})
+	if err != nil {
+		return respondError(c, 502, "Failed to list participants: "+err.Error())
+	}
+	present := map[string]*livekit.ParticipantInfo{}
+	for _, p := range resp.Participants {
+		present[p.Identity] = p
+	}
+	var missing []string
+	for _, g := range req.Rooms {
+		for _, identity := range g.Participants {
+			if present[identity] == nil {
+				missing = append(missing, identity)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return respondAPIError(c, 400, APIError{Message: "Participants are not in the meeting", Details: fiber.Map{"missing": missing}})
+	}
+
+	rooms := make([]BreakoutRoom, len(req.Rooms))
+	for i, g := range req.Rooms {
+		rooms[i] = BreakoutRoom{ParentRoom: parent, ChildRoom: breakoutRoomName(parent, i), Name: g.Name}
+		if _, err := s.rooms.CreateRoom(ctx, &livekit.CreateRoomRequest{
+			Name:            rooms[i].ChildRoom,
+			EmptyTimeout:    10 * 60, // 10 minutes
+			MaxParticipants: 50,
+		}); err != nil {
+			return respondError(c, 502, "Failed to create breakout room: "+err.Error())
+		}
+	}
+	if err := s.store.CreateBreakoutRooms(ctx, rooms); err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	failed := []string{}
+	for i, g := range req.Rooms {
+		for _, identity := range g.Participants {
+			if err := s.sendRoomSwitch(ctx, parent, present[identity], "breakout.move", rooms[i].ChildRoom, g.Name); err != nil {
+				ctxLogger(ctx).Warn("Failed to move participant to breakout", "identity", identity, "breakout", rooms[i].ChildRoom, "error", err)
+				failed = append(failed, identity)
+			}
+		}
+	}
+
+	recordAudit(c, "meeting.breakout_start", parent, fmt.Sprintf("%d rooms", len(rooms)))
+
+	return c.JSON(StartBreakoutResponse{ParentRoom: parent, Rooms: rooms, Failed: failed})
+}
+
+type EndBreakoutResponse struct {
+	ParentRoom string   `json:"parentRoom"`
+	Returned   int      `json:"returned"`
+	Failed     []string `json:"failed"` // identities that could not be moved back
+}
+
+func (s *server) endBreakoutHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	parent := roomParam(c)
+	if !s.canManageRoom(c, parent) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	rooms, err := s.store.ListBreakoutRooms(ctx, parent)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if len(rooms) == 0 {
+		return respondError(c, 404, "No breakout rooms for this meeting")
+	}
+
+	returned := 0
+	failed := []string{}
+	for _, r := range rooms {
+		resp, err := s.rooms.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: r.ChildRoom})
+		if err != nil {
+			// The room may already have closed after emptying
+			ctxLogger(ctx).Warn("Failed to list breakout participants", "breakout", r.ChildRoom, "error", err)
+			continue
+		}
+		for _, p := range resp.Participants {
+			if isBotParticipant(p) {
+				continue
+			}
+			if err := s.sendRoomSwitch(ctx, r.ChildRoom, p, "breakout.return", parent, ""); err != nil {
+				ctxLogger(ctx).Warn("Failed to return participant from breakout", "identity", p.Identity, "breakout", r.ChildRoom, "error", err)
+				failed = append(failed, p.Identity)
+				continue
+			}
+			returned++
+		}
+	}
+
+	if err := s.store.DeleteBreakoutRooms(ctx, parent); err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	// Close the child rooms once everyone has had time to reconnect
+	time.AfterFunc(breakoutCloseDelay, func() {
+		s.jobs.Submit(Job{Name: "close breakout rooms " + parent, Origin: ctx, Execute: func(ctx context.Context) error {
+			for _, r := range rooms {
+				if _, err := s.rooms.DeleteRoom(ctx, &livekit.DeleteRoomRequest{Room: r.ChildRoom}); err != nil {
+					ctxLogger(ctx).Warn("Failed to close breakout room", "breakout", r.ChildRoom, "error", err)
+				}
+			}
+			return nil
+		}})
+	})
+
+	recordAudit(c, "meeting.breakout_end", parent, fmt.Sprintf("%d rooms", len(rooms)))
+
+	return c.JSON(EndBreakoutResponse{ParentRoom: parent, Returned: returned, Failed: failed})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// A host going on leave cancels every meeting in a date range at once. Only
+// the caller's own meetings still in the scheduled status are touched; they
+// are cancelled in one transaction, and each client is then sent a notice.
+
+type BulkCancelScheduledMeetingsRequest struct {
+	From   string `json:"from"`             // ISO 8601, inclusive
+	To     string `json:"to"`               // ISO 8601, exclusive
+	Status string `json:"status,omitempty"` // scheduled, the only status that can be cancelled
+	DryRun bool   `json:"dryRun,omitempty"` // list the meetings without cancelling them
+
+	// Parsed by validate
+	from, to time.Time
+}
+
+func (r *BulkCancelScheduledMeetingsRequest) validate(errs *fieldErrors) {
+	errs.require("from", r.From)
+	errs.require("to", r.To)
+	r.from = errs.timestamp("from", r.From)
+	r.to = errs.timestamp("to", r.To)
+	if !r.from.IsZero() && !r.to.IsZero() && !r.to.After(r.from) {
+		errs.add("to", "to must be after from")
+	}
+	if r.Status != "" && r.Status != "scheduled" {
+		errs.add("status", "only scheduled meetings can be cancelled")
+	}
+}
+
+type BulkCancelScheduledMeetingsResponse struct {
+	DryRun    bool                       `json:"dryRun"`
+	Cancelled int                        `json:"cancelled"` // would be cancelled, on a dry run
+	Meetings  []ScheduledMeetingResponse `json:"meetings"`
+}
+
+// BulkCancelScheduledMeetings cancels a host's scheduled meetings with
+// scheduled_at in [from, to) and returns them. With dryRun set it only
+// returns them.
+func BulkCancelScheduledMeetings(ctx context.Context, hostUserID int64, from, to time.Time, dryRun bool) ([]ScheduledMeeting, error) {
+	meetings := []ScheduledMeeting{}
+	err := withTx(ctx, func(tx *storeTx) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT `+scheduledMeetingColumns+`
+			 FROM scheduled_meetings sm
+			 JOIN users u ON sm.host_user_id = u.id
+			 WHERE sm.host_user_id = ? AND sm.status = 'scheduled' AND sm.scheduled_at >= ? AND sm.scheduled_at < ?
+			 ORDER BY sm.scheduled_at ASC, sm.id ASC`,
+			hostUserID, from, to,
+		)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			m, err := scanScheduledMeeting(rows.Scan)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			meetings = append(meetings, *m)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if dryRun {
+			return nil
+		}
+		for i := range meetings {
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE scheduled_meetings SET status = 'cancelled' WHERE id = ? AND status = 'scheduled'",
+				meetings[i].ID,
+			); err != nil {
+				return err
+			}
+			meetings[i].Status = "cancelled"
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return meetings, nil
+}
+
+func (s *server) bulkCancelScheduledMeetingsHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	hostUserID := c.Locals("userID").(int64)
+	hostEmail := c.Locals("userEmail").(string)
+
+	var req BulkCancelScheduledMeetingsRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	meetings, err := s.store.BulkCancelScheduledMeetings(ctx, hostUserID, req.from, req.to, req.DryRun)
+	if err != nil {
+		ctxLogger(ctx).Error("Failed to bulk cancel scheduled meetings", "error", err)
+		return respondError(c, 500, "Failed to cancel meetings")
+	}
+
+	resp := BulkCancelScheduledMeetingsResponse{
+		DryRun:    req.DryRun,
+		Cancelled: len(meetings),
+		Meetings:  make([]ScheduledMeetingResponse, len(meetings)),
+	}
+	for i := range meetings {
+		resp.Meetings[i] = scheduledMeetingResponse(&meetings[i])
+	}
+	if req.DryRun || len(meetings) == 0 {
+		return c.JSON(resp)
+	}
+
+	for _, m := range meetings {
+		recordAudit(c, "meeting.cancel", m.RoomName, "bulk cancel")
+		if m.ClientEmail == "" {
+			continue
+		}
+		subject := fmt.Sprintf("Meeting with %s cancelled", m.HostName)
+		body := fmt.Sprintf("Your meeting with %s scheduled for %s has been cancelled. Contact %s to arrange a new time.",
+			m.HostName, m.ScheduledAt.Format(time.RFC1123), hostEmail)
+		s.jobs.Submit(Job{Name: "cancellation notice " + m.RoomName, Origin: ctx, Retries: notificationRetries, Execute: func(ctx context.Context) error {
+			return SendNotificationEmail(ctx, "meeting_cancelled", []string{m.ClientEmail}, subject, body)
+		}})
+	}
+
+	return c.JSON(resp)
+}
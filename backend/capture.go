@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/livekit/protocol/livekit"
+)
+
+// start-capture starts recording and transcription as one step. Recording
+// goes first because it is the half that can be refused (consent); if
+// transcription then fails, a recording this request started is stopped and
+// marked failed, so the room never ends up half captured. A recording that
+// was already running is left alone.
+
+// rollbackRecording stops an egress started by a capture request that could
+// not complete
+func (s *server) rollbackRecording(ctx context.Context, roomName string, rec *Recording) error {
+	if err := validateEgressID(rec.EgressID); err != nil {
+		ctxLogger(ctx).Error("Failed to roll back recording", "error", err)
+		return err
+	}
+	if _, err := s.egress.StopEgress(context.Background(), &livekit.StopEgressRequest{EgressId: rec.EgressID}); err != nil {
+		ctxLogger(ctx).Error("Failed to roll back recording", "egress_id", rec.EgressID, "error", err)
+		return err
+	}
+	if err := s.store.UpdateRecordingStatus(ctx, rec.EgressID, "failed", "", 0); err != nil {
+		ctxLogger(ctx).Error("Failed to mark rolled back recording failed", "egress_id", rec.EgressID, "error", err)
+	}
+	ctxLogger(ctx).Info("Rolled back recording", "egress_id", rec.EgressID)
+	return nil
+}
+
+type StartCaptureResponse struct {
+	Status    string `json:"status"` // capturing
+	RoomName  string `json:"roomName"`
+	MeetingID int64  `json:"meetingId"`
+	Recording struct {
+		Status      string `json:"status"` // recording or already_recording
+		EgressID    string `json:"egressId"`
+		RecordingID int64  `json:"recordingId"`
+	} `json:"recording"`
+	Transcription struct {
+		Status   string `json:"status"` // transcribing
+		Language string `json:"language"`
+	} `json:"transcription"`
+}
+
+func (s *server) startCaptureHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	roomName := roomParam(c)
+	if !roomScopeAllows(c, roomName, roomPermRecording) || !roomScopeAllows(c, roomName, roomPermTranscription) {
+		return roomScopeDenied(c)
+	}
+
+	requested, cerr := parseTranscriptionLanguage(c)
+	if cerr != nil {
+		return cerr.respond(c)
+	}
+
+	rec, started, cerr := s.startRecording(ctx, roomName)
+	if cerr != nil {
+		cerr.detail("failed", "recording")
+		return cerr.respond(c)
+	}
+
+	meeting, language, cerr := s.startTranscription(ctx, roomName, requested)
+	if cerr != nil {
+		cerr.detail("failed", "transcription")
+		if started {
+			rolledBack := s.rollbackRecording(ctx, roomName, rec) == nil
+			cerr.detail("rolledBack", rolledBack)
+			if !rolledBack {
+				// The egress is still running and tracked; report it so the
+				// caller can stop it
+				cerr.detail("egressId", rec.EgressID)
+			}
+		}
+		return cerr.respond(c)
+	}
+
+	recordingStatus := "already_recording"
+	if started {
+		recordingStatus = "recording"
+		recordAudit(c, "recording.start", roomName, rec.EgressID)
+		broadcastEvent(roomName, EventStatus, StatusEvent{Kind: "recording", State: "started"})
+	}
+	recordAudit(c, "transcription.start", roomName, language)
+	broadcastEvent(roomName, EventStatus, StatusEvent{Kind: "transcription", State: "started"})
+
+	resp := StartCaptureResponse{Status: "capturing", RoomName: roomName, MeetingID: meeting.ID}
+	resp.Recording.Status = recordingStatus
+	resp.Recording.EgressID = rec.EgressID
+	resp.Recording.RecordingID = rec.ID
+	resp.Transcription.Status = "transcribing"
+	resp.Transcription.Language = language
+	return c.JSON(resp)
+}
+
+// CaptureStatus reports what is being captured in a room. MeetingID and
+// Ended are omitted when the room has never had a meeting.
+type CaptureStatus struct {
+	RoomName  string `json:"roomName"`
+	MeetingID int64  `json:"meetingId,omitempty"`
+	Ended     *bool  `json:"ended,omitempty"`
+	Recording struct {
+		Active    bool       `json:"active"`
+		EgressID  string     `json:"egressId,omitempty"`
+		StartedAt *time.Time `json:"startedAt,omitempty"`
+	} `json:"recording"`
+	Transcription struct {
+		Active    bool       `json:"active"`
+		StartedAt *time.Time `json:"startedAt,omitempty"`
+		Language  string     `json:"language,omitempty"`
+	} `json:"transcription"`
+}
+
+// captureStatusHandler reports whether a room is being recorded or
+// transcribed. It reads only the database, so any backend instance gives the
+// same answer regardless of which one started the capture.
+func (s *server) captureStatusHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	roomName := roomParam(c)
+	if !roomScopeAllows(c, roomName, roomPermRecording) && !roomScopeAllows(c, roomName, roomPermTranscription) {
+		return roomScopeDenied(c)
+	}
+
+	status := CaptureStatus{RoomName: roomName}
+
+	meeting, err := s.store.GetMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		// Nothing has ever been captured in this room
+		return c.JSON(status)
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	ended := meeting.EndedAt != nil
+	status.MeetingID = meeting.ID
+	status.Ended = &ended
+
+	rec, err := s.store.GetActiveRecordingByMeeting(ctx, meeting.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return respondError(c, 500, err.Error())
+	}
+	if rec != nil {
+		startedAt := rec.CreatedAt
+		status.Recording.Active = true
+		status.Recording.EgressID = rec.EgressID
+		status.Recording.StartedAt = &startedAt
+	}
+
+	if meeting.TranscribingSince != nil {
+		status.Transcription.Active = true
+		status.Transcription.StartedAt = meeting.TranscribingSince
+		status.Transcription.Language = meeting.Language
+		if status.Transcription.Language == "" {
+			status.Transcription.Language = config.TranscriptionLanguage
+		}
+	}
+
+	return c.JSON(status)
+}
@@ -0,0 +1,705 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Config is every setting the backend reads from its environment. It is
+// loaded once at startup, from the process environment and an optional .env
+// file, and the rest of the code reads the package-level config instead of
+// calling os.Getenv. Each field's env tag names its variable; fields tagged
+// secret are redacted when the config is logged.
+type Config struct {
+	// LiveKit
+	LiveKitURL           string `env:"LIVEKIT_URL"`
+	LiveKitAPIKey        string `env:"LIVEKIT_API_KEY"`
+	LiveKitAPISecret     string `env:"LIVEKIT_API_SECRET" secret:"true"`
+	LiveKitLatencyWarnMS int    `env:"LIVEKIT_HEALTH_LATENCY_WARN_MS"`
+
+	// Services
+	FrontendURL      string `env:"FRONTEND_URL"`
+	PublicBackendURL string `env:"PUBLIC_BACKEND_URL"`
+	AIServiceURL     string `env:"AI_SERVICE_URL"`
+	MetricsAddr      string `env:"METRICS_ADDR"`
+	DebugEndpoints   bool   `env:"DEBUG_ENDPOINTS"`
+	DebugToken       string `env:"DEBUG_TOKEN" secret:"true"`
+	APIDocs          string `env:"API_DOCS"`
+
+	// Logging
+	LogFormat string `env:"LOG_FORMAT"`
+	LogLevel  string `env:"LOG_LEVEL"`
+
+	// HTTP server
+	ListenAddr       string        `env:"LISTEN_ADDR"`
+	TLSCertFile      string        `env:"TLS_CERT_FILE"`
+	TLSKeyFile       string        `env:"TLS_KEY_FILE"`
+	HTTPReadTimeout  time.Duration `env:"HTTP_READ_TIMEOUT"`
+	HTTPWriteTimeout time.Duration `env:"HTTP_WRITE_TIMEOUT"`
+	HTTPIdleTimeout  time.Duration `env:"HTTP_IDLE_TIMEOUT"`
+	HTTPBodyLimit    int           `env:"HTTP_BODY_LIMIT"`
+	HTTPHeaderLimit  int           `env:"HTTP_HEADER_LIMIT"`
+	ShutdownTimeout  time.Duration `env:"SHUTDOWN_TIMEOUT"`
+	ProxyHeader      string        `env:"PROXY_HEADER"`
+	TrustedProxies   []string      `env:"TRUSTED_PROXIES"`
+
+	// HTTP
+	CORSOrigins        []string       `env:"CORS_ORIGINS"`
+	CORSOriginPattern  string         `env:"CORS_ORIGIN_PATTERN"`
+	CORSAllowedMethods string         `env:"CORS_ALLOWED_METHODS"`
+	TokenRateLimit     int            `env:"TOKEN_RATE_LIMIT"`
+	JoinPinMaxAttempts int            `env:"JOIN_PIN_MAX_ATTEMPTS"`
+	RateLimit          int            `env:"RATE_LIMIT"`
+	RateLimitOverrides map[string]int `env:"RATE_LIMIT_OVERRIDES"`
+	RateLimitRedisURL  string         `env:"RATE_LIMIT_REDIS_URL" secret:"true"`
+	InternalAPISecret  string         `env:"INTERNAL_API_SECRET" secret:"true"`
+
+	// Auth
+	JWTSecret          string        `env:"JWT_SECRET" secret:"true"`
+	AdminPassword      string        `env:"BOOM_ADMIN_PASSWORD" secret:"true"`
+	AdminEmails        []string      `env:"BOOM_ADMIN_EMAILS"`
+	SessionMaxAge      time.Duration `env:"SESSION_MAX_AGE"`
+	SessionIdleTimeout time.Duration `env:"SESSION_IDLE_TIMEOUT"`
+
+	// Meetings
+	RoomNameMaxLength       int           `env:"ROOM_NAME_MAX_LENGTH"`
+	MaxActiveRoomsPerHost   int           `env:"MAX_ACTIVE_ROOMS_PER_HOST"`
+	InviteLinkTTL           time.Duration `env:"INVITE_LINK_TTL"`
+	RequireRecordingConsent bool          `env:"REQUIRE_RECORDING_CONSENT"`
+	EgressFilepathTemplate  string        `env:"EGRESS_FILEPATH_TEMPLATE"`
+	TranscriptionLanguage   string        `env:"TRANSCRIPTION_LANGUAGE"`
+	NotesDraftInterval      time.Duration `env:"NOTES_DRAFT_INTERVAL"`
+
+	// Email
+	N8NEmailWebhookURL   string        `env:"N8N_EMAIL_WEBHOOK_URL"`
+	N8NNotifyWebhookURL  string        `env:"N8N_NOTIFY_WEBHOOK_URL"`
+	N8NCallbackSecret    string        `env:"N8N_CALLBACK_SECRET" secret:"true"`
+	N8NPayloadTemplate   string        `env:"N8N_PAYLOAD_TEMPLATE"`
+	ResendAPIKey         string        `env:"RESEND_API_KEY" secret:"true"`
+	EmailFrom            string        `env:"EMAIL_FROM"`
+	EmailFromName        string        `env:"EMAIL_FROM_NAME"`
+	EmailReplyTo         string        `env:"EMAIL_REPLY_TO"`
+	EmailSoftBounceLimit int           `env:"EMAIL_SOFT_BOUNCE_LIMIT"`
+	RecordingLinkTTL     time.Duration `env:"RECORDING_LINK_TTL"`
+
+	// Recording-ready webhook
+	RecordingReadyWebhookURL    string `env:"RECORDING_READY_WEBHOOK_URL"`
+	RecordingReadyWebhookSecret string `env:"RECORDING_READY_WEBHOOK_SECRET" secret:"true"`
+
+	// Panic reports
+	ErrorWebhookURL    string `env:"ERROR_WEBHOOK_URL"`
+	ErrorWebhookSecret string `env:"ERROR_WEBHOOK_SECRET" secret:"true"`
+
+	// Background work
+	JobQueueWorkers int `env:"JOB_QUEUE_WORKERS"`
+	JobQueueSize    int `env:"JOB_QUEUE_SIZE"`
+
+	// Database
+	DatabaseURL           string        `env:"DATABASE_URL" secret:"true"`
+	DatabasePath          string        `env:"DATABASE_PATH"`
+	SQLiteJournalMode     string        `env:"SQLITE_JOURNAL_MODE"`
+	SQLiteSynchronous     string        `env:"SQLITE_SYNCHRONOUS"`
+	WALCheckpointInterval time.Duration `env:"WAL_CHECKPOINT_INTERVAL"`
+
+	// Backups
+	BackupDir      string        `env:"BACKUP_DIR"`
+	BackupKeep     int           `env:"BACKUP_KEEP"`
+	BackupSchedule time.Duration `env:"BACKUP_SCHEDULE"`
+
+	// Retention, in days; 0 keeps data forever
+	RetentionTranscriptsDays int  `env:"RETENTION_TRANSCRIPTS_DAYS"`
+	RetentionNotesDays       int  `env:"RETENTION_NOTES_DAYS"`
+	RetentionRecordingsDays  int  `env:"RETENTION_RECORDINGS_DAYS"`
+	RetentionEmailLogsDays   int  `env:"RETENTION_EMAIL_LOGS_DAYS"`
+	RetentionBatchSize       int  `env:"RETENTION_BATCH_SIZE"`
+	RetentionMaxPerRun       int  `env:"RETENTION_MAX_PER_RUN"`
+	RetentionDryRun          bool `env:"RETENTION_DRY_RUN"`
+}
+
+const (
+	defaultAIServiceURL  = "http://localhost:8081"
+	defaultJWTSecret     = "boom-dev-secret-change-in-production"
+	defaultAdminPassword = "boom2026"
+)
+
+// config is the process configuration. It holds the defaults until main
+// replaces it with the result of loadConfig.
+var config = defaultConfig()
+
+// defaultConfig returns the configuration used for anything the environment
+// leaves unset. The required LiveKit and frontend settings have no default.
+func defaultConfig() *Config {
+	return &Config{
+		LiveKitLatencyWarnMS:   defaultLiveKitLatencyWarnMS,
+		AIServiceURL:           defaultAIServiceURL,
+		APIDocs:                apiDocsAuth,
+		LogFormat:              "json",
+		LogLevel:               "info",
+		ListenAddr:             defaultListenAddr,
+		HTTPReadTimeout:        defaultReadTimeout,
+		HTTPWriteTimeout:       defaultWriteTimeout,
+		HTTPIdleTimeout:        defaultIdleTimeout,
+		HTTPBodyLimit:          defaultBodyLimit,
+		HTTPHeaderLimit:        defaultHeaderLimit,
+		ShutdownTimeout:        defaultShutdownTimeout,
+		CORSAllowedMethods:     defaultCORSAllowedMethods,
+		TokenRateLimit:         defaultTokenRateLimit,
+		JoinPinMaxAttempts:     defaultJoinPinMaxAttempts,
+		RateLimit:              defaultRateLimit,
+		JWTSecret:              defaultJWTSecret,
+		AdminPassword:          defaultAdminPassword,
+		SessionMaxAge:          defaultSessionMaxAge,
+		RoomNameMaxLength:      defaultRoomNameMaxLength,
+		InviteLinkTTL:          defaultInviteLinkTTL,
+		EgressFilepathTemplate: defaultEgressFilepathTemplate,
+		TranscriptionLanguage:  defaultLanguage,
+		NotesDraftInterval:     defaultDraftNotesInterval,
+		EmailSoftBounceLimit:   defaultSoftBounceLimit,
+		RecordingLinkTTL:       defaultRecordingLinkTTL,
+		JobQueueWorkers:        defaultJobQueueWorkers,
+		JobQueueSize:           defaultJobQueueSize,
+		DatabasePath:           defaultDatabasePath,
+		SQLiteJournalMode:      "WAL",
+		WALCheckpointInterval:  defaultWALCheckpointInterval,
+		BackupDir:              defaultBackupDir,
+		BackupKeep:             defaultBackupKeep,
+		RetentionBatchSize:     defaultRetentionBatchSize,
+		RetentionMaxPerRun:     defaultRetentionRunLimit,
+	}
+}
+
+// configError lists every problem found while loading the configuration
+type configError struct {
+	problems []string
+}
+
+func (e *configError) Error() string {
+	return "invalid configuration: " + strings.Join(e.problems, "; ")
+}
+
+// envReader reads typed values from the environment into a Config, keeping
+// the default for unset variables and collecting a problem for each invalid
+// one instead of stopping at the first
+type envReader struct {
+	problems []string
+}
+
+func (r *envReader) fail(key, format string, args ...any) {
+	r.problems = append(r.problems, key+" "+fmt.Sprintf(format, args...))
+}
+
+// lookup returns key's value, trimmed, and whether it is set to anything
+func (r *envReader) lookup(key string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(key))
+	return v, v != ""
+}
+
+func (r *envReader) str(dst *string, key string) {
+	if v := os.Getenv(key); v != "" {
+		*dst = v
+	}
+}
+
+func (r *envReader) required(dst *string, key string) {
+	if v, ok := r.lookup(key); ok {
+		*dst = v
+	} else {
+		r.fail(key, "is required")
+	}
+}
+
+// url accepts an absolute URL with one of schemes
+func (r *envReader) url(dst *string, key string, schemes ...string) {
+	v, ok := r.lookup(key)
+	if !ok {
+		return
+	}
+	u, err := url.Parse(v)
+	if err != nil || u.Host == "" {
+		r.fail(key, "must be an absolute URL, got %q", v)
+		return
+	}
+	for _, s := range schemes {
+		if u.Scheme == s {
+			*dst = strings.TrimSuffix(v, "/")
+			return
+		}
+	}
+	r.fail(key, "must use %s, got %q", strings.Join(schemes, " or "), v)
+}
+
+func (r *envReader) integer(dst *int, key string, min int) {
+	v, ok := r.lookup(key)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min {
+		r.fail(key, "must be an integer of at least %d, got %q", min, v)
+		return
+	}
+	*dst = n
+}
+
+// size accepts a byte count with an optional KB, MB, or GB suffix (powers
+// of 1024)
+func (r *envReader) size(dst *int, key string, min int) {
+	v, ok := r.lookup(key)
+	if !ok {
+		return
+	}
+	units := []struct {
+		suffix string
+		scale  int
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+	number, scale := strings.ToUpper(v), 1
+	for _, u := range units {
+		if n, found := strings.CutSuffix(number, u.suffix); found {
+			number, scale = strings.TrimSpace(n), u.scale
+			break
+		}
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n*scale < min {
+		r.fail(key, "must be a size of at least %d bytes, such as 512KB or 4MB, got %q", min, v)
+		return
+	}
+	*dst = n * scale
+}
+
+// file accepts the path of a readable file
+func (r *envReader) file(dst *string, key string) {
+	v, ok := r.lookup(key)
+	if !ok {
+		return
+	}
+	f, err := os.Open(v)
+	if err != nil {
+		r.fail(key, "must name a readable file: %v", err)
+		return
+	}
+	f.Close()
+	*dst = v
+}
+
+func (r *envReader) duration(dst *time.Duration, key string, min time.Duration) {
+	v, ok := r.lookup(key)
+	if !ok {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < min {
+		r.fail(key, "must be a duration of at least %s, got %q", min, v)
+		return
+	}
+	*dst = d
+}
+
+func (r *envReader) boolean(dst *bool, key string) {
+	v, ok := r.lookup(key)
+	if !ok {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		r.fail(key, "must be true or false, got %q", v)
+		return
+	}
+	*dst = b
+}
+
+// oneOf accepts one of allowed, compared case-insensitively and stored as
+// spelled in allowed
+func (r *envReader) oneOf(dst *string, key string, allowed ...string) {
+	v, ok := r.lookup(key)
+	if !ok {
+		return
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(v, a) {
+			*dst = a
+			return
+		}
+	}
+	r.fail(key, "must be one of %s, got %q", strings.Join(allowed, ", "), v)
+}
+
+// parse applies fn to key's value when it is set
+func (r *envReader) parse(key string, fn func(string) error) {
+	if v, ok := r.lookup(key); ok {
+		if err := fn(v); err != nil {
+			r.fail(key, "%v", err)
+		}
+	}
+}
+
+// loadConfig reads the configuration from the environment. It always
+// returns a usable config, with defaults in place of invalid values, and a
+// *configError naming every missing or invalid variable.
+func loadConfig() (*Config, error) {
+	c := defaultConfig()
+	r := &envReader{}
+
+	r.required(&c.LiveKitURL, "LIVEKIT_URL")
+	r.url(&c.LiveKitURL, "LIVEKIT_URL", "ws", "wss", "http", "https")
+	r.required(&c.LiveKitAPIKey, "LIVEKIT_API_KEY")
+	r.required(&c.LiveKitAPISecret, "LIVEKIT_API_SECRET")
+	r.integer(&c.LiveKitLatencyWarnMS, "LIVEKIT_HEALTH_LATENCY_WARN_MS", 1)
+
+	r.required(&c.FrontendURL, "FRONTEND_URL")
+	r.url(&c.FrontendURL, "FRONTEND_URL", "http", "https")
+	r.url(&c.PublicBackendURL, "PUBLIC_BACKEND_URL", "http", "https")
+	r.url(&c.AIServiceURL, "AI_SERVICE_URL", "http", "https")
+	if v, set := os.LookupEnv("AI_SERVICE_URL"); set && strings.TrimSpace(v) == "" {
+		// Set but empty: run without the AI service
+		c.AIServiceURL = ""
+	}
+	r.str(&c.MetricsAddr, "METRICS_ADDR")
+	r.boolean(&c.DebugEndpoints, "DEBUG_ENDPOINTS")
+	r.str(&c.DebugToken, "DEBUG_TOKEN")
+	r.oneOf(&c.APIDocs, "API_DOCS", apiDocsPublic, apiDocsAuth, apiDocsOff)
+
+	r.oneOf(&c.LogFormat, "LOG_FORMAT", "json", "text")
+	r.oneOf(&c.LogLevel, "LOG_LEVEL", "debug", "info", "warn", "error")
+
+	r.str(&c.ListenAddr, "LISTEN_ADDR")
+	r.file(&c.TLSCertFile, "TLS_CERT_FILE")
+	r.file(&c.TLSKeyFile, "TLS_KEY_FILE")
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		r.fail("TLS_CERT_FILE", "and TLS_KEY_FILE must be set together")
+	}
+	r.duration(&c.HTTPReadTimeout, "HTTP_READ_TIMEOUT", time.Second)
+	r.duration(&c.HTTPWriteTimeout, "HTTP_WRITE_TIMEOUT", time.Second)
+	r.duration(&c.HTTPIdleTimeout, "HTTP_IDLE_TIMEOUT", time.Second)
+	r.size(&c.HTTPBodyLimit, "HTTP_BODY_LIMIT", 1<<10)
+	r.size(&c.HTTPHeaderLimit, "HTTP_HEADER_LIMIT", 1<<10)
+	r.duration(&c.ShutdownTimeout, "SHUTDOWN_TIMEOUT", time.Second)
+	r.str(&c.ProxyHeader, "PROXY_HEADER")
+	r.parse("TRUSTED_PROXIES", func(v string) error {
+		proxies, err := parseTrustedProxies(v)
+		c.TrustedProxies = proxies
+		return err
+	})
+	if c.ProxyHeader != "" && len(c.TrustedProxies) == 0 {
+		r.fail("TRUSTED_PROXIES", "is required with PROXY_HEADER, or anyone could set the client IP")
+	}
+
+	r.parse("CORS_ORIGINS", func(v string) error {
+		origins, err := parseCORSOrigins(v)
+		c.CORSOrigins = origins
+		return err
+	})
+	if len(c.CORSOrigins) == 0 && c.FrontendURL != "" {
+		if origin, err := parseOrigin(c.FrontendURL); err == nil {
+			c.CORSOrigins = []string{origin}
+		}
+	}
+	r.parse("CORS_ORIGIN_PATTERN", func(v string) error {
+		if _, err := compileOriginPattern(v); err != nil {
+			return err
+		}
+		c.CORSOriginPattern = v
+		return nil
+	})
+	r.str(&c.CORSAllowedMethods, "CORS_ALLOWED_METHODS")
+	r.integer(&c.TokenRateLimit, "TOKEN_RATE_LIMIT", 1)
+	r.integer(&c.JoinPinMaxAttempts, "JOIN_PIN_MAX_ATTEMPTS", 1)
+	r.integer(&c.RateLimit, "RATE_LIMIT", 0)
+	r.parse("RATE_LIMIT_OVERRIDES", func(v string) error {
+		overrides, err := parseRateLimitOverrides(v)
+		c.RateLimitOverrides = overrides
+		return err
+	})
+	r.parse("RATE_LIMIT_REDIS_URL", func(v string) error {
+		if _, err := redis.ParseURL(v); err != nil {
+			return err
+		}
+		c.RateLimitRedisURL = v
+		return nil
+	})
+	r.str(&c.InternalAPISecret, "INTERNAL_API_SECRET")
+
+	r.str(&c.JWTSecret, "JWT_SECRET")
+	r.str(&c.AdminPassword, "BOOM_ADMIN_PASSWORD")
+	r.parse("BOOM_ADMIN_EMAILS", func(v string) error {
+		for _, email := range strings.Split(v, ",") {
+			if email = normalizeEmail(email); email != "" {
+				c.AdminEmails = append(c.AdminEmails, email)
+			}
+		}
+		return nil
+	})
+	r.duration(&c.SessionMaxAge, "SESSION_MAX_AGE", time.Second)
+	r.duration(&c.SessionIdleTimeout, "SESSION_IDLE_TIMEOUT", time.Second)
+	if c.SessionIdleTimeout > c.SessionMaxAge {
+		c.SessionIdleTimeout = c.SessionMaxAge
+	}
+
+	r.integer(&c.RoomNameMaxLength, "ROOM_NAME_MAX_LENGTH", 1)
+	r.integer(&c.MaxActiveRoomsPerHost, "MAX_ACTIVE_ROOMS_PER_HOST", 0)
+	r.duration(&c.InviteLinkTTL, "INVITE_LINK_TTL", time.Second)
+	r.boolean(&c.RequireRecordingConsent, "REQUIRE_RECORDING_CONSENT")
+	r.parse("EGRESS_FILEPATH_TEMPLATE", func(v string) (err error) {
+		c.EgressFilepathTemplate, err = parseEgressFilepathTemplate(v)
+		return err
+	})
+	r.parse("TRANSCRIPTION_LANGUAGE", func(v string) error {
+		code, ok := supportedLanguage(v)
+		if !ok {
+			return fmt.Errorf("is not a supported language (see GET /api/languages), got %q", v)
+		}
+		c.TranscriptionLanguage = code
+		return nil
+	})
+	r.duration(&c.NotesDraftInterval, "NOTES_DRAFT_INTERVAL", 0)
+
+	r.url(&c.N8NEmailWebhookURL, "N8N_EMAIL_WEBHOOK_URL", "http", "https")
+	r.url(&c.N8NNotifyWebhookURL, "N8N_NOTIFY_WEBHOOK_URL", "http", "https")
+	r.str(&c.N8NCallbackSecret, "N8N_CALLBACK_SECRET")
+	r.str(&c.N8NPayloadTemplate, "N8N_PAYLOAD_TEMPLATE")
+	r.parse("N8N_PAYLOAD_TEMPLATE", func(v string) error {
+		if _, err := template.New("n8n").Funcs(n8nTemplateFuncs).Parse(v); err != nil {
+			return fmt.Errorf("is not a valid template: %w", err)
+		}
+		return nil
+	})
+	r.str(&c.ResendAPIKey, "RESEND_API_KEY")
+	r.str(&c.EmailFrom, "EMAIL_FROM")
+	r.parse("EMAIL_FROM", parseEmailAddress)
+	if c.ResendAPIKey != "" && c.EmailFrom == "" {
+		r.fail("EMAIL_FROM", "is required when RESEND_API_KEY is set")
+	}
+	r.str(&c.EmailFromName, "EMAIL_FROM_NAME")
+	r.parse("EMAIL_FROM_NAME", func(v string) error {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("must be a single line")
+		}
+		return nil
+	})
+	r.str(&c.EmailReplyTo, "EMAIL_REPLY_TO")
+	r.parse("EMAIL_REPLY_TO", parseEmailAddress)
+	r.integer(&c.EmailSoftBounceLimit, "EMAIL_SOFT_BOUNCE_LIMIT", 1)
+	r.duration(&c.RecordingLinkTTL, "RECORDING_LINK_TTL", time.Minute)
+
+	r.url(&c.RecordingReadyWebhookURL, "RECORDING_READY_WEBHOOK_URL", "http", "https")
+	r.str(&c.RecordingReadyWebhookSecret, "RECORDING_READY_WEBHOOK_SECRET")
+	r.url(&c.ErrorWebhookURL, "ERROR_WEBHOOK_URL", "http", "https")
+	r.str(&c.ErrorWebhookSecret, "ERROR_WEBHOOK_SECRET")
+
+	// BACKGROUND_WORKERS and BACKGROUND_QUEUE_SIZE are the old names
+	r.integer(&c.JobQueueWorkers, "BACKGROUND_WORKERS", 1)
+	r.integer(&c.JobQueueWorkers, "JOB_QUEUE_WORKERS", 1)
+	r.integer(&c.JobQueueSize, "BACKGROUND_QUEUE_SIZE", 0)
+	r.integer(&c.JobQueueSize, "JOB_QUEUE_SIZE", 0)
+
+	r.str(&c.DatabaseURL, "DATABASE_URL")
+	r.str(&c.DatabasePath, "DATABASE_PATH")
+	r.oneOf(&c.SQLiteJournalMode, "SQLITE_JOURNAL_MODE", "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF")
+	r.oneOf(&c.SQLiteSynchronous, "SQLITE_SYNCHRONOUS", "OFF", "NORMAL", "FULL", "EXTRA")
+	r.duration(&c.WALCheckpointInterval, "WAL_CHECKPOINT_INTERVAL", 0)
+
+	r.str(&c.BackupDir, "BACKUP_DIR")
+	r.integer(&c.BackupKeep, "BACKUP_KEEP", 1)
+	r.parse("BACKUP_SCHEDULE", func(v string) (err error) {
+		c.BackupSchedule, err = parseBackupSchedule(v)
+		return err
+	})
+
+	r.integer(&c.RetentionTranscriptsDays, "RETENTION_TRANSCRIPTS_DAYS", 0)
+	r.integer(&c.RetentionNotesDays, "RETENTION_NOTES_DAYS", 0)
+	r.integer(&c.RetentionRecordingsDays, "RETENTION_RECORDINGS_DAYS", 0)
+	r.integer(&c.RetentionEmailLogsDays, "RETENTION_EMAIL_LOGS_DAYS", 0)
+	r.integer(&c.RetentionBatchSize, "RETENTION_BATCH_SIZE", 1)
+	r.integer(&c.RetentionMaxPerRun, "RETENTION_MAX_PER_RUN", 1)
+	r.boolean(&c.RetentionDryRun, "RETENTION_DRY_RUN")
+
+	if len(r.problems) > 0 {
+		return c, &configError{problems: r.problems}
+	}
+	return c, nil
+}
+
+// databaseLocation returns the database to open: DATABASE_URL, then
+// DATABASE_PATH, then the local default file
+func (c *Config) databaseLocation() string {
+	if c.DatabaseURL != "" {
+		return c.DatabaseURL
+	}
+	return c.DatabasePath
+}
+
+// redacted returns the config as slog attributes keyed by variable name,
+// with secrets replaced so the result is safe to log
+func (c *Config) redacted() []any {
+	var attrs []any
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i).Interface()
+		switch val := value.(type) {
+		case time.Duration:
+			value = val.String()
+		case string:
+			if field.Tag.Get("secret") == "true" && val != "" {
+				value = "[redacted]"
+			}
+		}
+		attrs = append(attrs, slog.Any(field.Tag.Get("env"), value))
+	}
+	return attrs
+}
+
+// parseEmailAddress checks an address setting, which may be bare or carry a
+// display name as in "Boom <notes@example.com>"
+func parseEmailAddress(v string) error {
+	if _, err := mail.ParseAddress(v); err != nil {
+		return fmt.Errorf("is not a valid email address")
+	}
+	return nil
+}
+
+// emailSender is the From of summary emails: EMAIL_FROM, with
+// EMAIL_FROM_NAME as its display name when set
+func (c *Config) emailSender() string {
+	if c.EmailFromName == "" {
+		return c.EmailFrom
+	}
+	addr, err := mail.ParseAddress(c.EmailFrom)
+	if err != nil {
+		return c.EmailFrom
+	}
+	addr.Name = c.EmailFromName
+	return addr.String()
+}
+
+// emailDeliveryMode names how summary emails are sent: through n8n, directly
+// through Resend, or not at all
+func (c *Config) emailDeliveryMode() string {
+	switch {
+	case c.N8NEmailWebhookURL != "":
+		return "n8n"
+	case c.ResendAPIKey != "":
+		return "resend"
+	default:
+		return "disabled"
+	}
+}
+
+// AdminConfigResponse summarizes the effective configuration. Settings holds
+// every variable, keyed by name, with secrets redacted.
+type AdminConfigResponse struct {
+	Email struct {
+		DeliveryMode          string `json:"deliveryMode"` // n8n, resend, or disabled
+		From                  string `json:"from"`
+		ReplyTo               string `json:"replyTo,omitempty"`
+		SoftBounceLimit       int    `json:"softBounceLimit"`
+		RecordingLinks        bool   `json:"recordingLinks"`
+		RecordingLinkTTL      string `json:"recordingLinkTTL"`
+		NotifyWebhook         bool   `json:"notifyWebhook"`
+		RecordingReadyWebhook bool   `json:"recordingReadyWebhook"`
+	} `json:"email"`
+	// The backend only asks LiveKit for a file; whether it lands on local
+	// disk or in object storage is the egress service's setting
+	Recording struct {
+		Output           string `json:"output"`
+		FilepathTemplate string `json:"filepathTemplate"`
+		RequireConsent   bool   `json:"requireConsent"`
+	} `json:"recording"`
+	RetentionDays struct {
+		Transcripts int  `json:"transcripts"`
+		Notes       int  `json:"notes"`
+		Recordings  int  `json:"recordings"`
+		EmailLogs   int  `json:"emailLogs"`
+		DryRun      bool `json:"dryRun"`
+	} `json:"retentionDays"`
+	RateLimits struct {
+		TokenPerMinute        int            `json:"tokenPerMinute"`
+		JoinPinAttempts       int            `json:"joinPinAttempts"` // wrong PINs per room per 15 minutes
+		PerMinute             map[string]int `json:"perMinute"`       // by rule; 0 is exempt
+		SharedStore           bool           `json:"sharedStore"`
+		MaxActiveRoomsPerHost int            `json:"maxActiveRoomsPerHost"`
+		HTTPBodyLimitBytes    int            `json:"httpBodyLimitBytes"`
+	} `json:"rateLimits"`
+	Sessions struct {
+		MaxAge      string `json:"maxAge"`
+		IdleTimeout string `json:"idleTimeout"`
+	} `json:"sessions"`
+	AIService struct {
+		Configured bool   `json:"configured"`
+		URL        string `json:"url"`
+	} `json:"aiService"`
+	Database struct {
+		Dialect string `json:"dialect"`
+	} `json:"database"`
+	ErrorWebhook bool           `json:"errorWebhook"`
+	Settings     map[string]any `json:"settings"`
+}
+
+// adminConfigHandler reports the effective configuration so operators can
+// check a deployment without reading its logs. Secrets are redacted as they
+// are when the config is logged.
+func adminConfigHandler(c *fiber.Ctx) error {
+	var resp AdminConfigResponse
+	resp.Email.DeliveryMode = config.emailDeliveryMode()
+	resp.Email.From = config.emailSender()
+	resp.Email.ReplyTo = config.EmailReplyTo
+	resp.Email.SoftBounceLimit = config.EmailSoftBounceLimit
+	resp.Email.RecordingLinks = config.PublicBackendURL != ""
+	resp.Email.RecordingLinkTTL = config.RecordingLinkTTL.String()
+	resp.Email.NotifyWebhook = config.N8NNotifyWebhookURL != ""
+	resp.Email.RecordingReadyWebhook = config.RecordingReadyWebhookURL != ""
+	resp.Recording.Output = "livekit-egress-file"
+	resp.Recording.FilepathTemplate = config.EgressFilepathTemplate
+	resp.Recording.RequireConsent = config.RequireRecordingConsent
+	resp.RetentionDays.Transcripts = config.RetentionTranscriptsDays
+	resp.RetentionDays.Notes = config.RetentionNotesDays
+	resp.RetentionDays.Recordings = config.RetentionRecordingsDays
+	resp.RetentionDays.EmailLogs = config.RetentionEmailLogsDays
+	resp.RetentionDays.DryRun = config.RetentionDryRun
+	resp.RateLimits.TokenPerMinute = config.TokenRateLimit
+	resp.RateLimits.JoinPinAttempts = config.JoinPinMaxAttempts
+	resp.RateLimits.PerMinute = map[string]int{}
+	for _, r := range rateLimitRules(config) {
+		resp.RateLimits.PerMinute[r.Name] = r.Limit
+	}
+	resp.RateLimits.SharedStore = config.RateLimitRedisURL != ""
+	resp.RateLimits.MaxActiveRoomsPerHost = config.MaxActiveRoomsPerHost
+	resp.RateLimits.HTTPBodyLimitBytes = config.HTTPBodyLimit
+	resp.Sessions.MaxAge = config.SessionMaxAge.String()
+	resp.Sessions.IdleTimeout = config.SessionIdleTimeout.String()
+	resp.AIService.Configured = config.AIServiceURL != ""
+	resp.AIService.URL = config.AIServiceURL
+	resp.Database.Dialect = db.dialect
+	resp.ErrorWebhook = config.ErrorWebhookURL != ""
+
+	resp.Settings = map[string]any{}
+	for _, attr := range config.redacted() {
+		a := attr.(slog.Attr)
+		resp.Settings[a.Key] = a.Value.Any()
+	}
+	return c.JSON(resp)
+}
+
+// logConfig logs the loaded configuration with secrets redacted, and warns
+// about insecure defaults
+func logConfig(c *Config) {
+	slog.Info("Configuration loaded", c.redacted()...)
+	if c.JWTSecret == defaultJWTSecret {
+		slog.Warn("JWT_SECRET not set, using default (insecure)")
+	}
+	if c.AdminPassword == defaultAdminPassword {
+		slog.Warn("BOOM_ADMIN_PASSWORD not set, using default")
+	}
+}
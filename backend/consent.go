@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/livekit/protocol/livekit"
+)
+
+// RecordConsent stores a participant's answer to the recording notice,
+// replacing any earlier answer
+func RecordConsent(ctx context.Context, roomName, identity string, given bool, at time.Time) error {
+	return withTx(ctx, func(tx *storeTx) error {
+		meeting, err := GetOrCreateMeeting(ctx, tx, roomName)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO recording_consents (meeting_id, participant_identity, consent_given, consent_at) VALUES (?, ?, ?, ?)
+			 ON CONFLICT(meeting_id, participant_identity) DO UPDATE SET consent_given = ?, consent_at = ?`,
+			meeting.ID, identity, given, at, given, at,
+		)
+		return err
+	})
+}
+
+// ListConsentedIdentities returns the participants in a room who have
+// consented to recording
+func ListConsentedIdentities(ctx context.Context, roomName string) (map[string]bool, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT c.participant_identity FROM recording_consents c
+		 JOIN meetings m ON m.id = c.meeting_id
+		 WHERE m.room_name = ? AND c.consent_given`,
+		roomName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	consented := map[string]bool{}
+	for rows.Next() {
+		var identity string
+		if err := rows.Scan(&identity); err != nil {
+			return nil, err
+		}
+		consented[identity] = true
+	}
+	return consented, rows.Err()
+}
+
+// nonConsentingParticipants lists the people currently in a room who have not
+// consented to recording. Egress, agents, and the transcription bot are not asked.
+func (s *server) nonConsentingParticipants(ctx context.Context, roomName string) ([]string, error) {
+	resp, err := s.rooms.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: roomName})
+	if err != nil {
+		return nil, err
+	}
+	consented, err := s.store.ListConsentedIdentities(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := []string{}
+	for _, p := range resp.Participants {
+		if isBotParticipant(p) || consented[p.Identity] {
+			continue
+		}
+		missing = append(missing, p.Identity)
+	}
+	return missing, nil
+}
+
+type ConsentRequest struct {
+	ConsentGiven        *bool  `json:"consentGiven"`
+	ParticipantIdentity string `json:"participantIdentity"`
+	ConsentTimestamp    string `json:"consentTimestamp"` // RFC3339; defaults to now
+
+	consentAt time.Time // ConsentTimestamp, parsed by validate
+}
+
+func (r *ConsentRequest) validate(errs *fieldErrors) {
+	if r.ConsentGiven == nil {
+		errs.add("consentGiven", "consentGiven is required")
+	}
+	errs.require("participantIdentity", r.ParticipantIdentity)
+	r.consentAt = errs.timestamp("consentTimestamp", r.ConsentTimestamp)
+}
+
+type ConsentResponse struct {
+	Status              string `json:"status"` // recorded
+	ParticipantIdentity string `json:"participantIdentity"`
+	ConsentGiven        bool   `json:"consentGiven"`
+}
+
+func (s *server) recordConsentHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+
+	var req ConsentRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	at := req.consentAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	if err := s.store.RecordConsent(ctx, room, req.ParticipantIdentity, *req.ConsentGiven, at); err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	return c.JSON(ConsentResponse{
+		Status:              "recorded",
+		ParticipantIdentity: req.ParticipantIdentity,
+		ConsentGiven:        *req.ConsentGiven,
+	})
+}
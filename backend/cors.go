@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// Browsers may call the API from the origins in CORS_ORIGINS (FRONTEND_URL's
+// origin when unset) and from any origin matching CORS_ORIGIN_PATTERN, which
+// is meant for preview deploys such as https://pr-[0-9]+\.preview\.example\.com.
+// Credentials are allowed, so "*" is not. A request from any other origin is
+// answered 403 rather than passed through without CORS headers, so a
+// misconfigured frontend shows up in the server log and not only in a
+// browser console.
+
+// defaultCORSAllowedMethods is used when CORS_ALLOWED_METHODS is not set
+const defaultCORSAllowedMethods = "GET, POST, DELETE, OPTIONS"
+
+// parseOrigin normalizes an origin setting to scheme://host[:port]
+func parseOrigin(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if strings.Contains(v, "*") {
+		return "", errors.New("wildcards cannot be used with credentials; use CORS_ORIGIN_PATTERN")
+	}
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%q is not an http or https origin", v)
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", fmt.Errorf("%q is not an origin: drop everything after the host", v)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+// parseCORSOrigins reads a comma-separated CORS_ORIGINS list
+func parseCORSOrigins(v string) ([]string, error) {
+	var origins []string
+	for _, entry := range strings.Split(v, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		origin, err := parseOrigin(entry)
+		if err != nil {
+			return nil, err
+		}
+		origins = append(origins, origin)
+	}
+	return origins, nil
+}
+
+// compileOriginPattern compiles CORS_ORIGIN_PATTERN, anchored so it must
+// match the whole origin
+func compileOriginPattern(p string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + p + `)$`)
+}
+
+// originAllowed reports whether browsers at origin may call the API
+func originAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, o := range config.CORSOrigins {
+		if o == origin {
+			return true
+		}
+	}
+	re, _ := corsOriginPattern()
+	return re != nil && re.MatchString(origin)
+}
+
+// corsOriginPattern is CORS_ORIGIN_PATTERN compiled, or nil if unset.
+// loadConfig has already rejected a pattern that does not compile.
+var corsOriginPattern = sync.OnceValues(func() (*regexp.Regexp, error) {
+	if config.CORSOriginPattern == "" {
+		return nil, nil
+	}
+	return compileOriginPattern(config.CORSOriginPattern)
+})
+
+// rejectDisallowedOrigins answers requests carrying an Origin that CORS does
+// not allow with 403. Requests without one, from servers and API clients,
+// and same-origin requests such as those from /api/docs pass.
+func rejectDisallowedOrigins() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		origin := c.Get(fiber.HeaderOrigin)
+		if origin == "" || originAllowed(origin) {
+			return c.Next()
+		}
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, string(c.Request().Host())) {
+			return c.Next()
+		}
+		ctxLogger(c.UserContext()).Warn("Request from disallowed origin", "origin", origin, "method", c.Method(), "path", c.Path())
+		return respondAPIError(c, 403, APIError{Code: "origin_not_allowed", Message: "Origin " + origin + " is not allowed"})
+	}
+}
+
+// corsConfig returns the base CORS config with each override applied in
+// order. Non-zero fields of an override replace the base value.
+func corsConfig(overrides ...cors.Config) cors.Config {
+	cfg := cors.Config{
+		AllowOriginsFunc: originAllowed,
+		AllowMethods:     config.CORSAllowedMethods,
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, If-Match, X-API-Key, X-Request-ID",
+		ExposeHeaders:    "ETag, X-Refreshed-Token, X-Request-ID, X-Boom-Version",
+		AllowCredentials: true,
+	}
+
+	for _, o := range overrides {
+		if o.Next != nil {
+			cfg.Next = o.Next
+		}
+		if o.AllowOriginsFunc != nil {
+			cfg.AllowOriginsFunc = o.AllowOriginsFunc
+		}
+		if o.AllowOrigins != "" {
+			cfg.AllowOrigins = o.AllowOrigins
+		}
+		if o.AllowMethods != "" {
+			cfg.AllowMethods = o.AllowMethods
+		}
+		if o.AllowHeaders != "" {
+			cfg.AllowHeaders = o.AllowHeaders
+		}
+		if o.ExposeHeaders != "" {
+			cfg.ExposeHeaders = o.ExposeHeaders
+		}
+		if o.AllowCredentials {
+			cfg.AllowCredentials = true
+		}
+		if o.MaxAge != 0 {
+			cfg.MaxAge = o.MaxAge
+		}
+	}
+	return cfg
+}
+
+// corsMethodsWith returns CORS_ALLOWED_METHODS with methods added, for route
+// groups that accept more methods than the rest of the API
+func corsMethodsWith(methods ...string) string {
+	allowed := config.CORSAllowedMethods
+	for _, m := range methods {
+		present := false
+		for _, a := range strings.Split(allowed, ",") {
+			if strings.EqualFold(strings.TrimSpace(a), m) {
+				present = true
+				break
+			}
+		}
+		if !present {
+			allowed += ", " + m
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// preflight sends a CORS preflight for method on path from origin
+func preflight(t *testing.T, app *fiber.App, path, origin, method string) *testResponse {
+	t.Helper()
+	return doRequest(t, app, http.MethodOptions, path, nil,
+		"Origin", origin,
+		"Access-Control-Request-Method", method,
+	)
+}
+
+// sameMethods compares two comma-separated method lists, ignoring spacing,
+// which Fiber strips
+func sameMethods(a, b string) bool {
+	split := func(s string) []string {
+		var methods []string
+		for _, m := range strings.Split(s, ",") {
+			methods = append(methods, strings.TrimSpace(m))
+		}
+		return methods
+	}
+	return slices.Equal(split(a), split(b))
+}
+
+func TestPreflightAllowMethodsPerRouteGroup(t *testing.T) {
+	useTestConfig(t)
+	config.CORSAllowedMethods = "GET, POST, PUT, OPTIONS"
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	// The editable groups add PATCH to the configured methods
+	editable := "GET, POST, PUT, OPTIONS, PATCH"
+	for _, tc := range []struct {
+		path, method, want string
+	}{
+		{"/api/scheduled-meetings", "POST", editable},
+		{"/api/scheduled-meetings/7", "PATCH", editable},
+		{"/api/meetings/retro/notes", "GET", editable},
+		{"/api/meetings/retro/notes/3", "PATCH", editable},
+		{"/api/rooms", "POST", "GET, POST, PUT, OPTIONS"},
+		{"/api/meetings/retro/subscribe-email", "POST", "GET, POST, PUT, OPTIONS"},
+	} {
+		resp := preflight(t, app, tc.path, testOrigin, tc.method)
+		if resp.Status != http.StatusNoContent {
+			t.Errorf("%s: got %d, want 204", tc.path, resp.Status)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Methods"); !sameMethods(got, tc.want) {
+			t.Errorf("%s: Access-Control-Allow-Methods %q, want %q", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestCORSMethodsWith(t *testing.T) {
+	useTestConfig(t)
+	config.CORSAllowedMethods = "GET, patch, OPTIONS"
+	if got := corsMethodsWith("PATCH", "DELETE"); got != "GET, patch, OPTIONS, DELETE" {
+		t.Errorf("got %q, want DELETE added and PATCH not repeated", got)
+	}
+}
+
+// useCORSOriginPattern sets CORS_ORIGIN_PATTERN for the rest of the test
+func useCORSOriginPattern(t *testing.T, pattern string) {
+	t.Helper()
+	prev := corsOriginPattern
+	config.CORSOriginPattern = pattern
+	corsOriginPattern = sync.OnceValues(func() (*regexp.Regexp, error) {
+		return compileOriginPattern(pattern)
+	})
+	t.Cleanup(func() { corsOriginPattern = prev })
+}
+
+func TestPreflightOrigins(t *testing.T) {
+	useTestConfig(t)
+	config.CORSOrigins = []string{"https://app.example.com", "https://staging.example.com", "http://localhost:5173"}
+	useCORSOriginPattern(t, `https://pr-[0-9]+\.preview\.example\.com`)
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	for _, origin := range []string{
+		"https://app.example.com",
+		"https://staging.example.com",
+		"http://localhost:5173",
+		"https://pr-42.preview.example.com",
+	} {
+		resp := preflight(t, app, "/api/rooms", origin, "POST")
+		if resp.Status != http.StatusNoContent {
+			t.Errorf("%s: got %d, want 204", origin, resp.Status)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != origin {
+			t.Errorf("%s: Access-Control-Allow-Origin %q", origin, got)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("%s: Access-Control-Allow-Credentials %q, want true", origin, got)
+		}
+	}
+
+	for _, origin := range []string{
+		"https://evil.example.net",
+		"http://app.example.com",
+		"http://localhost:3000",
+		"https://pr-x.preview.example.com",
+		"https://pr-42.preview.example.com.evil.test",
+		"https://evil.test/https://pr-42.preview.example.com",
+		"null",
+	} {
+		resp := preflight(t, app, "/api/rooms", origin, "POST")
+		if resp.Status != http.StatusForbidden {
+			t.Errorf("%s: got %d, want 403", origin, resp.Status)
+			continue
+		}
+		if code := resp.apiError(t).Code; code != "origin_not_allowed" {
+			t.Errorf("%s: code %q, want origin_not_allowed", origin, code)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("%s: Access-Control-Allow-Origin %q, want none", origin, got)
+		}
+	}
+}
+
+func TestParseCORSOrigins(t *testing.T) {
+	got, err := parseCORSOrigins(" https://App.Example.com/ , http://localhost:5173,,")
+	if err != nil || strings.Join(got, ",") != "https://app.example.com,http://localhost:5173" {
+		t.Errorf("valid list: got %v, %v", got, err)
+	}
+	for _, bad := range []string{
+		"*",
+		"https://*.example.com",
+		"app.example.com",
+		"ftp://app.example.com",
+		"https://app.example.com/path",
+		"https://app.example.com?x=1",
+		"https://user@app.example.com",
+	} {
+		if _, err := parseCORSOrigins(bad); err == nil {
+			t.Errorf("%q: got nil, want an error", bad)
+		}
+	}
+}
@@ -1,43 +1,386 @@
 package main
 
 import (
+	"context"
+	"crypto/md5"
 	"database/sql"
 	_ "embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"time"
 
-	_ "modernc.org/sqlite"
+	"modernc.org/sqlite"
 )
 
 //go:embed schema.sql
 var schemaSQL string
 
-var db *sql.DB
+//go:embed schema_postgres.sql
+var schemaPostgresSQL string
 
-func initDB() error {
-	var err error
-	db, err = sql.Open("sqlite", "./boom.db")
+var db *storeDB
+
+// busyTimeoutMS is how long SQLite waits on a locked database before giving up with SQLITE_BUSY
+const busyTimeoutMS = 5000
+
+// sqliteMaxOpenConns caps the SQLite pool. WAL allows concurrent readers but
+// only one writer, so a large pool just queues more connections on the lock.
+const sqliteMaxOpenConns = 4
+
+// Write retry settings for statements that still fail with SQLITE_BUSY after the busy timeout
+const (
+	writeRetryAttempts  = 4
+	writeRetryBaseDelay = 50 * time.Millisecond
+)
+
+// defaultStatementTimeout bounds any statement whose context has no deadline of its own
+const defaultStatementTimeout = 5 * time.Second
+
+// backgroundTaskTimeout bounds work that outlives the request that started it
+const backgroundTaskTimeout = 2 * time.Minute
+
+// SQLite primary result codes for lock contention
+const (
+	sqliteBusy   = 5
+	sqliteLocked = 6
+)
+
+// defaultDatabasePath is the SQLite file used when neither DATABASE_URL nor DATABASE_PATH is set
+const defaultDatabasePath = "./boom.db"
+
+// memoryDatabasePath selects a throwaway in-memory SQLite database
+const memoryDatabasePath = ":memory:"
+
+// memoryDatabaseSeq names in-memory databases so each initDB call, such as
+// one per test, starts from an empty database of its own
+var memoryDatabaseSeq atomic.Int64
+
+// sqliteDSN builds the driver DSN for a SQLite path. Pragmas in the DSN apply
+// to every connection the pool opens; SQLite only enforces foreign keys on
+// connections that ask for it. Immediate transactions take the write lock up
+// front, so they wait on busy_timeout instead of failing when upgrading from
+// a read.
+func sqliteDSN(path string) (string, error) {
+	options := fmt.Sprintf("_pragma=busy_timeout(%d)&_pragma=foreign_keys(1)&_txlock=immediate", busyTimeoutMS)
+	if synchronous := config.SQLiteSynchronous; synchronous != "" {
+		options += fmt.Sprintf("&_pragma=synchronous(%s)", synchronous)
+	}
+	if path == memoryDatabasePath {
+		// A plain :memory: database is private to one connection; a named
+		// one with a shared cache is seen by every connection in the pool
+		name := fmt.Sprintf("boom-memory-%d", memoryDatabaseSeq.Add(1))
+		return fmt.Sprintf("file:%s?mode=memory&cache=shared&%s", name, options), nil
+	}
+	if err := ensureWritable(path); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("file:%s?%s", path, options), nil
+}
+
+// ensureWritable creates the database's parent directories and checks that
+// both the file and its directory, which holds the WAL files, are writable
+func ensureWritable(path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("cannot create database directory %s: %w", dir, err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
 	if err != nil {
-		return err
+		return fmt.Errorf("database path %s is not writable: %w", path, err)
+	}
+	f.Close()
+	probe, err := os.CreateTemp(dir, ".boom-write-check-*")
+	if err != nil {
+		return fmt.Errorf("database directory %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
+
+// initDB opens the database at location, a postgres:// URL or a SQLite path
+// (":memory:" for a fresh in-memory database), and brings its schema up to
+// date. It replaces any database opened by an earlier call.
+func initDB(location string) error {
+	ctx := context.Background()
+
+	dialect, dsn := parseDatabaseURL(location)
+	schema := schemaSQL
+	path := ""
+	if dialect == dialectPostgres {
+		schema = schemaPostgresSQL
+	} else {
+		if dsn != memoryDatabasePath {
+			path = dsn
+		}
+		var err error
+		if dsn, err = sqliteDSN(dsn); err != nil {
+			return err
+		}
 	}
 
-	// Enable WAL mode for better concurrency
-	_, err = db.Exec("PRAGMA journal_mode=WAL")
+	pool, err := sql.Open(dialect, dsn)
 	if err != nil {
 		return err
 	}
+	if dialect == dialectSQLite {
+		pool.SetMaxOpenConns(sqliteMaxOpenConns)
+		pool.SetMaxIdleConns(sqliteMaxOpenConns)
+	}
+	if db != nil {
+		// Reinitializing (e.g. between tests) releases the previous database
+		db.Close()
+	}
+	db = &storeDB{DB: pool, dialect: dialect, path: path}
+
+	if dialect == dialectSQLite {
+		// WAL by default for better concurrency
+		if _, err := db.ExecContext(ctx, "PRAGMA journal_mode="+config.SQLiteJournalMode); err != nil {
+			return err
+		}
+	}
 
 	// Run schema migrations
-	_, err = db.Exec(schemaSQL)
+	_, err = db.ExecContext(ctx, schema)
 	if err != nil {
 		return err
 	}
 
-	log.Println("Database initialized")
+	if err := migrateColumns(ctx); err != nil {
+		return fmt.Errorf("column migrations: %w", err)
+	}
+
+	if err := migrateIndexes(ctx); err != nil {
+		return fmt.Errorf("index migrations: %w", err)
+	}
+
+	if err := migrateForeignKeys(ctx); err != nil {
+		return fmt.Errorf("foreign key migrations: %w", err)
+	}
+
+	if err := backfillNotesETags(ctx); err != nil {
+		return fmt.Errorf("backfill notes etags: %w", err)
+	}
+
+	if err := backfillNotesStats(ctx); err != nil {
+		return fmt.Errorf("backfill notes stats: %w", err)
+	}
+
+	if err := backfillNotesHTML(ctx); err != nil {
+		return fmt.Errorf("backfill notes HTML: %w", err)
+	}
+
+	if err := backfillNotesCosts(ctx); err != nil {
+		return fmt.Errorf("backfill notes costs: %w", err)
+	}
+
+	if err := backfillMeetingEndedAt(ctx); err != nil {
+		return fmt.Errorf("backfill meeting end times: %w", err)
+	}
+
+	if err := recordSchemaVersion(ctx); err != nil {
+		return fmt.Errorf("record schema version: %w", err)
+	}
+
+	if dialect == dialectSQLite {
+		slog.Info("Database initialized", "dialect", dialect, "location", location)
+	} else {
+		slog.Info("Database initialized", "dialect", dialect)
+	}
+	return nil
+}
+
+// columnMigrations adds columns that schema.sql's CREATE TABLE IF NOT EXISTS
+// statements cannot add to tables created by an earlier schema. New columns
+// go here and in schema_postgres.sql.
+var columnMigrations = []struct {
+	table, column, definition string
+}{
+	{"email_delivery_log", "attempt", "INTEGER NOT NULL DEFAULT 1"},
+	{"email_delivery_log", "provider_message_id", "TEXT"},
+	{"meeting_notes", "etag", "TEXT"},
+	{"meeting_notes", "word_count", "INTEGER"},
+	{"meeting_notes", "reading_time_s", "INTEGER"},
+	{"meeting_notes", "total_cost_usd", "DOUBLE PRECISION"},
+	{"meeting_notes", "notes_html", "TEXT"},
+	{"users", "active", "BOOLEAN NOT NULL DEFAULT 1"},
+	{"users", "namespace", "TEXT"},
+	{"users", "deleted_at", "DATETIME"},
+	{"meetings", "language", "TEXT"},
+	{"meetings", "auto_send_summary", "BOOLEAN NOT NULL DEFAULT 1"},
+	{"meetings", "stats_json", "TEXT"},
+	{"meetings", "recording_locked", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"meetings", "legal_hold", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"meetings", "transcribing_since", "DATETIME"},
+	{"meetings", "host_user_id", "INTEGER"},
+	{"scheduled_meetings", "link_expires_at", "DATETIME"},
+	{"scheduled_meetings", "slug", "TEXT"},
+	{"scheduled_meetings", "join_pin_hash", "TEXT"},
+	{"email_subscriptions", "include_recording", "BOOLEAN NOT NULL DEFAULT 0"},
+}
+
+func migrateColumns(ctx context.Context) error {
+	for _, m := range columnMigrations {
+		exists, err := columnExists(ctx, m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("add %s.%s: %w", m.table, m.column, err)
+		}
+		slog.Info("Added column", "table", m.table, "column", m.column)
+	}
 	return nil
 }
 
+// indexMigrations create indexes on columns added by columnMigrations,
+// which the schema files cannot index before the column exists
+var indexMigrations = []string{
+	"CREATE UNIQUE INDEX IF NOT EXISTS idx_scheduled_slug ON scheduled_meetings(slug)",
+}
+
+func migrateIndexes(ctx context.Context) error {
+	for _, stmt := range indexMigrations {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+func columnExists(ctx context.Context, table, column string) (bool, error) {
+	query := "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?"
+	if db.dialect == dialectPostgres {
+		query = "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?"
+	}
+
+	var n int
+	if err := db.QueryRowContext(ctx, query, table, column).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// withStatementTimeout applies the default statement timeout unless ctx
+// already carries a deadline
+func withStatementTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, defaultStatementTimeout)
+}
+
+// backgroundContext returns a bounded context for work that runs outside
+// any request
+func backgroundContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), backgroundTaskTimeout)
+}
+
+// detachedContext returns a bounded context for work started by a request
+// that must keep running after the response is sent. It keeps the request's
+// ID and logger but not its cancellation.
+func detachedContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(parent), backgroundTaskTimeout)
+}
+
+// isBusyError reports whether err is SQLite refusing a statement because
+// another connection holds the lock. Postgres errors never match.
+func isBusyError(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		code := sqliteErr.Code() & 0xff
+		return code == sqliteBusy || code == sqliteLocked
+	}
+	return false
+}
+
+// withTx runs fn in a transaction under the default statement timeout,
+// committing if it returns nil. The whole transaction is retried with
+// backoff while the database is busy.
+func withTx(ctx context.Context, fn func(tx *storeTx) error) error {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	delay := writeRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, fn)
+		if err == nil || !isBusyError(err) {
+			return err
+		}
+		if attempt == writeRetryAttempts {
+			dbBusyFailures.WithLabelValues("tx").Inc()
+			return err
+		}
+		dbBusyRetries.WithLabelValues("tx").Inc()
+		ctxLogger(ctx).Warn("Database busy, retrying transaction", "attempt", attempt, "max_attempts", writeRetryAttempts)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func runTx(ctx context.Context, fn func(tx *storeTx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// execWrite runs a write statement under the default statement timeout,
+// retrying with exponential backoff while the database is busy
+func execWrite(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	delay := writeRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		result, err := db.ExecContext(ctx, query, args...)
+		if err == nil || !isBusyError(err) {
+			return result, err
+		}
+		if attempt == writeRetryAttempts {
+			dbBusyFailures.WithLabelValues("write").Inc()
+			return result, err
+		}
+		dbBusyRetries.WithLabelValues("write").Inc()
+		ctxLogger(ctx).Warn("Database busy, retrying write", "attempt", attempt, "max_attempts", writeRetryAttempts)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// ErrNotFound is returned by store lookups when no row matches. Handlers map
+// it to 404; any other error is a failure to read the database.
+var ErrNotFound = errors.New("not found")
+
+// notFound converts sql.ErrNoRows into ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
 // Meeting represents a meeting record
 type Meeting struct {
 	ID        int64      `json:"id"`
@@ -45,6 +388,21 @@ type Meeting struct {
 	RoomSID   string     `json:"roomSid"`
 	CreatedAt time.Time  `json:"createdAt"`
 	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	Language  string     `json:"language,omitempty"` // transcription language chosen for this meeting
+
+	DurationMS int64 `json:"durationMs,omitempty"` // created to ended; 0 while live
+
+	AutoSendSummary bool `json:"autoSendSummary"` // email the summary when the room finishes
+	RecordingLocked bool `json:"recordingLocked"` // joiners must acknowledge the recording notice first
+	LegalHold       bool `json:"legalHold"`       // exempt from retention purges
+
+	// TranscribingSince is set while the AI service is transcribing the room.
+	// It lives in the database so every backend instance agrees on it.
+	TranscribingSince *time.Time `json:"transcribingSince,omitempty"`
+
+	// HostUserID is the user who created the room; 0 for scheduled and
+	// webhook-created rooms
+	HostUserID int64 `json:"-"`
 }
 
 // MeetingNotes represents generated notes for a meeting
@@ -56,66 +414,209 @@ type MeetingNotes struct {
 	ModelUsed    string    `json:"modelUsed"`
 	InputTokens  int       `json:"inputTokens"`
 	OutputTokens int       `json:"outputTokens"`
+	ETag         string    `json:"etag"`
+	TotalCostUSD *float64  `json:"totalCostUsd,omitempty"` // nil for models without a price
+
+	// Computed from Markdown
+	WordCount          int     `json:"wordCount"`
+	ReadingTimeMinutes float64 `json:"readingTimeMinutes"`
+	CodeBlockCount     int     `json:"codeBlockCount"`
+	HeadingCount       int     `json:"headingCount"`
 }
 
 // CreateMeeting inserts a new meeting record
-func CreateMeeting(roomName, roomSID string) (*Meeting, error) {
-	result, err := db.Exec(
-		"INSERT INTO meetings (room_name, room_sid) VALUES (?, ?) ON CONFLICT(room_name) DO UPDATE SET room_sid = ?",
-		roomName, roomSID, roomSID,
-	)
-	if err != nil {
+func CreateMeeting(ctx context.Context, roomName, roomSID string, hostUserID int64) (*Meeting, error) {
+	// An existing meeting for the room is kept and given the new SID, so
+	// retrying a room creation is safe. A meeting that has ended is reopened
+	// for the new host with its clock and transcription state reset, so the
+	// room is live again rather than refused with 409 for good. The row is
+	// read back because SQLite's LastInsertId is not the upserted row's id
+	// when the insert conflicts.
+	if _, err := execWrite(ctx,
+		`INSERT INTO meetings (room_name, room_sid, host_user_id) VALUES (?, ?, ?)
+		 ON CONFLICT(room_name) DO UPDATE SET room_sid = excluded.room_sid,
+		   host_user_id = CASE WHEN meetings.ended_at IS NULL
+		     THEN COALESCE(meetings.host_user_id, excluded.host_user_id) ELSE excluded.host_user_id END,
+		   created_at = CASE WHEN meetings.ended_at IS NULL THEN meetings.created_at ELSE CURRENT_TIMESTAMP END,
+		   transcribing_since = CASE WHEN meetings.ended_at IS NULL THEN meetings.transcribing_since END,
+		   ended_at = NULL`,
+		roomName, roomSID, hostUserID,
+	); err != nil {
 		return nil, err
 	}
-
-	id, _ := result.LastInsertId()
-	return &Meeting{
-		ID:        id,
-		RoomName:  roomName,
-		RoomSID:   roomSID,
-		CreatedAt: time.Now(),
-	}, nil
+	return GetMeetingByRoom(ctx, roomName)
 }
 
 // GetMeetingByRoom retrieves a meeting by room name
-func GetMeetingByRoom(roomName string) (*Meeting, error) {
-	var m Meeting
-	var endedAt sql.NullTime
-	err := db.QueryRow(
-		"SELECT id, room_name, room_sid, created_at, ended_at FROM meetings WHERE room_name = ?",
+func GetMeetingByRoom(ctx context.Context, roomName string) (*Meeting, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	return scanMeeting(db.QueryRowContext(ctx,
+		"SELECT "+meetingColumns+" FROM meetings WHERE room_name = ?",
 		roomName,
-	).Scan(&m.ID, &m.RoomName, &m.RoomSID, &m.CreatedAt, &endedAt)
-	if err != nil {
+	))
+}
+
+// GetOrCreateMeeting returns the meeting for a room inside tx, creating it
+// first if needed. Concurrent callers for a new room all get the same row.
+func GetOrCreateMeeting(ctx context.Context, tx *storeTx, roomName string) (*Meeting, error) {
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO meetings (room_name, room_sid) VALUES (?, '') ON CONFLICT(room_name) DO NOTHING",
+		roomName,
+	); err != nil {
 		return nil, err
 	}
+
+	return scanMeeting(tx.QueryRowContext(ctx,
+		"SELECT "+meetingColumns+" FROM meetings WHERE room_name = ?",
+		roomName,
+	))
+}
+
+// EnsureMeeting returns the meeting for a room, creating it if needed
+func EnsureMeeting(ctx context.Context, roomName string) (*Meeting, error) {
+	var meeting *Meeting
+	err := withTx(ctx, func(tx *storeTx) error {
+		var err error
+		meeting, err = GetOrCreateMeeting(ctx, tx, roomName)
+		return err
+	})
+	return meeting, err
+}
+
+const meetingColumns = "id, room_name, room_sid, created_at, ended_at, language, auto_send_summary, recording_locked, legal_hold, transcribing_since, host_user_id"
+
+func scanMeeting(row *sql.Row) (*Meeting, error) {
+	var m Meeting
+	var endedAt, transcribingSince sql.NullTime
+	var language sql.NullString
+	var hostUserID sql.NullInt64
+	if err := row.Scan(&m.ID, &m.RoomName, &m.RoomSID, &m.CreatedAt, &endedAt, &language, &m.AutoSendSummary, &m.RecordingLocked, &m.LegalHold, &transcribingSince, &hostUserID); err != nil {
+		return nil, notFound(err)
+	}
+	if transcribingSince.Valid {
+		m.TranscribingSince = &transcribingSince.Time
+	}
 	if endedAt.Valid {
 		m.EndedAt = &endedAt.Time
+		m.DurationMS = meetingDurationMS(m.CreatedAt, m.EndedAt)
 	}
+	m.Language = language.String
+	m.HostUserID = hostUserID.Int64
 	return &m, nil
 }
 
-// SaveNotes stores generated notes for a meeting
-func SaveNotes(roomName string, markdown string, model string, inputTokens, outputTokens int) (*MeetingNotes, error) {
-	// Get or create meeting
-	meeting, err := GetMeetingByRoom(roomName)
+// SetMeetingTranscribing records whether the AI service is transcribing a
+// room. Marking an already transcribing meeting keeps its original start.
+func SetMeetingTranscribing(ctx context.Context, roomName string, active bool) error {
+	if !active {
+		_, err := execWrite(ctx, "UPDATE meetings SET transcribing_since = NULL WHERE room_name = ?", roomName)
+		return err
+	}
+	_, err := execWrite(ctx,
+		"UPDATE meetings SET transcribing_since = COALESCE(transcribing_since, ?) WHERE room_name = ?",
+		time.Now().UTC(), roomName,
+	)
+	return err
+}
+
+// TranscriptionSession is a room the AI service is transcribing
+type TranscriptionSession struct {
+	RoomName string    `json:"roomName"`
+	Since    time.Time `json:"since"`
+}
+
+// ListTranscriptionSessions returns the rooms being transcribed, longest
+// running first
+func ListTranscriptionSessions(ctx context.Context) ([]TranscriptionSession, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT room_name, transcribing_since FROM meetings WHERE transcribing_since IS NOT NULL ORDER BY transcribing_since",
+	)
 	if err != nil {
-		// Create meeting if not exists
-		meeting, err = CreateMeeting(roomName, "")
-		if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []TranscriptionSession{}
+	for rows.Next() {
+		var s TranscriptionSession
+		if err := rows.Scan(&s.RoomName, &s.Since); err != nil {
 			return nil, err
 		}
+		sessions = append(sessions, s)
 	}
+	return sessions, rows.Err()
+}
 
-	result, err := db.Exec(
-		"INSERT INTO meeting_notes (meeting_id, notes_markdown, model_used, input_tokens, output_tokens) VALUES (?, ?, ?, ?, ?)",
-		meeting.ID, markdown, model, inputTokens, outputTokens,
-	)
+// CountFailedEmailsSince counts summary emails whose delivery failed after since
+func CountFailedEmailsSince(ctx context.Context, since time.Time) (int, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var n int
+	err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM email_delivery_log WHERE status = 'failed' AND created_at >= ?", since.UTC(),
+	).Scan(&n)
+	return n, err
+}
+
+// SetMeetingLanguage stores the transcription language chosen for a meeting
+func SetMeetingLanguage(ctx context.Context, roomName, language string) error {
+	_, err := execWrite(ctx, "UPDATE meetings SET language = ? WHERE room_name = ?", language, roomName)
+	return err
+}
+
+// RoomNameInUse reports whether a room name already belongs to a past or
+// scheduled meeting
+func RoomNameInUse(ctx context.Context, roomName string) (bool, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var n int
+	err := db.QueryRowContext(ctx,
+		"SELECT (SELECT COUNT(*) FROM meetings WHERE room_name = ?) + (SELECT COUNT(*) FROM scheduled_meetings WHERE room_name = ?)",
+		roomName, roomName,
+	).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// SaveNotes stores generated notes for a meeting
+func SaveNotes(ctx context.Context, roomName string, markdown string, model string, inputTokens, outputTokens int) (*MeetingNotes, error) {
+	etag := notesETag(markdown)
+	stats := computeNotesStats(markdown)
+	html, err := renderNotesHTML(markdown)
+	if err != nil {
+		return nil, err
+	}
+	var totalCost *float64
+	if cost, ok := notesCostUSD(model, inputTokens, outputTokens); ok {
+		totalCost = &cost
+	}
+	var meeting *Meeting
+	var id int64
+	err = withTx(ctx, func(tx *storeTx) error {
+		var err error
+		if meeting, err = GetOrCreateMeeting(ctx, tx, roomName); err != nil {
+			return err
+		}
+		id, err = tx.insertReturningID(ctx,
+			"INSERT INTO meeting_notes (meeting_id, notes_markdown, model_used, input_tokens, output_tokens, etag, word_count, reading_time_s, total_cost_usd, notes_html) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			meeting.ID, markdown, model, inputTokens, outputTokens, etag, stats.WordCount, stats.ReadingTimeS, totalCost, html,
+		)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	id, _ := result.LastInsertId()
-	return &MeetingNotes{
+	notes := &MeetingNotes{
 		ID:           id,
 		MeetingID:    meeting.ID,
 		Markdown:     markdown,
@@ -123,34 +624,201 @@ func SaveNotes(roomName string, markdown string, model string, inputTokens, outp
 		ModelUsed:    model,
 		InputTokens:  inputTokens,
 		OutputTokens: outputTokens,
-	}, nil
+		ETag:         etag,
+		TotalCostUSD: totalCost,
+	}
+	applyNotesStats(notes)
+	return notes, nil
 }
 
 // GetNotesByRoom retrieves the latest notes for a room
-func GetNotesByRoom(roomName string) (*MeetingNotes, error) {
-	meeting, err := GetMeetingByRoom(roomName)
+func GetNotesByRoom(ctx context.Context, roomName string) (*MeetingNotes, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	meeting, err := GetMeetingByRoom(ctx, roomName)
 	if err != nil {
 		return nil, err
 	}
 
-	var n MeetingNotes
-	err = db.QueryRow(
-		"SELECT id, meeting_id, notes_markdown, generated_at, model_used, input_tokens, output_tokens FROM meeting_notes WHERE meeting_id = ? ORDER BY generated_at DESC LIMIT 1",
+	return scanNotes(db.QueryRowContext(ctx,
+		"SELECT "+notesColumns+" FROM meeting_notes WHERE meeting_id = ? ORDER BY generated_at DESC, id DESC LIMIT 1",
 		meeting.ID,
-	).Scan(&n.ID, &n.MeetingID, &n.Markdown, &n.GeneratedAt, &n.ModelUsed, &n.InputTokens, &n.OutputTokens)
+	))
+}
+
+// GetNotesByID retrieves a specific notes revision belonging to a room
+func GetNotesByID(ctx context.Context, roomName string, id int64) (*MeetingNotes, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	meeting, err := GetMeetingByRoom(ctx, roomName)
 	if err != nil {
 		return nil, err
 	}
+
+	return scanNotes(db.QueryRowContext(ctx,
+		"SELECT "+notesColumns+" FROM meeting_notes WHERE id = ? AND meeting_id = ?",
+		id, meeting.ID,
+	))
+}
+
+// UpdateNotes replaces the markdown of a notes revision if its stored etag
+// still matches ifMatch. It returns false without writing when the notes were
+// changed by someone else in the meantime.
+func UpdateNotes(ctx context.Context, roomName string, id int64, markdown, ifMatch string) (*MeetingNotes, bool, error) {
+	notes, err := GetNotesByID(ctx, roomName, id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The etag check is part of the UPDATE so two writers holding the same
+	// etag cannot both succeed
+	etag := notesETag(markdown)
+	stats := computeNotesStats(markdown)
+	html, err := renderNotesHTML(markdown)
+	if err != nil {
+		return nil, false, err
+	}
+	result, err := execWrite(ctx,
+		"UPDATE meeting_notes SET notes_markdown = ?, etag = ?, word_count = ?, reading_time_s = ?, notes_html = ? WHERE id = ? AND etag = ?",
+		markdown, etag, stats.WordCount, stats.ReadingTimeS, html, notes.ID, ifMatch,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+	if n == 0 {
+		return nil, false, nil
+	}
+
+	notes.Markdown = markdown
+	notes.ETag = etag
+	applyNotesStats(notes)
+	return notes, true, nil
+}
+
+const notesColumns = "id, meeting_id, notes_markdown, generated_at, model_used, input_tokens, output_tokens, etag, total_cost_usd"
+
+func scanNotes(row *sql.Row) (*MeetingNotes, error) {
+	var n MeetingNotes
+	var etag sql.NullString
+	var totalCost sql.NullFloat64
+	err := row.Scan(&n.ID, &n.MeetingID, &n.Markdown, &n.GeneratedAt, &n.ModelUsed, &n.InputTokens, &n.OutputTokens, &etag, &totalCost)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	n.ETag = etag.String
+	if totalCost.Valid {
+		n.TotalCostUSD = &totalCost.Float64
+	}
+	applyNotesStats(&n)
 	return &n, nil
 }
 
-// ListMeetingsWithNotes returns recent meetings that have notes
-func ListMeetingsWithNotes(limit int) ([]map[string]interface{}, error) {
-	rows, err := db.Query(`
-		SELECT m.id, m.room_name, m.created_at, n.generated_at, n.model_used
+// notesETag returns the MD5 of a notes document, used for optimistic locking
+func notesETag(markdown string) string {
+	sum := md5.Sum([]byte(markdown))
+	return hex.EncodeToString(sum[:])
+}
+
+// backfillNotesETags computes etags for notes saved before the column existed
+func backfillNotesETags(ctx context.Context) error {
+	rows, err := db.QueryContext(ctx, "SELECT id, notes_markdown FROM meeting_notes WHERE etag IS NULL")
+	if err != nil {
+		return err
+	}
+	pending := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var markdown string
+		if err := rows.Scan(&id, &markdown); err != nil {
+			rows.Close()
+			return err
+		}
+		pending[id] = notesETag(markdown)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, etag := range pending {
+		if _, err := execWrite(ctx, "UPDATE meeting_notes SET etag = ? WHERE id = ?", etag, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MeetingListItem is one row of the meetings-with-notes list
+type MeetingListItem struct {
+	ID          int64      `json:"id"`
+	RoomName    string     `json:"roomName"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	EndedAt     *time.Time `json:"endedAt,omitempty"`
+	DurationMS  int64      `json:"durationMs,omitempty"`
+	GeneratedAt time.Time  `json:"generatedAt"`
+	Model       string     `json:"model"`
+
+	WordCount          int      `json:"wordCount"`
+	ReadingTimeMinutes float64  `json:"readingTimeMinutes"`
+	TotalCostUSD       *float64 `json:"totalCostUsd,omitempty"` // nil for models without a price
+}
+
+// Meeting list limits
+const (
+	defaultMeetingListLimit = 20
+	maxMeetingListLimit     = 100
+)
+
+// meetingListSorts maps the sort keys accepted by ListMeetingsWithNotes to columns
+var meetingListSorts = map[string]string{
+	"generatedAt": "n.generated_at",
+	"createdAt":   "m.created_at",
+	"roomName":    "m.room_name",
+}
+
+// MeetingListOptions controls the order and size of ListMeetingsWithNotes.
+// Sort is a key of meetingListSorts; zero values mean newest notes first.
+type MeetingListOptions struct {
+	Sort      string
+	Ascending bool
+	Limit     int
+}
+
+// ListMeetingsWithNotes returns meetings that have notes, one row per set of notes
+func ListMeetingsWithNotes(ctx context.Context, opts MeetingListOptions) ([]MeetingListItem, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	column, ok := meetingListSorts[opts.Sort]
+	if opts.Sort == "" {
+		column, ok = meetingListSorts["generatedAt"], true
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown sort %q", opts.Sort)
+	}
+	direction := "DESC"
+	if opts.Ascending {
+		direction = "ASC"
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultMeetingListLimit
+	}
+	if limit > maxMeetingListLimit {
+		limit = maxMeetingListLimit
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT m.id, m.room_name, m.created_at, m.ended_at, n.generated_at, n.model_used, n.word_count, n.reading_time_s, n.total_cost_usd
 		FROM meetings m
 		INNER JOIN meeting_notes n ON m.id = n.meeting_id
-		ORDER BY n.generated_at DESC
+		ORDER BY `+column+` `+direction+`, n.id `+direction+`
 		LIMIT ?
 	`, limit)
 	if err != nil {
@@ -158,24 +826,29 @@ func ListMeetingsWithNotes(limit int) ([]map[string]interface{}, error) {
 	}
 	defer rows.Close()
 
-	var results []map[string]interface{}
+	results := []MeetingListItem{}
 	for rows.Next() {
-		var id int64
-		var roomName string
-		var createdAt, generatedAt time.Time
-		var model string
-		if err := rows.Scan(&id, &roomName, &createdAt, &generatedAt, &model); err != nil {
-			continue
+		var item MeetingListItem
+		var model sql.NullString
+		var endedAt sql.NullTime
+		var wordCount, readingTimeS sql.NullInt64
+		var totalCost sql.NullFloat64
+		if err := rows.Scan(&item.ID, &item.RoomName, &item.CreatedAt, &endedAt, &item.GeneratedAt, &model, &wordCount, &readingTimeS, &totalCost); err != nil {
+			return nil, err
 		}
-		results = append(results, map[string]interface{}{
-			"id":          id,
-			"roomName":    roomName,
-			"createdAt":   createdAt,
-			"generatedAt": generatedAt,
-			"model":       model,
-		})
+		if endedAt.Valid {
+			item.EndedAt = &endedAt.Time
+			item.DurationMS = meetingDurationMS(item.CreatedAt, item.EndedAt)
+		}
+		item.Model = model.String
+		item.WordCount = int(wordCount.Int64)
+		item.ReadingTimeMinutes = readingTimeMinutes(int(readingTimeS.Int64))
+		if totalCost.Valid {
+			item.TotalCostUSD = &totalCost.Float64
+		}
+		results = append(results, item)
 	}
-	return results, nil
+	return results, rows.Err()
 }
 
 // Recording represents a meeting recording for batch transcription
@@ -191,8 +864,11 @@ type Recording struct {
 }
 
 // CreateRecording inserts a new recording record
-func CreateRecording(meetingID int64, egressID string) (*Recording, error) {
-	result, err := db.Exec(
+func CreateRecording(ctx context.Context, meetingID int64, egressID string) (*Recording, error) {
+	if err := validateEgressID(egressID); err != nil {
+		return nil, err
+	}
+	id, err := insertReturningID(ctx,
 		"INSERT INTO recordings (meeting_id, egress_id, status) VALUES (?, ?, 'recording')",
 		meetingID, egressID,
 	)
@@ -200,7 +876,6 @@ func CreateRecording(meetingID int64, egressID string) (*Recording, error) {
 		return nil, err
 	}
 
-	id, _ := result.LastInsertId()
 	return &Recording{
 		ID:        id,
 		MeetingID: meetingID,
@@ -211,18 +886,21 @@ func CreateRecording(meetingID int64, egressID string) (*Recording, error) {
 }
 
 // GetRecordingByEgressID retrieves a recording by egress ID
-func GetRecordingByEgressID(egressID string) (*Recording, error) {
+func GetRecordingByEgressID(ctx context.Context, egressID string) (*Recording, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
 	var r Recording
 	var audioURL sql.NullString
 	var durationMS sql.NullInt64
 	var completedAt sql.NullTime
 
-	err := db.QueryRow(
+	err := db.QueryRowContext(ctx,
 		"SELECT id, meeting_id, egress_id, status, audio_url, duration_ms, created_at, completed_at FROM recordings WHERE egress_id = ?",
 		egressID,
 	).Scan(&r.ID, &r.MeetingID, &r.EgressID, &r.Status, &audioURL, &durationMS, &r.CreatedAt, &completedAt)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 
 	if audioURL.Valid {
@@ -237,19 +915,48 @@ func GetRecordingByEgressID(egressID string) (*Recording, error) {
 	return &r, nil
 }
 
+// GetRecordingByID retrieves a recording by its ID
+func GetRecordingByID(ctx context.Context, id int64) (*Recording, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var r Recording
+	var audioURL sql.NullString
+	var durationMS sql.NullInt64
+	var completedAt sql.NullTime
+
+	err := db.QueryRowContext(ctx,
+		"SELECT id, meeting_id, egress_id, status, audio_url, duration_ms, created_at, completed_at FROM recordings WHERE id = ?",
+		id,
+	).Scan(&r.ID, &r.MeetingID, &r.EgressID, &r.Status, &audioURL, &durationMS, &r.CreatedAt, &completedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	r.AudioURL = audioURL.String
+	r.DurationMS = durationMS.Int64
+	if completedAt.Valid {
+		r.CompletedAt = &completedAt.Time
+	}
+	return &r, nil
+}
+
 // GetActiveRecordingByMeeting retrieves the active recording for a meeting
-func GetActiveRecordingByMeeting(meetingID int64) (*Recording, error) {
+func GetActiveRecordingByMeeting(ctx context.Context, meetingID int64) (*Recording, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
 	var r Recording
 	var audioURL sql.NullString
 	var durationMS sql.NullInt64
 	var completedAt sql.NullTime
 
-	err := db.QueryRow(
-		"SELECT id, meeting_id, egress_id, status, audio_url, duration_ms, created_at, completed_at FROM recordings WHERE meeting_id = ? AND status = 'recording' ORDER BY created_at DESC LIMIT 1",
+	err := db.QueryRowContext(ctx,
+		"SELECT id, meeting_id, egress_id, status, audio_url, duration_ms, created_at, completed_at FROM recordings WHERE meeting_id = ? AND status = 'recording' ORDER BY created_at DESC, id DESC LIMIT 1",
 		meetingID,
 	).Scan(&r.ID, &r.MeetingID, &r.EgressID, &r.Status, &audioURL, &durationMS, &r.CreatedAt, &completedAt)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 
 	if audioURL.Valid {
@@ -264,16 +971,85 @@ func GetActiveRecordingByMeeting(meetingID int64) (*Recording, error) {
 	return &r, nil
 }
 
+// ListRecordingsByMeeting returns all recordings for a meeting, oldest first
+func ListRecordingsByMeeting(ctx context.Context, meetingID int64) ([]Recording, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, meeting_id, egress_id, status, audio_url, duration_ms, created_at, completed_at FROM recordings WHERE meeting_id = ? ORDER BY created_at, id",
+		meetingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recordings []Recording
+	for rows.Next() {
+		var r Recording
+		var audioURL sql.NullString
+		var durationMS sql.NullInt64
+		var completedAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.MeetingID, &r.EgressID, &r.Status, &audioURL, &durationMS, &r.CreatedAt, &completedAt); err != nil {
+			return nil, err
+		}
+		r.AudioURL = audioURL.String
+		r.DurationMS = durationMS.Int64
+		if completedAt.Valid {
+			r.CompletedAt = &completedAt.Time
+		}
+		recordings = append(recordings, r)
+	}
+	return recordings, rows.Err()
+}
+
+// UnfinishedRecording is a recording still marked recording or processing,
+// with the room it belongs to
+type UnfinishedRecording struct {
+	Recording
+	RoomName string `json:"roomName"`
+}
+
+// ListUnfinishedRecordings returns every recording not yet completed or
+// failed, oldest first
+func ListUnfinishedRecordings(ctx context.Context) ([]UnfinishedRecording, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT r.id, r.meeting_id, r.egress_id, r.status, r.created_at, m.room_name
+		 FROM recordings r
+		 JOIN meetings m ON r.meeting_id = m.id
+		 WHERE r.status IN ('recording', 'processing')
+		 ORDER BY r.created_at, r.id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recordings []UnfinishedRecording
+	for rows.Next() {
+		var r UnfinishedRecording
+		if err := rows.Scan(&r.ID, &r.MeetingID, &r.EgressID, &r.Status, &r.CreatedAt, &r.RoomName); err != nil {
+			return nil, err
+		}
+		recordings = append(recordings, r)
+	}
+	return recordings, rows.Err()
+}
+
 // UpdateRecordingStatus updates a recording's status
-func UpdateRecordingStatus(egressID, status string, audioURL string, durationMS int64) error {
+func UpdateRecordingStatus(ctx context.Context, egressID, status string, audioURL string, durationMS int64) error {
 	if status == "completed" || status == "failed" {
-		_, err := db.Exec(
+		_, err := execWrite(ctx,
 			"UPDATE recordings SET status = ?, audio_url = ?, duration_ms = ?, completed_at = CURRENT_TIMESTAMP WHERE egress_id = ?",
 			status, audioURL, durationMS, egressID,
 		)
 		return err
 	}
-	_, err := db.Exec("UPDATE recordings SET status = ? WHERE egress_id = ?", status, egressID)
+	_, err := execWrite(ctx, "UPDATE recordings SET status = ? WHERE egress_id = ?", status, egressID)
 	return err
 }
 
@@ -284,48 +1060,89 @@ type EmailSubscription struct {
 	ParticipantName string    `json:"participantName"`
 	Email           string    `json:"email"`
 	CreatedAt       time.Time `json:"createdAt"`
+
+	// IncludeRecording asks for a link to the recording in the summary email
+	IncludeRecording bool `json:"includeRecording"`
 }
 
-// CreateEmailSubscription adds an email subscription for a meeting
-func CreateEmailSubscription(roomName, participantName, email string) (*EmailSubscription, error) {
-	// Get or create meeting
-	meeting, err := GetMeetingByRoom(roomName)
-	if err != nil {
-		meeting, err = CreateMeeting(roomName, "")
+// CreateEmailSubscription adds an email subscription for a meeting, or
+// updates the name and recording preference of an existing one
+func CreateEmailSubscription(ctx context.Context, roomName, participantName, email string, includeRecording bool) (*EmailSubscription, error) {
+	var sub EmailSubscription
+	err := withTx(ctx, func(tx *storeTx) error {
+		meeting, err := GetOrCreateMeeting(ctx, tx, roomName)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO email_subscriptions (meeting_id, participant_name, email, include_recording) VALUES (?, ?, ?, ?)
+			 ON CONFLICT(meeting_id, email) DO UPDATE SET participant_name = ?, include_recording = ?`,
+			meeting.ID, participantName, email, includeRecording, participantName, includeRecording,
+		); err != nil {
+			return err
+		}
+		// The upsert may have updated an existing row, whose ID no driver
+		// reports reliably, so read it back by its key
+		return tx.QueryRowContext(ctx,
+			"SELECT id, meeting_id, participant_name, email, created_at, include_recording FROM email_subscriptions WHERE meeting_id = ? AND email = ?",
+			meeting.ID, email,
+		).Scan(&sub.ID, &sub.MeetingID, &sub.ParticipantName, &sub.Email, &sub.CreatedAt, &sub.IncludeRecording)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return &sub, nil
+}
 
-	result, err := db.Exec(
-		"INSERT INTO email_subscriptions (meeting_id, participant_name, email) VALUES (?, ?, ?) ON CONFLICT(meeting_id, email) DO UPDATE SET participant_name = ?",
-		meeting.ID, participantName, email, participantName,
-	)
+// Email subscription page sizes
+const (
+	defaultEmailSubscriptionPageSize = 100
+	maxEmailSubscriptionPageSize     = 500
+)
+
+// GetEmailSubscriptionsByRoom retrieves all email subscriptions for a room,
+// oldest first
+func GetEmailSubscriptionsByRoom(ctx context.Context, roomName string) ([]EmailSubscription, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	meeting, err := GetMeetingByRoom(ctx, roomName)
 	if err != nil {
 		return nil, err
 	}
-
-	id, _ := result.LastInsertId()
-	return &EmailSubscription{
-		ID:              id,
-		MeetingID:       meeting.ID,
-		ParticipantName: participantName,
-		Email:           email,
-		CreatedAt:       time.Now(),
-	}, nil
+	return queryEmailSubscriptions(ctx, meeting.ID, 0, 0)
 }
 
-// GetEmailSubscriptionsByRoom retrieves all email subscriptions for a room
-func GetEmailSubscriptionsByRoom(roomName string) ([]EmailSubscription, error) {
-	meeting, err := GetMeetingByRoom(roomName)
+// GetEmailSubscriptionsPage returns a page of a room's email subscriptions,
+// oldest first, along with how many the room has in all
+func GetEmailSubscriptionsPage(ctx context.Context, roomName string, limit, offset int) ([]EmailSubscription, int, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	meeting, err := GetMeetingByRoom(ctx, roomName)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	rows, err := db.Query(
-		"SELECT id, meeting_id, participant_name, email, created_at FROM email_subscriptions WHERE meeting_id = ?",
-		meeting.ID,
-	)
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM email_subscriptions WHERE meeting_id = ?", meeting.ID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	subs, err := queryEmailSubscriptions(ctx, meeting.ID, limit, offset)
+	return subs, total, err
+}
+
+// queryEmailSubscriptions selects a meeting's subscriptions ordered by
+// creation, then ID so equal timestamps keep a stable order. A zero limit
+// selects them all.
+func queryEmailSubscriptions(ctx context.Context, meetingID int64, limit, offset int) ([]EmailSubscription, error) {
+	query := "SELECT id, meeting_id, participant_name, email, created_at, include_recording FROM email_subscriptions WHERE meeting_id = ? ORDER BY created_at ASC, id ASC"
+	args := []interface{}{meetingID}
+	if limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -334,23 +1151,30 @@ func GetEmailSubscriptionsByRoom(roomName string) ([]EmailSubscription, error) {
 	var subs []EmailSubscription
 	for rows.Next() {
 		var s EmailSubscription
-		if err := rows.Scan(&s.ID, &s.MeetingID, &s.ParticipantName, &s.Email, &s.CreatedAt); err != nil {
-			continue
+		if err := rows.Scan(&s.ID, &s.MeetingID, &s.ParticipantName, &s.Email, &s.CreatedAt, &s.IncludeRecording); err != nil {
+			return nil, err
 		}
 		subs = append(subs, s)
 	}
-	return subs, nil
+	return subs, rows.Err()
 }
 
-// DeleteEmailSubscription removes an email subscription
-func DeleteEmailSubscription(roomName, email string) error {
-	meeting, err := GetMeetingByRoom(roomName)
+// DeleteEmailSubscription removes an email subscription and returns how many
+// rows were deleted, which is 0 if the email was not subscribed
+func DeleteEmailSubscription(ctx context.Context, roomName, email string) (int64, error) {
+	meeting, err := GetMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	_, err = db.Exec("DELETE FROM email_subscriptions WHERE meeting_id = ? AND email = ?", meeting.ID, email)
-	return err
+	result, err := execWrite(ctx, "DELETE FROM email_subscriptions WHERE meeting_id = ? AND email = ?", meeting.ID, email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 // ScheduledMeeting represents a future meeting created by a host
@@ -359,58 +1183,132 @@ type ScheduledMeeting struct {
 	RoomName    string    `json:"roomName"`
 	HostUserID  int64     `json:"hostUserId"`
 	HostName    string    `json:"hostName,omitempty"`
+	HostEmail   string    `json:"-"` // for invite emails; never sent to guests
 	ClientName  string    `json:"clientName"`
 	ClientEmail string    `json:"clientEmail"`
 	ScheduledAt time.Time `json:"scheduledAt"`
 	Status      string    `json:"status"`
 	CreatedAt   time.Time `json:"createdAt"`
+
+	// LinkExpiresAt is when the invite link stops admitting guests
+	LinkExpiresAt time.Time `json:"linkExpiresAt"`
+
+	// Slug is the optional vanity name for the invite link
+	Slug string `json:"slug,omitempty"`
+
+	// JoinPinHash is the bcrypt hash of the PIN guests must give, if any
+	JoinPinHash string `json:"-"`
+}
+
+const scheduledMeetingColumns = "sm.id, sm.room_name, sm.host_user_id, u.name, u.email, sm.client_name, sm.client_email, sm.scheduled_at, sm.status, sm.created_at, sm.link_expires_at, sm.slug, sm.join_pin_hash"
+
+// scanScheduledMeeting reads one row selected with scheduledMeetingColumns
+func scanScheduledMeeting(scan func(dest ...any) error) (*ScheduledMeeting, error) {
+	var m ScheduledMeeting
+	var linkExpiresAt sql.NullTime
+	var slug, joinPinHash sql.NullString
+	if err := scan(&m.ID, &m.RoomName, &m.HostUserID, &m.HostName, &m.HostEmail, &m.ClientName, &m.ClientEmail, &m.ScheduledAt, &m.Status, &m.CreatedAt, &linkExpiresAt, &slug, &joinPinHash); err != nil {
+		return nil, notFound(err)
+	}
+	m.Slug = slug.String
+	m.JoinPinHash = joinPinHash.String
+	m.LinkExpiresAt = linkExpiresAt.Time
+	if !linkExpiresAt.Valid {
+		// Meetings scheduled before links expired get the default window
+		m.LinkExpiresAt = defaultLinkExpiry(m.ScheduledAt)
+	}
+	return &m, nil
 }
 
-// CreateScheduledMeeting inserts a new scheduled meeting
-func CreateScheduledMeeting(roomName string, hostUserID int64, clientName, clientEmail string, scheduledAt time.Time) (*ScheduledMeeting, error) {
-	result, err := db.Exec(
-		"INSERT INTO scheduled_meetings (room_name, host_user_id, client_name, client_email, scheduled_at) VALUES (?, ?, ?, ?, ?)",
-		roomName, hostUserID, clientName, clientEmail, scheduledAt,
+// CreateScheduledMeeting inserts a new scheduled meeting whose invite link
+// works until linkExpiresAt. slug is optional.
+func CreateScheduledMeeting(ctx context.Context, roomName string, hostUserID int64, clientName, clientEmail string, scheduledAt, linkExpiresAt time.Time, slug, joinPinHash string) (*ScheduledMeeting, error) {
+	// NULL rather than "" so the unique index ignores meetings without one
+	slugValue := sql.NullString{String: slug, Valid: slug != ""}
+	id, err := insertReturningID(ctx,
+		"INSERT INTO scheduled_meetings (room_name, host_user_id, client_name, client_email, scheduled_at, link_expires_at, slug, join_pin_hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		roomName, hostUserID, clientName, clientEmail, scheduledAt, linkExpiresAt, slugValue, sql.NullString{String: joinPinHash, Valid: joinPinHash != ""},
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	id, _ := result.LastInsertId()
 	return &ScheduledMeeting{
-		ID:          id,
-		RoomName:    roomName,
-		HostUserID:  hostUserID,
-		ClientName:  clientName,
-		ClientEmail: clientEmail,
-		ScheduledAt: scheduledAt,
-		Status:      "scheduled",
-		CreatedAt:   time.Now(),
+		ID:            id,
+		RoomName:      roomName,
+		HostUserID:    hostUserID,
+		ClientName:    clientName,
+		ClientEmail:   clientEmail,
+		ScheduledAt:   scheduledAt,
+		Status:        "scheduled",
+		CreatedAt:     time.Now(),
+		LinkExpiresAt: linkExpiresAt,
+		Slug:          slug,
+		JoinPinHash:   joinPinHash,
 	}, nil
 }
 
+// ScheduledMeetingSlugInUse reports whether a scheduled meeting already has slug
+func ScheduledMeetingSlugInUse(ctx context.Context, slug string) (bool, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM scheduled_meetings WHERE slug = ?", slug).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
 // GetScheduledMeetingByRoom retrieves a scheduled meeting by room name
-func GetScheduledMeetingByRoom(roomName string) (*ScheduledMeeting, error) {
-	var m ScheduledMeeting
-	var hostName string
-	err := db.QueryRow(
-		`SELECT sm.id, sm.room_name, sm.host_user_id, u.name, sm.client_name, sm.client_email, sm.scheduled_at, sm.status, sm.created_at
+func GetScheduledMeetingByRoom(ctx context.Context, roomName string) (*ScheduledMeeting, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	return scanScheduledMeeting(db.QueryRowContext(ctx,
+		`SELECT `+scheduledMeetingColumns+`
 		 FROM scheduled_meetings sm
 		 JOIN users u ON sm.host_user_id = u.id
 		 WHERE sm.room_name = ?`,
 		roomName,
-	).Scan(&m.ID, &m.RoomName, &m.HostUserID, &hostName, &m.ClientName, &m.ClientEmail, &m.ScheduledAt, &m.Status, &m.CreatedAt)
-	if err != nil {
-		return nil, err
-	}
-	m.HostName = hostName
-	return &m, nil
+	).Scan)
+}
+
+// GetScheduledMeetingBySlug retrieves a scheduled meeting by its vanity slug
+func GetScheduledMeetingBySlug(ctx context.Context, slug string) (*ScheduledMeeting, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	return scanScheduledMeeting(db.QueryRowContext(ctx,
+		`SELECT `+scheduledMeetingColumns+`
+		 FROM scheduled_meetings sm
+		 JOIN users u ON sm.host_user_id = u.id
+		 WHERE sm.slug = ?`,
+		slug,
+	).Scan)
+}
+
+// GetScheduledMeetingByID retrieves a scheduled meeting by ID
+func GetScheduledMeetingByID(ctx context.Context, id int64) (*ScheduledMeeting, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	return scanScheduledMeeting(db.QueryRowContext(ctx,
+		`SELECT `+scheduledMeetingColumns+`
+		 FROM scheduled_meetings sm
+		 JOIN users u ON sm.host_user_id = u.id
+		 WHERE sm.id = ?`,
+		id,
+	).Scan)
 }
 
 // ListScheduledMeetingsByHost returns scheduled meetings for a host
-func ListScheduledMeetingsByHost(hostUserID int64) ([]ScheduledMeeting, error) {
-	rows, err := db.Query(
-		`SELECT sm.id, sm.room_name, sm.host_user_id, u.name, sm.client_name, sm.client_email, sm.scheduled_at, sm.status, sm.created_at
+func ListScheduledMeetingsByHost(ctx context.Context, hostUserID int64) ([]ScheduledMeeting, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT `+scheduledMeetingColumns+`
 		 FROM scheduled_meetings sm
 		 JOIN users u ON sm.host_user_id = u.id
 		 WHERE sm.host_user_id = ? AND sm.status IN ('scheduled', 'active')
@@ -424,32 +1322,110 @@ func ListScheduledMeetingsByHost(hostUserID int64) ([]ScheduledMeeting, error) {
 
 	var meetings []ScheduledMeeting
 	for rows.Next() {
-		var m ScheduledMeeting
-		var hostName string
-		if err := rows.Scan(&m.ID, &m.RoomName, &m.HostUserID, &hostName, &m.ClientName, &m.ClientEmail, &m.ScheduledAt, &m.Status, &m.CreatedAt); err != nil {
-			continue
+		m, err := scanScheduledMeeting(rows.Scan)
+		if err != nil {
+			return nil, err
 		}
-		m.HostName = hostName
-		meetings = append(meetings, m)
+		meetings = append(meetings, *m)
 	}
-	return meetings, nil
+	return meetings, rows.Err()
 }
 
-// UpdateScheduledMeetingStatus updates the status of a scheduled meeting
-func UpdateScheduledMeetingStatus(id int64, status string) error {
-	_, err := db.Exec("UPDATE scheduled_meetings SET status = ? WHERE id = ?", status, id)
-	return err
+// ErrInvalidStatusTransition is returned when a scheduled meeting is asked
+// to move to a status it cannot reach from its current one
+var ErrInvalidStatusTransition = errors.New("invalid status transition")
+
+// scheduledMeetingTransitions lists the legal status changes: a meeting is
+// started and then completed, or cancelled before it starts
+var scheduledMeetingTransitions = map[string]map[string]bool{
+	"scheduled": {"active": true, "cancelled": true},
+	"active":    {"completed": true},
 }
 
-// CancelScheduledMeeting cancels a scheduled meeting owned by the given user
-func CancelScheduledMeeting(id, hostUserID int64) error {
-	result, err := db.Exec("UPDATE scheduled_meetings SET status = 'cancelled' WHERE id = ? AND host_user_id = ?", id, hostUserID)
+// ValidateStatusTransition reports whether a scheduled meeting may move from
+// one status to another
+func ValidateStatusTransition(from, to string) error {
+	if scheduledMeetingTransitions[from][to] {
+		return nil
+	}
+	return fmt.Errorf("%w from %s to %s", ErrInvalidStatusTransition, from, to)
+}
+
+// UpdateScheduledMeetingStatus moves a scheduled meeting to a new status. It
+// returns ErrNotFound if there is no such meeting and
+// ErrInvalidStatusTransition if the move is not allowed.
+func UpdateScheduledMeetingStatus(ctx context.Context, id int64, status string) error {
+	return withTx(ctx, func(tx *storeTx) error {
+		var current string
+		if err := tx.QueryRowContext(ctx, "SELECT status FROM scheduled_meetings WHERE id = ?", id).Scan(&current); err != nil {
+			return notFound(err)
+		}
+		if err := ValidateStatusTransition(current, status); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE scheduled_meetings SET status = ? WHERE id = ?", status, id)
+		return err
+	})
+}
+
+// CancelScheduledMeeting cancels a scheduled meeting owned by the given user.
+// It returns ErrNotFound if the user has no such meeting and
+// ErrInvalidStatusTransition if it has already started or been cancelled.
+func CancelScheduledMeeting(ctx context.Context, id, hostUserID int64) error {
+	return withTx(ctx, func(tx *storeTx) error {
+		var current string
+		err := tx.QueryRowContext(ctx,
+			"SELECT status FROM scheduled_meetings WHERE id = ? AND host_user_id = ?", id, hostUserID,
+		).Scan(&current)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := ValidateStatusTransition(current, "cancelled"); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE scheduled_meetings SET status = 'cancelled' WHERE id = ?", id)
+		return err
+	})
+}
+
+// TransferScheduledMeeting hands a scheduled meeting to a new host. Active
+// meetings cannot be transferred; it returns ErrNotFound if the meeting is
+// active or not owned by fromUserID.
+func TransferScheduledMeeting(ctx context.Context, id, fromUserID, toUserID int64) error {
+	result, err := execWrite(ctx,
+		"UPDATE scheduled_meetings SET host_user_id = ? WHERE id = ? AND host_user_id = ? AND status != 'active'",
+		toUserID, id, fromUserID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RescheduleMeeting moves a scheduled meeting owned by hostUserID and sets
+// when its invite link expires. Cancelled meetings cannot be rescheduled; it
+// returns ErrNotFound if the meeting is cancelled or not owned by hostUserID.
+func RescheduleMeeting(ctx context.Context, id, hostUserID int64, scheduledAt, linkExpiresAt time.Time) error {
+	result, err := execWrite(ctx,
+		"UPDATE scheduled_meetings SET scheduled_at = ?, link_expires_at = ? WHERE id = ? AND host_user_id = ? AND status != 'cancelled'",
+		scheduledAt, linkExpiresAt, id, hostUserID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
-	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("meeting not found or not owned by user")
+		return ErrNotFound
 	}
 	return nil
 }
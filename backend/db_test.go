@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// breakSchema runs statements that damage the test database's schema
+func breakSchema(t *testing.T, statements ...string) {
+	t.Helper()
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(context.Background(), stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+}
+
+func TestStoreLookupMissingRowIsNotFound(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx := context.Background()
+
+	if _, err := GetMeetingByRoom(ctx, "no-such-room"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetMeetingByRoom: got %v, want ErrNotFound", err)
+	}
+	if _, err := GetNotesByRoom(ctx, "no-such-room"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetNotesByRoom: got %v, want ErrNotFound", err)
+	}
+	if _, err := GetScheduledMeetingByID(ctx, 12345); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetScheduledMeetingByID: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreScanErrorsAreNotNotFound(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx := context.Background()
+
+	if _, err := SaveNotes(ctx, "broken-room", "# Notes", "test-model", 1, 1); err != nil {
+		t.Fatalf("SaveNotes: %v", err)
+	}
+	// SQLite keeps whatever is stored, so a value that cannot be read back
+	// as a time makes every scan of the row fail
+	breakSchema(t,
+		"UPDATE meetings SET created_at = 'not a time' WHERE room_name = 'broken-room'",
+		"UPDATE meeting_notes SET generated_at = 'not a time'",
+	)
+
+	if _, err := GetMeetingByRoom(ctx, "broken-room"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("GetMeetingByRoom: got %v, want a scan error", err)
+	}
+	if _, err := GetNotesByRoom(ctx, "broken-room"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("GetNotesByRoom: got %v, want a scan error", err)
+	}
+	if _, err := ListMeetingsWithNotes(ctx, MeetingListOptions{}); err == nil {
+		t.Error("ListMeetingsWithNotes: got nil, want a scan error")
+	}
+}
+
+func TestStoreMissingTableIsNotNotFound(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx := context.Background()
+
+	if _, err := CreateEmailSubscription(ctx, "room", "Ada", "ada@example.com", false); err != nil {
+		t.Fatalf("CreateEmailSubscription: %v", err)
+	}
+	breakSchema(t,
+		"PRAGMA foreign_keys = OFF",
+		"DROP TABLE email_subscriptions",
+		"DROP TABLE scheduled_meetings",
+	)
+
+	if _, err := GetEmailSubscriptionsByRoom(ctx, "room"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("GetEmailSubscriptionsByRoom: got %v, want a query error", err)
+	}
+	if _, err := DeleteEmailSubscription(ctx, "room", "ada@example.com"); err == nil {
+		t.Error("DeleteEmailSubscription: got nil, want a query error")
+	}
+	if _, err := GetScheduledMeetingByRoom(ctx, "room"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("GetScheduledMeetingByRoom: got %v, want a query error", err)
+	}
+	if _, err := ScheduledMeetingSlugInUse(ctx, "slug"); err == nil {
+		t.Error("ScheduledMeetingSlugInUse: got nil, want a query error")
+	}
+}
+
+func TestHandlersReportBrokenStoreAsServerError(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	app := newTestApp(t, newTestServer(t, sqlStore{}))
+
+	if _, err := SaveNotes(context.Background(), "broken-room", "# Notes", "test-model", 1, 1); err != nil {
+		t.Fatalf("SaveNotes: %v", err)
+	}
+	if resp := doRequest(t, app, http.MethodGet, "/api/meetings/missing-room/notes", nil); resp.Status != http.StatusNotFound {
+		t.Errorf("notes for a missing room: got %d, want 404", resp.Status)
+	}
+
+	breakSchema(t, "UPDATE meeting_notes SET generated_at = 'not a time'")
+	if resp := doRequest(t, app, http.MethodGet, "/api/meetings/broken-room/notes", nil); resp.Status != http.StatusInternalServerError {
+		t.Errorf("notes from a broken row: got %d, want 500: %s", resp.Status, resp.Body)
+	}
+}
+
+func TestCreateEmailSubscriptionUpsertReturnsExistingRow(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx := context.Background()
+
+	first, err := CreateEmailSubscription(ctx, "room", "Ada", "ada@example.com", false)
+	if err != nil {
+		t.Fatalf("CreateEmailSubscription: %v", err)
+	}
+	if _, err := CreateEmailSubscription(ctx, "room", "Bob", "bob@example.com", false); err != nil {
+		t.Fatalf("CreateEmailSubscription: %v", err)
+	}
+	again, err := CreateEmailSubscription(ctx, "room", "Ada Lovelace", "ada@example.com", true)
+	if err != nil {
+		t.Fatalf("CreateEmailSubscription: %v", err)
+	}
+
+	if again.ID != first.ID {
+		t.Errorf("ID after update: got %d, want %d", again.ID, first.ID)
+	}
+	if again.ParticipantName != "Ada Lovelace" || !again.IncludeRecording {
+		t.Errorf("updated subscription: got %+v", again)
+	}
+	if !again.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("CreatedAt after update: got %v, want %v", again.CreatedAt, first.CreatedAt)
+	}
+}
+
+func TestConcurrentSaveNotesCreatesOneMeeting(t *testing.T) {
+	useTestConfig(t)
+	useTestDBAt(t, filepath.Join(t.TempDir(), "boom.db"))
+
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := SaveNotes(context.Background(), "fresh-room", "# Notes", "test-model", 1, 1)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("SaveNotes: %v", err)
+		}
+	}
+
+	if n := countRows(t, "SELECT COUNT(*) FROM meetings WHERE room_name = ?", "fresh-room"); n != 1 {
+		t.Errorf("meetings rows: got %d, want 1", n)
+	}
+	if n := countRows(t, "SELECT COUNT(*) FROM meeting_notes"); n != writers {
+		t.Errorf("notes rows: got %d, want %d", n, writers)
+	}
+}
+
+// slowQuery counts far enough that it runs for many seconds unless interrupted
+const slowQuery = `WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n LIMIT 1000000000)
+	SELECT COUNT(*) FROM n`
+
+func TestCancelledContextAbortsSlowQuery(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	var n int
+	err := db.QueryRowContext(ctx, slowQuery).Scan(&n)
+	if err == nil {
+		t.Fatalf("slow query finished with %d, want it cancelled", n)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("cancelled query ran for %v", elapsed)
+	}
+}
+
+func TestStatementTimeoutKeepsCallerDeadline(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	ctx, stop := withStatementTimeout(parent)
+	defer stop()
+	want, _ := parent.Deadline()
+	if got, ok := ctx.Deadline(); !ok || !got.Equal(want) {
+		t.Errorf("deadline: got %v, want the caller's %v", got, want)
+	}
+
+	ctx, stop = withStatementTimeout(context.Background())
+	defer stop()
+	if got, ok := ctx.Deadline(); !ok || time.Until(got) > defaultStatementTimeout {
+		t.Errorf("deadline without a caller deadline: got %v, want within %v", got, defaultStatementTimeout)
+	}
+}
+
+func TestWritesGiveUpOnCancelledContext(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := SaveNotes(ctx, "room", "# Notes", "test-model", 1, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("SaveNotes: got %v, want context.Canceled", err)
+	}
+	if _, err := execWrite(ctx, "DELETE FROM meetings"); !errors.Is(err, context.Canceled) {
+		t.Errorf("execWrite: got %v, want context.Canceled", err)
+	}
+}
+
+func TestParallelWritesDoNotFailWithLockErrors(t *testing.T) {
+	useTestConfig(t)
+	useTestDBAt(t, filepath.Join(t.TempDir(), "boom.db"))
+	ctx := context.Background()
+
+	var timeout int
+	if err := db.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&timeout); err != nil {
+		t.Fatalf("read busy_timeout: %v", err)
+	}
+	if timeout != busyTimeoutMS {
+		t.Errorf("busy_timeout: got %d, want %d", timeout, busyTimeoutMS)
+	}
+	failuresBefore := testutil.ToFloat64(dbBusyFailures.WithLabelValues("write")) + testutil.ToFloat64(dbBusyFailures.WithLabelValues("tx"))
+
+	// Every kind of write the request handlers and workers make, at once
+	const workers, rounds = 16, 20
+	writes := []func(worker, round int) error{
+		func(w, r int) error {
+			_, err := SaveNotes(ctx, fmt.Sprintf("room-%d", r%4), "# Notes", "test-model", 1, 1)
+			return err
+		},
+		func(w, r int) error {
+			_, err := CreateEmailSubscription(ctx, fmt.Sprintf("room-%d", r%4), "", fmt.Sprintf("user%d@example.com", w), false)
+			return err
+		},
+		func(w, r int) error {
+			_, err := SaveFinalTranscriptSegment(ctx, fmt.Sprintf("room-%d", r%4), "Ada", "hello", fmt.Sprintf("%d-%d", w, r))
+			return err
+		},
+		func(w, r int) error {
+			return LogEmailDelivery(ctx, 1, fmt.Sprintf("user%d@example.com", w), "sent", "", "")
+		},
+	}
+	if _, err := EnsureMeeting(ctx, "room-0"); err != nil {
+		t.Fatalf("EnsureMeeting: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				if err := writes[(w+r)%len(writes)](w, r); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if isBusyError(err) {
+			t.Errorf("write failed with a lock error: %v", err)
+		} else {
+			t.Errorf("write failed: %v", err)
+		}
+	}
+
+	failuresAfter := testutil.ToFloat64(dbBusyFailures.WithLabelValues("write")) + testutil.ToFloat64(dbBusyFailures.WithLabelValues("tx"))
+	if failuresAfter != failuresBefore {
+		t.Errorf("boom_db_busy_failures_total went from %v to %v", failuresBefore, failuresAfter)
+	}
+}
+
+func TestValidateStatusTransition(t *testing.T) {
+	allowed := map[[2]string]bool{
+		{"scheduled", "active"}:    true,
+		{"scheduled", "cancelled"}: true,
+		{"active", "completed"}:    true,
+	}
+	statuses := []string{"scheduled", "active", "completed", "cancelled"}
+	checked := 0
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if from == to {
+				continue
+			}
+			checked++
+			err := ValidateStatusTransition(from, to)
+			if allowed[[2]string{from, to}] {
+				if err != nil {
+					t.Errorf("%s → %s: got %v, want allowed", from, to, err)
+				}
+			} else if !errors.Is(err, ErrInvalidStatusTransition) {
+				t.Errorf("%s → %s: got %v, want ErrInvalidStatusTransition", from, to, err)
+			}
+		}
+	}
+	if checked != 12 {
+		t.Fatalf("checked %d transitions, want 12", checked)
+	}
+}
+
+func TestUpdateScheduledMeetingStatusRejectsIllegalTransition(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx := context.Background()
+	host := testUser(t, testUserEmail)
+	at := time.Now().Add(time.Hour)
+	m, err := CreateScheduledMeeting(ctx, "status-room", host.ID, "Client", "client@example.com", at, at.Add(time.Hour), "status-room", "")
+	if err != nil {
+		t.Fatalf("CreateScheduledMeeting: %v", err)
+	}
+
+	if err := UpdateScheduledMeetingStatus(ctx, m.ID, "active"); err != nil {
+		t.Fatalf("scheduled → active: %v", err)
+	}
+	if err := UpdateScheduledMeetingStatus(ctx, m.ID, "scheduled"); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Errorf("active → scheduled: got %v, want ErrInvalidStatusTransition", err)
+	}
+	got, err := GetScheduledMeetingByID(ctx, m.ID)
+	if err != nil {
+		t.Fatalf("GetScheduledMeetingByID: %v", err)
+	}
+	if got.Status != "active" {
+		t.Errorf("status after a rejected transition: got %s, want active", got.Status)
+	}
+	if err := UpdateScheduledMeetingStatus(ctx, 12345, "active"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing meeting: got %v, want ErrNotFound", err)
+	}
+}
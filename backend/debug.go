@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/subtle"
+	"runtime"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
+)
+
+// With DEBUG_ENDPOINTS set, /debug/pprof serves the Go profiler and
+// /debug/vars a snapshot of goroutines, memory, and the in-process hubs, for
+// tracking down leaks in production. Both need an admin token, or the
+// DEBUG_TOKEN value in X-Debug-Token so they can be scraped without a user
+// session.
+
+// debugTokenHeader carries DEBUG_TOKEN
+const debugTokenHeader = "X-Debug-Token"
+
+// registerDebugRoutes mounts the debug endpoints when they are enabled
+func registerDebugRoutes(app *fiber.App, s *server) {
+	if !config.DebugEndpoints {
+		return
+	}
+	debug := app.Group("/debug",
+		debugTokenAuth(),
+		unlessDebugToken(authRequired()),
+		unlessDebugToken(adminRequired()),
+	)
+	debug.Get("/vars", s.debugVarsHandler)
+	debug.Use(pprof.New())
+}
+
+// debugTokenAuth admits requests presenting DEBUG_TOKEN and rejects ones
+// presenting anything else in its header. Requests without the header fall
+// through to the usual admin check.
+func debugTokenAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		presented := c.Get(debugTokenHeader)
+		if presented == "" {
+			return c.Next()
+		}
+		token := config.DebugToken
+		if token == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			return respondError(c, 401, "Unauthorized")
+		}
+		c.Locals("debugToken", true)
+		return c.Next()
+	}
+}
+
+// unlessDebugToken skips h for requests already admitted by DEBUG_TOKEN
+func unlessDebugToken(h fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if ok, _ := c.Locals("debugToken").(bool); ok {
+			return c.Next()
+		}
+		return h(c)
+	}
+}
+
+// DebugVars is a snapshot of runtime and hub counters
+type DebugVars struct {
+	Goroutines int `json:"goroutines"`
+	Memory     struct {
+		HeapAllocBytes uint64 `json:"heapAllocBytes"`
+		HeapInuseBytes uint64 `json:"heapInuseBytes"`
+		HeapObjects    uint64 `json:"heapObjects"`
+		SysBytes       uint64 `json:"sysBytes"`
+		NumGC          uint32 `json:"numGC"`
+		GCPauseTotalNs uint64 `json:"gcPauseTotalNs"`
+	} `json:"memory"`
+	WebSockets struct {
+		Connections int `json:"connections"`
+		Rooms       int `json:"rooms"`
+	} `json:"websockets"`
+	Hubs struct {
+		ActiveSpeakerRooms int `json:"activeSpeakerRooms"`
+		DraftNoteRooms     int `json:"draftNoteRooms"`
+		BackgroundQueued   int `json:"backgroundQueued"`
+	} `json:"hubs"`
+}
+
+// debugVarsHandler reports runtime and hub counters
+func (s *server) debugVarsHandler(c *fiber.Ctx) error {
+	var vars DebugVars
+	vars.Goroutines = runtime.NumGoroutine()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	vars.Memory.HeapAllocBytes = mem.HeapAlloc
+	vars.Memory.HeapInuseBytes = mem.HeapInuse
+	vars.Memory.HeapObjects = mem.HeapObjects
+	vars.Memory.SysBytes = mem.Sys
+	vars.Memory.NumGC = mem.NumGC
+	vars.Memory.GCPauseTotalNs = mem.PauseTotalNs
+
+	transcriptLock.RLock()
+	vars.WebSockets.Rooms = len(transcriptWS)
+	for _, clients := range transcriptWS {
+		vars.WebSockets.Connections += len(clients)
+	}
+	transcriptLock.RUnlock()
+
+	activeSpeakersLock.Lock()
+	vars.Hubs.ActiveSpeakerRooms = len(activeSpeakers)
+	activeSpeakersLock.Unlock()
+
+	s.drafts.mu.Lock()
+	vars.Hubs.DraftNoteRooms = len(s.drafts.rooms)
+	s.drafts.mu.Unlock()
+	vars.Hubs.BackgroundQueued = len(s.jobs.jobs)
+
+	return c.JSON(vars)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+var debugPaths = []string{"/debug/vars", "/debug/pprof/", "/debug/pprof/goroutine?debug=1", "/debug/pprof/heap"}
+
+func TestDebugEndpointsRequireCredentials(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	config.DebugEndpoints = true
+	config.DebugToken = "test-debug-token"
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	for _, path := range debugPaths {
+		if resp := doRequest(t, app, http.MethodGet, path, nil); resp.Status != http.StatusUnauthorized {
+			t.Errorf("%s without credentials: got %d, want 401", path, resp.Status)
+		}
+		if resp := doRequest(t, app, http.MethodGet, path, nil, debugTokenHeader, "wrong"); resp.Status != http.StatusUnauthorized {
+			t.Errorf("%s with the wrong debug token: got %d, want 401", path, resp.Status)
+		}
+		if resp := doRequest(t, app, http.MethodGet, path, nil, "Authorization", "Bearer not-a-token"); resp.Status != http.StatusUnauthorized {
+			t.Errorf("%s with a bad bearer token: got %d, want 401", path, resp.Status)
+		}
+		if resp := doRequest(t, app, http.MethodGet, path, nil, bearer(t, testUserEmail)...); resp.Status != http.StatusForbidden {
+			t.Errorf("%s as a non-admin: got %d, want 403", path, resp.Status)
+		}
+		if resp := doRequest(t, app, http.MethodGet, path, nil, bearer(t, testAdminEmail)...); resp.Status != http.StatusOK {
+			t.Errorf("%s as an admin: got %d, want 200", path, resp.Status)
+		}
+		if resp := doRequest(t, app, http.MethodGet, path, nil, debugTokenHeader, config.DebugToken); resp.Status != http.StatusOK {
+			t.Errorf("%s with the debug token: got %d, want 200", path, resp.Status)
+		}
+	}
+}
+
+func TestDebugTokenUnsetRejectsTokenHeader(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	config.DebugEndpoints = true
+	config.DebugToken = ""
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	if resp := doRequest(t, app, http.MethodGet, "/debug/vars", nil, debugTokenHeader, ""); resp.Status != http.StatusUnauthorized {
+		t.Errorf("empty debug token: got %d, want 401", resp.Status)
+	}
+	if resp := doRequest(t, app, http.MethodGet, "/debug/vars", nil, debugTokenHeader, "anything"); resp.Status != http.StatusUnauthorized {
+		t.Errorf("debug token with none configured: got %d, want 401", resp.Status)
+	}
+}
+
+func TestDebugEndpointsDisabledByDefault(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	for _, path := range debugPaths {
+		if resp := doRequest(t, app, http.MethodGet, path, nil, bearer(t, testAdminEmail)...); resp.Status != http.StatusNotFound {
+			t.Errorf("%s with debug endpoints off: got %d, want 404", path, resp.Status)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// SQL dialects supported by the store layer
+const (
+	dialectSQLite   = "sqlite"
+	dialectPostgres = "postgres"
+)
+
+// storeDB wraps the connection pool so store code can be written once with
+// SQLite-style ? placeholders and run unchanged against Postgres
+type storeDB struct {
+	*sql.DB
+	dialect string
+	path    string // SQLite file, empty for in-memory and Postgres databases
+}
+
+// Every statement's latency is recorded in boom_db_query_duration_seconds
+
+func (s *storeDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer observeQuery(query, time.Now())
+	return s.DB.ExecContext(ctx, rebind(s.dialect, query), args...)
+}
+
+func (s *storeDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer observeQuery(query, time.Now())
+	return s.DB.QueryContext(ctx, rebind(s.dialect, query), args...)
+}
+
+func (s *storeDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer observeQuery(query, time.Now())
+	return s.DB.QueryRowContext(ctx, rebind(s.dialect, query), args...)
+}
+
+// BeginTx starts a transaction that rebinds placeholders like storeDB does
+func (s *storeDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*storeTx, error) {
+	tx, err := s.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &storeTx{Tx: tx, dialect: s.dialect}, nil
+}
+
+// storeTx is a transaction on a storeDB
+type storeTx struct {
+	*sql.Tx
+	dialect string
+}
+
+func (t *storeTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer observeQuery(query, time.Now())
+	return t.Tx.ExecContext(ctx, rebind(t.dialect, query), args...)
+}
+
+func (t *storeTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer observeQuery(query, time.Now())
+	return t.Tx.QueryContext(ctx, rebind(t.dialect, query), args...)
+}
+
+func (t *storeTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer observeQuery(query, time.Now())
+	return t.Tx.QueryRowContext(ctx, rebind(t.dialect, query), args...)
+}
+
+// insertReturningID is the transactional form of the package-level insertReturningID
+func (t *storeTx) insertReturningID(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	if t.dialect == dialectPostgres {
+		var id int64
+		err := t.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := t.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// rebind rewrites ? placeholders to Postgres' numbered $N form, leaving
+// quoted string literals alone
+func rebind(dialect, query string) string {
+	if dialect != dialectPostgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	inQuote := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+		case r == '?' && !inQuote:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sqliteTimestampLayout is how SQLite's CURRENT_TIMESTAMP writes a time
+const sqliteTimestampLayout = "2006-01-02 15:04:05"
+
+// timestampArg converts t for comparison with a column filled by
+// CURRENT_TIMESTAMP. SQLite stores those as UTC text to the second and
+// compares them as text, so t has to be written the same way; Postgres
+// compares timestamps natively.
+func (s *storeDB) timestampArg(t time.Time) interface{} {
+	if s.dialect == dialectSQLite {
+		return t.UTC().Format(sqliteTimestampLayout)
+	}
+	return t
+}
+
+// parseDatabaseURL picks the driver and DSN for a database location. An empty
+// value keeps the local SQLite file.
+func parseDatabaseURL(databaseURL string) (dialect, dsn string) {
+	switch {
+	case strings.HasPrefix(databaseURL, "postgres://"), strings.HasPrefix(databaseURL, "postgresql://"):
+		return dialectPostgres, databaseURL
+	case strings.HasPrefix(databaseURL, "sqlite://"):
+		return dialectSQLite, strings.TrimPrefix(databaseURL, "sqlite://")
+	case databaseURL == "":
+		return dialectSQLite, defaultDatabasePath
+	default:
+		return dialectSQLite, databaseURL
+	}
+}
+
+// insertReturningID runs an INSERT and returns the id of the new row. SQLite
+// reports it through LastInsertId; Postgres needs a RETURNING clause.
+func insertReturningID(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	if db.dialect == dialectPostgres {
+		ctx, cancel := withStatementTimeout(ctx)
+		defer cancel()
+
+		var id int64
+		err := db.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := execWrite(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
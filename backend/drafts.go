@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultDraftNotesInterval is how often a running meeting's draft notes are
+// regenerated when NOTES_DRAFT_INTERVAL is not set
+const defaultDraftNotesInterval = 10 * time.Minute
+
+// NotesDraft is an interim summary of a meeting that is still running
+type NotesDraft struct {
+	ID           int64     `json:"id"`
+	MeetingID    int64     `json:"meetingId"`
+	Markdown     string    `json:"markdown"`
+	ModelUsed    string    `json:"modelUsed"`
+	InputTokens  int       `json:"inputTokens"`
+	OutputTokens int       `json:"outputTokens"`
+	SegmentCount int       `json:"segmentCount"`
+	GeneratedAt  time.Time `json:"generatedAt"`
+}
+
+// SaveNotesDraft stores a new draft version for a meeting
+func SaveNotesDraft(ctx context.Context, meetingID int64, markdown, model string, inputTokens, outputTokens, segmentCount int) (*NotesDraft, error) {
+	id, err := insertReturningID(ctx,
+		"INSERT INTO meeting_note_drafts (meeting_id, notes_markdown, model_used, input_tokens, output_tokens, segment_count) VALUES (?, ?, ?, ?, ?, ?)",
+		meetingID, markdown, model, inputTokens, outputTokens, segmentCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotesDraft{
+		ID:           id,
+		MeetingID:    meetingID,
+		Markdown:     markdown,
+		ModelUsed:    model,
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+		SegmentCount: segmentCount,
+		GeneratedAt:  time.Now(),
+	}, nil
+}
+
+// GetLatestNotesDraft returns the newest draft for a room, or ErrNotFound
+func GetLatestNotesDraft(ctx context.Context, roomName string) (*NotesDraft, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var d NotesDraft
+	var model sql.NullString
+	err := db.QueryRowContext(ctx,
+		`SELECT d.id, d.meeting_id, d.notes_markdown, d.model_used, d.input_tokens, d.output_tokens, d.segment_count, d.generated_at
+		 FROM meeting_note_drafts d JOIN meetings m ON m.id = d.meeting_id
+		 WHERE m.room_name = ?
+		 ORDER BY d.generated_at DESC, d.id DESC LIMIT 1`,
+		roomName,
+	).Scan(&d.ID, &d.MeetingID, &d.Markdown, &model, &d.InputTokens, &d.OutputTokens, &d.SegmentCount, &d.GeneratedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	d.ModelUsed = model.String
+	return &d, nil
+}
+
+// draftRuns tracks the rooms that are periodically generating draft notes
+type draftRuns struct {
+	mu    sync.Mutex
+	rooms map[string]context.CancelFunc
+}
+
+func newDraftRuns() *draftRuns {
+	return &draftRuns{rooms: make(map[string]context.CancelFunc)}
+}
+
+// startDraftNotes begins regenerating a room's draft notes every
+// NOTES_DRAFT_INTERVAL until stopDraftNotes is called
+func (s *server) startDraftNotes(roomName string) {
+	interval := config.NotesDraftInterval
+	if interval == 0 || config.AIServiceURL == "" {
+		return
+	}
+
+	s.drafts.mu.Lock()
+	defer s.drafts.mu.Unlock()
+	if _, running := s.drafts.rooms[roomName]; running {
+		return
+	}
+	ctx, cancel := context.WithCancel(withLogAttrs(context.Background(), "room", roomName))
+	s.drafts.rooms[roomName] = cancel
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				taskCtx, taskCancel := context.WithTimeout(ctx, backgroundTaskTimeout)
+				runRecovered(ctx, "draft notes", func() {
+					if err := s.generateDraftNotes(taskCtx, roomName); err != nil {
+						ctxLogger(ctx).Error("Failed to generate draft notes", "error", err)
+					}
+				})
+				taskCancel()
+			}
+		}
+	}()
+}
+
+func (s *server) stopDraftNotes(roomName string) {
+	s.drafts.mu.Lock()
+	defer s.drafts.mu.Unlock()
+	if cancel, ok := s.drafts.rooms[roomName]; ok {
+		cancel()
+		delete(s.drafts.rooms, roomName)
+	}
+}
+
+// generateDraftNotes asks the AI service to summarize the transcript so far
+// and saves the result as a draft. Nothing is generated if the transcript
+// has not grown since the last draft.
+func (s *server) generateDraftNotes(ctx context.Context, roomName string) error {
+	meeting, err := s.store.GetMeetingByRoom(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	segments, err := s.store.GetTranscriptByMeeting(ctx, meeting.ID)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+	latest, err := s.store.GetLatestNotesDraft(ctx, roomName)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if latest != nil && latest.SegmentCount == len(segments) {
+		return nil
+	}
+
+	var transcript strings.Builder
+	for _, seg := range segments {
+		fmt.Fprintf(&transcript, "[%s] %s: %s\n", seg.SegmentTS, seg.Speaker, seg.Text)
+	}
+	payload, _ := json.Marshal(fiber.Map{"room_name": roomName, "transcript": transcript.String()})
+
+	resp, err := callAIService(ctx, "summarize", "/summarize", payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("AI service returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Markdown string `json:"markdown"`
+		Model    string `json:"model"`
+		Usage    struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode AI service response: %w", err)
+	}
+
+	draft, err := s.store.SaveNotesDraft(ctx, meeting.ID, result.Markdown, result.Model, result.Usage.InputTokens, result.Usage.OutputTokens, len(segments))
+	if err != nil {
+		return err
+	}
+	ctxLogger(ctx).Info("Saved draft notes", "draft_id", draft.ID, "segments", len(segments))
+	return nil
+}
+
+func (s *server) getNotesDraftHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !roomScopeAllows(c, room, roomPermNotes) {
+		return roomScopeDenied(c)
+	}
+
+	draft, err := s.store.GetLatestNotesDraft(ctx, room)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "No draft notes yet")
+	}
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	return c.JSON(draft)
+}
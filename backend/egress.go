@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/livekit/protocol/livekit"
+)
+
+// Recording files are laid out by EGRESS_FILEPATH_TEMPLATE. {room},
+// {meeting_id}, and {date} (UTC, YYYY-MM-DD) are filled in here; {time},
+// {utc}, {room_name}, and {room_id} are left for LiveKit to expand when the
+// file is written. A meeting can be recorded more than once, so the template
+// must include {time} or {utc} to keep files from overwriting each other.
+const defaultEgressFilepathTemplate = "{date}/{meeting_id}/{room}-{time}.ogg"
+
+// egressIDPattern matches LiveKit egress IDs, such as EG_aB3dE5fG7hJ9
+var egressIDPattern = regexp.MustCompile(`^EG_[A-Za-z0-9]{1,64}$`)
+
+// validateEgressID rejects IDs that LiveKit could not have issued, so a bad
+// value is caught before it is stored or sent back to LiveKit
+func validateEgressID(id string) error {
+	if !egressIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid egress ID %q", id)
+	}
+	return nil
+}
+
+// convertEgressDuration converts a FileInfo duration to milliseconds.
+// LiveKit egress reports file durations in nanoseconds, as the difference of
+// the file's started_at and ended_at, which are Unix nanosecond timestamps.
+func convertEgressDuration(raw int64) int64 {
+	return time.Duration(raw).Milliseconds()
+}
+
+var (
+	egressPathPlaceholder  = regexp.MustCompile(`\{[a-z_]+\}`)
+	egressPathPlaceholders = map[string]bool{
+		"{room}": true, "{meeting_id}": true, "{date}": true,
+		"{time}": true, "{utc}": true, "{room_name}": true, "{room_id}": true,
+	}
+)
+
+// parseEgressFilepathTemplate validates EGRESS_FILEPATH_TEMPLATE
+func parseEgressFilepathTemplate(template string) (string, error) {
+	for _, p := range egressPathPlaceholder.FindAllString(template, -1) {
+		if !egressPathPlaceholders[p] {
+			return "", fmt.Errorf("has unknown placeholder %s", p)
+		}
+	}
+	if !strings.Contains(template, "{time}") && !strings.Contains(template, "{utc}") {
+		return "", fmt.Errorf("must include {time} or {utc} so recordings do not overwrite each other")
+	}
+	return template, nil
+}
+
+// egressFilepath fills in the placeholders LiveKit does not know about
+func egressFilepath(template, roomName string, meetingID int64, now time.Time) string {
+	return strings.NewReplacer(
+		"{room}", roomName,
+		"{meeting_id}", strconv.FormatInt(meetingID, 10),
+		"{date}", now.UTC().Format("2006-01-02"),
+	).Replace(template)
+}
+
+// recordingStatusForEgress maps a LiveKit egress status onto the recording
+// statuses stored in the database
+func recordingStatusForEgress(status livekit.EgressStatus) string {
+	switch status {
+	case livekit.EgressStatus_EGRESS_STARTING, livekit.EgressStatus_EGRESS_ACTIVE:
+		return "recording"
+	case livekit.EgressStatus_EGRESS_ENDING:
+		return "processing"
+	case livekit.EgressStatus_EGRESS_COMPLETE, livekit.EgressStatus_EGRESS_LIMIT_REACHED:
+		return "completed"
+	default:
+		return "failed"
+	}
+}
+
+// fetchEgress asks LiveKit for the current state of one egress
+func (s *server) fetchEgress(ctx context.Context, egressID string) (*livekit.EgressInfo, error) {
+	resp, err := s.egress.ListEgress(ctx, &livekit.ListEgressRequest{EgressId: egressID})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Items) == 0 {
+		return nil, fmt.Errorf("egress %s not found in LiveKit", egressID)
+	}
+	return resp.Items[0], nil
+}
+
+// reconcileRecording brings a recording's stored status in line with its
+// live egress. Finished egresses go through the same path as the
+// egress_ended webhook. It returns the recording's status afterwards.
+func (s *server) reconcileRecording(ctx context.Context, rec *Recording, info *livekit.EgressInfo) (string, error) {
+	live := recordingStatusForEgress(info.GetStatus())
+	if live == rec.Status || rec.Status == "completed" || rec.Status == "failed" {
+		return rec.Status, nil
+	}
+
+	switch live {
+	case "completed", "failed":
+		if err := s.handleEgressEnded(ctx, info); err != nil {
+			return rec.Status, err
+		}
+	case "processing":
+		if err := s.store.UpdateRecordingStatus(ctx, rec.EgressID, live, "", 0); err != nil {
+			return rec.Status, err
+		}
+	default:
+		// Never move a stopped recording back to recording
+		return rec.Status, nil
+	}
+	return live, nil
+}
+
+// Admin egress listing. A crash between starting an egress and hearing its
+// egress_ended webhook leaves recordings stuck in recording; an egress
+// started just before a crash may never get a row at all. The admin list
+// cross-references LiveKit's active egresses with the unfinished recordings
+// and flags the ones only one side knows about.
+
+// egressOrphanGrace is how long a new egress may be known to only one side:
+// the recording row is written just after LiveKit accepts the egress
+const egressOrphanGrace = time.Minute
+
+const (
+	// orphanDB is a recording the database thinks is running that LiveKit
+	// has no active egress for
+	orphanDB = "db"
+	// orphanLiveKit is an active egress no unfinished recording tracks
+	orphanLiveKit = "livekit"
+)
+
+// EgressEntry is an active egress, an unfinished recording, or both
+type EgressEntry struct {
+	EgressID    string     `json:"egressId"`
+	RoomName    string     `json:"roomName"`
+	LiveStatus  string     `json:"liveStatus,omitempty"` // empty when LiveKit has no active egress
+	DBStatus    string     `json:"dbStatus,omitempty"`   // empty when the database has no row
+	RecordingID int64      `json:"recordingId,omitempty"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	Orphan      string     `json:"orphan,omitempty"` // db or livekit
+}
+
+type EgressListResponse struct {
+	Egresses []EgressEntry `json:"egresses"`
+	Orphans  int           `json:"orphans"`
+}
+
+// egressSnapshot lists the unfinished recordings, then any active egress
+// none of them tracks
+func (s *server) egressSnapshot(ctx context.Context, now time.Time) ([]EgressEntry, *requestError) {
+	resp, err := s.egress.ListEgress(ctx, &livekit.ListEgressRequest{Active: true})
+	if err != nil {
+		ctxLogger(ctx).Error("Failed to list egresses", "error", err)
+		return nil, &requestError{502, APIError{Message: "Failed to list egresses from LiveKit: " + err.Error()}}
+	}
+	recordings, err := s.store.ListUnfinishedRecordings(ctx)
+	if err != nil {
+		return nil, &requestError{500, APIError{Message: err.Error()}}
+	}
+
+	live := map[string]*livekit.EgressInfo{}
+	for _, info := range resp.Items {
+		live[info.GetEgressId()] = info
+	}
+
+	entries := []EgressEntry{}
+	for _, rec := range recordings {
+		createdAt := rec.CreatedAt
+		entry := EgressEntry{
+			EgressID:    rec.EgressID,
+			RoomName:    rec.RoomName,
+			DBStatus:    rec.Status,
+			RecordingID: rec.ID,
+			StartedAt:   &createdAt,
+		}
+		if info := live[rec.EgressID]; info != nil {
+			entry.LiveStatus = info.GetStatus().String()
+			delete(live, rec.EgressID)
+		} else if now.Sub(rec.CreatedAt) > egressOrphanGrace {
+			entry.Orphan = orphanDB
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, info := range resp.Items {
+		if live[info.GetEgressId()] == nil {
+			continue
+		}
+		entry := EgressEntry{
+			EgressID:   info.GetEgressId(),
+			RoomName:   info.GetRoomName(),
+			LiveStatus: info.GetStatus().String(),
+		}
+		if started := info.GetStartedAt(); started > 0 {
+			t := time.Unix(0, started).UTC()
+			entry.StartedAt = &t
+			if now.Sub(t) > egressOrphanGrace {
+				entry.Orphan = orphanLiveKit
+			}
+		}
+		// A finished row means the egress outlived what the database recorded
+		if rec, err := s.store.GetRecordingByEgressID(ctx, entry.EgressID); err == nil {
+			entry.DBStatus = rec.Status
+			entry.RecordingID = rec.ID
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (s *server) listEgressHandler(c *fiber.Ctx) error {
+	entries, rerr := s.egressSnapshot(c.UserContext(), time.Now())
+	if rerr != nil {
+		return rerr.respond(c)
+	}
+	resp := EgressListResponse{Egresses: entries}
+	for _, e := range entries {
+		if e.Orphan != "" {
+			resp.Orphans++
+		}
+	}
+	return c.JSON(resp)
+}
+
+type ReconcileEgressRequest struct {
+	// StopUntracked stops active egresses no recording tracks. They may
+	// belong to another service sharing the LiveKit server, so it is opt-in.
+	StopUntracked bool `json:"stopUntracked"`
+}
+
+// EgressReconcileAction is what reconciling did about one orphan
+type EgressReconcileAction struct {
+	EgressID string `json:"egressId"`
+	RoomName string `json:"roomName"`
+	Orphan   string `json:"orphan"`
+	Action   string `json:"action"`           // finalized, marked_failed, stopped, skipped, unchanged, or error
+	Status   string `json:"status,omitempty"` // the recording's status afterwards
+	Error    string `json:"error,omitempty"`
+}
+
+type ReconcileEgressResponse struct {
+	Actions []EgressReconcileAction `json:"actions"`
+}
+
+// reconcileEgressHandler settles every orphan in the egress list. Recordings
+// whose egress has finished are finalized as the egress_ended webhook would
+// have; ones LiveKit has never heard of are marked failed. Untracked
+// egresses are stopped only on request.
+func (s *server) reconcileEgressHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	var req ReconcileEgressRequest
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, &req); err != nil {
+			return err
+		}
+	}
+
+	entries, rerr := s.egressSnapshot(ctx, time.Now())
+	if rerr != nil {
+		return rerr.respond(c)
+	}
+
+	actions := []EgressReconcileAction{}
+	for _, e := range entries {
+		if e.Orphan == "" {
+			continue
+		}
+		action := EgressReconcileAction{EgressID: e.EgressID, RoomName: e.RoomName, Orphan: e.Orphan}
+		var err error
+		switch e.Orphan {
+		case orphanDB:
+			action.Action, action.Status, err = s.reconcileOrphanRecording(ctx, e.EgressID)
+		case orphanLiveKit:
+			action.Action = "skipped"
+			if req.StopUntracked {
+				if _, err = s.egress.StopEgress(ctx, &livekit.StopEgressRequest{EgressId: e.EgressID}); err == nil {
+					action.Action = "stopped"
+				}
+			}
+		}
+		if err != nil {
+			ctxLogger(ctx).Error("Failed to reconcile egress", "egress_id", e.EgressID, "orphan", e.Orphan, "error", err)
+			action.Action = "error"
+			action.Error = err.Error()
+		} else if action.Action != "skipped" && action.Action != "unchanged" {
+			ctxLogger(ctx).Info("Reconciled egress", "egress_id", e.EgressID, "orphan", e.Orphan, "action", action.Action)
+		}
+		actions = append(actions, action)
+	}
+
+	counts := map[string]int{}
+	for _, a := range actions {
+		counts[a.Action]++
+	}
+	recordAudit(c, "egress.reconcile", "", fmt.Sprintf("%d orphans %v", len(actions), counts))
+
+	return c.JSON(ReconcileEgressResponse{Actions: actions})
+}
+
+// reconcileOrphanRecording settles a recording LiveKit no longer lists as
+// active, returning the action taken and the recording's new status
+func (s *server) reconcileOrphanRecording(ctx context.Context, egressID string) (action, status string, err error) {
+	rec, err := s.store.GetRecordingByEgressID(ctx, egressID)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := s.egress.ListEgress(ctx, &livekit.ListEgressRequest{EgressId: egressID})
+	if err != nil {
+		return "", "", err
+	}
+	if len(resp.Items) == 0 {
+		if err := s.store.UpdateRecordingStatus(ctx, egressID, "failed", "", 0); err != nil {
+			return "", "", err
+		}
+		return "marked_failed", "failed", nil
+	}
+	status, err = s.reconcileRecording(ctx, rec, resp.Items[0])
+	if err != nil {
+		return "", "", err
+	}
+	if status == rec.Status {
+		// LiveKit still reports it running; the list was out of date
+		return "unchanged", status, nil
+	}
+	return "finalized", status, nil
+}
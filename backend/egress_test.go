@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestValidateEgressID(t *testing.T) {
+	for _, id := range []string{
+		"EG_aB3dE5fG7hJ9",
+		"EG_1",
+		"EG_" + strings.Repeat("a", 64),
+	} {
+		if err := validateEgressID(id); err != nil {
+			t.Errorf("%q: got %v, want valid", id, err)
+		}
+	}
+	for _, id := range []string{
+		"",
+		"EG_",
+		"eg_aB3dE5fG7hJ9",
+		"aB3dE5fG7hJ9",
+		"EG-aB3dE5fG7hJ9",
+		"EG_aB3d E5fG",
+		"EG_aB3d-E5fG",
+		"EG_aB3d\n",
+		"EG_" + strings.Repeat("a", 65),
+		"RM_aB3dE5fG7hJ9",
+	} {
+		if err := validateEgressID(id); err == nil {
+			t.Errorf("%q: got valid, want an error", id)
+		}
+	}
+}
+
+func TestCreateRecordingRejectsInvalidEgressID(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx := context.Background()
+	meeting, err := EnsureMeeting(ctx, "standup")
+	if err != nil {
+		t.Fatalf("EnsureMeeting: %v", err)
+	}
+
+	if _, err := CreateRecording(ctx, meeting.ID, "not-an-egress"); err == nil {
+		t.Error("CreateRecording with a malformed ID: got nil, want an error")
+	}
+	if n := countRows(t, "SELECT COUNT(*) FROM recordings"); n != 0 {
+		t.Errorf("recordings rows: got %d, want 0", n)
+	}
+}
+
+func TestStopRecordingRejectsMalformedStoredEgressID(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	srv := newTestServer(t, sqlStore{})
+	app := newTestApp(t, srv)
+	ctx := context.Background()
+
+	meeting, err := EnsureMeeting(ctx, "standup")
+	if err != nil {
+		t.Fatalf("EnsureMeeting: %v", err)
+	}
+	// A damaged row, written past CreateRecording's check
+	if _, err := db.ExecContext(ctx, "INSERT INTO recordings (meeting_id, egress_id, status) VALUES (?, 'EG_bad id', 'recording')", meeting.ID); err != nil {
+		t.Fatalf("insert recording: %v", err)
+	}
+
+	resp := doRequest(t, app, http.MethodPost, "/api/meetings/standup/stop-recording", nil)
+	if resp.Status != http.StatusBadRequest {
+		t.Fatalf("stop with a malformed egress ID: got %d, want 400: %s", resp.Status, resp.Body)
+	}
+	if stopped := srv.egress.(*fakeEgress).stopped; len(stopped) != 0 {
+		t.Errorf("StopEgress was called with %v", stopped)
+	}
+}
+
+func TestConvertEgressDuration(t *testing.T) {
+	for _, tc := range []struct {
+		raw  int64
+		want int64
+	}{
+		{0, 0},
+		{int64(999 * time.Microsecond), 0},
+		{int64(time.Millisecond), 1},
+		{int64(90 * time.Second), 90_000},
+		{int64(time.Hour + 23*time.Minute + 45*time.Second + 678*time.Millisecond), 5_025_678},
+	} {
+		if got := convertEgressDuration(tc.raw); got != tc.want {
+			t.Errorf("convertEgressDuration(%d): got %d, want %d", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestStopRecordingStoresEgressDuration(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	srv := newTestServer(t, sqlStore{})
+	app := newTestApp(t, srv)
+	ctx := context.Background()
+
+	meeting, err := EnsureMeeting(ctx, "standup")
+	if err != nil {
+		t.Fatalf("EnsureMeeting: %v", err)
+	}
+	if _, err := CreateRecording(ctx, meeting.ID, "EG_duration1"); err != nil {
+		t.Fatalf("CreateRecording: %v", err)
+	}
+	// LiveKit reports the file's length in nanoseconds: 12 minutes 34.5 seconds
+	const recorded = 12*time.Minute + 34500*time.Millisecond
+	srv.egress.(*fakeEgress).egress["EG_duration1"] = &livekit.EgressInfo{
+		EgressId: "EG_duration1",
+		RoomName: "standup",
+		Status:   livekit.EgressStatus_EGRESS_ACTIVE,
+		Result: &livekit.EgressInfo_File{File: &livekit.FileInfo{
+			Location: "https://storage.test/standup.ogg",
+			Duration: int64(recorded),
+		}},
+	}
+
+	var body RecordingStatusResponse
+	resp := doRequest(t, app, http.MethodPost, "/api/meetings/standup/stop-recording", nil)
+	if resp.Status != http.StatusOK {
+		t.Fatalf("stop-recording: got %d: %s", resp.Status, resp.Body)
+	}
+	resp.decode(t, &body)
+	if body.DurationMS != 754_500 {
+		t.Errorf("durationMs in the response: got %d, want 754500", body.DurationMS)
+	}
+	rec, err := GetRecordingByEgressID(ctx, "EG_duration1")
+	if err != nil {
+		t.Fatalf("GetRecordingByEgressID: %v", err)
+	}
+	if got := time.Duration(rec.DurationMS) * time.Millisecond; got != recorded {
+		t.Errorf("stored duration_ms: got %d (%v), want %v", rec.DurationMS, got, recorded)
+	}
+}
+
+// recordingStatusFailStore is the SQL store with recording status writes
+// failing
+type recordingStatusFailStore struct{ sqlStore }
+
+func (recordingStatusFailStore) UpdateRecordingStatus(ctx context.Context, egressID, status, audioURL string, durationMS int64) error {
+	return errors.New("database is locked")
+}
+
+func TestStopRecordingStatusWriteFails(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	var notified atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notified.Add(1)
+	}))
+	defer hook.Close()
+	config.RecordingReadyWebhookURL = hook.URL
+	srv := newTestServer(t, recordingStatusFailStore{})
+	app := newTestApp(t, srv)
+	ctx := context.Background()
+
+	meeting, err := EnsureMeeting(ctx, "standup")
+	if err != nil {
+		t.Fatalf("EnsureMeeting: %v", err)
+	}
+	if _, err := CreateRecording(ctx, meeting.ID, "EG_unsaved1"); err != nil {
+		t.Fatalf("CreateRecording: %v", err)
+	}
+	srv.egress.(*fakeEgress).egress["EG_unsaved1"] = &livekit.EgressInfo{
+		EgressId: "EG_unsaved1",
+		RoomName: "standup",
+		Status:   livekit.EgressStatus_EGRESS_ACTIVE,
+		Result:   &livekit.EgressInfo_File{File: &livekit.FileInfo{Location: "https://storage.test/standup.ogg"}},
+	}
+
+	resp := doRequest(t, app, http.MethodPost, "/api/meetings/standup/stop-recording", nil)
+	if resp.Status != http.StatusInternalServerError {
+		t.Fatalf("stop-recording with the status write failing: got %d, want 500: %s", resp.Status, resp.Body)
+	}
+	if e := resp.apiError(t); !strings.Contains(e.Message, "could not be saved") {
+		t.Errorf("500 message: got %q, want it to say the status was not saved", e.Message)
+	}
+
+	// Let any queued webhook run before checking it never went out
+	stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	srv.jobs.Stop(stopCtx)
+	if n := notified.Load(); n != 0 {
+		t.Errorf("recording-ready webhook sent %d times, want none for an unsaved recording", n)
+	}
+}
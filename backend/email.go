@@ -2,60 +2,506 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
+	"slices"
+	"strings"
+	"text/template"
 	"time"
+
+	"github.com/gofiber/fiber/v2"
 )
 
 // N8NEmailPayload is the payload sent to n8n webhook for email delivery
 type N8NEmailPayload struct {
-	RoomName   string               `json:"roomName"`
-	Notes      string               `json:"notes"`
-	Timestamp  string               `json:"timestamp"`
-	Recipients []EmailSubscription  `json:"recipients"`
+	RoomName   string              `json:"roomName"`
+	Notes      string              `json:"notes"`
+	Timestamp  string              `json:"timestamp"`
+	Recipients []EmailSubscription `json:"recipients"`
+
+	// Sender identity from EMAIL_FROM_NAME and EMAIL_REPLY_TO, for n8n to
+	// put on the message
+	FromName string `json:"fromName,omitempty"`
+	ReplyTo  string `json:"replyTo,omitempty"`
+
+	// RecordingURL is a signed link to the recording, set when it is ready
+	// and every recipient in this payload asked for it. Recipients who did
+	// not are sent a separate payload without it.
+	RecordingURL       string     `json:"recordingUrl,omitempty"`
+	RecordingExpiresAt *time.Time `json:"recordingExpiresAt,omitempty"`
 }
 
-// TriggerEmailWorkflow sends meeting summary to n8n for email delivery
-func TriggerEmailWorkflow(roomName string, notes string) error {
-	webhookURL := os.Getenv("N8N_EMAIL_WEBHOOK_URL")
-	if webhookURL == "" {
-		log.Println("N8N_EMAIL_WEBHOOK_URL not set, skipping email trigger")
+// EmailDelivery records what happened to a summary email for one recipient
+type EmailDelivery struct {
+	ID                int64     `json:"id"`
+	MeetingID         int64     `json:"meetingId"`
+	Email             string    `json:"email"`
+	Status            string    `json:"status"` // sent, delivered, failed, suppressed, bounced, complained
+	BounceType        string    `json:"bounceType,omitempty"`
+	Reason            string    `json:"reason,omitempty"`
+	Attempt           int       `json:"attempt"`
+	ProviderMessageID string    `json:"providerMessageId,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// N8NTemplateData holds every field available to N8N_PAYLOAD_TEMPLATE
+type N8NTemplateData struct {
+	RoomName        string
+	MeetingID       int64
+	Notes           string
+	Timestamp       string
+	Recipients      []EmailSubscription
+	RecipientEmails []string
+	FromName        string // EMAIL_FROM_NAME
+	ReplyTo         string // EMAIL_REPLY_TO
+
+	// RecordingURL is empty unless a recording is ready and every recipient
+	// asked for it; RecordingExpiresAt is when the link stops working
+	RecordingURL       string
+	RecordingExpiresAt time.Time
+}
+
+// n8nTemplateFuncs are available inside N8N_PAYLOAD_TEMPLATE. Use {{json .Notes}}
+// to emit a correctly quoted and escaped JSON value.
+var n8nTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// buildN8NPayload renders the payload for the n8n email webhook. With no
+// template it falls back to the standard N8NEmailPayload JSON.
+func buildN8NPayload(tmpl string, data N8NTemplateData) ([]byte, error) {
+	if tmpl == "" {
+		payload := N8NEmailPayload{
+			RoomName:   data.RoomName,
+			Notes:      data.Notes,
+			Timestamp:  data.Timestamp,
+			Recipients: data.Recipients,
+			FromName:   data.FromName,
+			ReplyTo:    data.ReplyTo,
+		}
+		if data.RecordingURL != "" {
+			payload.RecordingURL = data.RecordingURL
+			payload.RecordingExpiresAt = &data.RecordingExpiresAt
+		}
+		return json.Marshal(payload)
+	}
+
+	t, err := template.New("n8n").Funcs(n8nTemplateFuncs).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("parse N8N_PAYLOAD_TEMPLATE: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("render N8N_PAYLOAD_TEMPLATE: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return buf.Bytes(), fmt.Errorf("N8N_PAYLOAD_TEMPLATE did not render valid JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+// N8NPayloadTestResponse is the payload an example summary would send to n8n
+type N8NPayloadTestResponse struct {
+	Source  string          `json:"source"` // default or N8N_PAYLOAD_TEMPLATE
+	Payload json.RawMessage `json:"payload"`
+}
+
+func testN8NPayloadHandler(c *fiber.Ctx) error {
+	tmpl := config.N8NPayloadTemplate
+	data := N8NTemplateData{
+		RoomName:  "flying-falcon",
+		MeetingID: 1,
+		Notes:     "## Meeting Summary\n\n- Example \"quoted\" point",
+		Timestamp: time.Now().Format(time.RFC3339),
+		Recipients: []EmailSubscription{
+			{ID: 1, MeetingID: 1, ParticipantName: "Alice", Email: "alice@example.com", CreatedAt: time.Now()},
+			{ID: 2, MeetingID: 1, ParticipantName: "Bob", Email: "bob@example.com", CreatedAt: time.Now()},
+		},
+		RecipientEmails: []string{"alice@example.com", "bob@example.com"},
+		FromName:        config.EmailFromName,
+		ReplyTo:         config.EmailReplyTo,
+	}
+
+	source := "default"
+	if tmpl != "" {
+		source = "N8N_PAYLOAD_TEMPLATE"
+	}
+
+	payload, err := buildN8NPayload(tmpl, data)
+	if err != nil {
+		return respondAPIError(c, 422, APIError{
+			Message: err.Error(),
+			Details: fiber.Map{"source": source, "rendered": string(payload)},
+		})
+	}
+
+	return c.JSON(N8NPayloadTestResponse{Source: source, Payload: payload})
+}
+
+// postJSON posts a JSON body, giving up when ctx is done
+func postJSON(ctx context.Context, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setRequestIDHeader(ctx, req)
+	return http.DefaultClient.Do(req)
+}
+
+// LogEmailDelivery records the outcome of sending a summary email to one
+// recipient. Each send to the same address for a meeting counts as a new attempt.
+// providerMessageID is the ID assigned by the mail provider, if it returned one.
+func LogEmailDelivery(ctx context.Context, meetingID int64, email, status, reason, providerMessageID string) error {
+	email = normalizeEmail(email)
+	messageID := sql.NullString{String: providerMessageID, Valid: providerMessageID != ""}
+	_, err := execWrite(ctx,
+		`INSERT INTO email_delivery_log (meeting_id, email, status, reason, provider_message_id, attempt)
+		 VALUES (?, ?, ?, ?, ?, (SELECT COUNT(*) + 1 FROM email_delivery_log WHERE meeting_id = ? AND email = ?))`,
+		meetingID, email, status, reason, messageID, meetingID, email,
+	)
+	return err
+}
+
+// MarkLatestDelivery updates the most recent delivery to an address with a
+// status reported after the fact (e.g. a bounce)
+func MarkLatestDelivery(ctx context.Context, email, status, bounceType, reason string) error {
+	_, err := execWrite(ctx,
+		`UPDATE email_delivery_log SET status = ?, bounce_type = ?, reason = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = (SELECT id FROM email_delivery_log WHERE email = ? AND status = 'sent' ORDER BY created_at DESC, id DESC LIMIT 1)`,
+		status, bounceType, reason, normalizeEmail(email),
+	)
+	return err
+}
+
+// UpdateDeliveryStatus sets the status of the latest summary email sent to an
+// address for a room. It reports whether a matching delivery was found.
+func UpdateDeliveryStatus(ctx context.Context, roomName, email, status, bounceType, reason string) (bool, error) {
+	meeting, err := GetMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	result, err := execWrite(ctx,
+		`UPDATE email_delivery_log SET status = ?, bounce_type = ?, reason = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = (SELECT id FROM email_delivery_log WHERE meeting_id = ? AND email = ? ORDER BY created_at DESC, id DESC LIMIT 1)`,
+		status, bounceType, reason, meeting.ID, normalizeEmail(email),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// TriggerEmailWorkflow sends the meeting summary to subscribers through n8n,
+// or directly through Resend when only RESEND_API_KEY is configured
+func TriggerEmailWorkflow(ctx context.Context, roomName string, notes string) error {
+	webhookURL := config.N8NEmailWebhookURL
+	resend := newResendEmailSender()
+	if webhookURL == "" && resend == nil {
+		ctxLogger(ctx).Warn("Neither N8N_EMAIL_WEBHOOK_URL nor RESEND_API_KEY is set, skipping email trigger")
 		return nil
 	}
 
 	// Get all email subscriptions for this room
-	subs, err := GetEmailSubscriptionsByRoom(roomName)
-	if err != nil || len(subs) == 0 {
-		log.Printf("No email subscriptions for room %s", roomName)
+	subs, err := GetEmailSubscriptionsByRoom(ctx, roomName)
+	if err != nil {
+		ctxLogger(ctx).Error("Failed to list email subscriptions", "error", err)
+		return nil
+	}
+	if len(subs) == 0 {
+		ctxLogger(ctx).Info("No email subscriptions")
 		return nil
 	}
 
-	payload := N8NEmailPayload{
-		RoomName:   roomName,
-		Notes:      notes,
-		Timestamp:  time.Now().Format(time.RFC3339),
-		Recipients: subs,
+	// Link the recording only if someone wants it and it has finished
+	// processing; a missing or unfinished recording just leaves it out
+	var recordingURL string
+	var recordingExpiresAt time.Time
+	for _, r := range subs {
+		if !r.IncludeRecording {
+			continue
+		}
+		if recordingURL, recordingExpiresAt, err = summaryRecordingLink(ctx, r.MeetingID); err != nil {
+			ctxLogger(ctx).Error("Failed to look up recording for summary email", "error", err)
+		}
+		break
+	}
+
+	if webhookURL == "" {
+		return sendSummaryViaResend(ctx, resend, roomName, notes, recordingURL, subs)
+	}
+
+	emails := make([]string, len(subs))
+	for i, s := range subs {
+		emails[i] = s.Email
+	}
+	return deliverEmail(ctx, subs[0].MeetingID, emails, func(to []string) error {
+		recipients := subscribersIn(subs, to)
+		if recordingURL == "" {
+			return postSummaryToN8N(ctx, webhookURL, roomName, notes, "", time.Time{}, recipients)
+		}
+		// n8n mails everyone in a payload the same message, so recipients
+		// who asked for the recording get their own payload with the link
+		var linked, unlinked []EmailSubscription
+		for _, r := range recipients {
+			if r.IncludeRecording {
+				linked = append(linked, r)
+			} else {
+				unlinked = append(unlinked, r)
+			}
+		}
+		var errs []error
+		if len(unlinked) > 0 {
+			errs = append(errs, postSummaryToN8N(ctx, webhookURL, roomName, notes, "", time.Time{}, unlinked))
+		}
+		if len(linked) > 0 {
+			errs = append(errs, postSummaryToN8N(ctx, webhookURL, roomName, notes, recordingURL, recordingExpiresAt, linked))
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// subscribersIn returns the subscriptions whose address is in emails
+func subscribersIn(subs []EmailSubscription, emails []string) []EmailSubscription {
+	var matched []EmailSubscription
+	for _, s := range subs {
+		if slices.Contains(emails, s.Email) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// postSummaryToN8N hands the summary to the n8n email workflow and records
+// the outcome for each recipient
+func postSummaryToN8N(ctx context.Context, webhookURL, roomName, notes, recordingURL string, recordingExpiresAt time.Time, recipients []EmailSubscription) error {
+	data := N8NTemplateData{
+		RoomName:           roomName,
+		MeetingID:          recipients[0].MeetingID,
+		Notes:              notes,
+		Timestamp:          time.Now().Format(time.RFC3339),
+		Recipients:         recipients,
+		RecordingURL:       recordingURL,
+		RecordingExpiresAt: recordingExpiresAt,
+		FromName:           config.EmailFromName,
+		ReplyTo:            config.EmailReplyTo,
+	}
+	for _, r := range recipients {
+		data.RecipientEmails = append(data.RecipientEmails, r.Email)
 	}
 
-	jsonPayload, err := json.Marshal(payload)
+	jsonPayload, err := buildN8NPayload(config.N8NPayloadTemplate, data)
 	if err != nil {
+		ctxLogger(ctx).Error("Failed to build n8n payload", "error", err)
 		return err
 	}
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonPayload))
+	resp, err := postJSON(ctx, webhookURL, jsonPayload)
 	if err != nil {
-		log.Printf("Failed to trigger n8n email workflow: %v", err)
+		ctxLogger(ctx).Error("Failed to trigger n8n email workflow", "error", err)
+		for _, r := range recipients {
+			LogEmailDelivery(ctx, r.MeetingID, r.Email, "failed", err.Error(), "")
+		}
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		log.Printf("Email workflow triggered for room %s, %d recipients", roomName, len(subs))
+		ctxLogger(ctx).Info("Email workflow triggered", "recipients", len(recipients))
+		for _, r := range recipients {
+			LogEmailDelivery(ctx, r.MeetingID, r.Email, "sent", "", "")
+		}
 	} else {
-		log.Printf("n8n webhook returned status %d", resp.StatusCode)
+		ctxLogger(ctx).Error("n8n webhook rejected email workflow", "status", resp.StatusCode)
+		for _, r := range recipients {
+			LogEmailDelivery(ctx, r.MeetingID, r.Email, "failed", fmt.Sprintf("n8n webhook returned status %d", resp.StatusCode), "")
+		}
 	}
 
 	return nil
 }
+
+// deliverEmail is the single point email leaves the backend through, so no
+// sender can mail an address that has hard-bounced or complained. It drops
+// suppressed addresses from to, recording each against meetingID (0 for
+// mail that is not about a meeting), and calls send with the rest, if any.
+// Addresses whose suppression cannot be checked are not mailed.
+func deliverEmail(ctx context.Context, meetingID int64, to []string, send func(to []string) error) error {
+	var allowed []string
+	for _, email := range to {
+		sup, err := GetSuppression(ctx, email)
+		if err != nil {
+			ctxLogger(ctx).Error("Failed to check suppression", "email", email, "error", err)
+			continue
+		}
+		if sup != nil {
+			ctxLogger(ctx).Info("Skipping suppressed address", "email", email, "reason", sup.Reason)
+			if meetingID != 0 {
+				LogEmailDelivery(ctx, meetingID, email, "suppressed", sup.Reason, "")
+			}
+			continue
+		}
+		allowed = append(allowed, email)
+	}
+	if len(allowed) == 0 {
+		ctxLogger(ctx).Info("Every recipient is suppressed; nothing to send")
+		return nil
+	}
+	return send(allowed)
+}
+
+// NotificationEmailPayload is sent to the n8n notification webhook for
+// one-off emails that are not meeting summaries
+type NotificationEmailPayload struct {
+	Type      string   `json:"type"`
+	To        []string `json:"to"`
+	Subject   string   `json:"subject"`
+	Body      string   `json:"body"`
+	Timestamp string   `json:"timestamp"`
+}
+
+// SendNotificationEmail asks n8n to deliver a plain notification email,
+// skipping suppressed addresses
+func SendNotificationEmail(ctx context.Context, kind string, to []string, subject, body string) error {
+	webhookURL := config.N8NNotifyWebhookURL
+	if webhookURL == "" {
+		ctxLogger(ctx).Warn("N8N_NOTIFY_WEBHOOK_URL not set, skipping notification", "kind", kind)
+		return nil
+	}
+	return deliverEmail(ctx, 0, to, func(recipients []string) error {
+		return postNotificationToN8N(ctx, webhookURL, kind, recipients, subject, body)
+	})
+}
+
+// postNotificationToN8N hands a notification to the n8n notification workflow
+func postNotificationToN8N(ctx context.Context, webhookURL, kind string, recipients []string, subject, body string) error {
+	jsonPayload, err := json.Marshal(NotificationEmailPayload{
+		Type:      kind,
+		To:        recipients,
+		Subject:   subject,
+		Body:      body,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := postJSON(ctx, webhookURL, jsonPayload)
+	if err != nil {
+		ctxLogger(ctx).Error("Failed to send notification", "kind", kind, "error", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		ctxLogger(ctx).Error("n8n notification webhook rejected notification", "kind", kind, "status", resp.StatusCode)
+		return fmt.Errorf("notification webhook returned status %d", resp.StatusCode)
+	}
+	ctxLogger(ctx).Info("Sent notification", "kind", kind, "recipients", len(recipients))
+	return nil
+}
+
+// N8NCallbackPayload is sent by the n8n workflow to report delivery status for one recipient
+type N8NCallbackPayload struct {
+	RoomName   string `json:"roomName"`
+	Email      string `json:"email"`
+	Status     string `json:"status"`     // delivered, failed, bounced, complained
+	BounceType string `json:"bounceType"` // hard or soft, when status is bounced
+	Reason     string `json:"reason"`
+}
+
+func (p *N8NCallbackPayload) validate(errs *fieldErrors) {
+	errs.require("roomName", p.RoomName)
+	errs.require("email", p.Email)
+	switch p.Status {
+	case "delivered", "failed", "complained":
+	case "bounced":
+		if p.BounceType != BounceHard && p.BounceType != BounceSoft {
+			errs.add("bounceType", "bounceType must be hard or soft")
+		}
+	default:
+		errs.add("status", "status must be delivered, failed, bounced, or complained")
+	}
+}
+
+// verifyN8NSignature checks an "X-N8N-Signature: sha256=<hex hmac>" header
+// against the raw callback body
+func verifyN8NSignature(secret string, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expectedSig := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(strings.ToLower(sig)), []byte(expectedSig))
+}
+
+func n8nCallbackHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	secret := config.N8NCallbackSecret
+	if secret == "" {
+		return respondError(c, 503, "n8n callbacks are not configured")
+	}
+	if !verifyN8NSignature(secret, c.Body(), c.Get("X-N8N-Signature")) {
+		return respondError(c, 401, "Invalid signature")
+	}
+
+	// Parsed by hand: the signature covers the raw body
+	var req N8NCallbackPayload
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return invalidBody()
+	}
+	if err := validateRequest(&req); err != nil {
+		return err
+	}
+
+	bounceType := ""
+	switch req.Status {
+	case "bounced":
+		bounceType = req.BounceType
+	case "complained":
+		bounceType = BounceComplaint
+	}
+
+	found, err := UpdateDeliveryStatus(ctx, req.RoomName, req.Email, req.Status, bounceType, req.Reason)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if !found {
+		return respondError(c, 404, "Delivery not found")
+	}
+
+	suppressed := false
+	if bounceType != "" {
+		suppressed, err = recordBounce(ctx, req.Email, bounceType, req.Reason)
+		if err != nil {
+			return respondError(c, 500, err.Error())
+		}
+	}
+
+	ctxLogger(ctx).Info("n8n callback", "room", req.RoomName, "email", req.Email, "status", req.Status)
+
+	return c.JSON(BounceResponse{Status: "updated", Suppressed: suppressed})
+}
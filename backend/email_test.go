@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingWebhook is an HTTP endpoint that keeps every JSON body posted to it
+type recordingWebhook struct {
+	mu     sync.Mutex
+	bodies []map[string]any
+}
+
+func newRecordingWebhook(t *testing.T, reply string) (*recordingWebhook, string) {
+	t.Helper()
+	w := &recordingWebhook{}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode webhook body: %v", err)
+		}
+		w.mu.Lock()
+		w.bodies = append(w.bodies, body)
+		w.mu.Unlock()
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return w, srv.URL
+}
+
+func (w *recordingWebhook) recipients(field string) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var to []string
+	for _, body := range w.bodies {
+		list, _ := body[field].([]any)
+		for _, v := range list {
+			to = append(to, v.(string))
+		}
+	}
+	return to
+}
+
+// subscribeWithBounce subscribes ada and bob to room and hard-bounces bob
+func subscribeWithBounce(t *testing.T, room string) int64 {
+	t.Helper()
+	ctx := context.Background()
+	for _, email := range []string{"ada@example.com", "bob@example.com"} {
+		if _, err := CreateEmailSubscription(ctx, room, "", email, false); err != nil {
+			t.Fatalf("CreateEmailSubscription(%s): %v", email, err)
+		}
+	}
+	if err := SuppressEmail(ctx, "bob@example.com", "hard", "mailbox does not exist"); err != nil {
+		t.Fatalf("SuppressEmail: %v", err)
+	}
+	meeting, err := GetMeetingByRoom(ctx, room)
+	if err != nil {
+		t.Fatalf("GetMeetingByRoom: %v", err)
+	}
+	return meeting.ID
+}
+
+func assertSuppressedLogged(t *testing.T, meetingID int64) {
+	t.Helper()
+	entries, err := GetEmailActivity(context.Background(), meetingID, "bob@example.com")
+	if err != nil {
+		t.Fatalf("GetEmailActivity: %v", err)
+	}
+	for _, e := range entries {
+		if e.Type == "delivery" && e.Status == "suppressed" {
+			return
+		}
+	}
+	t.Errorf("no suppressed delivery logged for bob: %+v", entries)
+}
+
+func TestSummaryThroughN8NSkipsSuppressedAddresses(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	hook, url := newRecordingWebhook(t, `{}`)
+	config.N8NEmailWebhookURL = url
+	config.N8NPayloadTemplate = `{"to": {{json .RecipientEmails}}}`
+	meetingID := subscribeWithBounce(t, "standup")
+
+	if err := TriggerEmailWorkflow(context.Background(), "standup", "# Notes"); err != nil {
+		t.Fatalf("TriggerEmailWorkflow: %v", err)
+	}
+	if got := hook.recipients("to"); !slices.Equal(got, []string{"ada@example.com"}) {
+		t.Errorf("n8n recipients: got %v, want only ada", got)
+	}
+	assertSuppressedLogged(t, meetingID)
+}
+
+func TestSummaryThroughResendSkipsSuppressedAddresses(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	hook, url := newRecordingWebhook(t, `{"id": "msg-1"}`)
+	meetingID := subscribeWithBounce(t, "standup")
+
+	subs, err := GetEmailSubscriptionsByRoom(context.Background(), "standup")
+	if err != nil {
+		t.Fatalf("GetEmailSubscriptionsByRoom: %v", err)
+	}
+	sender := &ResendEmailSender{APIKey: "key", From: "notes@example.com", URL: url, Client: http.DefaultClient}
+	if err := sendSummaryViaResend(context.Background(), sender, "standup", "# Notes", "", subs); err != nil {
+		t.Fatalf("sendSummaryViaResend: %v", err)
+	}
+	if got := hook.recipients("to"); !slices.Equal(got, []string{"ada@example.com"}) {
+		t.Errorf("Resend recipients: got %v, want only ada", got)
+	}
+	assertSuppressedLogged(t, meetingID)
+}
+
+func TestNotificationSkipsSuppressedAddresses(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	hook, url := newRecordingWebhook(t, `{}`)
+	config.N8NNotifyWebhookURL = url
+	if err := SuppressEmail(context.Background(), "bob@example.com", "complaint", "marked as spam"); err != nil {
+		t.Fatalf("SuppressEmail: %v", err)
+	}
+
+	to := []string{"ada@example.com", "bob@example.com"}
+	if err := SendNotificationEmail(context.Background(), "test", to, "Subject", "Body"); err != nil {
+		t.Fatalf("SendNotificationEmail: %v", err)
+	}
+	if got := hook.recipients("to"); !slices.Equal(got, []string{"ada@example.com"}) {
+		t.Errorf("notification recipients: got %v, want only ada", got)
+	}
+
+	if err := SendNotificationEmail(context.Background(), "test", []string{"bob@example.com"}, "Subject", "Body"); err != nil {
+		t.Fatalf("SendNotificationEmail: %v", err)
+	}
+	if n := len(hook.bodies); n != 1 {
+		t.Errorf("webhook calls after mailing only a suppressed address: got %d, want 1", n)
+	}
+}
+
+// signN8N returns the X-N8N-Signature header n8n sends for body
+func signN8N(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestN8NCallbackUpdatesDeliveryStatus(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	config.N8NCallbackSecret = "callback-secret"
+	app := newTestApp(t, newTestServer(t, sqlStore{}))
+	ctx := context.Background()
+
+	sub, err := CreateEmailSubscription(ctx, "standup", "Ada", "ada@example.com", false)
+	if err != nil {
+		t.Fatalf("CreateEmailSubscription: %v", err)
+	}
+	if err := LogEmailDelivery(ctx, sub.MeetingID, "ada@example.com", "sent", "", ""); err != nil {
+		t.Fatalf("LogEmailDelivery: %v", err)
+	}
+	status := func() string {
+		var s string
+		if err := db.QueryRowContext(ctx, "SELECT status FROM email_delivery_log WHERE meeting_id = ?", sub.MeetingID).Scan(&s); err != nil {
+			t.Fatalf("read delivery status: %v", err)
+		}
+		return s
+	}
+
+	body, _ := json.Marshal(N8NCallbackPayload{RoomName: "standup", Email: "ada@example.com", Status: "bounced", BounceType: BounceHard, Reason: "no such mailbox"})
+	invalid := map[string][]string{
+		"no signature":     nil,
+		"wrong secret":     {"X-N8N-Signature", signN8N("other-secret", body)},
+		"no sha256 prefix": {"X-N8N-Signature", strings.TrimPrefix(signN8N("callback-secret", body), "sha256=")},
+		"other body":       {"X-N8N-Signature", signN8N("callback-secret", []byte(`{}`))},
+	}
+	for name, header := range invalid {
+		if resp := doRequest(t, app, http.MethodPost, "/api/webhooks/n8n/callback", body, header...); resp.Status != http.StatusUnauthorized {
+			t.Errorf("%s: got %d, want 401", name, resp.Status)
+		}
+	}
+	if got := status(); got != "sent" {
+		t.Fatalf("status after rejected callbacks: got %q, want sent", got)
+	}
+
+	resp := doRequest(t, app, http.MethodPost, "/api/webhooks/n8n/callback", body, "X-N8N-Signature", signN8N("callback-secret", body))
+	if resp.Status != http.StatusOK {
+		t.Fatalf("signed callback: got %d, want 200: %s", resp.Status, resp.Body)
+	}
+	var result BounceResponse
+	resp.decode(t, &result)
+	if !result.Suppressed {
+		t.Error("hard bounce did not suppress the address")
+	}
+	if got := status(); got != "bounced" {
+		t.Errorf("status after signed callback: got %q, want bounced", got)
+	}
+}
+
+func TestSummaryThroughN8NLinksRecordingOnlyForOptedIn(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	hook, url := newRecordingWebhook(t, `{}`)
+	config.N8NEmailWebhookURL = url
+	config.PublicBackendURL = "https://api.example.com"
+	ctx := context.Background()
+
+	for _, sub := range []struct {
+		email   string
+		include bool
+	}{
+		{"ada@example.com", true},
+		{"bob@example.com", false},
+	} {
+		if _, err := CreateEmailSubscription(ctx, "standup", "", sub.email, sub.include); err != nil {
+			t.Fatalf("CreateEmailSubscription(%s): %v", sub.email, err)
+		}
+	}
+	meeting, err := GetMeetingByRoom(ctx, "standup")
+	if err != nil {
+		t.Fatalf("GetMeetingByRoom: %v", err)
+	}
+	if _, err := CreateRecording(ctx, meeting.ID, "EG_summary1"); err != nil {
+		t.Fatalf("CreateRecording: %v", err)
+	}
+	if err := UpdateRecordingStatus(ctx, "EG_summary1", "completed", "https://storage.test/standup.ogg", 1000); err != nil {
+		t.Fatalf("UpdateRecordingStatus: %v", err)
+	}
+
+	if err := TriggerEmailWorkflow(ctx, "standup", "# Notes"); err != nil {
+		t.Fatalf("TriggerEmailWorkflow: %v", err)
+	}
+	if len(hook.bodies) != 2 {
+		t.Fatalf("n8n payloads: got %d, want one with the link and one without", len(hook.bodies))
+	}
+	for _, body := range hook.bodies {
+		recipients, _ := body["recipients"].([]any)
+		if len(recipients) != 1 {
+			t.Fatalf("payload recipients: got %v, want one per group", recipients)
+		}
+		email := recipients[0].(map[string]any)["email"]
+		link, _ := body["recordingUrl"].(string)
+		switch email {
+		case "ada@example.com":
+			if !strings.HasPrefix(link, config.PublicBackendURL) {
+				t.Errorf("ada opted in: recordingUrl %q, want a signed link", link)
+			}
+		case "bob@example.com":
+			if link != "" || body["recordingExpiresAt"] != nil {
+				t.Errorf("bob did not opt in: payload carries recording link %q", link)
+			}
+		default:
+			t.Errorf("unexpected recipient %v", email)
+		}
+	}
+}
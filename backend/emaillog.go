@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// EmailLogEntry is one event in a meeting's email activity timeline
+type EmailLogEntry struct {
+	Type              string     `json:"type"` // delivery, bounce, suppression
+	Email             string     `json:"email"`
+	Status            string     `json:"status,omitempty"`
+	Attempt           int        `json:"attempt,omitempty"`
+	ProviderMessageID string     `json:"providerMessageId,omitempty"`
+	BounceType        string     `json:"bounceType,omitempty"`
+	Reason            string     `json:"reason,omitempty"`
+	Timestamp         time.Time  `json:"timestamp"`
+	UpdatedAt         *time.Time `json:"updatedAt,omitempty"`
+}
+
+// GetEmailActivity assembles the email timeline for a meeting from the
+// delivery log plus bounce and suppression events for the addresses it mailed.
+// It reads only log tables, so history survives unsubscribes.
+func GetEmailActivity(ctx context.Context, meetingID int64, email string) ([]EmailLogEntry, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	filter := ""
+	args := []interface{}{meetingID}
+	if email != "" {
+		filter = " AND email = ?"
+		args = append(args, normalizeEmail(email))
+	}
+
+	var entries []EmailLogEntry
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT email, status, attempt, provider_message_id, bounce_type, reason, created_at, updated_at FROM email_delivery_log WHERE meeting_id = ?"+filter,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		e := EmailLogEntry{Type: "delivery"}
+		var messageID, bounceType, reason sql.NullString
+		var updatedAt time.Time
+		if err := rows.Scan(&e.Email, &e.Status, &e.Attempt, &messageID, &bounceType, &reason, &e.Timestamp, &updatedAt); err != nil {
+			return nil, err
+		}
+		e.ProviderMessageID = messageID.String
+		e.UpdatedAt = &updatedAt
+		e.BounceType = bounceType.String
+		e.Reason = reason.String
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	recipients := "SELECT DISTINCT email FROM email_delivery_log WHERE meeting_id = ?" + filter
+
+	bounceRows, err := db.QueryContext(ctx,
+		"SELECT email, bounce_type, reason, created_at FROM email_bounces WHERE email IN ("+recipients+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer bounceRows.Close()
+	for bounceRows.Next() {
+		e := EmailLogEntry{Type: "bounce"}
+		var reason sql.NullString
+		if err := bounceRows.Scan(&e.Email, &e.BounceType, &reason, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		e.Reason = reason.String
+		entries = append(entries, e)
+	}
+	if err := bounceRows.Err(); err != nil {
+		return nil, err
+	}
+
+	supRows, err := db.QueryContext(ctx,
+		"SELECT email, reason, bounce_type, created_at FROM email_suppressions WHERE email IN ("+recipients+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer supRows.Close()
+	for supRows.Next() {
+		e := EmailLogEntry{Type: "suppression", Status: "suppressed"}
+		var bounceType sql.NullString
+		if err := supRows.Scan(&e.Email, &e.Reason, &bounceType, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		e.BounceType = bounceType.String
+		entries = append(entries, e)
+	}
+	if err := supRows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	return entries, nil
+}
+
+// EmailLogResponse is a meeting's email activity with a count of delivery
+// attempts by final status
+type EmailLogResponse struct {
+	RoomName   string          `json:"roomName"`
+	Entries    []EmailLogEntry `json:"entries"`
+	Count      int             `json:"count"`
+	Recipients int             `json:"recipients"`
+	Summary    map[string]int  `json:"summary"`
+}
+
+func (s *server) getEmailLogHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	meeting, err := s.store.GetMeetingByRoom(ctx, room)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	entries, err := s.store.GetEmailActivity(ctx, meeting.ID, c.Query("email"))
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if entries == nil {
+		entries = []EmailLogEntry{}
+	}
+
+	// Summarize the final status of each delivery attempt
+	summary := map[string]int{}
+	recipients := map[string]bool{}
+	for _, e := range entries {
+		if e.Type == "delivery" {
+			summary[e.Status]++
+			recipients[e.Email] = true
+		}
+	}
+
+	return c.JSON(EmailLogResponse{
+		RoomName:   room,
+		Entries:    entries,
+		Count:      len(entries),
+		Recipients: len(recipients),
+		Summary:    summary,
+	})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/gofiber/websocket/v2"
+)
+
+// WebSocket protocol versions. Version 1 clients receive bare transcript
+// objects; version 2 clients (connecting with ?v=2) receive every event
+// wrapped in a WSEnvelope.
+const (
+	wsProtocolLegacy   = 1
+	wsProtocolEnvelope = 2
+)
+
+// wsClient is one room WebSocket. A connection allows only one writer at a
+// time, so every write goes through writeLock.
+type wsClient struct {
+	conn      *websocket.Conn
+	version   int
+	writeLock sync.Mutex
+	closed    bool // guarded by writeLock
+}
+
+// errWSClientClosed is returned for writes to a client that has left
+var errWSClientClosed = errors.New("websocket client closed")
+
+func (w *wsClient) write(msg []byte) error {
+	w.writeLock.Lock()
+	defer w.writeLock.Unlock()
+	if w.closed {
+		return errWSClientClosed
+	}
+	return w.conn.WriteMessage(websocket.TextMessage, msg)
+}
+
+// close closes the socket once any write in progress has finished. Fiber
+// reuses the connection after the handler returns, so a broadcast that
+// copied this client out before it left must not touch conn afterwards.
+func (w *wsClient) close() {
+	w.writeLock.Lock()
+	defer w.writeLock.Unlock()
+	w.closed = true
+	w.conn.Close()
+}
+
+// Event types carried over the room WebSocket
+const (
+	EventTranscript    = "transcript"
+	EventStatus        = "status"
+	EventParticipant   = "participant"
+	EventSpeakerActive = "speaker-active"
+)
+
+// WSEnvelope wraps every message sent to version 2 WebSocket clients
+type WSEnvelope struct {
+	Version int         `json:"v"`
+	Type    string      `json:"type"`
+	Payload interface{} `json:"payload"`
+}
+
+// TranscriptEvent is the payload of a transcript event
+type TranscriptEvent struct {
+	Speaker   string `json:"speaker"`
+	Text      string `json:"text"`
+	IsFinal   bool   `json:"is_final"`
+	Timestamp string `json:"timestamp"`
+	// SpeakerLabel is the AI service's label when the host has mapped it
+	// to a name
+	SpeakerLabel string `json:"speaker_label,omitempty"`
+}
+
+// StatusEvent reports a change in a room's capture state
+type StatusEvent struct {
+	Kind  string `json:"kind"`  // transcription, recording, meeting
+	State string `json:"state"` // started, stopped, paused, resumed, ended
+}
+
+// broadcastEvent sends a typed event to every WebSocket client in a room.
+// Legacy clients only understand transcripts, so they receive the bare
+// transcript payload and nothing else.
+func broadcastEvent(room, eventType string, payload interface{}) {
+	envelope, err := json.Marshal(WSEnvelope{Version: wsProtocolEnvelope, Type: eventType, Payload: payload})
+	if err != nil {
+		slog.Error("Failed to encode event", "room", room, "type", eventType, "error", err)
+		return
+	}
+
+	var legacy []byte
+	if eventType == EventTranscript {
+		if legacy, err = json.Marshal(payload); err != nil {
+			slog.Error("Failed to encode legacy transcript", "room", room, "error", err)
+			return
+		}
+	}
+
+	broadcastToRoom(room, envelope, legacy)
+
+	// Mirrored rooms share the transcript stream but not capture status
+	if eventType == EventTranscript {
+		broadcastToMirrors(room, envelope, legacy)
+	}
+}
+
+// relayClientMessage rebroadcasts a message received on a room socket.
+// Sockets are unauthenticated, so only transcripts are relayed: any other
+// enveloped type is dropped rather than letting a client fake a server
+// event. Anything not enveloped is a legacy transcript line, passed through
+// when it is JSON and sent as a JSON string otherwise.
+func relayClientMessage(room string, msg []byte) {
+	var env struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(msg, &env); err == nil && env.Type != "" {
+		if env.Type != EventTranscript {
+			slog.Warn("Dropped client message of a server event type", "room", room, "type", env.Type)
+			return
+		}
+		broadcastEvent(room, EventTranscript, env.Payload)
+		return
+	}
+	if json.Valid(msg) {
+		broadcastEvent(room, EventTranscript, json.RawMessage(msg))
+		return
+	}
+	broadcastEvent(room, EventTranscript, string(msg))
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	gofiberws "github.com/gofiber/websocket/v2"
+)
+
+// useTestRooms gives the test an empty WebSocket registry
+func useTestRooms(t *testing.T) {
+	t.Helper()
+	transcriptLock.Lock()
+	prev := transcriptWS
+	transcriptWS = make(map[string]map[*gofiberws.Conn]*wsClient)
+	transcriptLock.Unlock()
+	t.Cleanup(func() {
+		transcriptLock.Lock()
+		transcriptWS = prev
+		transcriptLock.Unlock()
+	})
+}
+
+// dialRoom opens a version 2 room socket on the server at baseURL and
+// waits until the server has registered it
+func dialRoom(t *testing.T, baseURL, room string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(baseURL, "http") + "/ws/transcription/" + room + "?v=2"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", room, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	deadline := time.Now().Add(2 * time.Second)
+	for roomClientCount(room) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("socket for %s was never registered", room)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return conn
+}
+
+func roomClientCount(room string) int {
+	transcriptLock.RLock()
+	defer transcriptLock.RUnlock()
+	return len(transcriptWS[room])
+}
+
+func TestBroadcastRoomIsolation(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	useTestRooms(t)
+	baseURL := serveTestApp(t, newTestApp(t, newTestServer(t, newFakeStore())))
+
+	foo := dialRoom(t, baseURL, "foo")
+	foobar := dialRoom(t, baseURL, "foobar")
+
+	broadcastEvent("foo", EventTranscript, TranscriptEvent{Speaker: "Ada", Text: "only for foo", IsFinal: true})
+
+	foo.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, msg, err := foo.ReadMessage(); err != nil {
+		t.Fatalf("foo: %v", err)
+	} else if !strings.Contains(string(msg), "only for foo") {
+		t.Errorf("foo got %s", msg)
+	}
+
+	foobar.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, msg, err := foobar.ReadMessage()
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("foobar received %q (err %v), want nothing", msg, err)
+	}
+}
+
+// TestBroadcastConcurrentWithRegistration is meant for go test -race: rooms
+// are broadcast to while sockets join and leave them
+func TestBroadcastConcurrentWithRegistration(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	useTestRooms(t)
+	baseURL := serveTestApp(t, newTestApp(t, newTestServer(t, newFakeStore())))
+	rooms := []string{"race-a", "race-b"}
+
+	var broadcasters, joiners sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		room := rooms[i%len(rooms)]
+		broadcasters.Add(1)
+		go func() {
+			defer broadcasters.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					broadcastEvent(room, EventStatus, StatusEvent{Kind: "meeting", State: "started"})
+				}
+			}
+		}()
+		joiners.Add(1)
+		go func() {
+			defer joiners.Done()
+			url := "ws" + strings.TrimPrefix(baseURL, "http") + "/ws/transcription/" + room + "?v=2"
+			for j := 0; j < 5; j++ {
+				conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+				if err != nil {
+					t.Errorf("dial %s: %v", room, err)
+					return
+				}
+				time.Sleep(5 * time.Millisecond)
+				conn.Close()
+			}
+		}()
+	}
+
+	joiners.Wait()
+	close(stop)
+	broadcasters.Wait()
+}
+
+func TestRelayClientMessage(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	useTestRooms(t)
+	baseURL := serveTestApp(t, newTestApp(t, newTestServer(t, newFakeStore())))
+
+	sender := dialRoom(t, baseURL, "relay")
+	listener := dialRoom(t, baseURL, "relay")
+	for roomClientCount("relay") < 2 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	send := func(msg string) {
+		t.Helper()
+		if err := sender.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("send %s: %v", msg, err)
+		}
+	}
+	receive := func() WSEnvelope {
+		t.Helper()
+		listener.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, msg, err := listener.ReadMessage()
+		if err != nil {
+			t.Fatalf("listener: %v", err)
+		}
+		var env WSEnvelope
+		if err := json.Unmarshal(msg, &env); err != nil {
+			t.Fatalf("listener got %s: %v", msg, err)
+		}
+		return env
+	}
+
+	// A client can't pass itself off as the server; the transcript after it
+	// is the first thing the listener sees
+	send(`{"type":"status","payload":{"kind":"recording","state":"stopped"}}`)
+	send(`{"type":"transcript","payload":{"speaker":"Ada","text":"enveloped","is_final":true}}`)
+	if env := receive(); env.Type != EventTranscript || !strings.Contains(fmt.Sprint(env.Payload), "enveloped") {
+		t.Errorf("after a forged status event: got %+v, want the enveloped transcript", env)
+	}
+
+	// Legacy lines that aren't JSON arrive as a JSON string
+	send("plain transcript line")
+	if env := receive(); env.Type != EventTranscript || env.Payload != "plain transcript line" {
+		t.Errorf("legacy text line: got %+v, want a transcript with the line as a string", env)
+	}
+}
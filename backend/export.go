@@ -0,0 +1,514 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TimelineEvent is one entry in a meeting's event timeline
+type TimelineEvent struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// ExportOmission explains why a section is missing from an export
+type ExportOmission struct {
+	Section string `json:"section"`
+	Reason  string `json:"reason"`
+}
+
+// ExportManifest describes the contents of a meeting export archive
+type ExportManifest struct {
+	RoomName   string           `json:"roomName"`
+	MeetingID  int64            `json:"meetingId"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	EndedAt    *time.Time       `json:"endedAt,omitempty"`
+	DurationMS int64            `json:"durationMs,omitempty"`
+	ExportedAt time.Time        `json:"exportedAt"`
+	Files      []string         `json:"files"`
+	Omitted    []ExportOmission `json:"omitted"`
+}
+
+// buildMeetingTimeline collects everything that happened to a meeting in time order
+func buildMeetingTimeline(ctx context.Context, store Store, meeting *Meeting) ([]TimelineEvent, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	events := []TimelineEvent{{Time: meeting.CreatedAt, Type: "meeting_created"}}
+	if meeting.EndedAt != nil {
+		events = append(events, TimelineEvent{Time: *meeting.EndedAt, Type: "meeting_ended"})
+	}
+
+	recordings, err := store.ListRecordingsByMeeting(ctx, meeting.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range recordings {
+		events = append(events, TimelineEvent{Time: r.CreatedAt, Type: "recording_started", Detail: r.EgressID})
+		if r.CompletedAt != nil {
+			events = append(events, TimelineEvent{Time: *r.CompletedAt, Type: "recording_" + r.Status, Detail: r.EgressID})
+		}
+	}
+
+	err = store.ForEachNotesVersion(ctx, meeting.ID, func(n *MeetingNotes) error {
+		events = append(events, TimelineEvent{Time: n.GeneratedAt, Type: "notes_generated", Detail: n.ModelUsed})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	attendance, err := store.ListMeetingEvents(ctx, meeting.ID)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, attendance...)
+
+	emails, err := store.GetEmailActivity(ctx, meeting.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range emails {
+		detail := e.Email
+		if e.Status != "" {
+			detail += " (" + e.Status + ")"
+		}
+		events = append(events, TimelineEvent{Time: e.Timestamp, Type: "email_" + e.Type, Detail: detail})
+	}
+
+	audits, err := store.ListAuditByTarget(ctx, meeting.RoomName)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range audits {
+		events = append(events, TimelineEvent{Time: a.CreatedAt, Type: a.Action, Detail: a.Details})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events, nil
+}
+
+// writeMeetingExport writes a meeting's notes, transcript, timeline, and
+// manifest to w as a ZIP archive
+func writeMeetingExport(ctx context.Context, store Store, w io.Writer, meeting *Meeting) error {
+	zw := zip.NewWriter(w)
+	manifest := ExportManifest{
+		RoomName:   meeting.RoomName,
+		MeetingID:  meeting.ID,
+		CreatedAt:  meeting.CreatedAt,
+		EndedAt:    meeting.EndedAt,
+		DurationMS: meeting.DurationMS,
+		ExportedAt: time.Now(),
+		Files:      []string{},
+		Omitted:    []ExportOmission{},
+	}
+
+	// Notes
+	notes, err := store.GetNotesByRoom(ctx, meeting.RoomName)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		manifest.Omitted = append(manifest.Omitted, ExportOmission{"notes", "no notes have been generated for this meeting"})
+	case err != nil:
+		return err
+	default:
+		f, err := zw.Create("notes.md")
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, notes.Markdown); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, "notes.md")
+	}
+
+	// Transcript
+	segments, err := store.GetTranscriptByMeeting(ctx, meeting.ID)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		manifest.Omitted = append(manifest.Omitted, ExportOmission{"transcript", "no transcript was captured for this meeting"})
+	} else {
+		f, err := zw.Create("transcript.txt")
+		if err != nil {
+			return err
+		}
+		for _, s := range segments {
+			if _, err := fmt.Fprintf(f, "[%s] %s: %s\n", s.SegmentTS, s.Speaker, s.Text); err != nil {
+				return err
+			}
+		}
+		manifest.Files = append(manifest.Files, "transcript.txt")
+	}
+
+	// Event timeline
+	events, err := buildMeetingTimeline(ctx, store, meeting)
+	if err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "timeline.json", events); err != nil {
+		return err
+	}
+	manifest.Files = append(manifest.Files, "timeline.json")
+
+	// Manifest goes last so it can list what was included
+	if err := writeZipJSON(zw, "manifest.json", manifest); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func (s *server) exportMeetingZipHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	meeting, err := s.store.GetMeetingByRoom(ctx, room)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	recordAudit(c, "meeting.export", room, "")
+
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, room))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// The stream is written after the handler returns, so it gets its own deadline
+		ctx, cancel := detachedContext(ctx)
+		defer cancel()
+
+		if err := writeMeetingExport(ctx, s.store, w, meeting); err != nil {
+			ctxLogger(ctx).Error("Failed to export meeting", "error", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+// exportBundleTimeout bounds how long streaming a full export may take
+const exportBundleTimeout = 10 * time.Minute
+
+// writeZipJSON adds name to the archive as indented JSON
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// ForEachNotesVersion calls fn for every notes version saved for a meeting, oldest first
+func ForEachNotesVersion(ctx context.Context, meetingID int64, fn func(*MeetingNotes) error) error {
+	rows, err := db.QueryContext(ctx,
+		"SELECT "+notesColumns+" FROM meeting_notes WHERE meeting_id = ? ORDER BY generated_at, id",
+		meetingID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n MeetingNotes
+		var etag sql.NullString
+		var totalCost sql.NullFloat64
+		if err := rows.Scan(&n.ID, &n.MeetingID, &n.Markdown, &n.GeneratedAt, &n.ModelUsed, &n.InputTokens, &n.OutputTokens, &etag, &totalCost); err != nil {
+			return err
+		}
+		n.ETag = etag.String
+		if totalCost.Valid {
+			n.TotalCostUSD = &totalCost.Float64
+		}
+		if err := fn(&n); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// srtTimestamp formats an offset from the start of a meeting as HH:MM:SS,mmm
+func srtTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// writeTranscriptSRT writes a meeting's transcript as SubRip captions. Segments
+// have no duration, so each cue lasts as long as its words take to say at
+// speakingWordsPerMinute, cut short when the next segment begins.
+func writeTranscriptSRT(ctx context.Context, store Store, w io.Writer, meetingID int64) error {
+	var start time.Time
+	var pending *TranscriptSegment
+	cue := 0
+
+	flush := func(next *time.Time) error {
+		if pending == nil {
+			return nil
+		}
+		words := len(strings.Fields(pending.Text))
+		length := time.Duration(words) * time.Minute / speakingWordsPerMinute
+		if length < time.Second {
+			length = time.Second
+		}
+		from := pending.CreatedAt.Sub(start)
+		to := from + length
+		if next != nil && next.After(pending.CreatedAt) && next.Sub(start) < to {
+			to = next.Sub(start)
+		}
+		cue++
+		text := pending.Text
+		if pending.Speaker != "" {
+			text = pending.Speaker + ": " + text
+		}
+		_, err := fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", cue, srtTimestamp(from), srtTimestamp(to), text)
+		return err
+	}
+
+	err := store.ForEachTranscriptSegment(ctx, meetingID, func(s *TranscriptSegment) error {
+		if pending == nil {
+			start = s.CreatedAt
+		} else if err := flush(&s.CreatedAt); err != nil {
+			return err
+		}
+		pending = s
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush(nil)
+}
+
+// localRecordingPath returns the path of a recording the backend can read
+// directly, or "" when it lives elsewhere (e.g. on the egress host or in a bucket)
+func localRecordingPath(r Recording) string {
+	if r.AudioURL == "" || strings.Contains(r.AudioURL, "://") {
+		return ""
+	}
+	if info, err := os.Stat(r.AudioURL); err != nil || info.IsDir() {
+		return ""
+	}
+	return r.AudioURL
+}
+
+// writeMeetingBundle streams everything held about a meeting as a ZIP: every
+// notes version, the transcript as text and SRT, attendance, email activity,
+// recording references, the event timeline, and a manifest. Audio is only
+// included when includeAudio is set and the file is stored locally.
+func writeMeetingBundle(ctx context.Context, store Store, w io.Writer, meeting *Meeting, includeAudio bool) error {
+	zw := zip.NewWriter(w)
+	manifest := ExportManifest{
+		RoomName:   meeting.RoomName,
+		MeetingID:  meeting.ID,
+		CreatedAt:  meeting.CreatedAt,
+		EndedAt:    meeting.EndedAt,
+		DurationMS: meeting.DurationMS,
+		ExportedAt: time.Now(),
+		Files:      []string{},
+		Omitted:    []ExportOmission{},
+	}
+	add := func(name string) { manifest.Files = append(manifest.Files, name) }
+	omit := func(section, reason string) {
+		manifest.Omitted = append(manifest.Omitted, ExportOmission{section, reason})
+	}
+
+	// Notes, one file per saved version
+	versions := 0
+	err := store.ForEachNotesVersion(ctx, meeting.ID, func(n *MeetingNotes) error {
+		versions++
+		name := fmt.Sprintf("notes/v%d-%s.md", versions, n.GeneratedAt.UTC().Format("20060102T150405Z"))
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, n.Markdown); err != nil {
+			return err
+		}
+		add(name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if versions == 0 {
+		omit("notes", "no notes have been generated for this meeting")
+	}
+
+	// Transcript document and captions; the entry is only created once
+	// there is a segment to write
+	var transcript io.Writer
+	err = store.ForEachTranscriptSegment(ctx, meeting.ID, func(s *TranscriptSegment) error {
+		if transcript == nil {
+			var err error
+			if transcript, err = zw.Create("transcript.txt"); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(transcript, "[%s] %s: %s\n", s.SegmentTS, s.Speaker, s.Text)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if transcript == nil {
+		omit("transcript", "no transcript was captured for this meeting")
+	} else {
+		add("transcript.txt")
+		f, err := zw.Create("transcript.srt")
+		if err != nil {
+			return err
+		}
+		if err := writeTranscriptSRT(ctx, store, f, meeting.ID); err != nil {
+			return err
+		}
+		add("transcript.srt")
+	}
+
+	// Attendance
+	participants, err := store.ListParticipants(ctx, meeting.RoomName)
+	if err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "participants.json", participants); err != nil {
+		return err
+	}
+	add("participants.json")
+
+	// Email activity
+	emails, err := store.GetEmailActivity(ctx, meeting.ID, "")
+	if err != nil {
+		return err
+	}
+	if emails == nil {
+		emails = []EmailLogEntry{}
+	}
+	if err := writeZipJSON(zw, "email_activity.json", emails); err != nil {
+		return err
+	}
+	add("email_activity.json")
+
+	// Recordings are listed by reference; audio is large and usually stored
+	// on the egress host rather than here
+	recordings, err := store.ListRecordingsByMeeting(ctx, meeting.ID)
+	if err != nil {
+		return err
+	}
+	if recordings == nil {
+		recordings = []Recording{}
+	}
+	if err := writeZipJSON(zw, "recordings.json", recordings); err != nil {
+		return err
+	}
+	add("recordings.json")
+	for _, r := range recordings {
+		if r.Status != "completed" {
+			continue
+		}
+		if !includeAudio {
+			omit("audio:"+r.EgressID, "audio is only included with ?includeAudio=true")
+			continue
+		}
+		path := localRecordingPath(r)
+		if path == "" {
+			omit("audio:"+r.EgressID, "recording is not in local storage; see audioUrl in recordings.json")
+			continue
+		}
+		if err := addZipFile(zw, "audio/"+filepath.Base(path), path); err != nil {
+			return err
+		}
+		add("audio/" + filepath.Base(path))
+	}
+
+	// Event timeline
+	events, err := buildMeetingTimeline(ctx, store, meeting)
+	if err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "timeline.json", events); err != nil {
+		return err
+	}
+	add("timeline.json")
+
+	// Manifest goes last so it can list what was included
+	if err := writeZipJSON(zw, "manifest.json", manifest); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// addZipFile copies a file from disk into the archive. Audio is already
+// compressed, so it is stored rather than deflated.
+func addZipFile(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func (s *server) exportMeetingBundleHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	meeting, err := s.store.GetMeetingByRoom(ctx, room)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	includeAudio := c.QueryBool("includeAudio", false)
+
+	details := ""
+	if includeAudio {
+		details = "includeAudio"
+	}
+	recordAudit(c, "meeting.export_full", room, details)
+
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-export.zip"`, room))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), exportBundleTimeout)
+		defer cancel()
+
+		if err := writeMeetingBundle(ctx, s.store, w, meeting, includeAudio); err != nil {
+			ctxLogger(ctx).Error("Failed to export meeting bundle", "error", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
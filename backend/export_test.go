@@ -0,0 +1,93 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// readZip returns the files in a ZIP archive by name
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestExportMeetingBundleUsesStore(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	store := newFakeStore()
+	app := newTestApp(t, newTestServer(t, store))
+	ctx := context.Background()
+
+	// Everything exported lives only in the fake store
+	for _, md := range []string{"# Draft", "# Final"} {
+		if _, err := store.SaveNotes(ctx, "exported", md, "test-model", 1, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, text := range []string{"Hello there", "Hi"} {
+		if _, err := store.SaveTranscriptSegment(ctx, "exported", "Ada", text, "00:00:01"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if resp := doRequest(t, app, "GET", "/api/meetings/missing/export", nil, bearer(t, testAdminEmail)...); resp.Status != 404 {
+		t.Errorf("missing meeting: got %d, want 404", resp.Status)
+	}
+	if resp := doRequest(t, app, "GET", "/api/meetings/exported/export", nil, bearer(t, testUserEmail)...); resp.Status != 403 {
+		t.Errorf("not the host: got %d, want 403", resp.Status)
+	}
+
+	resp := doRequest(t, app, "GET", "/api/meetings/exported/export", nil, bearer(t, testAdminEmail)...)
+	if resp.Status != 200 {
+		t.Fatalf("got %d, want 200: %s", resp.Status, resp.Body)
+	}
+	files := readZip(t, resp.Body)
+
+	var notes []string
+	for name := range files {
+		if strings.HasPrefix(name, "notes/") {
+			notes = append(notes, name)
+		}
+	}
+	sort.Strings(notes)
+	if len(notes) != 2 || files[notes[0]] != "# Draft" || files[notes[1]] != "# Final" {
+		t.Errorf("notes versions %v, want the draft then the final notes", notes)
+	}
+	if got := files["transcript.txt"]; !strings.Contains(got, "Ada: Hello there") || !strings.Contains(got, "Ada: Hi") {
+		t.Errorf("transcript.txt = %q", got)
+	}
+	if !strings.Contains(files["transcript.srt"], "00:00:00,000 --> ") {
+		t.Errorf("transcript.srt = %q", files["transcript.srt"])
+	}
+
+	var manifest ExportManifest
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if manifest.RoomName != "exported" || len(manifest.Files) != len(files)-1 {
+		t.Errorf("manifest %+v does not list the %d other files", manifest, len(files)-1)
+	}
+}
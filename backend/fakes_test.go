@@ -0,0 +1,551 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// fakeStore keeps meetings, notes, subscriptions, and scheduled meetings in
+// memory, so handler tests run without a database. Operations it does not
+// implement fall through to the embedded nil Store and panic, which fails
+// the test that reached them.
+type fakeStore struct {
+	Store
+
+	mu         sync.Mutex
+	nextID     int64
+	meetings   map[string]*Meeting // by room name
+	notes      []MeetingNotes
+	subs       []EmailSubscription
+	segments   []TranscriptSegment
+	scheduled  []ScheduledMeeting
+	namespaces map[int64]string
+	audit      []AuditEntry
+
+	// expired is how many rows of each table are past retention; batches
+	// records the limit of every PurgeExpiredBatch call
+	expired map[string]int
+	batches []int
+
+	// backupErr, if set, is returned by BackupDatabase
+	backupErr error
+
+	// createMeetingErr, if set, is returned by the next CreateMeeting call
+	createMeetingErr error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{meetings: map[string]*Meeting{}, namespaces: map[int64]string{}, expired: map[string]int{}}
+}
+
+func (s *fakeStore) id() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *fakeStore) ensureMeeting(roomName string) *Meeting {
+	m, ok := s.meetings[roomName]
+	if !ok {
+		m = &Meeting{ID: s.id(), RoomName: roomName, CreatedAt: time.Now()}
+		s.meetings[roomName] = m
+	}
+	return m
+}
+
+func (s *fakeStore) CreateMeeting(ctx context.Context, roomName, roomSID string, hostUserID int64) (*Meeting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.createMeetingErr; err != nil {
+		s.createMeetingErr = nil
+		return nil, err
+	}
+	m := s.ensureMeeting(roomName)
+	m.RoomSID = roomSID
+	if m.EndedAt != nil {
+		m.EndedAt, m.TranscribingSince, m.CreatedAt = nil, nil, time.Now()
+		m.HostUserID = hostUserID
+	} else if m.HostUserID == 0 {
+		m.HostUserID = hostUserID
+	}
+	copy := *m
+	return &copy, nil
+}
+
+func (s *fakeStore) ReopenMeeting(ctx context.Context, roomName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.meetings[roomName]; ok && m.EndedAt != nil {
+		m.EndedAt, m.TranscribingSince, m.CreatedAt = nil, nil, time.Now()
+	}
+	return nil
+}
+
+func (s *fakeStore) CountActiveHostRooms(ctx context.Context, hostUserID int64, exceptRoom string) (int, error) {
+	return 0, nil
+}
+
+func (s *fakeStore) GetMeetingByRoom(ctx context.Context, roomName string) (*Meeting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.meetings[roomName]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copy := *m
+	return &copy, nil
+}
+
+func (s *fakeStore) EnsureMeeting(ctx context.Context, roomName string) (*Meeting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy := *s.ensureMeeting(roomName)
+	return &copy, nil
+}
+
+func (s *fakeStore) RoomNameInUse(ctx context.Context, roomName string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.meetings[roomName]; ok {
+		return true, nil
+	}
+	for _, m := range s.scheduled {
+		if m.RoomName == roomName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeStore) SaveNotes(ctx context.Context, roomName, markdown, model string, inputTokens, outputTokens int) (*MeetingNotes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.ensureMeeting(roomName)
+	notes := MeetingNotes{
+		ID:           s.id(),
+		MeetingID:    m.ID,
+		Markdown:     markdown,
+		GeneratedAt:  time.Now(),
+		ModelUsed:    model,
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+		ETag:         notesETag(markdown),
+	}
+	applyNotesStats(&notes)
+	s.notes = append(s.notes, notes)
+	return &notes, nil
+}
+
+func (s *fakeStore) GetNotesByRoom(ctx context.Context, roomName string) (*MeetingNotes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.meetings[roomName]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	for i := len(s.notes) - 1; i >= 0; i-- {
+		if s.notes[i].MeetingID == m.ID {
+			notes := s.notes[i]
+			return &notes, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *fakeStore) ForEachNotesVersion(ctx context.Context, meetingID int64, fn func(*MeetingNotes) error) error {
+	s.mu.Lock()
+	var versions []MeetingNotes
+	for _, n := range s.notes {
+		if n.MeetingID == meetingID {
+			versions = append(versions, n)
+		}
+	}
+	s.mu.Unlock()
+	for i := range versions {
+		if err := fn(&versions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) SaveTranscriptSegment(ctx context.Context, roomName, speaker, text, segmentTS string) (*TranscriptSegment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.ensureMeeting(roomName)
+	seg := TranscriptSegment{ID: s.id(), MeetingID: m.ID, Speaker: speaker, Text: text, SegmentTS: segmentTS, CreatedAt: time.Now()}
+	s.segments = append(s.segments, seg)
+	return &seg, nil
+}
+
+func (s *fakeStore) GetTranscriptByMeeting(ctx context.Context, meetingID int64) ([]TranscriptSegment, error) {
+	var segments []TranscriptSegment
+	err := s.ForEachTranscriptSegment(ctx, meetingID, func(seg *TranscriptSegment) error {
+		segments = append(segments, *seg)
+		return nil
+	})
+	return segments, err
+}
+
+func (s *fakeStore) ForEachTranscriptSegment(ctx context.Context, meetingID int64, fn func(*TranscriptSegment) error) error {
+	s.mu.Lock()
+	var segments []TranscriptSegment
+	for _, seg := range s.segments {
+		if seg.MeetingID == meetingID {
+			segments = append(segments, seg)
+		}
+	}
+	s.mu.Unlock()
+	for i := range segments {
+		if err := fn(&segments[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The fake keeps no attendance, recordings, or email history
+
+func (s *fakeStore) ListParticipants(ctx context.Context, roomName string) ([]Participant, error) {
+	return nil, nil
+}
+
+func (s *fakeStore) ListMeetingEvents(ctx context.Context, meetingID int64) ([]TimelineEvent, error) {
+	return nil, nil
+}
+
+func (s *fakeStore) ListRecordingsByMeeting(ctx context.Context, meetingID int64) ([]Recording, error) {
+	return nil, nil
+}
+
+func (s *fakeStore) GetEmailActivity(ctx context.Context, meetingID int64, email string) ([]EmailLogEntry, error) {
+	return nil, nil
+}
+
+func (s *fakeStore) LogAudit(ctx context.Context, actorUserID int64, action, target, details, ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.audit = append(s.audit, AuditEntry{ID: s.id(), ActorUserID: actorUserID, Action: action, Target: target, Details: details, IPAddress: ip, CreatedAt: time.Now()})
+	return nil
+}
+
+func (s *fakeStore) ListAuditByTarget(ctx context.Context, target string) ([]AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []AuditEntry
+	for _, e := range s.audit {
+		if e.Target == target {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (s *fakeStore) CountExpiredRows(ctx context.Context, p retentionPolicy, cutoff time.Time, limit int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return min(s.expired[p.Table], limit), nil
+}
+
+func (s *fakeStore) PurgeExpiredBatch(ctx context.Context, p retentionPolicy, cutoff time.Time, limit int) (int, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, limit)
+	n := min(s.expired[p.Table], limit)
+	s.expired[p.Table] -= n
+	return n, nil, nil
+}
+
+func (s *fakeStore) CreateEmailSubscription(ctx context.Context, roomName, participantName, email string, includeRecording bool) (*EmailSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.ensureMeeting(roomName)
+	for i := range s.subs {
+		if sub := &s.subs[i]; sub.MeetingID == m.ID && sub.Email == email {
+			sub.ParticipantName, sub.IncludeRecording = participantName, includeRecording
+			copy := *sub
+			return &copy, nil
+		}
+	}
+	sub := EmailSubscription{ID: s.id(), MeetingID: m.ID, ParticipantName: participantName, Email: email, CreatedAt: time.Now(), IncludeRecording: includeRecording}
+	s.subs = append(s.subs, sub)
+	return &sub, nil
+}
+
+func (s *fakeStore) GetEmailSubscriptionsByRoom(ctx context.Context, roomName string) ([]EmailSubscription, error) {
+	subs, _, err := s.GetEmailSubscriptionsPage(ctx, roomName, 0, 0)
+	return subs, err
+}
+
+func (s *fakeStore) GetEmailSubscriptionsPage(ctx context.Context, roomName string, limit, offset int) ([]EmailSubscription, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.meetings[roomName]
+	if !ok {
+		return nil, 0, ErrNotFound
+	}
+	var subs []EmailSubscription
+	for _, sub := range s.subs {
+		if sub.MeetingID == m.ID {
+			subs = append(subs, sub)
+		}
+	}
+	total := len(subs)
+	if limit > 0 {
+		subs = subs[min(offset, total):min(offset+limit, total)]
+	}
+	return subs, total, nil
+}
+
+func (s *fakeStore) DeleteEmailSubscription(ctx context.Context, roomName, email string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.meetings[roomName]
+	if !ok {
+		return 0, nil
+	}
+	var n int64
+	kept := s.subs[:0]
+	for _, sub := range s.subs {
+		if sub.MeetingID == m.ID && sub.Email == email {
+			n++
+			continue
+		}
+		kept = append(kept, sub)
+	}
+	s.subs = kept
+	return n, nil
+}
+
+func (s *fakeStore) CreateScheduledMeeting(ctx context.Context, roomName string, hostUserID int64, clientName, clientEmail string, scheduledAt, linkExpiresAt time.Time, slug, joinPinHash string) (*ScheduledMeeting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := ScheduledMeeting{
+		ID:            s.id(),
+		RoomName:      roomName,
+		HostUserID:    hostUserID,
+		ClientName:    clientName,
+		ClientEmail:   clientEmail,
+		ScheduledAt:   scheduledAt,
+		Status:        "scheduled",
+		CreatedAt:     time.Now(),
+		LinkExpiresAt: linkExpiresAt,
+		Slug:          slug,
+		JoinPinHash:   joinPinHash,
+	}
+	s.scheduled = append(s.scheduled, m)
+	return &m, nil
+}
+
+func (s *fakeStore) ScheduledMeetingSlugInUse(ctx context.Context, slug string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.scheduled {
+		if m.Slug == slug {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeStore) findScheduled(match func(*ScheduledMeeting) bool) (*ScheduledMeeting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.scheduled {
+		if match(&s.scheduled[i]) {
+			m := s.scheduled[i]
+			return &m, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *fakeStore) GetScheduledMeetingByRoom(ctx context.Context, roomName string) (*ScheduledMeeting, error) {
+	return s.findScheduled(func(m *ScheduledMeeting) bool { return m.RoomName == roomName })
+}
+
+func (s *fakeStore) GetScheduledMeetingBySlug(ctx context.Context, slug string) (*ScheduledMeeting, error) {
+	return s.findScheduled(func(m *ScheduledMeeting) bool { return m.Slug == slug })
+}
+
+func (s *fakeStore) GetScheduledMeetingByID(ctx context.Context, id int64) (*ScheduledMeeting, error) {
+	return s.findScheduled(func(m *ScheduledMeeting) bool { return m.ID == id })
+}
+
+func (s *fakeStore) ListScheduledMeetingsByHost(ctx context.Context, hostUserID int64) ([]ScheduledMeeting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var meetings []ScheduledMeeting
+	for _, m := range s.scheduled {
+		if m.HostUserID == hostUserID && (m.Status == "scheduled" || m.Status == "active") {
+			meetings = append(meetings, m)
+		}
+	}
+	sort.Slice(meetings, func(i, j int) bool { return meetings[i].ScheduledAt.Before(meetings[j].ScheduledAt) })
+	return meetings, nil
+}
+
+func (s *fakeStore) CancelScheduledMeeting(ctx context.Context, id, hostUserID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.scheduled {
+		if m := &s.scheduled[i]; m.ID == id && m.HostUserID == hostUserID {
+			if err := ValidateStatusTransition(m.Status, "cancelled"); err != nil {
+				return err
+			}
+			m.Status = "cancelled"
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *fakeStore) BackupDatabase(ctx context.Context) (*BackupResult, error) {
+	if s.backupErr != nil {
+		return nil, s.backupErr
+	}
+	return &BackupResult{Path: "backups/boom-test.db", SizeBytes: 1, SHA256: "00", CreatedAt: time.Now(), Rotated: []string{}}, nil
+}
+
+func (s *fakeStore) GetUserNamespace(ctx context.Context, userID int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.namespaces[userID], nil
+}
+
+// fakeRooms is an in-memory LiveKit room service. delay, if set, is how long
+// each call takes; failCreate, if set, is returned by every CreateRoom call.
+type fakeRooms struct {
+	mu         sync.Mutex
+	rooms      map[string]*livekit.Room
+	sent       []*livekit.SendDataRequest
+	delay      time.Duration
+	failCreate error
+}
+
+func newFakeRooms() *fakeRooms {
+	return &fakeRooms{rooms: map[string]*livekit.Room{}}
+}
+
+func (f *fakeRooms) wait(ctx context.Context) error {
+	if f.delay == 0 {
+		return nil
+	}
+	select {
+	case <-time.After(f.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CreateRoom returns the existing room when the name is taken, as LiveKit does
+func (f *fakeRooms) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failCreate != nil {
+		return nil, f.failCreate
+	}
+	room, ok := f.rooms[req.Name]
+	if !ok {
+		room = &livekit.Room{Name: req.Name, Sid: fmt.Sprintf("RM_%d", len(f.rooms)+1)}
+		f.rooms[req.Name] = room
+	}
+	return room, nil
+}
+
+func (f *fakeRooms) ListRooms(ctx context.Context, req *livekit.ListRoomsRequest) (*livekit.ListRoomsResponse, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &livekit.ListRoomsResponse{}
+	for name, room := range f.rooms {
+		if len(req.Names) == 0 || containsString(req.Names, name) {
+			resp.Rooms = append(resp.Rooms, room)
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeRooms) ListParticipants(ctx context.Context, req *livekit.ListParticipantsRequest) (*livekit.ListParticipantsResponse, error) {
+	return &livekit.ListParticipantsResponse{}, nil
+}
+
+func (f *fakeRooms) DeleteRoom(ctx context.Context, req *livekit.DeleteRoomRequest) (*livekit.DeleteRoomResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.rooms, req.Room)
+	return &livekit.DeleteRoomResponse{}, nil
+}
+
+func (f *fakeRooms) SendData(ctx context.Context, req *livekit.SendDataRequest) (*livekit.SendDataResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, req)
+	return &livekit.SendDataResponse{}, nil
+}
+
+// fakeEgress is an in-memory LiveKit egress service
+type fakeEgress struct {
+	mu      sync.Mutex
+	egress  map[string]*livekit.EgressInfo
+	stopped []string
+}
+
+func newFakeEgress() *fakeEgress {
+	return &fakeEgress{egress: map[string]*livekit.EgressInfo{}}
+}
+
+func (f *fakeEgress) StartRoomCompositeEgress(ctx context.Context, req *livekit.RoomCompositeEgressRequest) (*livekit.EgressInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info := &livekit.EgressInfo{
+		EgressId: fmt.Sprintf("EG_fake%d", len(f.egress)+1),
+		RoomName: req.RoomName,
+		Status:   livekit.EgressStatus_EGRESS_ACTIVE,
+	}
+	f.egress[info.EgressId] = info
+	return info, nil
+}
+
+func (f *fakeEgress) StopEgress(ctx context.Context, req *livekit.StopEgressRequest) (*livekit.EgressInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, ok := f.egress[req.EgressId]
+	if !ok {
+		return nil, fmt.Errorf("egress %s not found", req.EgressId)
+	}
+	info.Status = livekit.EgressStatus_EGRESS_COMPLETE
+	f.stopped = append(f.stopped, req.EgressId)
+	return info, nil
+}
+
+func (f *fakeEgress) ListEgress(ctx context.Context, req *livekit.ListEgressRequest) (*livekit.ListEgressResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &livekit.ListEgressResponse{}
+	for _, info := range f.egress {
+		if req.RoomName == "" || req.RoomName == info.RoomName {
+			resp.Items = append(resp.Items, info)
+		}
+	}
+	return resp, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
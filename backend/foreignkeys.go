@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// foreignKeyRules lists every foreign key and what deleting the parent row
+// does. Meeting data goes with its meeting; a host with scheduled meetings
+// cannot be deleted until they are reassigned.
+var foreignKeyRules = []struct {
+	table, column, parent, onDelete string
+}{
+	{"meeting_notes", "meeting_id", "meetings", "CASCADE"},
+	{"recordings", "meeting_id", "meetings", "CASCADE"},
+	{"email_subscriptions", "meeting_id", "meetings", "CASCADE"},
+	{"email_delivery_log", "meeting_id", "meetings", "CASCADE"},
+	{"transcript_segments", "meeting_id", "meetings", "CASCADE"},
+	{"recording_consents", "meeting_id", "meetings", "CASCADE"},
+	{"participants", "meeting_id", "meetings", "CASCADE"},
+	{"meeting_events", "meeting_id", "meetings", "CASCADE"},
+	{"meeting_note_drafts", "meeting_id", "meetings", "CASCADE"},
+	{"recording_pauses", "meeting_id", "meetings", "CASCADE"},
+	{"scheduled_meetings", "host_user_id", "users", "RESTRICT"},
+}
+
+// migrateForeignKeys moves rows whose parent no longer exists into
+// orphaned_rows, then brings each foreign key's ON DELETE rule in line with
+// foreignKeyRules. Tables created by schema.sql already match; older
+// databases are upgraded in place.
+func migrateForeignKeys(ctx context.Context) error {
+	for _, r := range foreignKeyRules {
+		if err := quarantineOrphans(ctx, r.table, r.column, r.parent); err != nil {
+			return fmt.Errorf("quarantine %s orphans: %w", r.table, err)
+		}
+	}
+	if db.dialect == dialectPostgres {
+		return migratePostgresForeignKeys(ctx)
+	}
+	return migrateSQLiteForeignKeys(ctx)
+}
+
+// quarantineOrphans copies rows of table that point at a missing parent into
+// orphaned_rows as JSON and deletes them, so the constraint can be enforced
+func quarantineOrphans(ctx context.Context, table, column, parent string) error {
+	rowJSON := "row_to_json(t)::text"
+	if db.dialect == dialectSQLite {
+		columns, err := tableColumns(ctx, table)
+		if err != nil {
+			return err
+		}
+		pairs := make([]string, len(columns))
+		for i, c := range columns {
+			pairs[i] = fmt.Sprintf("'%s', t.%s", c, c)
+		}
+		rowJSON = "json_object(" + strings.Join(pairs, ", ") + ")"
+	}
+	orphaned := fmt.Sprintf("t.%s IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %s p WHERE p.id = t.%s)", column, parent, column)
+
+	var n int64
+	err := withTx(ctx, func(tx *storeTx) error {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+			"INSERT INTO orphaned_rows (table_name, row_id, row_data) SELECT ?, t.id, %s FROM %s t WHERE %s",
+			rowJSON, table, orphaned,
+		), table); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(
+			"DELETE FROM %s WHERE id IN (SELECT t.id FROM %s t WHERE %s)", table, table, orphaned,
+		))
+		if err != nil {
+			return err
+		}
+		n, err = result.RowsAffected()
+		return err
+	})
+	if err == nil && n > 0 {
+		slog.Info("Moved orphaned rows to orphaned_rows", "table", table, "count", n)
+	}
+	return err
+}
+
+func tableColumns(ctx context.Context, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// migratePostgresForeignKeys replaces any constraint whose delete rule differs
+func migratePostgresForeignKeys(ctx context.Context) error {
+	for _, r := range foreignKeyRules {
+		var name, rule string
+		err := db.QueryRowContext(ctx, `
+			SELECT tc.constraint_name, rc.delete_rule
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.key_column_usage kcu
+			  ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema
+			JOIN information_schema.referential_constraints rc
+			  ON rc.constraint_name = tc.constraint_name AND rc.constraint_schema = tc.table_schema
+			WHERE tc.table_schema = current_schema() AND tc.table_name = ?
+			  AND tc.constraint_type = 'FOREIGN KEY' AND kcu.column_name = ?`,
+			r.table, r.column,
+		).Scan(&name, &rule)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if rule == r.onDelete {
+			continue
+		}
+
+		err = withTx(ctx, func(tx *storeTx) error {
+			if name != "" {
+				if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", r.table, name)); err != nil {
+					return err
+				}
+			} else {
+				name = r.table + "_" + r.column + "_fkey"
+			}
+			_, err := tx.ExecContext(ctx, fmt.Sprintf(
+				"ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(id) ON DELETE %s",
+				r.table, name, r.column, r.parent, r.onDelete,
+			))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", r.table, r.column, err)
+		}
+		slog.Info("Set foreign key delete rule", "table", r.table, "column", r.column, "on_delete", r.onDelete)
+	}
+	return nil
+}
+
+var createTablePattern = regexp.MustCompile(`(?i)^\s*CREATE TABLE\s+(IF NOT EXISTS\s+)?"?\w+"?`)
+
+// foreignKeyClausePattern matches a table-level foreign key on one column,
+// along with any ON DELETE rule it already has
+func foreignKeyClausePattern(column string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(FOREIGN KEY\s*\(\s*` + column + `\s*\)\s*REFERENCES\s+\w+\s*\(\s*\w+\s*\))(\s+ON DELETE\s+(CASCADE|RESTRICT|SET NULL|SET DEFAULT|NO ACTION))?`)
+}
+
+// migrateSQLiteForeignKeys rebuilds tables whose foreign keys lack the right
+// delete rule. SQLite cannot alter a constraint, so each table is copied into
+// a new one created from its stored definition with the rule added. This runs
+// on a single connection with enforcement off, as the SQLite docs require,
+// and is rolled back if the result fails foreign_key_check.
+func migrateSQLiteForeignKeys(ctx context.Context) error {
+	var tables, changed []string
+	for _, r := range foreignKeyRules {
+		var rule string
+		err := db.QueryRowContext(ctx,
+			`SELECT on_delete FROM pragma_foreign_key_list(?) WHERE "from" = ?`, r.table, r.column,
+		).Scan(&rule)
+		if err == sql.ErrNoRows {
+			slog.Warn("Table has no foreign key on column; leaving it unchanged", "table", r.table, "column", r.column)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(rule, r.onDelete) {
+			tables = append(tables, r.table)
+			changed = append(changed, fmt.Sprintf("%s.%s to ON DELETE %s", r.table, r.column, r.onDelete))
+		}
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range tables {
+		var createSQL string
+		if err := tx.QueryRowContext(ctx, "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&createSQL); err != nil {
+			return fmt.Errorf("read %s definition: %w", table, err)
+		}
+		indexes, err := tableIndexSQL(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range foreignKeyRules {
+			if r.table != table {
+				continue
+			}
+			createSQL = foreignKeyClausePattern(r.column).ReplaceAllString(createSQL, "${1} ON DELETE "+r.onDelete)
+		}
+		rebuilt := table + "_fk_rebuild"
+		createSQL = createTablePattern.ReplaceAllString(createSQL, "CREATE TABLE "+rebuilt)
+
+		for _, stmt := range []string{
+			createSQL,
+			fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", rebuilt, table),
+			"DROP TABLE " + table,
+			fmt.Sprintf("ALTER TABLE %s RENAME TO %s", rebuilt, table),
+		} {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("rebuild %s: %w", table, err)
+			}
+		}
+		for _, stmt := range indexes {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("recreate %s index: %w", table, err)
+			}
+		}
+	}
+
+	var violations int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM pragma_foreign_key_check").Scan(&violations); err != nil {
+		return err
+	}
+	if violations > 0 {
+		return fmt.Errorf("%d rows violate foreign keys after rebuild", violations)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, c := range changed {
+		slog.Info("Set foreign key delete rule", "rule", c)
+	}
+	return nil
+}
+
+// tableIndexSQL returns the CREATE INDEX statements of a table's explicit
+// indexes, which are dropped along with it
+func tableIndexSQL(ctx context.Context, tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stmts []string
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts, rows.Err()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeletingMeetingCascades(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx := context.Background()
+
+	if _, err := SaveNotes(ctx, "doomed", "# Notes", "test-model", 1, 1); err != nil {
+		t.Fatalf("SaveNotes: %v", err)
+	}
+	if _, err := SaveNotes(ctx, "kept", "# Notes", "test-model", 1, 1); err != nil {
+		t.Fatalf("SaveNotes: %v", err)
+	}
+	sub, err := CreateEmailSubscription(ctx, "doomed", "Ada", "ada@example.com", false)
+	if err != nil {
+		t.Fatalf("CreateEmailSubscription: %v", err)
+	}
+	if err := LogEmailDelivery(ctx, sub.MeetingID, "ada@example.com", "sent", "", ""); err != nil {
+		t.Fatalf("LogEmailDelivery: %v", err)
+	}
+	if _, err := SaveTranscriptSegment(ctx, "doomed", "Ada", "hello", "1"); err != nil {
+		t.Fatalf("SaveTranscriptSegment: %v", err)
+	}
+	if _, err := CreateRecording(ctx, sub.MeetingID, "EG_1"); err != nil {
+		t.Fatalf("CreateRecording: %v", err)
+	}
+	if _, err := RecordParticipantJoined(ctx, "doomed", "ada", "Ada", "", time.Now()); err != nil {
+		t.Fatalf("RecordParticipantJoined: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, "DELETE FROM meetings WHERE id = ?", sub.MeetingID); err != nil {
+		t.Fatalf("delete meeting: %v", err)
+	}
+	for _, r := range foreignKeyRules {
+		if r.parent != "meetings" {
+			continue
+		}
+		if n := countRows(t, "SELECT COUNT(*) FROM "+r.table+" WHERE meeting_id = ?", sub.MeetingID); n != 0 {
+			t.Errorf("%s: %d rows left after their meeting was deleted", r.table, n)
+		}
+	}
+	if n := countRows(t, "SELECT COUNT(*) FROM meeting_notes"); n != 1 {
+		t.Errorf("notes of other meetings: got %d rows, want 1", n)
+	}
+}
+
+func TestDeletingHostWithScheduledMeetingsIsRestricted(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx := context.Background()
+	host := testUser(t, testUserEmail)
+
+	at := time.Now().Add(time.Hour)
+	if _, err := CreateScheduledMeeting(ctx, "client-call", host.ID, "Client", "client@example.com", at, at.Add(time.Hour), "", ""); err != nil {
+		t.Fatalf("CreateScheduledMeeting: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", host.ID); err == nil {
+		t.Error("deleting a host with scheduled meetings succeeded")
+	}
+	if n := countRows(t, "SELECT COUNT(*) FROM scheduled_meetings WHERE host_user_id = ?", host.ID); n != 1 {
+		t.Errorf("scheduled meetings after the refused delete: got %d, want 1", n)
+	}
+}
+
+func TestInsertingOrphanIsRefused(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+
+	if _, err := db.ExecContext(context.Background(),
+		"INSERT INTO meeting_notes (meeting_id, notes_markdown, model_used) VALUES (?, ?, ?)", 9999, "# Orphan", "test-model",
+	); err == nil {
+		t.Error("notes for a missing meeting were stored")
+	}
+}
+
+func TestMigrationQuarantinesOrphans(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx := context.Background()
+
+	// Orphans predate enforcement, so write one with it switched off
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"PRAGMA foreign_keys = OFF",
+		"INSERT INTO meeting_notes (meeting_id, notes_markdown, model_used) VALUES (9999, '# Orphan', 'test-model')",
+		"PRAGMA foreign_keys = ON",
+	} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	conn.Close()
+
+	if err := migrateForeignKeys(ctx); err != nil {
+		t.Fatalf("migrateForeignKeys: %v", err)
+	}
+	if n := countRows(t, "SELECT COUNT(*) FROM meeting_notes WHERE meeting_id = 9999"); n != 0 {
+		t.Errorf("orphaned notes left in place: %d", n)
+	}
+	var data string
+	if err := db.QueryRowContext(ctx, "SELECT row_data FROM orphaned_rows WHERE table_name = 'meeting_notes'").Scan(&data); err != nil {
+		t.Fatalf("read quarantined row: %v", err)
+	}
+	if !strings.Contains(data, "# Orphan") {
+		t.Errorf("quarantined row_data: got %s", data)
+	}
+}
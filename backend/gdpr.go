@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Data subject requests: everything stored against an email address can be
+// exported, and erased in one transaction. Participants carry no address of
+// their own, so a join counts as the address's when the participant used it
+// as their name or metadata, or joined under the name they subscribed with.
+// Erasure keeps the user row, deactivated and marked deleted, because
+// scheduled meetings still reference their host.
+
+// anonymizedSpeaker replaces a transcript speaker that named an erased address
+const anonymizedSpeaker = "Anonymized participant"
+
+// likeContaining is a LIKE pattern matching any value containing s
+func likeContaining(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(s))
+	return "%" + s + "%"
+}
+
+// ExportedSubscription is an email_subscriptions row
+type ExportedSubscription struct {
+	ID               int64     `json:"id"`
+	RoomName         string    `json:"roomName"`
+	ParticipantName  string    `json:"participantName"`
+	Email            string    `json:"email"`
+	IncludeRecording bool      `json:"includeRecording"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// ExportedParticipant is a participants row
+type ExportedParticipant struct {
+	RoomName string `json:"roomName"`
+	Participant
+}
+
+// ExportedDelivery is an email_delivery_log row
+type ExportedDelivery struct {
+	RoomName string `json:"roomName"`
+	EmailDelivery
+}
+
+// DataExport is everything stored against an email address
+type DataExport struct {
+	Email           string                 `json:"email"`
+	ExportedAt      time.Time              `json:"exportedAt"`
+	Users           []User                 `json:"users"`
+	Subscriptions   []ExportedSubscription `json:"subscriptions"`
+	Participants    []ExportedParticipant  `json:"participants"`
+	EmailDeliveries []ExportedDelivery     `json:"emailDeliveries"`
+}
+
+// ExportEmailData collects the rows associated with email
+func ExportEmailData(ctx context.Context, email string) (*DataExport, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	export := &DataExport{
+		Email:           email,
+		ExportedAt:      time.Now().UTC(),
+		Users:           []User{},
+		Subscriptions:   []ExportedSubscription{},
+		Participants:    []ExportedParticipant{},
+		EmailDeliveries: []ExportedDelivery{},
+	}
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, email, name, active, namespace, created_at FROM users WHERE LOWER(email) = LOWER(?) ORDER BY id",
+		email,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("users: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u User
+		var namespace sql.NullString
+		if err := rows.Scan(&u.ID, &u.Email, &u.Name, &u.Active, &namespace, &u.CreatedAt); err != nil {
+			return nil, fmt.Errorf("users: %w", err)
+		}
+		u.Namespace = namespace.String
+		export.Users = append(export.Users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("users: %w", err)
+	}
+
+	rows, err = db.QueryContext(ctx,
+		`SELECT es.id, m.room_name, es.participant_name, es.email, es.include_recording, es.created_at
+		 FROM email_subscriptions es JOIN meetings m ON es.meeting_id = m.id
+		 WHERE LOWER(es.email) = LOWER(?) ORDER BY es.created_at, es.id`,
+		email,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("subscriptions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s ExportedSubscription
+		if err := rows.Scan(&s.ID, &s.RoomName, &s.ParticipantName, &s.Email, &s.IncludeRecording, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("subscriptions: %w", err)
+		}
+		export.Subscriptions = append(export.Subscriptions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("subscriptions: %w", err)
+	}
+
+	rows, err = db.QueryContext(ctx,
+		`SELECT m.room_name, p.id, p.identity, p.name, p.metadata, p.joined_at, p.left_at
+		 FROM participants p JOIN meetings m ON p.meeting_id = m.id
+		 WHERE LOWER(p.name) = LOWER(?) OR LOWER(p.metadata) LIKE ? ESCAPE '\'
+		    OR EXISTS (SELECT 1 FROM email_subscriptions es
+		               WHERE es.meeting_id = p.meeting_id AND LOWER(es.email) = LOWER(?) AND es.participant_name = p.name)
+		 ORDER BY p.joined_at, p.id`,
+		email, likeContaining(email), email,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("participants: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p ExportedParticipant
+		var name, metadata sql.NullString
+		var leftAt sql.NullTime
+		if err := rows.Scan(&p.RoomName, &p.ID, &p.Identity, &name, &metadata, &p.JoinedAt, &leftAt); err != nil {
+			return nil, fmt.Errorf("participants: %w", err)
+		}
+		p.Name, p.Metadata = name.String, metadata.String
+		end := time.Now()
+		if leftAt.Valid {
+			p.LeftAt = &leftAt.Time
+			end = leftAt.Time
+		}
+		p.DurationMS = end.Sub(p.JoinedAt).Milliseconds()
+		export.Participants = append(export.Participants, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("participants: %w", err)
+	}
+
+	rows, err = db.QueryContext(ctx,
+		`SELECT m.room_name, dl.id, dl.meeting_id, dl.email, dl.status, dl.bounce_type, dl.reason, dl.attempt, dl.provider_message_id, dl.created_at, dl.updated_at
+		 FROM email_delivery_log dl JOIN meetings m ON dl.meeting_id = m.id
+		 WHERE LOWER(dl.email) = LOWER(?) ORDER BY dl.created_at, dl.id`,
+		email,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("email deliveries: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d ExportedDelivery
+		var bounceType, reason, messageID sql.NullString
+		if err := rows.Scan(&d.RoomName, &d.ID, &d.MeetingID, &d.Email, &d.Status, &bounceType, &reason,
+			&d.Attempt, &messageID, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("email deliveries: %w", err)
+		}
+		d.BounceType, d.Reason, d.ProviderMessageID = bounceType.String, reason.String, messageID.String
+		export.EmailDeliveries = append(export.EmailDeliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("email deliveries: %w", err)
+	}
+	return export, nil
+}
+
+// DataErasure counts what erasing an address changed
+type DataErasure struct {
+	Email                  string `json:"email"`
+	UsersDeleted           int64  `json:"usersDeleted"` // deactivated and marked deleted
+	SubscriptionsDeleted   int64  `json:"subscriptionsDeleted"`
+	TranscriptSegments     int64  `json:"transcriptSegmentsAnonymized"`
+	SpeakerNames           int64  `json:"speakerNamesAnonymized"`
+	EmailDeliveriesDeleted int64  `json:"emailDeliveriesDeleted"`
+}
+
+// EraseEmailData removes or anonymizes the rows associated with email. Every
+// change is made in one transaction, so a failure leaves nothing half erased.
+func EraseEmailData(ctx context.Context, email string) (*DataErasure, error) {
+	erasure := &DataErasure{Email: email}
+	pattern := likeContaining(email)
+	err := withTx(ctx, func(tx *storeTx) error {
+		steps := []struct {
+			name  string
+			count *int64
+			query string
+			args  []any
+		}{
+			{"users", &erasure.UsersDeleted,
+				"UPDATE users SET active = ?, deleted_at = ? WHERE LOWER(email) = LOWER(?) AND deleted_at IS NULL",
+				[]any{false, time.Now().UTC(), email}},
+			{"subscriptions", &erasure.SubscriptionsDeleted,
+				"DELETE FROM email_subscriptions WHERE LOWER(email) = LOWER(?)",
+				[]any{email}},
+			{"transcript speakers", &erasure.TranscriptSegments,
+				`UPDATE transcript_segments SET speaker = ? WHERE LOWER(speaker) LIKE ? ESCAPE '\'`,
+				[]any{anonymizedSpeaker, pattern}},
+			// Names assigned to speaker labels are shown in place of the
+			// transcript's speaker, so they go too
+			{"speaker names", &erasure.SpeakerNames,
+				`UPDATE speaker_names SET display_name = ? WHERE LOWER(display_name) LIKE ? ESCAPE '\'`,
+				[]any{anonymizedSpeaker, pattern}},
+			{"email deliveries", &erasure.EmailDeliveriesDeleted,
+				"DELETE FROM email_delivery_log WHERE LOWER(email) = LOWER(?)",
+				[]any{email}},
+		}
+		for _, step := range steps {
+			result, err := tx.ExecContext(ctx, step.query, step.args...)
+			if err != nil {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+			if *step.count, err = result.RowsAffected(); err != nil {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return erasure, nil
+}
+
+func (s *server) dataExportHandler(c *fiber.Ctx) error {
+	email, err := emailParam(c)
+	if err != nil {
+		return err
+	}
+
+	export, err := s.store.ExportEmailData(c.UserContext(), email)
+	if err != nil {
+		ctxLogger(c.UserContext()).Error("Failed to export data", "error", err)
+		return respondError(c, 500, err.Error())
+	}
+	recordAudit(c, "data.export", email, "")
+
+	c.Set("Content-Disposition", `attachment; filename="data-export.json"`)
+	return c.JSON(export)
+}
+
+func (s *server) dataErasureHandler(c *fiber.Ctx) error {
+	email, err := emailParam(c)
+	if err != nil {
+		return err
+	}
+	if self, _ := c.Locals("userEmail").(string); strings.EqualFold(email, self) {
+		return respondError(c, 400, "You cannot erase your own account")
+	}
+
+	erasure, err := s.store.EraseEmailData(c.UserContext(), email)
+	if err != nil {
+		ctxLogger(c.UserContext()).Error("Failed to erase data", "error", err)
+		return respondError(c, 500, err.Error())
+	}
+	recordAudit(c, "data.erase", email, fmt.Sprintf("%d users, %d subscriptions, %d transcript segments, %d speaker names, %d deliveries",
+		erasure.UsersDeleted, erasure.SubscriptionsDeleted, erasure.TranscriptSegments, erasure.SpeakerNames, erasure.EmailDeliveriesDeleted))
+
+	return c.JSON(erasure)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/livekit/protocol/livekit"
+)
+
+// defaultLiveKitLatencyWarnMS is the LiveKit round-trip time above which the
+// ready check reports the connection as degraded
+const defaultLiveKitLatencyWarnMS = 500
+
+const readyCheckTimeout = 5 * time.Second
+
+// DependencyCheck is the ready check's verdict on one dependency
+type DependencyCheck struct {
+	OK        bool       `json:"ok"`
+	Error     string     `json:"error,omitempty"`
+	LatencyMS *int64     `json:"latencyMs,omitempty"`
+	Degraded  bool       `json:"degraded,omitempty"`
+	WAL       *WALStatus `json:"wal,omitempty"`
+}
+
+// ReadyResponse is the body of /api/ready
+type ReadyResponse struct {
+	Ready    bool            `json:"ready"`
+	Database DependencyCheck `json:"database"`
+	LiveKit  DependencyCheck `json:"livekit"`
+}
+
+// checkLiveKit times a ListRooms call against the LiveKit server
+func (s *server) checkLiveKit(ctx context.Context) DependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, readyCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := s.rooms.ListRooms(ctx, &livekit.ListRoomsRequest{})
+	latencyMS := time.Since(start).Milliseconds()
+	if err != nil {
+		return DependencyCheck{OK: false, Error: err.Error()}
+	}
+
+	liveKitRTT.Set(float64(latencyMS))
+	return DependencyCheck{
+		OK:        true,
+		LatencyMS: &latencyMS,
+		Degraded:  latencyMS > int64(config.LiveKitLatencyWarnMS),
+	}
+}
+
+// readyHandler reports whether the database and LiveKit are reachable. A slow
+// but working LiveKit is reported as degraded without failing the check.
+func (s *server) readyHandler(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), readyCheckTimeout)
+	defer cancel()
+
+	database := DependencyCheck{OK: true}
+	if err := db.PingContext(ctx); err != nil {
+		database = DependencyCheck{OK: false, Error: err.Error()}
+	} else {
+		database.WAL = walStatus(ctx)
+	}
+	lk := s.checkLiveKit(ctx)
+
+	status := 200
+	if !database.OK || !lk.OK {
+		status = 503
+	}
+	return c.Status(status).JSON(ReadyResponse{
+		Ready:    status == 200,
+		Database: database,
+		LiveKit:  lk,
+	})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestReadyReportsSlowLiveKitAsDegraded(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	config.LiveKitLatencyWarnMS = 20
+	srv := newTestServer(t, newFakeStore())
+	rooms := srv.rooms.(*fakeRooms)
+	app := newTestApp(t, srv)
+
+	for _, tc := range []struct {
+		delay    time.Duration
+		degraded bool
+	}{
+		{0, false},
+		{60 * time.Millisecond, true},
+	} {
+		rooms.delay = tc.delay
+		resp := doRequest(t, app, http.MethodGet, "/healthz/ready", nil)
+		if resp.Status != http.StatusOK {
+			t.Fatalf("delay %v: got %d, want 200 even when degraded", tc.delay, resp.Status)
+		}
+		var ready ReadyResponse
+		resp.decode(t, &ready)
+		lk := ready.LiveKit
+		if !lk.OK || lk.LatencyMS == nil || lk.Degraded != tc.degraded {
+			t.Fatalf("delay %v: got %+v, want ok with degraded=%v", tc.delay, lk, tc.degraded)
+		}
+		if tc.delay > 0 && *lk.LatencyMS < tc.delay.Milliseconds() {
+			t.Errorf("delay %v: latency %dms is below the delay", tc.delay, *lk.LatencyMS)
+		}
+		if got := testutil.ToFloat64(liveKitRTT); got != float64(*lk.LatencyMS) {
+			t.Errorf("delay %v: boom_livekit_rtt_ms is %v, want %d", tc.delay, got, *lk.LatencyMS)
+		}
+	}
+}
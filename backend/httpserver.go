@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HTTP server limits. Timeouts apply to ordinary requests only; a WebSocket
+// connection is handed off before they would cut it, and stays open until
+// either side closes it or the server shuts down.
+const (
+	defaultListenAddr      = ":8080"
+	defaultReadTimeout     = 30 * time.Second
+	defaultWriteTimeout    = 30 * time.Second
+	defaultIdleTimeout     = 2 * time.Minute
+	defaultBodyLimit       = 4 << 20
+	defaultHeaderLimit     = 8 << 10
+	defaultShutdownTimeout = 30 * time.Second
+)
+
+// fiberConfig returns the Fiber settings for the HTTP server. Behind a
+// reverse proxy, PROXY_HEADER names the header carrying the client's address
+// (Fly-Client-IP on Fly, X-Forwarded-For elsewhere); it is only believed on
+// connections from TRUSTED_PROXIES, so c.IP() and the rate limits it keys
+// see the real client rather than the proxy.
+func fiberConfig(c *Config) fiber.Config {
+	return fiber.Config{
+		ReadTimeout:             c.HTTPReadTimeout,
+		WriteTimeout:            c.HTTPWriteTimeout,
+		IdleTimeout:             c.HTTPIdleTimeout,
+		BodyLimit:               c.HTTPBodyLimit,
+		ReadBufferSize:          c.HTTPHeaderLimit,
+		ErrorHandler:            errorHandler,
+		ProxyHeader:             c.ProxyHeader,
+		EnableTrustedProxyCheck: c.ProxyHeader != "",
+		TrustedProxies:          c.TrustedProxies,
+		EnableIPValidation:      true,
+	}
+}
+
+// parseTrustedProxies reads a comma-separated TRUSTED_PROXIES list of
+// addresses and CIDR ranges
+func parseTrustedProxies(v string) ([]string, error) {
+	var proxies []string
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+		}
+		proxies = append(proxies, entry)
+	}
+	return proxies, nil
+}
+
+// errorHandler answers errors no handler turned into a response, including
+// request errors handlers return and ones Fiber raises before routing such
+// as an oversized body, in the same envelope the handlers use
+func errorHandler(c *fiber.Ctx, err error) error {
+	if re, ok := asRequestError(err); ok {
+		return re.respond(c)
+	}
+	status := errorStatus(err)
+	message := "Internal server error"
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		message = fe.Message
+	}
+	switch status {
+	case fiber.StatusRequestEntityTooLarge:
+		message = "Request body too large"
+	case fiber.StatusRequestHeaderFieldsTooLarge:
+		message = "Request headers too large"
+	case fiber.StatusInternalServerError:
+		ctxLogger(c.UserContext()).Error("Unhandled error", "method", c.Method(), "path", c.Path(), "error", err)
+	}
+	return respondError(c, status, message)
+}
+
+// errorStatus is the status errorHandler will answer err with
+func errorStatus(err error) int {
+	if re, ok := asRequestError(err); ok {
+		return re.status
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code
+	}
+	return fiber.StatusInternalServerError
+}
+
+// listen serves app on LISTEN_ADDR, over TLS when a certificate is configured
+func listen(app *fiber.App, c *Config) error {
+	if c.TLSCertFile != "" {
+		slog.Info("Backend starting", "addr", c.ListenAddr, "tls", true)
+		return app.ListenTLS(c.ListenAddr, c.TLSCertFile, c.TLSKeyFile)
+	}
+	slog.Info("Backend starting", "addr", c.ListenAddr, "tls", false)
+	return app.Listen(c.ListenAddr)
+}
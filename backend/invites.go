@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Invite links for scheduled meetings stop working at link_expires_at, so a
+// link that leaks is only usable for a bounded window. Past that, the join
+// page and token issuance answer 410 until the host extends the link through
+// PATCH /api/scheduled-meetings/:id.
+const defaultInviteLinkTTL = 24 * time.Hour
+
+// defaultLinkExpiry is when the invite link of a meeting scheduled for
+// scheduledAt expires if the host does not choose
+func defaultLinkExpiry(scheduledAt time.Time) time.Time {
+	return scheduledAt.Add(config.InviteLinkTTL)
+}
+
+// inviteExpiredError is the response for a join through an expired link
+func inviteExpiredError(meeting *ScheduledMeeting) *requestError {
+	return &requestError{410, APIError{Message: "Invite link has expired", Details: fiber.Map{"linkExpiresAt": meeting.LinkExpiresAt}}}
+}
+
+// checkInviteLink refuses joins to a scheduled meeting whose invite link has
+// expired. Rooms that were not scheduled have no link to expire.
+func (s *server) checkInviteLink(ctx context.Context, roomName string) *requestError {
+	meeting, err := s.store.GetScheduledMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return &requestError{500, APIError{Message: err.Error()}}
+	}
+	if !time.Now().Before(meeting.LinkExpiresAt) {
+		return inviteExpiredError(meeting)
+	}
+	return nil
+}
+
+type RescheduleMeetingRequest struct {
+	ScheduledAt   string `json:"scheduledAt"`   // ISO 8601, optional
+	LinkExpiresAt string `json:"linkExpiresAt"` // ISO 8601, optional
+
+	// Parsed by validate; zero when not given
+	scheduledAt, linkExpiresAt time.Time
+}
+
+func (r *RescheduleMeetingRequest) validate(errs *fieldErrors) {
+	if r.ScheduledAt == "" && r.LinkExpiresAt == "" {
+		errs.add("scheduledAt", "scheduledAt or linkExpiresAt is required")
+	}
+	r.scheduledAt = errs.timestamp("scheduledAt", r.ScheduledAt)
+	r.linkExpiresAt = errs.timestamp("linkExpiresAt", r.LinkExpiresAt)
+}
+
+// rescheduleMeetingHandler moves a scheduled meeting and/or changes when its
+// invite link expires. Moving the meeting without naming an expiry keeps the
+// current one unless the default window after the new time is later.
+func (s *server) rescheduleMeetingHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	var id int64
+	if _, err := fmt.Sscanf(c.Params("id"), "%d", &id); err != nil {
+		return respondError(c, 400, "Invalid ID")
+	}
+
+	hostUserID := c.Locals("userID").(int64)
+
+	var req RescheduleMeetingRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	meeting, err := s.store.GetScheduledMeetingByID(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Scheduled meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if meeting.HostUserID != hostUserID {
+		return respondError(c, 403, "Not your meeting")
+	}
+	if meeting.Status == "cancelled" {
+		return respondError(c, 409, "Cannot reschedule a cancelled meeting")
+	}
+
+	scheduledAt := meeting.ScheduledAt
+	if !req.scheduledAt.IsZero() {
+		scheduledAt = req.scheduledAt
+	}
+	linkExpiresAt := meeting.LinkExpiresAt
+	if !req.linkExpiresAt.IsZero() {
+		linkExpiresAt = req.linkExpiresAt
+	} else if def := defaultLinkExpiry(scheduledAt); def.After(linkExpiresAt) {
+		linkExpiresAt = def
+	}
+	if !linkExpiresAt.After(scheduledAt) {
+		return invalidField("linkExpiresAt", "linkExpiresAt must be after scheduledAt")
+	}
+
+	if err := s.store.RescheduleMeeting(ctx, id, hostUserID, scheduledAt, linkExpiresAt); errors.Is(err, ErrNotFound) {
+		return respondError(c, 409, "meeting not found, not owned by user, or cancelled")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	recordAudit(c, "meeting.reschedule", meeting.RoomName, fmt.Sprintf("scheduled %s, link expires %s",
+		scheduledAt.Format(time.RFC3339), linkExpiresAt.Format(time.RFC3339)))
+
+	resp := scheduledMeetingResponse(meeting)
+	resp.ScheduledAt, resp.LinkExpiresAt = scheduledAt, linkExpiresAt
+	return c.JSON(resp)
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Background work started by requests (summary emails, batch transcription,
+// outbound webhooks) runs as jobs on a fixed pool of workers, so a burst of
+// requests cannot spawn unbounded goroutines against the AI service and n8n,
+// and shutdown can wait for work in flight instead of losing it.
+const (
+	defaultJobQueueWorkers = 8
+	defaultJobQueueSize    = 100
+
+	// jobSubmitTimeout is how long a submitter waits for queue space before
+	// the job is dropped
+	jobSubmitTimeout = 5 * time.Second
+
+	// jobRetryDelay is the wait before a failed job's first retry; it
+	// doubles with each further attempt
+	jobRetryDelay = 2 * time.Second
+
+	// notificationRetries is how many times a notification email job is
+	// retried after n8n fails to accept it
+	notificationRetries = 2
+)
+
+// maxTaskFailures is how many failed, panicked, or dropped jobs the queue
+// remembers for the admin overview
+const maxTaskFailures = 20
+
+var (
+	ErrJobQueueClosed = errors.New("job queue is shutting down")
+	ErrJobQueueFull   = errors.New("job queue is full")
+)
+
+// Job is a unit of background work
+type Job struct {
+	Name    string
+	Execute func(ctx context.Context) error
+	// Retries is how many more times Execute runs after returning an error
+	Retries int
+	// Timeout bounds each attempt; backgroundTaskTimeout if zero
+	Timeout time.Duration
+	// Origin, if set, lends its request ID and logger to the context Execute
+	// gets, but not its cancellation
+	Origin context.Context
+}
+
+// TaskFailure is a job that failed, panicked, or was dropped
+type TaskFailure struct {
+	Task   string    `json:"task"`
+	Result string    `json:"result"` // failed, panicked, or dropped
+	Error  string    `json:"error,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// JobQueue runs submitted jobs on a fixed number of workers
+type JobQueue struct {
+	jobs    chan Job
+	wg      sync.WaitGroup
+	running atomic.Int64 // jobs a worker is running now
+
+	// ctx is cancelled when Stop gives up waiting, abandoning running jobs
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+
+	failuresMu sync.Mutex
+	failures   []TaskFailure // oldest first
+}
+
+func newJobQueue(workers, queueSize int) *JobQueue {
+	q := &JobQueue{jobs: make(chan Job, queueSize)}
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+func (q *JobQueue) work() {
+	defer q.wg.Done()
+	for job := range q.jobs {
+		backgroundQueueDepth.Set(float64(len(q.jobs)))
+		if q.ctx.Err() != nil {
+			q.fail(job.Name, "dropped", "abandoned at shutdown")
+			continue
+		}
+		q.run(job)
+	}
+}
+
+// run executes job, retrying it with a doubling delay while it fails and
+// has retries left
+func (q *JobQueue) run(job Job) {
+	q.running.Add(1)
+	defer q.running.Add(-1)
+
+	logCtx := context.Background()
+	if job.Origin != nil {
+		logCtx = job.Origin
+	}
+	for attempt := 0; ; attempt++ {
+		var err error
+		if !runRecovered(logCtx, job.Name, func() { err = q.execute(job) }) {
+			q.fail(job.Name, "panicked", "")
+			return
+		}
+		if err == nil {
+			backgroundTasks.WithLabelValues("completed").Inc()
+			return
+		}
+		if attempt >= job.Retries || q.ctx.Err() != nil {
+			ctxLogger(logCtx).Error("Background job failed", "job", job.Name, "attempts", attempt+1, "error", err)
+			q.fail(job.Name, "failed", err.Error())
+			return
+		}
+
+		delay := jobRetryDelay << attempt
+		backgroundTasks.WithLabelValues("retried").Inc()
+		ctxLogger(logCtx).Warn("Background job failed, retrying", "job", job.Name, "attempt", attempt+1, "error", err, "retry_in", delay.String())
+		select {
+		case <-time.After(delay):
+		case <-q.ctx.Done():
+			q.fail(job.Name, "failed", err.Error())
+			return
+		}
+	}
+}
+
+// execute runs one attempt of job. Its context ends at the attempt's timeout
+// or when Stop abandons running jobs.
+func (q *JobQueue) execute(job Job) error {
+	parent := context.Background()
+	if job.Origin != nil {
+		parent = context.WithoutCancel(job.Origin)
+	}
+	timeout := job.Timeout
+	if timeout == 0 {
+		timeout = backgroundTaskTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	stop := context.AfterFunc(q.ctx, cancel)
+	defer stop()
+	return job.Execute(ctx)
+}
+
+// Submit queues job. When the queue is full it waits up to jobSubmitTimeout
+// for space, then drops the job and returns ErrJobQueueFull so the caller
+// can record the failure.
+func (q *JobQueue) Submit(job Job) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		slog.Warn("Background job dropped: shutting down", "job", job.Name)
+		q.fail(job.Name, "dropped", ErrJobQueueClosed.Error())
+		return ErrJobQueueClosed
+	}
+
+	select {
+	case q.jobs <- job:
+		backgroundQueueDepth.Set(float64(len(q.jobs)))
+		return nil
+	default:
+	}
+
+	slog.Warn("Background queue full, waiting", "job", job.Name, "queue_size", cap(q.jobs))
+	timer := time.NewTimer(jobSubmitTimeout)
+	defer timer.Stop()
+	select {
+	case q.jobs <- job:
+		backgroundQueueDepth.Set(float64(len(q.jobs)))
+		return nil
+	case <-timer.C:
+		slog.Error("Background job dropped: queue still full", "job", job.Name, "waited", jobSubmitTimeout.String())
+		q.fail(job.Name, "dropped", ErrJobQueueFull.Error())
+		return ErrJobQueueFull
+	}
+}
+
+// fail counts a failed, panicked, or dropped job and remembers it
+func (q *JobQueue) fail(name, result, reason string) {
+	backgroundTasks.WithLabelValues(result).Inc()
+	q.failuresMu.Lock()
+	defer q.failuresMu.Unlock()
+	q.failures = append(q.failures, TaskFailure{Task: name, Result: result, Error: reason, At: time.Now()})
+	if len(q.failures) > maxTaskFailures {
+		q.failures = q.failures[len(q.failures)-maxTaskFailures:]
+	}
+}
+
+// recentFailures returns the remembered failures, newest first
+func (q *JobQueue) recentFailures() []TaskFailure {
+	q.failuresMu.Lock()
+	defer q.failuresMu.Unlock()
+	failures := make([]TaskFailure, len(q.failures))
+	for i, f := range q.failures {
+		failures[len(failures)-1-i] = f
+	}
+	return failures
+}
+
+// Pending counts the jobs queued or running
+func (q *JobQueue) Pending() int {
+	return len(q.jobs) + int(q.running.Load())
+}
+
+// abandonedJobsError is returned by Stop when ctx ended before the queue drained
+type abandonedJobsError struct {
+	count int
+	cause error
+}
+
+func (e *abandonedJobsError) Error() string {
+	return fmt.Sprintf("%d background jobs abandoned: %v", e.count, e.cause)
+}
+
+func (e *abandonedJobsError) Unwrap() error { return e.cause }
+
+// Stop stops accepting jobs and waits for queued and running ones to finish.
+// If ctx ends first, running jobs have their contexts cancelled, queued ones
+// are dropped, and the returned error counts them.
+func (q *JobQueue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		abandoned := q.Pending()
+		q.cancel()
+		return &abandonedJobsError{count: abandoned, cause: ctx.Err()}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// A scheduled meeting may require a PIN before /api/token issues a join
+// token. Only a bcrypt hash is stored. Wrong PINs are counted per room, not
+// per IP, so spreading guesses across addresses does not help; after
+// JOIN_PIN_MAX_ATTEMPTS wrong PINs in joinPinWindow the room refuses PINs
+// until the window ends. The host, admins, and room keys with the manage
+// permission join without a PIN. Counters are kept per instance.
+
+const (
+	// defaultJoinPinMaxAttempts is how many wrong PINs a room accepts per window
+	defaultJoinPinMaxAttempts = 5
+	// joinPinWindow is how long wrong PINs count against a room
+	joinPinWindow = 15 * time.Minute
+)
+
+var joinPinPattern = regexp.MustCompile(`^[0-9]{4,12}$`)
+
+func hashJoinPin(pin string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// joinPinAttempts counts wrong PINs per room in fixed windows
+type joinPinAttempts struct {
+	mu      sync.Mutex
+	windows map[string]*pinWindow // room -> current window
+}
+
+type pinWindow struct {
+	start    time.Time
+	failures int
+}
+
+func newJoinPinAttempts() *joinPinAttempts {
+	return &joinPinAttempts{windows: map[string]*pinWindow{}}
+}
+
+// blocked reports how long until roomName accepts PINs again, or 0
+func (a *joinPinAttempts) blocked(roomName string, now time.Time) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	w, ok := a.windows[roomName]
+	if !ok || now.Sub(w.start) >= joinPinWindow {
+		return 0
+	}
+	if w.failures < config.JoinPinMaxAttempts {
+		return 0
+	}
+	return w.start.Add(joinPinWindow).Sub(now)
+}
+
+// fail counts a wrong PIN for roomName, dropping windows that have ended
+func (a *joinPinAttempts) fail(roomName string, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for room, w := range a.windows {
+		if now.Sub(w.start) >= joinPinWindow {
+			delete(a.windows, room)
+		}
+	}
+	w, ok := a.windows[roomName]
+	if !ok {
+		w = &pinWindow{start: now}
+		a.windows[roomName] = w
+	}
+	w.failures++
+}
+
+// isRoomHost reports whether the caller is signed in, or holds a room key,
+// with the right to manage roomName
+func (s *server) isRoomHost(c *fiber.Ctx, roomName string) bool {
+	_, scoped := c.Locals("room_scope").(string)
+	_, signedIn := c.Locals("userID").(int64)
+	return (scoped || signedIn) && s.canManageRoom(c, roomName)
+}
+
+// checkJoinPin refuses a token for a PIN-protected scheduled meeting unless
+// pin matches or the caller is its host
+func (s *server) checkJoinPin(c *fiber.Ctx, roomName, pin string) *requestError {
+	ctx := c.UserContext()
+	meeting, err := s.store.GetScheduledMeetingByRoom(ctx, roomName)
+	if err != nil || meeting.JoinPinHash == "" {
+		// checkInviteLink has already reported lookup errors
+		return nil
+	}
+	if s.isRoomHost(c, roomName) {
+		return nil
+	}
+	if pin == "" {
+		return &requestError{403, APIError{Code: "join_pin_required", Message: "This meeting requires a PIN"}}
+	}
+
+	now := time.Now()
+	if wait := s.pinAttempts.blocked(roomName, now); wait > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(wait.Seconds())+1))
+		return &requestError{429, APIError{Code: "join_pin_locked", Message: "Too many wrong PINs for this meeting, try again later"}}
+	}
+	if bcrypt.CompareHashAndPassword([]byte(meeting.JoinPinHash), []byte(pin)) != nil {
+		s.pinAttempts.fail(roomName, now)
+		ctxLogger(ctx).Warn("Wrong join PIN", "ip", c.IP())
+		return &requestError{403, APIError{Code: "join_pin_invalid", Message: "Wrong PIN"}}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Language is a transcription language the AI service can stream
+type Language struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// supportedLanguages are the languages Deepgram's nova-2 model transcribes live
+var supportedLanguages = []Language{
+	{"en", "English"},
+	{"en-US", "English (US)"},
+	{"en-GB", "English (UK)"},
+	{"es", "Spanish"},
+	{"fr", "French"},
+	{"de", "German"},
+	{"it", "Italian"},
+	{"pt", "Portuguese"},
+	{"pt-BR", "Portuguese (Brazil)"},
+	{"nl", "Dutch"},
+	{"sv", "Swedish"},
+	{"da", "Danish"},
+	{"no", "Norwegian"},
+	{"fi", "Finnish"},
+	{"pl", "Polish"},
+	{"ru", "Russian"},
+	{"uk", "Ukrainian"},
+	{"tr", "Turkish"},
+	{"hi", "Hindi"},
+	{"ja", "Japanese"},
+	{"ko", "Korean"},
+	{"zh", "Chinese"},
+}
+
+// defaultLanguage is used when neither the request nor the meeting picks one
+const defaultLanguage = "en"
+
+// supportedLanguage returns the canonical spelling of code if it is supported
+func supportedLanguage(code string) (string, bool) {
+	code = strings.TrimSpace(code)
+	for _, l := range supportedLanguages {
+		if strings.EqualFold(l.Code, code) {
+			return l.Code, true
+		}
+	}
+	return "", false
+}
+
+type LanguagesResponse struct {
+	Languages []Language `json:"languages"`
+	Default   string     `json:"default"` // TRANSCRIPTION_LANGUAGE
+}
+
+func listLanguagesHandler(c *fiber.Ctx) error {
+	return c.JSON(LanguagesResponse{Languages: supportedLanguages, Default: config.TranscriptionLanguage})
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/livekit/protocol/livekit"
+)
+
+// A meeting ends when LiveKit reports room_finished, when its host ends it,
+// or when the participant reconciler finds its room gone. Once ended_at is
+// set, actions that need a live room are refused with 409.
+
+// endedAtBackfillIdle is how long a meeting must have been quiet before the
+// startup backfill treats it as over
+const endedAtBackfillIdle = 24 * time.Hour
+
+// EndMeeting sets ended_at for a room's meeting. It reports false if the
+// meeting had already ended or does not exist.
+func EndMeeting(ctx context.Context, roomName string, at time.Time) (bool, error) {
+	result, err := execWrite(ctx,
+		"UPDATE meetings SET ended_at = ? WHERE room_name = ? AND ended_at IS NULL",
+		at.UTC(), roomName,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ReopenMeeting makes an ended meeting live again, restarting its clock and
+// clearing its transcription state. A missing or live meeting is left alone.
+func ReopenMeeting(ctx context.Context, roomName string) error {
+	_, err := execWrite(ctx,
+		`UPDATE meetings SET ended_at = NULL, created_at = CURRENT_TIMESTAMP, transcribing_since = NULL
+		 WHERE room_name = ? AND ended_at IS NOT NULL`,
+		roomName,
+	)
+	return err
+}
+
+// backfillMeetingEndedAt estimates ended_at for meetings recorded before it
+// was written, using the latest timestamp on any of their rows. Meetings
+// with someone still present, with no activity at all, or with activity in
+// the last endedAtBackfillIdle are left open.
+func backfillMeetingEndedAt(ctx context.Context) error {
+	lastActivity := `(SELECT MAX(t) FROM (
+		SELECT joined_at AS t FROM participants WHERE meeting_id = meetings.id
+		UNION ALL SELECT left_at FROM participants WHERE meeting_id = meetings.id
+		UNION ALL SELECT created_at FROM transcript_segments WHERE meeting_id = meetings.id
+		UNION ALL SELECT generated_at FROM meeting_notes WHERE meeting_id = meetings.id
+		UNION ALL SELECT generated_at FROM meeting_note_drafts WHERE meeting_id = meetings.id
+		UNION ALL SELECT created_at FROM recordings WHERE meeting_id = meetings.id
+		UNION ALL SELECT completed_at FROM recordings WHERE meeting_id = meetings.id
+	) activity)`
+
+	result, err := execWrite(ctx,
+		`UPDATE meetings SET ended_at = `+lastActivity+`
+		 WHERE ended_at IS NULL
+		   AND NOT EXISTS (SELECT 1 FROM participants p WHERE p.meeting_id = meetings.id AND p.left_at IS NULL)
+		   AND `+lastActivity+` < ?`,
+		time.Now().Add(-endedAtBackfillIdle).UTC(),
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		slog.Info("Estimated ended_at for past meetings", "count", n)
+	}
+	return nil
+}
+
+// meetingEndedError is the response for a live-only action on an ended meeting
+func meetingEndedError(meeting *Meeting) *requestError {
+	return &requestError{409, APIError{Message: "Meeting has ended", Details: fiber.Map{"endedAt": meeting.EndedAt}}}
+}
+
+// finishMeeting records that a room's meeting is over: drafts and speaker
+// tracking stop, anyone still marked present is closed out, transcription is
+// marked stopped, ended_at is set, and a scheduled meeting is marked
+// completed. It reports whether this call ended the meeting.
+func (s *server) finishMeeting(ctx context.Context, roomName string, at time.Time) (bool, error) {
+	s.stopDraftNotes(roomName)
+	s.leaves.cancelRoom(roomName)
+	setActiveSpeakers(roomName, nil)
+	if err := s.store.CloseOpenParticipants(ctx, roomName, at); err != nil {
+		return false, err
+	}
+	// The AI service leaves with everyone else
+	if err := s.store.SetMeetingTranscribing(ctx, roomName, false); err != nil {
+		return false, err
+	}
+	ended, err := s.store.EndMeeting(ctx, roomName, at)
+	if err != nil {
+		return false, err
+	}
+	if err := s.completeScheduledMeeting(ctx, roomName); err != nil {
+		return false, err
+	}
+	if ended {
+		ctxLogger(ctx).Info("Meeting ended")
+	}
+	return ended, nil
+}
+
+// completeScheduledMeeting moves a room's scheduled meeting from active to
+// completed. Rooms that were not scheduled, or never started, are left alone.
+func (s *server) completeScheduledMeeting(ctx context.Context, roomName string) error {
+	scheduled, err := s.store.GetScheduledMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if scheduled.Status != "active" {
+		return nil
+	}
+	err = s.store.UpdateScheduledMeetingStatus(ctx, scheduled.ID, "completed")
+	if errors.Is(err, ErrInvalidStatusTransition) {
+		// Another caller completed it first
+		return nil
+	}
+	return err
+}
+
+// Lifecycle handlers
+
+type EndMeetingResponse struct {
+	Status     string     `json:"status"` // ended
+	RoomName   string     `json:"roomName"`
+	EndedAt    *time.Time `json:"endedAt"`
+	DurationMS int64      `json:"durationMs"`
+	RoomClosed bool       `json:"roomClosed"` // false if LiveKit could not close the room
+}
+
+// endMeetingHandler closes the LiveKit room, disconnecting everyone, and
+// marks the meeting ended
+func (s *server) endMeetingHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	meeting, err := s.store.GetMeetingByRoom(ctx, room)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if meeting.EndedAt != nil {
+		return meetingEndedError(meeting).respond(c)
+	}
+
+	// Marking the meeting ended first means the room_finished webhook that
+	// follows DeleteRoom finds it already handled and sends no second summary
+	ended, err := s.finishMeeting(ctx, room, time.Now())
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if ended {
+		recordAudit(c, "meeting.end", room, "")
+		broadcastEvent(room, EventStatus, StatusEvent{Kind: "meeting", State: "ended"})
+		s.scheduleAutoSummary(ctx, room)
+	}
+
+	// The room may already be empty and gone from LiveKit; the meeting is
+	// ended either way
+	roomClosed := true
+	if _, err := s.rooms.DeleteRoom(ctx, &livekit.DeleteRoomRequest{Room: room}); err != nil {
+		ctxLogger(ctx).Warn("Failed to close room", "error", err)
+		roomClosed = false
+	}
+
+	meeting, err = s.store.GetMeetingByRoom(ctx, room)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	return c.JSON(EndMeetingResponse{
+		Status:     "ended",
+		RoomName:   room,
+		EndedAt:    meeting.EndedAt,
+		DurationMS: meeting.DurationMS,
+		RoomClosed: roomClosed,
+	})
+}
+
+// meetingDurationMS is how long an ended meeting ran, from creation to end
+func meetingDurationMS(createdAt time.Time, endedAt *time.Time) int64 {
+	if endedAt == nil || endedAt.Before(createdAt) {
+		return 0
+	}
+	return endedAt.Sub(createdAt).Milliseconds()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Logs go through log/slog. Every request gets an ID, the caller's
+// X-Request-ID if it sent a usable one, which is echoed in the response,
+// forwarded on calls to the AI service and n8n, and attached to each line
+// logged while serving it, including by background work the request starts.
+// The standard log package is routed through the same handler.
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+type (
+	loggerKey    struct{}
+	requestIDKey struct{}
+)
+
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug, "info": slog.LevelInfo, "warn": slog.LevelWarn, "error": slog.LevelError,
+}
+
+// initLogging installs the default logger from LOG_FORMAT (json or text)
+// and LOG_LEVEL (debug, info, warn, error)
+func initLogging(c *Config) {
+	opts := &slog.HandlerOptions{Level: logLevels[c.LogLevel]}
+	var handler slog.Handler
+	if c.LogFormat == "text" {
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	} else {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// fatal logs an error and exits; it replaces log.Fatal, which would log at info
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// ctxLogger returns the logger carried by ctx, or the default logger
+func ctxLogger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// withLogAttrs returns a context whose logger adds args to every line
+func withLogAttrs(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, loggerKey{}, ctxLogger(ctx).With(args...))
+}
+
+// requestIDFrom returns the ID of the request ctx belongs to, or ""
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// setRequestIDHeader forwards ctx's request ID on an outbound call
+func setRequestIDHeader(ctx context.Context, req *http.Request) {
+	if id := requestIDFrom(ctx); id != "" {
+		req.Header.Set(requestIDHeader, id)
+	}
+}
+
+// validRequestID accepts caller-supplied IDs that are short and printable,
+// so they are safe to echo and log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestID is Fiber middleware that assigns the request its ID and puts it,
+// with a logger carrying it, on the request's user context
+func requestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Locals("requestID", id)
+		c.Set(requestIDHeader, id)
+
+		ctx := context.WithValue(c.UserContext(), requestIDKey{}, id)
+		c.SetUserContext(withLogAttrs(ctx, "request_id", id))
+		return c.Next()
+	}
+}
+
+// logRoom is Fiber middleware for routes under a :room parameter that adds
+// the room to the request's log lines
+func logRoom() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if room := roomParam(c); room != "" {
+			c.SetUserContext(withLogAttrs(c.UserContext(), "room", room))
+		}
+		return c.Next()
+	}
+}
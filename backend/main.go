@@ -1,123 +1,236 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
-	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"regexp"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/websocket/v2"
 	"github.com/joho/godotenv"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	lksdk "github.com/livekit/server-sdk-go/v2"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 var (
-	livekitHost    string
-	apiKey         string
-	apiSecret      string
-	aiServiceURL   string
-	roomClient     *lksdk.RoomServiceClient
-	egressClient   *lksdk.EgressClient
-	transcriptWS   = make(map[string]map[*websocket.Conn]bool) // room -> connections
+	transcriptWS   = make(map[string]map[*websocket.Conn]*wsClient) // full room name -> connection -> client; no prefix matching
 	transcriptLock sync.RWMutex
 )
 
-func validateEnv() {
-	required := []string{"LIVEKIT_URL", "LIVEKIT_API_KEY", "LIVEKIT_API_SECRET", "FRONTEND_URL"}
-	for _, key := range required {
-		if os.Getenv(key) == "" {
-			log.Fatalf("Missing required environment variable: %s", key)
-		}
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "openapi" {
+		os.Exit(openAPICommand(os.Stdout))
 	}
-}
 
-func main() {
 	godotenv.Load()
-	validateEnv()
-
-	livekitHost = os.Getenv("LIVEKIT_URL")
-	apiKey = os.Getenv("LIVEKIT_API_KEY")
-	apiSecret = os.Getenv("LIVEKIT_API_SECRET")
-	aiServiceURL = os.Getenv("AI_SERVICE_URL")
-	if aiServiceURL == "" {
-		aiServiceURL = "http://localhost:8081"
+	conf, err := loadConfig()
+	initLogging(conf)
+	var cerr *configError
+	if errors.As(err, &cerr) {
+		fatal("Invalid configuration", "problems", cerr.problems)
 	}
+	config = conf
+	slog.Info("Starting boom backend", "version", version, "git_sha", gitSHA, "build_time", buildTime, "schema_version", schemaVersion)
+	logConfig(config)
 
 	// Initialize database
-	if err := initDB(); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+	if err := initDB(config.databaseLocation()); err != nil {
+		fatal("Failed to initialize database", "error", err)
 	}
 
 	// Initialize auth (seed users, set JWT secret)
 	initAuth()
 
-	roomClient = lksdk.NewRoomServiceClient(livekitHost, apiKey, apiSecret)
-	egressClient = lksdk.NewEgressClient(livekitHost, apiKey, apiSecret)
+	goPeriodic("transcript hash cleanup", runTranscriptHashCleanup)
+	goPeriodic("WAL maintenance", runWALMaintenance)
+
+	srv := newServer(
+		sqlStore{},
+		lksdk.NewRoomServiceClient(config.LiveKitURL, config.LiveKitAPIKey, config.LiveKitAPISecret),
+		instrumentedEgress{lksdk.NewEgressClient(config.LiveKitURL, config.LiveKitAPIKey, config.LiveKitAPISecret)},
+	)
+	goPeriodic("participant reconciler", srv.runParticipantReconciler)
+	goPeriodic("retention purge", srv.runRetentionPurge)
+	goPeriodic("scheduled backups", srv.runScheduledBackups)
+
+	app := fiber.New(fiberConfig(config))
+	registerRoutes(app, srv)
+
+	// Graceful shutdown
+	go func() {
+		if err := listen(app, config); err != nil {
+			fatal("Server error", "error", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
 
-	app := fiber.New()
+	shutdown(app, srv)
+}
 
-	// CORS
-	app.Use(cors.New(cors.Config{
-		AllowOrigins:     os.Getenv("FRONTEND_URL"),
-		AllowMethods:     "GET, POST, DELETE, OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-		AllowCredentials: true,
-	}))
+// registerRoutes mounts the middleware and every route on app
+func registerRoutes(app *fiber.App, srv *server) {
+	// Middleware order matters: the request ID is assigned first so every
+	// later log line carries it, the build version header is set on every
+	// response including errors, panics are recovered inside the metrics
+	// middleware so their 500s are counted, requests from origins CORS does
+	// not allow are refused next, CORS runs after that so error responses
+	// from later middleware still carry CORS headers, rate limits run before
+	// any credential lookup so rejected floods stay cheap, and auth runs
+	// last, just before the handler. Fiber enforces the body limit while
+	// reading the request, before any of these run.
+	app.Use(requestID())
+	app.Use(versionHeaders())
+	app.Use(httpMetrics())
+	app.Use(recoverPanics())
+
+	// CORS. Route groups that need extra methods register their own
+	// middleware first so it answers their preflight requests.
+	app.Use(rejectDisallowedOrigins())
+	editableCORS := cors.New(corsConfig(cors.Config{AllowMethods: corsMethodsWith(fiber.MethodPatch)}))
+	app.Use("/api/scheduled-meetings", editableCORS)
+	app.Use("/api/meetings/:room/notes", editableCORS)
+	app.Use(cors.New(corsConfig()))
+	app.Use(rateLimiter())
+
+	// Log lines from room routes name the room
+	app.Use("/api/meetings/:room", logRoom())
+	app.Use("/api/admin/meetings/:room", logRoom())
+	app.Use("/api/join/:room", logRoom())
+	app.Use("/ws/transcription/:room", logRoom())
 
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "ok",
-			"service": "backend",
-		})
+		return c.JSON(HealthResponse{Status: "ok", Service: "backend", Version: version, GitSHA: gitSHA, BuildTime: buildTime})
 	})
+	app.Get("/api/version", srv.versionHandler)
+	app.Get("/healthz/ready", srv.readyHandler)
+	// With METRICS_ADDR set, /metrics is served there instead of on the
+	// public listener
+	if addr := config.MetricsAddr; addr != "" {
+		go serveMetrics(addr)
+	} else {
+		app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+	}
+	registerDebugRoutes(app, srv)
+	registerAPIDocsRoutes(app)
 
 	// Auth routes
-	app.Post("/api/auth/login", loginHandler)
+	app.Post("/api/auth/login", srv.loginHandler)
 	app.Get("/api/auth/me", authRequired(), meHandler)
+	app.Post("/api/auth/verify", internalSecretRequired(), verifyTokenHandler)
 
 	// Routes (room creation requires auth)
-	app.Post("/api/rooms", authRequired(), createRoom)
-	app.Post("/api/token", getToken)
-	app.Get("/api/rooms/:id", getRoom)
+	app.Post("/api/rooms", authRequired(), srv.createRoom)
+	app.Post("/api/token", apiKeyOptional(), authOptional(), srv.getToken)
+	app.Get("/api/languages", listLanguagesHandler)
+	app.Get("/api/rooms/suggest-name", authRequired(), srv.suggestRoomNameHandler)
+	app.Get("/api/rooms/:id", srv.getRoom)
 
 	// Scheduling routes
-	app.Post("/api/scheduled-meetings", authRequired(), createScheduledMeetingHandler)
-	app.Get("/api/scheduled-meetings", authRequired(), listScheduledMeetingsHandler)
-	app.Delete("/api/scheduled-meetings/:id", authRequired(), cancelScheduledMeetingHandler)
-	app.Post("/api/scheduled-meetings/:id/start", authRequired(), startScheduledMeetingHandler)
-	app.Get("/api/join/:room", getJoinInfoHandler)
+	app.Post("/api/scheduled-meetings", authRequired(), srv.createScheduledMeetingHandler)
+	app.Get("/api/scheduled-meetings", authRequired(), srv.listScheduledMeetingsHandler)
+	app.Post("/api/scheduled-meetings/bulk-cancel", authRequired(), srv.bulkCancelScheduledMeetingsHandler)
+	app.Delete("/api/scheduled-meetings/:id", authRequired(), srv.cancelScheduledMeetingHandler)
+	app.Post("/api/scheduled-meetings/:id/start", authRequired(), srv.startScheduledMeetingHandler)
+	app.Post("/api/scheduled-meetings/:id/transfer", authRequired(), srv.transferScheduledMeetingHandler)
+	app.Patch("/api/scheduled-meetings/:id", authRequired(), srv.rescheduleMeetingHandler)
+	app.Get("/api/scheduled-meetings/:id/qr-code", authRequired(), srv.scheduledMeetingQRCodeHandler)
+	app.Get("/api/join/:room", srv.getJoinInfoHandler)
+	app.Get("/api/join/slug/:slug", srv.getJoinInfoHandler)
 
 	// Notes API
-	app.Post("/api/meetings/:room/notes", saveNotesHandler)
-	app.Get("/api/meetings/:room/notes", getNotesHandler)
-	app.Get("/api/meetings", listMeetingsHandler)
+	app.Post("/api/meetings/:room/notes", srv.saveNotesHandler)
+	app.Get("/api/meetings/:room/notes", apiKeyOptional(), srv.getNotesHandler)
+	app.Get("/api/meetings/:room/notes/draft", apiKeyOptional(), srv.getNotesDraftHandler)
+	app.Patch("/api/meetings/:room/notes/:id", authRequired(), srv.updateNotesHandler)
+	app.Get("/api/meetings", srv.listMeetingsHandler)
+	app.Get("/api/meetings/:room/export.zip", apiKeyRequired(), srv.exportMeetingZipHandler)
+	app.Get("/api/meetings/:room/export", apiKeyRequired(), srv.exportMeetingBundleHandler)
+	app.Get("/api/meetings/:room/participants", apiKeyRequired(), srv.listParticipantsHandler)
+	app.Get("/api/meetings/:room/stats", apiKeyRequired(), srv.meetingStatsHandler)
+	app.Post("/api/meetings/:room/breakout", apiKeyRequired(), srv.startBreakoutHandler)
+	app.Post("/api/meetings/:room/breakout/end", apiKeyRequired(), srv.endBreakoutHandler)
+	app.Post("/api/meetings/:room/end", apiKeyRequired(), srv.endMeetingHandler)
+	app.Get("/api/stats/overview", authRequired(), srv.statsOverviewHandler)
+	app.Post("/api/meetings/:room/auto-summary/enable", apiKeyRequired(), srv.autoSummaryHandler(true))
+	app.Post("/api/meetings/:room/auto-summary/disable", apiKeyRequired(), srv.autoSummaryHandler(false))
 
 	// Email subscription API
-	app.Post("/api/meetings/:room/subscribe-email", subscribeEmailHandler)
-	app.Get("/api/meetings/:room/email-subscriptions", getEmailSubscriptionsHandler)
-	app.Delete("/api/meetings/:room/unsubscribe-email", unsubscribeEmailHandler)
+	app.Post("/api/meetings/:room/subscribe-email", srv.subscribeEmailHandler)
+	app.Get("/api/meetings/:room/email-subscriptions", srv.getEmailSubscriptionsHandler)
+	app.Get("/api/subscriptions/:email/history", authRequired(), srv.subscriptionHistoryHandler)
+	app.Delete("/api/subscriptions/:email", authRequired(), adminRequired(), srv.deleteSubscriptionsHandler)
+	app.Delete("/api/meetings/:room/unsubscribe-email", srv.unsubscribeEmailHandler)
+	app.Get("/api/meetings/:room/email-log", apiKeyRequired(), srv.getEmailLogHandler)
+	app.Post("/api/internal/email-bounce", noteWebhookReceipt("email-bounce"), internalSecretRequired(), receiveEmailBounceHandler)
+	app.Post("/api/webhooks/n8n/callback", noteWebhookReceipt("n8n-callback"), n8nCallbackHandler)
+	app.Post("/api/webhooks/livekit", noteWebhookReceipt("livekit"), srv.liveKitWebhookHandler)
+
+	// Email suppression admin API
+	app.Get("/api/admin/suppressions", authRequired(), adminRequired(), listSuppressionsHandler)
+	app.Delete("/api/admin/suppressions/:email", authRequired(), adminRequired(), removeSuppressionHandler)
+	app.Post("/api/admin/n8n/test", authRequired(), adminRequired(), testN8NPayloadHandler)
+	app.Get("/api/admin/audit", authRequired(), adminRequired(), listAuditHandler)
+	app.Get("/api/admin/config", authRequired(), adminRequired(), adminConfigHandler)
+	app.Post("/api/admin/retention/run", authRequired(), adminRequired(), srv.retentionRunHandler)
+	app.Post("/api/admin/backup", authRequired(), adminRequired(), srv.backupHandler)
+	app.Get("/api/admin/overview", authRequired(), adminRequired(), srv.adminOverviewHandler)
+	app.Get("/api/admin/egress", authRequired(), adminRequired(), srv.listEgressHandler)
+	app.Post("/api/admin/egress/reconcile", authRequired(), adminRequired(), srv.reconcileEgressHandler)
+	app.Post("/api/admin/meetings/:room/legal-hold", authRequired(), adminRequired(), srv.legalHoldHandler(true))
+	app.Delete("/api/admin/meetings/:room/legal-hold", authRequired(), adminRequired(), srv.legalHoldHandler(false))
+	app.Post("/api/admin/meetings/:room/api-key", authRequired(), adminRequired(), srv.createRoomAPIKeyHandler)
+	app.Get("/api/ai-service/health", authRequired(), adminRequired(), srv.aiServiceHealthHandler)
+
+	// User admin API
+	app.Get("/api/admin/users", authRequired(), adminRequired(), srv.listUsersHandler)
+	app.Get("/api/admin/data-export/:email", authRequired(), adminRequired(), srv.dataExportHandler)
+	app.Delete("/api/admin/data/:email", authRequired(), adminRequired(), srv.dataErasureHandler)
+	app.Post("/api/admin/users/:id/active", authRequired(), adminRequired(), srv.setUserActiveHandler)
+	app.Post("/api/admin/users/:id/namespace", authRequired(), adminRequired(), srv.setUserNamespaceHandler)
 
 	// Real-time transcription API
-	app.Post("/api/meetings/:room/start-transcription", startTranscriptionHandler)
-	app.Post("/api/meetings/:room/end-transcription", endTranscriptionHandler)
-	app.Post("/api/internal/transcript", receiveTranscriptHandler)
+	app.Post("/api/meetings/:room/start-transcription", apiKeyOptional(), srv.startTranscriptionHandler)
+	app.Post("/api/meetings/:room/end-transcription", apiKeyOptional(), srv.endTranscriptionHandler)
+	app.Post("/api/internal/transcript", internalSecretRequired(), srv.receiveTranscriptHandler)
+	app.Post("/api/internal/speakers", internalSecretRequired(), srv.receiveSpeakersHandler)
+	app.Post("/api/meetings/:room/transcript/mirror", authRequired(), srv.addTranscriptMirrorHandler)
+	app.Delete("/api/meetings/:room/transcript/mirror/:target", authRequired(), srv.removeTranscriptMirrorHandler)
+	app.Get("/api/meetings/:room/speaker-map", apiKeyRequired(), srv.getSpeakerMapHandler)
+	app.Post("/api/meetings/:room/speaker-map", apiKeyRequired(), srv.setSpeakerMapHandler)
 
 	// Egress (recording) API - deprecated, kept for backwards compatibility
-	app.Post("/api/meetings/:room/start-recording", startRecordingHandler)
-	app.Post("/api/meetings/:room/stop-recording", stopRecordingHandler)
-	app.Get("/api/meetings/:room/recording-status", getRecordingStatusHandler)
+	app.Post("/api/meetings/:room/consent", srv.recordConsentHandler)
+	app.Post("/api/meetings/:room/acknowledge", srv.acknowledgeHandler)
+	app.Get("/api/meetings/:room/acknowledgements", apiKeyRequired(), srv.listAcknowledgementsHandler)
+	app.Post("/api/meetings/:room/recording-lock/enable", apiKeyRequired(), srv.recordingLockHandler(true))
+	app.Post("/api/meetings/:room/recording-lock/disable", apiKeyRequired(), srv.recordingLockHandler(false))
+	app.Post("/api/meetings/:room/start-recording", apiKeyOptional(), srv.startRecordingHandler)
+	app.Post("/api/meetings/:room/start-capture", apiKeyOptional(), srv.startCaptureHandler)
+	app.Post("/api/meetings/:room/stop-recording", apiKeyOptional(), srv.stopRecordingHandler)
+	app.Post("/api/meetings/:room/pause-recording", apiKeyOptional(), srv.pauseRecordingHandler)
+	app.Post("/api/meetings/:room/resume-recording", apiKeyOptional(), srv.resumeRecordingHandler)
+	app.Get("/api/meetings/:room/recording-status", apiKeyOptional(), srv.getRecordingStatusHandler)
+	app.Get("/api/recordings/:id/audio", srv.recordingAudioHandler)
+	app.Get("/api/meetings/:room/capture-status", apiKeyOptional(), srv.captureStatusHandler)
 
 	// WebSocket for transcription broadcast
 	app.Use("/ws", func(c *fiber.Ctx) error {
@@ -125,23 +238,8 @@ func main() {
 			return c.Next()
 		}
 		return fiber.ErrUpgradeRequired
-	})
+	}, refuseWSDuringShutdown)
 	app.Get("/ws/transcription/:room", websocket.New(handleTranscriptionWS))
-
-	// Graceful shutdown
-	go func() {
-		log.Println("Backend starting on :8080")
-		if err := app.Listen(":8080"); err != nil {
-			log.Fatalf("Server error: %v", err)
-		}
-	}()
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	log.Println("Shutting down...")
-	app.Shutdown()
 }
 
 type CreateRoomRequest struct {
@@ -153,24 +251,64 @@ type CreateRoomResponse struct {
 	RoomID   string `json:"roomId"`
 }
 
-func createRoom(c *fiber.Ctx) error {
+func (s *server) createRoom(c *fiber.Ctx) error {
 	var req CreateRoomRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	roomName := req.Name
-	if roomName == "" {
-		roomName = generateRoomName()
+	namespace, err := s.hostNamespace(c)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	var roomName string
+	if req.Name == "" {
+		// The word lists are small, so a generated name is checked against
+		// past meetings: reusing one would reopen someone else's room
+		names, err := s.suggestRoomNames(c.UserContext(), namespace, 1)
+		if err != nil {
+			return respondError(c, 500, err.Error())
+		}
+		if len(names) == 0 {
+			return respondError(c, 503, "No unused room name is left to generate; choose a name")
+		}
+		roomName = names[0]
+	} else {
+		name, err := sanitizeRoomName(req.Name)
+		if err != nil {
+			return invalidField("name", err.Error())
+		}
+		if err := validateRoomName(namespace, name); err != nil {
+			return invalidField("name", err.Error())
+		}
+		roomName = qualifyRoomName(namespace, name)
+	}
+	if cerr := s.checkActiveRoomLimit(c, roomName); cerr != nil {
+		return cerr.respond(c)
 	}
 
-	room, err := roomClient.CreateRoom(context.Background(), &livekit.CreateRoomRequest{
+	room, err := s.rooms.CreateRoom(context.Background(), &livekit.CreateRoomRequest{
 		Name:            roomName,
 		EmptyTimeout:    10 * 60, // 10 minutes
 		MaxParticipants: 50,
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return respondError(c, 500, err.Error())
+	}
+
+	// LiveKit returns the existing room when the name is taken, and the
+	// meeting insert is an upsert, so a client can retry the whole request
+	// after a failure here. An empty room is closed again so a failed
+	// attempt does not leave one behind that nothing records.
+	hostUserID, _ := c.Locals("userID").(int64)
+	if _, err := s.store.CreateMeeting(c.UserContext(), room.Name, room.Sid, hostUserID); err != nil {
+		ctxLogger(c.UserContext()).Error("Failed to record meeting", "room", room.Name, "error", err)
+		if room.NumParticipants == 0 {
+			if _, delErr := s.rooms.DeleteRoom(context.Background(), &livekit.DeleteRoomRequest{Room: room.Name}); delErr != nil {
+				ctxLogger(c.UserContext()).Error("Failed to close unrecorded room", "room", room.Name, "error", delErr)
+			}
+		}
+		return respondError(c, 500, "Failed to record meeting")
 	}
 
 	return c.JSON(CreateRoomResponse{
@@ -182,118 +320,280 @@ func createRoom(c *fiber.Ctx) error {
 type TokenRequest struct {
 	RoomName        string `json:"roomName"`
 	ParticipantName string `json:"participantName"`
+	JoinPin         string `json:"joinPin,omitempty"` // for PIN-protected scheduled meetings
+}
+
+func (r *TokenRequest) validate(errs *fieldErrors) {
+	errs.require("roomName", r.RoomName)
+	errs.require("participantName", r.ParticipantName)
 }
 
 type TokenResponse struct {
 	Token string `json:"token"`
 }
 
-func getToken(c *fiber.Ctx) error {
+// roomExists reports whether a room is live in LiveKit or belongs to a
+// scheduled meeting that has not been cancelled
+func (s *server) roomExists(ctx context.Context, roomName string) (bool, error) {
+	rooms, err := s.rooms.ListRooms(ctx, &livekit.ListRoomsRequest{Names: []string{roomName}})
+	if err != nil {
+		return false, err
+	}
+	if len(rooms.Rooms) > 0 {
+		return true, nil
+	}
+
+	scheduled, err := s.store.GetScheduledMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return scheduled.Status != "cancelled", nil
+}
+
+func (s *server) getToken(c *fiber.Ctx) error {
 	var req TokenRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	if !roomScopeAllows(c, req.RoomName, roomPermJoin) {
+		return roomScopeDenied(c)
+	}
+
+	exists, err := s.roomExists(c.UserContext(), req.RoomName)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if !exists {
+		ctxLogger(c.UserContext()).Warn("Token requested for unknown room", "room", req.RoomName, "ip", c.IP())
+		return respondError(c, 404, "Room not found")
+	}
+	if cerr := s.checkInviteLink(c.UserContext(), req.RoomName); cerr != nil {
+		return cerr.respond(c)
+	}
+	if cerr := s.checkJoinPin(c, req.RoomName, req.JoinPin); cerr != nil {
+		return cerr.respond(c)
+	}
+
+	mustAcknowledge, err := s.acknowledgementRequired(c.UserContext(), req.RoomName, req.ParticipantName)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if mustAcknowledge {
+		return respondAPIError(c, 403, APIError{
+			Code:    "acknowledgement_required",
+			Message: "Acknowledge the recording notice before joining",
+			Details: fiber.Map{"ackUrl": "/api/meetings/" + url.PathEscape(req.RoomName) + "/acknowledge"},
+		})
 	}
 
 	// Use unique identity per connection so multiple devices can join as the same name
 	identity := fmt.Sprintf("%s-%d", req.ParticipantName, rand.Intn(100000))
 
-	at := auth.NewAccessToken(apiKey, apiSecret)
+	token, err := joinToken(req.RoomName, identity, req.ParticipantName)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	return c.JSON(TokenResponse{Token: token})
+}
+
+// joinToken issues a LiveKit access token that lets identity join roomName
+func joinToken(roomName, identity, name string) (string, error) {
+	at := auth.NewAccessToken(config.LiveKitAPIKey, config.LiveKitAPISecret)
 	grant := &auth.VideoGrant{
 		RoomJoin: true,
-		Room:     req.RoomName,
+		Room:     roomName,
 	}
 	at.AddGrant(grant).
 		SetIdentity(identity).
-		SetName(req.ParticipantName).
+		SetName(name).
 		SetValidFor(24 * time.Hour)
-
-	token, err := at.ToJWT()
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	return c.JSON(TokenResponse{Token: token})
+	return at.ToJWT()
 }
 
 // Egress (Recording) Handlers
 
-func startRecordingHandler(c *fiber.Ctx) error {
-	roomName := c.Params("room")
+// StatusResponse is the body of a handler that only reports an outcome
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+type HealthResponse struct {
+	Status    string `json:"status"`
+	Service   string `json:"service"`
+	Version   string `json:"version"`
+	GitSHA    string `json:"gitSha,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+}
 
-	// Get or create meeting
-	meeting, err := GetMeetingByRoom(roomName)
+// startRecording starts an audio egress for the room. If the room is already
+// being recorded it returns that recording with started set to false.
+func (s *server) startRecording(ctx context.Context, roomName string) (rec *Recording, started bool, cerr *requestError) {
+	meeting, err := s.store.EnsureMeeting(ctx, roomName)
 	if err != nil {
-		meeting, err = CreateMeeting(roomName, "")
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Failed to create meeting"})
-		}
+		return nil, false, &requestError{500, APIError{Message: "Failed to create meeting"}}
+	}
+	if meeting.EndedAt != nil {
+		return nil, false, meetingEndedError(meeting)
 	}
 
 	// Check if already recording
-	existingRec, _ := GetActiveRecordingByMeeting(meeting.ID)
-	if existingRec != nil {
-		return c.JSON(fiber.Map{
-			"status":   "already_recording",
-			"egressId": existingRec.EgressID,
-		})
+	existing, err := s.store.GetActiveRecordingByMeeting(ctx, meeting.ID)
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, false, &requestError{500, APIError{Message: err.Error()}}
 	}
 
+	// Recordings may only start once every participant has consented
+	if config.RequireRecordingConsent {
+		missing, err := s.nonConsentingParticipants(ctx, roomName)
+		if err != nil {
+			ctxLogger(ctx).Error("Failed to check recording consent", "error", err)
+			return nil, false, &requestError{500, APIError{Message: "Failed to check recording consent"}}
+		}
+		if len(missing) > 0 {
+			return nil, false, &requestError{403, APIError{
+				Message: "Not all participants have consented to recording",
+				Details: fiber.Map{"nonConsenting": missing},
+			}}
+		}
+	}
+
+	template := config.EgressFilepathTemplate
+
 	// Start room composite egress (audio only for transcription)
 	egressReq := &livekit.RoomCompositeEgressRequest{
-		RoomName: roomName,
+		RoomName:  roomName,
 		AudioOnly: true,
 		Output: &livekit.RoomCompositeEgressRequest_File{
 			File: &livekit.EncodedFileOutput{
 				FileType: livekit.EncodedFileType_OGG,
-				Filepath: roomName + "-{time}.ogg",
+				Filepath: egressFilepath(template, roomName, meeting.ID, time.Now()),
 			},
 		},
 	}
 
-	info, err := egressClient.StartRoomCompositeEgress(context.Background(), egressReq)
+	info, err := s.egress.StartRoomCompositeEgress(context.Background(), egressReq)
 	if err != nil {
-		log.Printf("Failed to start egress: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		ctxLogger(ctx).Error("Failed to start egress", "error", err)
+		return nil, false, &requestError{500, APIError{Message: err.Error()}}
+	}
+
+	if err := validateEgressID(info.EgressId); err != nil {
+		ctxLogger(ctx).Error("LiveKit returned a malformed egress ID", "error", err)
+		return nil, false, &requestError{500, APIError{Message: "Failed to start recording"}}
 	}
 
 	// Save recording to database
-	rec, err := CreateRecording(meeting.ID, info.EgressId)
+	rec, err = s.store.CreateRecording(ctx, meeting.ID, info.EgressId)
 	if err != nil {
-		log.Printf("Failed to save recording: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to save recording"})
+		ctxLogger(ctx).Error("Failed to save recording", "egress_id", info.EgressId, "error", err)
+		// Don't leave an egress running that nothing tracks
+		if _, stopErr := s.egress.StopEgress(context.Background(), &livekit.StopEgressRequest{EgressId: info.EgressId}); stopErr != nil {
+			ctxLogger(ctx).Error("Failed to stop untracked egress", "egress_id", info.EgressId, "error", stopErr)
+		}
+		return nil, false, &requestError{500, APIError{Message: "Failed to save recording"}}
 	}
 
-	log.Printf("Started recording for room %s, egress ID: %s", roomName, info.EgressId)
+	ctxLogger(ctx).Info("Started recording", "egress_id", info.EgressId)
+	return rec, true, nil
+}
 
-	return c.JSON(fiber.Map{
-		"status":      "recording",
-		"egressId":    info.EgressId,
-		"recordingId": rec.ID,
-	})
+type StartRecordingResponse struct {
+	Status      string `json:"status"` // recording or already_recording
+	EgressID    string `json:"egressId"`
+	RecordingID int64  `json:"recordingId,omitempty"` // set when this request started the recording
+}
+
+func (s *server) startRecordingHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	roomName := roomParam(c)
+	if !roomScopeAllows(c, roomName, roomPermRecording) {
+		return roomScopeDenied(c)
+	}
+
+	rec, started, cerr := s.startRecording(ctx, roomName)
+	if cerr != nil {
+		return cerr.respond(c)
+	}
+	if !started {
+		return c.JSON(StartRecordingResponse{Status: "already_recording", EgressID: rec.EgressID})
+	}
+
+	recordAudit(c, "recording.start", roomName, rec.EgressID)
+	broadcastEvent(roomName, EventStatus, StatusEvent{Kind: "recording", State: "started"})
+
+	return c.JSON(StartRecordingResponse{Status: "recording", EgressID: rec.EgressID, RecordingID: rec.ID})
 }
 
-func stopRecordingHandler(c *fiber.Ctx) error {
-	roomName := c.Params("room")
+func (s *server) stopRecordingHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	roomName := roomParam(c)
+	if !roomScopeAllows(c, roomName, roomPermRecording) {
+		return roomScopeDenied(c)
+	}
 
 	// Get meeting
-	meeting, err := GetMeetingByRoom(roomName)
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Meeting not found"})
+	meeting, err := s.store.GetMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
 	}
 
 	// Get active recording
-	rec, err := GetActiveRecordingByMeeting(meeting.ID)
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "No active recording"})
+	rec, err := s.store.GetActiveRecordingByMeeting(ctx, meeting.ID)
+	if errors.Is(err, ErrNotFound) {
+		// Stopping a paused recording just ends the pause: its egress
+		// already stopped when it was paused
+		pause, perr := s.store.GetOpenRecordingPause(ctx, meeting.ID)
+		if perr == nil {
+			if err := s.store.EndRecordingPause(ctx, pause.ID, 0, time.Now().UTC()); err != nil {
+				return respondError(c, 500, err.Error())
+			}
+			recordAudit(c, "recording.stop", roomName, "while paused")
+			broadcastEvent(roomName, EventStatus, StatusEvent{Kind: "recording", State: "stopped"})
+			return c.JSON(StatusResponse{Status: "stopped"})
+		}
+		if !errors.Is(perr, ErrNotFound) {
+			return respondError(c, 500, perr.Error())
+		}
+		return respondError(c, 404, "No active recording")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	resp, cerr := s.stopRecording(ctx, roomName, rec)
+	if cerr != nil {
+		return cerr.respond(c)
+	}
+	recordAudit(c, "recording.stop", roomName, rec.EgressID)
+	broadcastEvent(roomName, EventStatus, StatusEvent{Kind: "recording", State: "stopped"})
+	return c.JSON(resp)
+}
+
+// stopRecording stops rec's egress and hands the audio on: to batch
+// transcription when an AI service is configured, otherwise straight to the
+// recording-ready notifications
+func (s *server) stopRecording(ctx context.Context, roomName string, rec *Recording) (RecordingStatusResponse, *requestError) {
+	// A malformed ID can only come from a damaged row; don't send it to LiveKit
+	if err := validateEgressID(rec.EgressID); err != nil {
+		ctxLogger(ctx).Error("Stored egress ID is malformed", "recording_id", rec.ID, "error", err)
+		return RecordingStatusResponse{}, &requestError{400, APIError{Message: "Recording has a malformed egress ID", Details: fiber.Map{"recordingId": rec.ID}}}
 	}
 
 	// Stop egress
-	info, err := egressClient.StopEgress(context.Background(), &livekit.StopEgressRequest{
+	info, err := s.egress.StopEgress(context.Background(), &livekit.StopEgressRequest{
 		EgressId: rec.EgressID,
 	})
 	if err != nil {
-		log.Printf("Failed to stop egress: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		ctxLogger(ctx).Error("Failed to stop egress", "egress_id", rec.EgressID, "error", err)
+		return RecordingStatusResponse{}, &requestError{500, APIError{Message: err.Error()}}
 	}
 
 	// Extract file URL from egress result
@@ -301,122 +601,303 @@ func stopRecordingHandler(c *fiber.Ctx) error {
 	var durationMS int64
 	if info.GetFile() != nil {
 		audioURL = info.GetFile().Location
-		durationMS = info.GetFile().Duration / 1000000 // nanoseconds to ms
+		durationMS = convertEgressDuration(info.GetFile().Duration)
 	}
 
-	// Update recording status
-	UpdateRecordingStatus(rec.EgressID, "processing", audioURL, durationMS)
+	ctxLogger(ctx).Info("Stopped recording", "egress_id", rec.EgressID, "audio_url", audioURL)
 
-	log.Printf("Stopped recording for room %s, audio URL: %s", roomName, audioURL)
+	// Without an AI service there is nothing left to do: the audio is the
+	// finished recording
+	if config.AIServiceURL == "" {
+		if err := s.store.UpdateRecordingStatus(ctx, rec.EgressID, "completed", audioURL, durationMS); err != nil {
+			ctxLogger(ctx).Error("Failed to mark recording completed", "egress_id", rec.EgressID, "error", err)
+			return RecordingStatusResponse{}, &requestError{500, APIError{Message: "Recording stopped but its status could not be saved"}}
+		}
+		ctxLogger(ctx).Info("AI service not configured, skipping batch transcription", "egress_id", rec.EgressID)
+		s.recordingReady(ctx, roomName, rec.EgressID, audioURL, durationMS)
+		return RecordingStatusResponse{
+			Status:     "completed",
+			EgressID:   rec.EgressID,
+			AudioURL:   audioURL,
+			DurationMS: durationMS,
+		}, nil
+	}
+	if err := s.store.UpdateRecordingStatus(ctx, rec.EgressID, "processing", audioURL, durationMS); err != nil {
+		ctxLogger(ctx).Error("Failed to mark recording processing", "egress_id", rec.EgressID, "error", err)
+		return RecordingStatusResponse{}, &requestError{500, APIError{Message: "Recording stopped but its status could not be saved"}}
+	}
 
 	// Trigger batch transcription in AI service
-	go func() {
-		if aiServiceURL == "" {
-			return
-		}
-		payload := []byte(`{"room_name": "` + roomName + `", "audio_url": "` + audioURL + `", "egress_id": "` + rec.EgressID + `"}`)
-		resp, err := http.Post(aiServiceURL+"/transcribe-recording", "application/json", bytes.NewBuffer(payload))
+	payload := []byte(`{"room_name": "` + roomName + `", "audio_url": "` + audioURL + `", "egress_id": "` + rec.EgressID + `"}`)
+	err = s.jobs.Submit(Job{Name: "batch transcription " + rec.EgressID, Origin: ctx, Execute: func(ctx context.Context) error {
+		resp, err := callAIService(ctx, "transcribe_recording", "/transcribe-recording", payload)
 		if err != nil {
-			log.Printf("Failed to trigger batch transcription: %v", err)
-			UpdateRecordingStatus(rec.EgressID, "failed", audioURL, durationMS)
-			return
+			if uerr := s.store.UpdateRecordingStatus(ctx, rec.EgressID, "failed", audioURL, durationMS); uerr != nil {
+				ctxLogger(ctx).Error("Failed to mark recording failed", "egress_id", rec.EgressID, "error", uerr)
+			}
+			return fmt.Errorf("trigger batch transcription for %s: %w", rec.EgressID, err)
 		}
 		defer resp.Body.Close()
-		log.Printf("Batch transcription triggered for room: %s", roomName)
-	}()
+		ctxLogger(ctx).Info("Batch transcription triggered", "egress_id", rec.EgressID)
+		return nil
+	}})
+	status := "processing"
+	if err != nil {
+		status = "failed"
+		if uerr := s.store.UpdateRecordingStatus(ctx, rec.EgressID, "failed", audioURL, durationMS); uerr != nil {
+			ctxLogger(ctx).Error("Failed to mark recording failed", "egress_id", rec.EgressID, "error", uerr)
+		}
+	}
 
-	return c.JSON(fiber.Map{
-		"status":     "processing",
-		"egressId":   rec.EgressID,
-		"audioUrl":   audioURL,
-		"durationMs": durationMS,
-	})
+	return RecordingStatusResponse{
+		Status:     status,
+		EgressID:   rec.EgressID,
+		AudioURL:   audioURL,
+		DurationMS: durationMS,
+	}, nil
+}
+
+// RecordingStatusResponse describes a meeting's current recording. The live
+// fields are only set when ?live=true asked LiveKit for the egress state.
+type RecordingStatusResponse struct {
+	Status     string `json:"status"` // recording, paused, processing, completed, failed
+	EgressID   string `json:"egressId"`
+	AudioURL   string `json:"audioUrl"`
+	DurationMS int64  `json:"durationMs"`
+
+	// Set once the meeting's recording has been paused. DurationMS covers
+	// only the current segment, so time spent paused is never counted in it.
+	PausedAt *time.Time `json:"pausedAt,omitempty"` // while paused
+	PausedMS int64      `json:"pausedMs,omitempty"` // total time paused so far
+
+	DBStatus   string `json:"dbStatus,omitempty"`
+	LiveStatus string `json:"liveStatus,omitempty"`
+	LiveError  string `json:"liveError,omitempty"`
+	Reconciled *bool  `json:"reconciled,omitempty"` // whether the stored status was corrected
 }
 
-func getRecordingStatusHandler(c *fiber.Ctx) error {
-	roomName := c.Params("room")
+func (s *server) getRecordingStatusHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	roomName := roomParam(c)
+	if !roomScopeAllows(c, roomName, roomPermRecording) {
+		return roomScopeDenied(c)
+	}
+
+	meeting, err := s.store.GetMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
 
-	meeting, err := GetMeetingByRoom(roomName)
+	pauses, err := s.store.ListRecordingPauses(ctx, meeting.ID)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Meeting not found"})
+		return respondError(c, 500, err.Error())
+	}
+	open, pausedMS := pausedTime(pauses, time.Now())
+
+	rec, err := s.store.GetActiveRecordingByMeeting(ctx, meeting.ID)
+	if errors.Is(err, ErrNotFound) {
+		if open != nil && meeting.EndedAt == nil {
+			resp := RecordingStatusResponse{Status: "paused", PausedAt: &open.PausedAt, PausedMS: pausedMS}
+			if paused, err := s.store.GetRecordingByID(ctx, open.RecordingID); err == nil {
+				resp.EgressID, resp.AudioURL, resp.DurationMS = paused.EgressID, paused.AudioURL, paused.DurationMS
+			}
+			return c.JSON(resp)
+		}
+		return c.JSON(StatusResponse{Status: "no_recording"})
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	if !c.QueryBool("live") {
+		return c.JSON(RecordingStatusResponse{
+			Status:     rec.Status,
+			EgressID:   rec.EgressID,
+			AudioURL:   rec.AudioURL,
+			DurationMS: rec.DurationMS,
+			PausedMS:   pausedMS,
+		})
 	}
 
-	rec, err := GetActiveRecordingByMeeting(meeting.ID)
+	// Ask LiveKit for the real egress state and fix the stored status if it drifted
+	info, err := s.fetchEgress(ctx, rec.EgressID)
 	if err != nil {
-		// Check for completed recordings
-		return c.JSON(fiber.Map{"status": "no_recording"})
+		ctxLogger(ctx).Error("Failed to fetch egress", "egress_id", rec.EgressID, "error", err)
+		return respondAPIError(c, 502, APIError{Message: err.Error(), Details: fiber.Map{"dbStatus": rec.Status}})
+	}
+	dbStatus := rec.Status
+	status, err := s.reconcileRecording(ctx, rec, info)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if status != dbStatus {
+		ctxLogger(ctx).Info("Reconciled recording status", "egress_id", rec.EgressID, "from", dbStatus, "to", status)
+		if updated, err := s.store.GetRecordingByEgressID(ctx, rec.EgressID); err == nil {
+			rec = updated
+		}
 	}
 
-	return c.JSON(fiber.Map{
-		"status":     rec.Status,
-		"egressId":   rec.EgressID,
-		"audioUrl":   rec.AudioURL,
-		"durationMs": rec.DurationMS,
+	reconciled := status != dbStatus
+	return c.JSON(RecordingStatusResponse{
+		Status:     status,
+		EgressID:   rec.EgressID,
+		AudioURL:   rec.AudioURL,
+		DurationMS: rec.DurationMS,
+		PausedMS:   pausedMS,
+		DBStatus:   dbStatus,
+		LiveStatus: info.GetStatus().String(),
+		LiveError:  info.GetError(),
+		Reconciled: &reconciled,
 	})
 }
 
 // Real-time transcription handlers
 
-func startTranscriptionHandler(c *fiber.Ctx) error {
-	roomName := c.Params("room")
+type StartTranscriptionRequest struct {
+	Language string `json:"language"` // optional; overrides the meeting's language
+}
 
-	// Get or create meeting
-	meeting, err := GetMeetingByRoom(roomName)
+// TranscriptionResponse reports a transcription being started or ended;
+// MeetingID and Language are only set when it starts
+type TranscriptionResponse struct {
+	Status    string `json:"status"` // transcribing or processing
+	RoomName  string `json:"roomName"`
+	MeetingID int64  `json:"meetingId,omitempty"`
+	Language  string `json:"language,omitempty"`
+}
+
+// parseTranscriptionLanguage reads the optional language from a start
+// request body and normalizes it to a supported code
+func parseTranscriptionLanguage(c *fiber.Ctx) (string, *requestError) {
+	var req StartTranscriptionRequest
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, &req); err != nil {
+			re, _ := asRequestError(err)
+			return "", re
+		}
+	}
+	if req.Language == "" {
+		return "", nil
+	}
+	code, ok := supportedLanguage(req.Language)
+	if !ok {
+		re := invalidField("language", fmt.Sprintf("language %q is not supported", req.Language))
+		re.detail("languages", supportedLanguages)
+		return "", re
+	}
+	return code, nil
+}
+
+// startTranscription has the AI service join the room. An explicit language
+// overrides the meeting's and is kept for next time.
+func (s *server) startTranscription(ctx context.Context, roomName, requestedLanguage string) (*Meeting, string, *requestError) {
+	meeting, err := s.store.EnsureMeeting(ctx, roomName)
 	if err != nil {
-		meeting, err = CreateMeeting(roomName, "")
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Failed to create meeting"})
+		return nil, "", &requestError{500, APIError{Message: "Failed to create meeting"}}
+	}
+	if meeting.EndedAt != nil {
+		return nil, "", meetingEndedError(meeting)
+	}
+
+	language := meeting.Language
+	if requestedLanguage != "" {
+		language = requestedLanguage
+		if err := s.store.SetMeetingLanguage(ctx, roomName, language); err != nil {
+			return nil, "", &requestError{500, APIError{Message: err.Error()}}
 		}
 	}
+	if language == "" {
+		language = config.TranscriptionLanguage
+	}
+
+	if !s.aiServiceAvailable(ctx) {
+		return nil, "", &requestError{503, APIError{Code: "ai_service_unavailable", Message: "AI service is unavailable"}}
+	}
 
 	// Call AI service to join the room
-	payload := []byte(`{"room_name": "` + roomName + `"}`)
-	resp, err := http.Post(aiServiceURL+"/join", "application/json", bytes.NewBuffer(payload))
+	payload, _ := json.Marshal(fiber.Map{"room_name": roomName, "language": language})
+	resp, err := callAIService(ctx, "join", "/join", payload)
 	if err != nil {
-		log.Printf("Failed to start transcription: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to connect to AI service"})
+		ctxLogger(ctx).Error("Failed to start transcription", "error", err)
+		return nil, "", &requestError{500, APIError{Message: "Failed to connect to AI service"}}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return c.Status(500).JSON(fiber.Map{"error": "AI service failed to join room"})
+		return nil, "", &requestError{500, APIError{Message: "AI service failed to join room"}}
 	}
 
-	log.Printf("Started transcription for room %s, meeting ID: %d", roomName, meeting.ID)
+	if err := s.store.SetMeetingTranscribing(ctx, roomName, true); err != nil {
+		ctxLogger(ctx).Error("Failed to record transcription state", "error", err)
+	}
+	s.startDraftNotes(roomName)
 
-	return c.JSON(fiber.Map{
-		"status":    "transcribing",
-		"roomName":  roomName,
-		"meetingId": meeting.ID,
-	})
+	ctxLogger(ctx).Info("Started transcription", "meeting_id", meeting.ID, "language", language)
+	return meeting, language, nil
+}
+
+func (s *server) startTranscriptionHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	roomName := roomParam(c)
+	if !roomScopeAllows(c, roomName, roomPermTranscription) {
+		return roomScopeDenied(c)
+	}
+
+	requested, cerr := parseTranscriptionLanguage(c)
+	if cerr != nil {
+		return cerr.respond(c)
+	}
+
+	meeting, language, cerr := s.startTranscription(ctx, roomName, requested)
+	if cerr != nil {
+		return cerr.respond(c)
+	}
+
+	recordAudit(c, "transcription.start", roomName, language)
+	broadcastEvent(roomName, EventStatus, StatusEvent{Kind: "transcription", State: "started"})
+
+	return c.JSON(TranscriptionResponse{Status: "transcribing", RoomName: roomName, MeetingID: meeting.ID, Language: language})
 }
 
-func endTranscriptionHandler(c *fiber.Ctx) error {
-	roomName := c.Params("room")
+func (s *server) endTranscriptionHandler(c *fiber.Ctx) error {
+	roomName := roomParam(c)
+	if !roomScopeAllows(c, roomName, roomPermTranscription) {
+		return roomScopeDenied(c)
+	}
+
+	s.stopDraftNotes(roomName)
 
 	// Call AI service to leave the room and generate notes
 	payload := []byte(`{"room_name": "` + roomName + `"}`)
-	resp, err := http.Post(aiServiceURL+"/leave", "application/json", bytes.NewBuffer(payload))
+	resp, err := callAIService(c.UserContext(), "leave", "/leave", payload)
 	if err != nil {
-		log.Printf("Failed to end transcription: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to connect to AI service"})
+		ctxLogger(c.UserContext()).Error("Failed to end transcription", "error", err)
+		return respondError(c, 500, "Failed to connect to AI service")
 	}
 	defer resp.Body.Close()
 
+	// Either way the AI service is no longer in the room
+	if resp.StatusCode == 200 || resp.StatusCode == 404 {
+		if err := s.store.SetMeetingTranscribing(c.UserContext(), roomName, false); err != nil {
+			ctxLogger(c.UserContext()).Error("Failed to record transcription state", "error", err)
+		}
+	}
+
 	if resp.StatusCode == 404 {
-		return c.Status(404).JSON(fiber.Map{"error": "Room not active"})
+		return respondError(c, 404, "Room not active")
 	}
 
 	if resp.StatusCode != 200 {
-		return c.Status(500).JSON(fiber.Map{"error": "AI service failed to process notes"})
+		return respondError(c, 500, "AI service failed to process notes")
 	}
 
-	log.Printf("Ended transcription for room %s, notes should be saved automatically", roomName)
+	ctxLogger(c.UserContext()).Info("Ended transcription, notes should be saved automatically")
+	recordAudit(c, "transcription.end", roomName, "")
+	broadcastEvent(roomName, EventStatus, StatusEvent{Kind: "transcription", State: "stopped"})
+	clearActiveSpeakers(roomName)
 
-	return c.JSON(fiber.Map{
-		"status":   "processing",
-		"roomName": roomName,
-	})
+	return c.JSON(TranscriptionResponse{Status: "processing", RoomName: roomName})
 }
 
 // TranscriptMessage represents an incoming transcript from AI service
@@ -428,64 +909,114 @@ type TranscriptMessage struct {
 	Timestamp string `json:"timestamp"`
 }
 
-func receiveTranscriptHandler(c *fiber.Ctx) error {
+func (m *TranscriptMessage) validate(errs *fieldErrors) {
+	errs.require("room_name", m.RoomName)
+}
+
+func (s *server) receiveTranscriptHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
 	var msg TranscriptMessage
-	if err := c.BodyParser(&msg); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	if err := parseBody(c, &msg); err != nil {
+		return err
+	}
+
+	// The AI service may resend a final segment when it retries; only the
+	// first copy is stored and broadcast. Final lines are persisted so the
+	// transcript outlives the WebSocket session.
+	if msg.IsFinal {
+		isNew, err := s.store.SaveFinalTranscriptSegment(ctx, msg.RoomName, msg.Speaker, msg.Text, msg.Timestamp)
+		if err != nil {
+			ctxLogger(ctx).Error("Failed to save transcript segment", "room", msg.RoomName, "error", err)
+		} else if !isNew {
+			return c.JSON(StatusResponse{Status: "duplicate"})
+		}
 	}
 
 	// Broadcast to all WebSocket clients for this room
-	broadcastJSON := []byte(`{"speaker":"` + msg.Speaker + `","text":"` + msg.Text + `","is_final":` + boolToString(msg.IsFinal) + `,"timestamp":"` + msg.Timestamp + `"}`)
-	broadcastToRoom(msg.RoomName, broadcastJSON)
+	event := TranscriptEvent{
+		Speaker:   msg.Speaker,
+		Text:      msg.Text,
+		IsFinal:   msg.IsFinal,
+		Timestamp: msg.Timestamp,
+	}
+	if msg.Speaker != "" {
+		name, err := s.store.SpeakerDisplayName(ctx, msg.RoomName, msg.Speaker)
+		if err != nil {
+			ctxLogger(ctx).Error("Failed to look up speaker name", "room", msg.RoomName, "error", err)
+		} else if name != msg.Speaker {
+			event.Speaker, event.SpeakerLabel = name, msg.Speaker
+		}
+	}
+	broadcastEvent(msg.RoomName, EventTranscript, event)
 
-	return c.JSON(fiber.Map{"status": "broadcast"})
+	return c.JSON(StatusResponse{Status: "broadcast"})
 }
 
-func boolToString(b bool) string {
-	if b {
-		return "true"
-	}
-	return "false"
+// RoomInfo is a live LiveKit room
+type RoomInfo struct {
+	Name         string `json:"name"`
+	SID          string `json:"sid"`
+	Participants uint32 `json:"participants"`
 }
 
-func getRoom(c *fiber.Ctx) error {
+func (s *server) getRoom(c *fiber.Ctx) error {
 	roomID := c.Params("id")
 
-	rooms, err := roomClient.ListRooms(context.Background(), &livekit.ListRoomsRequest{
+	rooms, err := s.rooms.ListRooms(context.Background(), &livekit.ListRoomsRequest{
 		Names: []string{roomID},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return respondError(c, 500, err.Error())
 	}
 
 	if len(rooms.Rooms) == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "Room not found"})
+		return respondError(c, 404, "Room not found")
 	}
 
 	room := rooms.Rooms[0]
-	return c.JSON(fiber.Map{
-		"name":         room.Name,
-		"sid":          room.Sid,
-		"participants": room.NumParticipants,
-	})
+	return c.JSON(RoomInfo{Name: room.Name, SID: room.Sid, Participants: room.NumParticipants})
 }
 
 func handleTranscriptionWS(c *websocket.Conn) {
-	room := c.Params("room")
+	// The socket runs outside Fiber's handler chain, so recoverPanics does
+	// not cover it
+	defer recoverPanic(context.Background(), "websocket", "transcription socket")
+
+	room := roomParam(c)
+	version := wsProtocolLegacy
+	if c.Query("v") == "2" {
+		version = wsProtocolEnvelope
+	}
 
 	// Register connection with mutex
+	client := &wsClient{conn: c, version: version}
 	transcriptLock.Lock()
 	if transcriptWS[room] == nil {
-		transcriptWS[room] = make(map[*websocket.Conn]bool)
+		transcriptWS[room] = make(map[*websocket.Conn]*wsClient)
 	}
-	transcriptWS[room][c] = true
+	transcriptWS[room][c] = client
+	setWSConnections(room, len(transcriptWS[room]))
 	transcriptLock.Unlock()
 
+	// Late joiners learn who is already here and who is speaking
+	if version >= wsProtocolEnvelope {
+		if event := currentSpeakerEvent(room); event != nil {
+			client.write(event)
+		}
+		if event := currentRosterEvent(room); event != nil {
+			client.write(event)
+		}
+	}
+
 	defer func() {
 		transcriptLock.Lock()
 		delete(transcriptWS[room], c)
+		setWSConnections(room, len(transcriptWS[room]))
+		if len(transcriptWS[room]) == 0 {
+			delete(transcriptWS, room)
+		}
 		transcriptLock.Unlock()
-		c.Close()
+		client.close()
 	}()
 
 	// Keep connection alive, receive messages from AI service
@@ -495,155 +1026,386 @@ func handleTranscriptionWS(c *websocket.Conn) {
 			break
 		}
 		// Broadcast to all clients in room
-		broadcastToRoom(room, msg)
+		relayClientMessage(room, msg)
 	}
 }
 
-func broadcastToRoom(room string, msg []byte) {
+// broadcastToRoom writes the enveloped message to version 2 clients and the
+// legacy message, when there is one, to version 1 clients. The room's
+// clients are copied out first so a slow socket never holds transcriptLock
+// and blocks connections from registering or leaving.
+func broadcastToRoom(room string, envelope, legacy []byte) {
 	transcriptLock.RLock()
-	defer transcriptLock.RUnlock()
-	for conn := range transcriptWS[room] {
-		conn.WriteMessage(websocket.TextMessage, msg)
+	clients := make([]*wsClient, 0, len(transcriptWS[room]))
+	for _, client := range transcriptWS[room] {
+		clients = append(clients, client)
+	}
+	transcriptLock.RUnlock()
+
+	for _, client := range clients {
+		if client.version >= wsProtocolEnvelope {
+			client.write(envelope)
+		} else if legacy != nil {
+			client.write(legacy)
+		}
 	}
 }
 
 // Scheduling handlers
 
+// inviteLink returns the public join URL for a room
+func inviteLink(roomName string) string {
+	return fmt.Sprintf("%s/join/%s", config.FrontendURL, url.PathEscape(roomName))
+}
+
+// slugInviteLink returns the public join URL for a meeting's vanity slug
+func slugInviteLink(slug string) string {
+	return fmt.Sprintf("%s/join/slug/%s", config.FrontendURL, slug)
+}
+
+// slugPattern is what a vanity slug may look like; slugs are stored lowercased
+var slugPattern = regexp.MustCompile(`^[A-Za-z0-9-]{3,32}$`)
+
 type CreateScheduledMeetingRequest struct {
 	ClientName  string `json:"clientName"`
 	ClientEmail string `json:"clientEmail"`
 	ScheduledAt string `json:"scheduledAt"` // ISO 8601
+
+	// LinkExpiresAt is optional; the invite link otherwise expires
+	// INVITE_LINK_TTL after ScheduledAt
+	LinkExpiresAt string `json:"linkExpiresAt"`
+
+	// Slug is optional: 3-32 letters, digits, and hyphens, giving the
+	// meeting a second invite link at /join/slug/<slug>
+	Slug string `json:"slug"`
+
+	// JoinPin is optional: 4-12 digits guests must give to get a token
+	JoinPin string `json:"joinPin"`
+
+	// Parsed by validate
+	scheduledAt, linkExpiresAt time.Time
+}
+
+func (r *CreateScheduledMeetingRequest) validate(errs *fieldErrors) {
+	errs.email("clientEmail", r.ClientEmail)
+	errs.require("scheduledAt", r.ScheduledAt)
+	r.scheduledAt = errs.timestamp("scheduledAt", r.ScheduledAt)
+	r.linkExpiresAt = errs.timestamp("linkExpiresAt", r.LinkExpiresAt)
+	if !r.scheduledAt.IsZero() && !r.linkExpiresAt.IsZero() && !r.linkExpiresAt.After(r.scheduledAt) {
+		errs.add("linkExpiresAt", "linkExpiresAt must be after scheduledAt")
+	}
+	if r.Slug != "" && !slugPattern.MatchString(r.Slug) {
+		errs.add("slug", "slug must be 3-32 letters, digits, and hyphens")
+	}
+	r.Slug = strings.ToLower(r.Slug)
+	if r.JoinPin != "" && !joinPinPattern.MatchString(r.JoinPin) {
+		errs.add("joinPin", "joinPin must be 4-12 digits")
+	}
 }
 
-func createScheduledMeetingHandler(c *fiber.Ctx) error {
+// ScheduledMeetingResponse is a scheduled meeting as shown to its host
+type ScheduledMeetingResponse struct {
+	ID            int64     `json:"id"`
+	RoomName      string    `json:"roomName"`
+	ClientName    string    `json:"clientName"`
+	ClientEmail   string    `json:"clientEmail"`
+	ScheduledAt   time.Time `json:"scheduledAt"`
+	LinkExpiresAt time.Time `json:"linkExpiresAt"`
+	Status        string    `json:"status"`
+	InviteLink    string    `json:"inviteLink"`
+
+	Slug           string `json:"slug,omitempty"`
+	InviteLinkSlug string `json:"inviteLinkSlug,omitempty"`
+	PinProtected   bool   `json:"pinProtected"`
+}
+
+func scheduledMeetingResponse(m *ScheduledMeeting) ScheduledMeetingResponse {
+	resp := ScheduledMeetingResponse{
+		ID:            m.ID,
+		RoomName:      m.RoomName,
+		ClientName:    m.ClientName,
+		ClientEmail:   m.ClientEmail,
+		ScheduledAt:   m.ScheduledAt,
+		LinkExpiresAt: m.LinkExpiresAt,
+		Status:        m.Status,
+		InviteLink:    inviteLink(m.RoomName),
+		Slug:          m.Slug,
+		PinProtected:  m.JoinPinHash != "",
+	}
+	if m.Slug != "" {
+		resp.InviteLinkSlug = slugInviteLink(m.Slug)
+	}
+	return resp
+}
+
+func (s *server) createScheduledMeetingHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
 	var req CreateScheduledMeetingRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	scheduledAt, linkExpiresAt := req.scheduledAt, req.linkExpiresAt
+	if linkExpiresAt.IsZero() {
+		linkExpiresAt = defaultLinkExpiry(scheduledAt)
 	}
 
-	scheduledAt, err := time.Parse(time.RFC3339, req.ScheduledAt)
+	hostUserID := c.Locals("userID").(int64)
+	namespace, err := s.store.GetUserNamespace(ctx, hostUserID)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid date format, use ISO 8601"})
+		return respondError(c, 500, err.Error())
 	}
+	roomName := qualifyRoomName(namespace, generateRoomName())
 
-	hostUserID := c.Locals("userID").(int64)
-	roomName := generateRoomName()
+	if req.Slug != "" {
+		if taken, err := s.store.ScheduledMeetingSlugInUse(ctx, req.Slug); err != nil {
+			return respondError(c, 500, err.Error())
+		} else if taken {
+			return slugTakenError(req.Slug).respond(c)
+		}
+	}
+
+	var joinPinHash string
+	if req.JoinPin != "" {
+		if joinPinHash, err = hashJoinPin(req.JoinPin); err != nil {
+			return respondError(c, 500, "Failed to hash PIN")
+		}
+	}
 
-	meeting, err := CreateScheduledMeeting(roomName, hostUserID, req.ClientName, req.ClientEmail, scheduledAt)
+	meeting, err := s.store.CreateScheduledMeeting(ctx, roomName, hostUserID, req.ClientName, req.ClientEmail, scheduledAt, linkExpiresAt, req.Slug, joinPinHash)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create scheduled meeting"})
+		// The unique index catches a slug claimed since the check above
+		if req.Slug != "" {
+			if taken, _ := s.store.ScheduledMeetingSlugInUse(ctx, req.Slug); taken {
+				return slugTakenError(req.Slug).respond(c)
+			}
+		}
+		return respondError(c, 500, "Failed to create scheduled meeting")
 	}
 
-	frontendURL := os.Getenv("FRONTEND_URL")
-	inviteLink := fmt.Sprintf("%s/join/%s", frontendURL, roomName)
+	recordAudit(c, "meeting.schedule", roomName, scheduledAt.Format(time.RFC3339))
 
-	return c.JSON(fiber.Map{
-		"id":          meeting.ID,
-		"roomName":    meeting.RoomName,
-		"scheduledAt": meeting.ScheduledAt,
-		"inviteLink":  inviteLink,
-		"clientName":  meeting.ClientName,
-		"clientEmail": meeting.ClientEmail,
-	})
+	return c.JSON(scheduledMeetingResponse(meeting))
+}
+
+func slugTakenError(slug string) *requestError {
+	return &requestError{409, APIError{Code: "slug_taken", Message: "slug " + slug + " is already in use", Fields: []FieldError{{Field: "slug", Message: "slug is already in use"}}}}
 }
 
-func listScheduledMeetingsHandler(c *fiber.Ctx) error {
+func (s *server) listScheduledMeetingsHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
 	hostUserID := c.Locals("userID").(int64)
 
-	meetings, err := ListScheduledMeetingsByHost(hostUserID)
+	meetings, err := s.store.ListScheduledMeetingsByHost(ctx, hostUserID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-	}
-	if meetings == nil {
-		meetings = []ScheduledMeeting{}
+		return respondError(c, 500, err.Error())
 	}
 
-	frontendURL := os.Getenv("FRONTEND_URL")
-	var results []fiber.Map
-	for _, m := range meetings {
-		results = append(results, fiber.Map{
-			"id":          m.ID,
-			"roomName":    m.RoomName,
-			"clientName":  m.ClientName,
-			"clientEmail": m.ClientEmail,
-			"scheduledAt": m.ScheduledAt,
-			"status":      m.Status,
-			"inviteLink":  fmt.Sprintf("%s/join/%s", frontendURL, m.RoomName),
-		})
-	}
-	if results == nil {
-		results = []fiber.Map{}
+	results := make([]ScheduledMeetingResponse, len(meetings))
+	for i := range meetings {
+		results[i] = scheduledMeetingResponse(&meetings[i])
 	}
-
 	return c.JSON(results)
 }
 
-func cancelScheduledMeetingHandler(c *fiber.Ctx) error {
-	idStr := c.Params("id")
+func (s *server) cancelScheduledMeetingHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
 	var id int64
-	fmt.Sscanf(idStr, "%d", &id)
+	if _, err := fmt.Sscanf(c.Params("id"), "%d", &id); err != nil {
+		return respondError(c, 400, "Invalid ID")
+	}
 
 	hostUserID := c.Locals("userID").(int64)
 
-	if err := CancelScheduledMeeting(id, hostUserID); err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	if err := s.store.CancelScheduledMeeting(ctx, id, hostUserID); errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "meeting not found or not owned by user")
+	} else if errors.Is(err, ErrInvalidStatusTransition) {
+		return respondError(c, 409, "Meeting has already started or been cancelled")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
 	}
 
-	return c.JSON(fiber.Map{"status": "cancelled"})
+	target := fmt.Sprintf("scheduled_meeting:%d", id)
+	if meeting, err := s.store.GetScheduledMeetingByID(ctx, id); err == nil {
+		target = meeting.RoomName
+	}
+	recordAudit(c, "meeting.cancel", target, "")
+
+	return c.JSON(StatusResponse{Status: "cancelled"})
 }
 
-func startScheduledMeetingHandler(c *fiber.Ctx) error {
-	idStr := c.Params("id")
+type StartScheduledMeetingResponse struct {
+	Status   string `json:"status"` // active
+	RoomName string `json:"roomName"`
+	RoomID   string `json:"roomId"`
+}
+
+func (s *server) startScheduledMeetingHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
 	var id int64
-	fmt.Sscanf(idStr, "%d", &id)
+	if _, err := fmt.Sscanf(c.Params("id"), "%d", &id); err != nil {
+		return respondError(c, 400, "Invalid ID")
+	}
 
 	hostUserID := c.Locals("userID").(int64)
 
 	// Get the scheduled meeting
-	var roomName string
-	var meetingHostID int64
-	err := db.QueryRow("SELECT room_name, host_user_id FROM scheduled_meetings WHERE id = ? AND status = 'scheduled'", id).Scan(&roomName, &meetingHostID)
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Scheduled meeting not found"})
+	meeting, err := s.store.GetScheduledMeetingByID(ctx, id)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return respondError(c, 500, err.Error())
 	}
-	if meetingHostID != hostUserID {
-		return c.Status(403).JSON(fiber.Map{"error": "Not your meeting"})
+	if err != nil || meeting.Status != "scheduled" {
+		return respondError(c, 404, "Scheduled meeting not found")
+	}
+	roomName := meeting.RoomName
+	if meeting.HostUserID != hostUserID {
+		return respondError(c, 403, "Not your meeting")
+	}
+	if cerr := s.checkActiveRoomLimit(c, roomName); cerr != nil {
+		return cerr.respond(c)
 	}
 
 	// Create the LiveKit room
-	room, err := roomClient.CreateRoom(context.Background(), &livekit.CreateRoomRequest{
+	room, err := s.rooms.CreateRoom(context.Background(), &livekit.CreateRoomRequest{
 		Name:            roomName,
 		EmptyTimeout:    10 * 60,
 		MaxParticipants: 50,
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return respondError(c, 500, err.Error())
+	}
+
+	// A meeting row left ended from before the start is live again
+	if err := s.store.ReopenMeeting(ctx, roomName); err != nil {
+		return respondError(c, 500, err.Error())
 	}
 
 	// Update status to active
-	UpdateScheduledMeetingStatus(id, "active")
+	if err := s.store.UpdateScheduledMeetingStatus(ctx, id, "active"); err != nil {
+		ctxLogger(ctx).Error("Failed to mark scheduled meeting active", "id", id, "error", err)
+	}
+	recordAudit(c, "meeting.start", roomName, "")
+
+	return c.JSON(StartScheduledMeetingResponse{Status: "active", RoomName: room.Name, RoomID: room.Sid})
+}
+
+type TransferScheduledMeetingRequest struct {
+	NewOwnerEmail string `json:"newOwnerEmail"`
+}
+
+func (r *TransferScheduledMeetingRequest) validate(errs *fieldErrors) {
+	errs.require("newOwnerEmail", r.NewOwnerEmail)
+}
+
+type TransferScheduledMeetingResponse struct {
+	Status   string      `json:"status"` // transferred
+	ID       int64       `json:"id"`
+	RoomName string      `json:"roomName"`
+	NewOwner UserSummary `json:"newOwner"`
+}
+
+func (s *server) transferScheduledMeetingHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	var id int64
+	if _, err := fmt.Sscanf(c.Params("id"), "%d", &id); err != nil {
+		return respondError(c, 400, "Invalid ID")
+	}
+
+	hostUserID := c.Locals("userID").(int64)
+	hostEmail := c.Locals("userEmail").(string)
+
+	var req TransferScheduledMeetingRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	meeting, err := s.store.GetScheduledMeetingByID(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Scheduled meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if meeting.HostUserID != hostUserID {
+		return respondError(c, 403, "Not your meeting")
+	}
+	if meeting.Status == "active" {
+		return respondError(c, 409, "Cannot transfer an active meeting")
+	}
+
+	newOwner, err := s.store.GetUserByEmail(ctx, req.NewOwnerEmail)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "User not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if newOwner.ID == hostUserID {
+		return respondError(c, 400, "You already own this meeting")
+	}
+
+	if err := s.store.TransferScheduledMeeting(ctx, id, hostUserID, newOwner.ID); errors.Is(err, ErrNotFound) {
+		return respondError(c, 409, "meeting not found, not owned by user, or active")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
 
-	return c.JSON(fiber.Map{
-		"status":   "active",
-		"roomName": room.Name,
-		"roomId":   room.Sid,
+	// LiveKit room tokens are stateless and scheduled meetings issue no
+	// host-only tokens, so there is nothing to revoke for the old owner
+	// beyond the ownership check on the scheduling endpoints.
+	recordAudit(c, "meeting.transfer", meeting.RoomName, fmt.Sprintf("from %s to %s", hostEmail, newOwner.Email))
+
+	subject := fmt.Sprintf("Meeting %s has a new host", meeting.RoomName)
+	body := fmt.Sprintf("The meeting with %s scheduled for %s was transferred from %s to %s.",
+		meeting.ClientName, meeting.ScheduledAt.Format(time.RFC1123), hostEmail, newOwner.Email)
+	s.jobs.Submit(Job{Name: "transfer email " + meeting.RoomName, Origin: ctx, Retries: notificationRetries, Execute: func(ctx context.Context) error {
+		return SendNotificationEmail(ctx, "meeting_transfer", []string{hostEmail, newOwner.Email}, subject, body)
+	}})
+
+	return c.JSON(TransferScheduledMeetingResponse{
+		Status:   "transferred",
+		ID:       meeting.ID,
+		RoomName: meeting.RoomName,
+		NewOwner: UserSummary{ID: newOwner.ID, Email: newOwner.Email, Name: newOwner.Name},
 	})
 }
 
-func getJoinInfoHandler(c *fiber.Ctx) error {
-	roomName := c.Params("room")
+// JoinInfo is what a guest following an invite link learns about the meeting
+type JoinInfo struct {
+	RoomName      string    `json:"roomName"`
+	HostName      string    `json:"hostName"`
+	ClientName    string    `json:"clientName"`
+	ScheduledAt   time.Time `json:"scheduledAt"`
+	LinkExpiresAt time.Time `json:"linkExpiresAt"`
+	Status        string    `json:"status"`
+	PinRequired   bool      `json:"pinRequired"` // the token request must include joinPin
+}
 
-	meeting, err := GetScheduledMeetingByRoom(roomName)
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Meeting not found"})
+// getJoinInfoHandler serves both /api/join/:room and /api/join/slug/:slug
+func (s *server) getJoinInfoHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var meeting *ScheduledMeeting
+	var err error
+	if slug := pathParam(c, "slug"); slug != "" {
+		meeting, err = s.store.GetScheduledMeetingBySlug(ctx, strings.ToLower(slug))
+	} else {
+		meeting, err = s.store.GetScheduledMeetingByRoom(ctx, roomParam(c))
+	}
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if !time.Now().Before(meeting.LinkExpiresAt) {
+		return inviteExpiredError(meeting).respond(c)
 	}
 
-	return c.JSON(fiber.Map{
-		"roomName":    meeting.RoomName,
-		"hostName":    meeting.HostName,
-		"clientName":  meeting.ClientName,
-		"scheduledAt": meeting.ScheduledAt,
-		"status":      meeting.Status,
+	return c.JSON(JoinInfo{
+		RoomName:      meeting.RoomName,
+		HostName:      meeting.HostName,
+		ClientName:    meeting.ClientName,
+		ScheduledAt:   meeting.ScheduledAt,
+		LinkExpiresAt: meeting.LinkExpiresAt,
+		Status:        meeting.Status,
+		PinRequired:   meeting.JoinPinHash != "",
 	})
 }
 
@@ -668,7 +1430,91 @@ var nouns = []string{
 func generateRoomName() string {
 	verb := verbs[rand.Intn(len(verbs))]
 	noun := nouns[rand.Intn(len(nouns))]
-	return verb + "-" + noun
+	// The word lists are already URL-safe; sanitizing keeps generated names
+	// to the same rules as requested ones if the lists are ever edited
+	name, err := sanitizeRoomName(verb + "-" + noun)
+	if err != nil {
+		return verb + "-" + noun
+	}
+	return name
+}
+
+// maxRoomNameSuggestions caps the count accepted by the suggest-name endpoint
+const maxRoomNameSuggestions = 10
+
+// suggestRoomNames generates up to count distinct room names in namespace
+// that are not used by any stored meeting or live LiveKit room. It may return
+// fewer when the name space is nearly exhausted.
+func (s *server) suggestRoomNames(ctx context.Context, namespace string, count int) ([]string, error) {
+	seen := map[string]bool{}
+	var candidates []string
+	for attempts := 0; len(candidates) < count && attempts < count*20; attempts++ {
+		name := qualifyRoomName(namespace, generateRoomName())
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		inUse, err := s.store.RoomNameInUse(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if !inUse {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	// Rooms that were created directly in LiveKit have no meeting row yet
+	rooms, err := s.rooms.ListRooms(ctx, &livekit.ListRoomsRequest{Names: candidates})
+	if err != nil {
+		return nil, err
+	}
+	live := map[string]bool{}
+	for _, room := range rooms.Rooms {
+		live[room.Name] = true
+	}
+
+	names := make([]string, 0, len(candidates))
+	for _, name := range candidates {
+		if !live[name] {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+type RoomNameSuggestions struct {
+	RoomName    string   `json:"roomName"` // the first suggestion
+	Suggestions []string `json:"suggestions"`
+}
+
+func (s *server) suggestRoomNameHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	var errs fieldErrors
+	count := errs.queryInt(c, "count", 1)
+	if count < 1 || count > maxRoomNameSuggestions {
+		errs.add("count", fmt.Sprintf("count must be between 1 and %d", maxRoomNameSuggestions))
+	}
+	if err := errs.err(); err != nil {
+		return err
+	}
+
+	namespace, err := s.hostNamespace(c)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	names, err := s.suggestRoomNames(ctx, namespace, count)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if len(names) == 0 {
+		return respondError(c, 503, "No unused room names available")
+	}
+
+	return c.JSON(RoomNameSuggestions{RoomName: names[0], Suggestions: names})
 }
 
 // Notes API handlers
@@ -680,42 +1526,156 @@ type SaveNotesRequest struct {
 	OutputTokens int    `json:"outputTokens"`
 }
 
-func saveNotesHandler(c *fiber.Ctx) error {
-	room := c.Params("room")
+func (r *SaveNotesRequest) validate(errs *fieldErrors) {
+	if r.InputTokens < 0 {
+		errs.add("inputTokens", "inputTokens cannot be negative")
+	} else if limit, ok := modelTokenLimits[r.Model]; ok && r.InputTokens > limit {
+		errs.add("inputTokens", fmt.Sprintf("inputTokens %d exceeds the %d token context window of %s", r.InputTokens, limit, r.Model))
+	}
+	if r.OutputTokens < 0 {
+		errs.add("outputTokens", "outputTokens cannot be negative")
+	}
+}
+
+type SaveNotesResponse struct {
+	Status string `json:"status"` // saved
+	ID     int64  `json:"id"`
+}
+
+func (s *server) saveNotesHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
 	var req SaveNotesRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	notes, err := SaveNotes(room, req.Markdown, req.Model, req.InputTokens, req.OutputTokens)
+	notes, err := s.store.SaveNotes(ctx, room, req.Markdown, req.Model, req.InputTokens, req.OutputTokens)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return respondError(c, 500, err.Error())
 	}
 
 	// Trigger email workflow in background (non-blocking)
-	go TriggerEmailWorkflow(room, req.Markdown)
+	if err := s.jobs.Submit(Job{Name: "email workflow " + room, Origin: ctx, Execute: func(ctx context.Context) error {
+		return TriggerEmailWorkflow(ctx, room, req.Markdown)
+	}}); err != nil {
+		ctxLogger(ctx).Error("Summary email was not sent", "error", err)
+	}
 
-	return c.JSON(fiber.Map{
-		"status": "saved",
-		"id":     notes.ID,
-	})
+	return c.JSON(SaveNotesResponse{Status: "saved", ID: notes.ID})
 }
 
-func getNotesHandler(c *fiber.Ctx) error {
-	room := c.Params("room")
+func (s *server) getNotesHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !roomScopeAllows(c, room, roomPermNotes) {
+		return roomScopeDenied(c)
+	}
+
+	format := c.Query("format", "raw")
+	if format != "raw" && format != "html" {
+		var errs fieldErrors
+		errs.add("format", "format must be raw or html")
+		return errs.err()
+	}
 
-	notes, err := GetNotesByRoom(room)
+	notes, err := s.store.GetNotesByRoom(ctx, room)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Notes not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	c.Set("ETag", `"`+notes.ETag+`"`)
+	if format == "html" {
+		html, err := s.store.GetNotesHTML(ctx, notes)
+		if err != nil {
+			ctxLogger(ctx).Error("Failed to render notes HTML", "notes_id", notes.ID, "error", err)
+			return respondError(c, 500, "Failed to render notes")
+		}
+		c.Type("html", "utf-8")
+		return c.SendString(html)
+	}
+	return c.JSON(notes)
+}
+
+type UpdateNotesRequest struct {
+	Markdown string `json:"markdown"`
+}
+
+func (s *server) updateNotesHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	var id int64
+	if _, err := fmt.Sscanf(c.Params("id"), "%d", &id); err != nil {
+		return respondError(c, 400, "Invalid ID")
+	}
+
+	// Accept both bare and quoted etags, as browsers echo back the quoted form
+	ifMatch := strings.Trim(strings.TrimPrefix(c.Get("If-Match"), "W/"), `"`)
+	if ifMatch == "" {
+		return respondError(c, 428, "If-Match header is required")
+	}
+
+	var req UpdateNotesRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	notes, updated, err := s.store.UpdateNotes(ctx, room, id, req.Markdown, ifMatch)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Notes not found")
+	}
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Notes not found"})
+		return respondError(c, 500, err.Error())
 	}
+	if !updated {
+		current, err := s.store.GetNotesByID(ctx, room, id)
+		if err != nil {
+			return respondError(c, 500, err.Error())
+		}
+		c.Set("ETag", `"`+current.ETag+`"`)
+		return respondAPIError(c, 412, APIError{
+			Message: "Notes were modified by someone else",
+			Details: fiber.Map{"etag": current.ETag, "markdown": current.Markdown},
+		})
+	}
+
+	recordAudit(c, "notes.update", room, fmt.Sprintf("notes %d", id))
 
+	c.Set("ETag", `"`+notes.ETag+`"`)
 	return c.JSON(notes)
 }
 
-func listMeetingsHandler(c *fiber.Ctx) error {
-	meetings, err := ListMeetingsWithNotes(20)
+func (s *server) listMeetingsHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	var errs fieldErrors
+	opts := MeetingListOptions{
+		Sort:  c.Query("sort"),
+		Limit: errs.queryInt(c, "limit", defaultMeetingListLimit),
+	}
+	if _, ok := meetingListSorts[opts.Sort]; opts.Sort != "" && !ok {
+		errs.add("sort", "sort must be generatedAt, createdAt, or roomName")
+	}
+	switch c.Query("order", "desc") {
+	case "asc":
+		opts.Ascending = true
+	case "desc":
+	default:
+		errs.add("order", "order must be asc or desc")
+	}
+	if err := errs.err(); err != nil {
+		return err
+	}
+
+	meetings, err := s.store.ListMeetingsWithNotes(ctx, opts)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		ctxLogger(ctx).Error("Failed to list meetings", "error", err)
+		return respondError(c, 500, "Failed to list meetings")
 	}
 
 	return c.JSON(meetings)
@@ -724,64 +1684,150 @@ func listMeetingsHandler(c *fiber.Ctx) error {
 // Email subscription handlers
 
 type SubscribeEmailRequest struct {
-	Email           string `json:"email"`
-	ParticipantName string `json:"participantName"`
+	Email            string `json:"email"`
+	ParticipantName  string `json:"participantName"`
+	IncludeRecording bool   `json:"includeRecording"` // link the recording in the summary email
+}
+
+func (r *SubscribeEmailRequest) validate(errs *fieldErrors) {
+	errs.require("email", r.Email)
+	errs.email("email", r.Email)
+}
+
+type SubscribeEmailResponse struct {
+	Status           string `json:"status"` // subscribed
+	ID               int64  `json:"id"`
+	Email            string `json:"email"`
+	IncludeRecording bool   `json:"includeRecording"`
 }
 
-func subscribeEmailHandler(c *fiber.Ctx) error {
-	room := c.Params("room")
+func (s *server) subscribeEmailHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
 	var req SubscribeEmailRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	if req.Email == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "Email is required"})
+	sup, err := GetSuppression(ctx, req.Email)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if sup != nil {
+		return respondAPIError(c, 409, APIError{
+			Message: "This email address is suppressed because earlier emails to it bounced or were reported as spam",
+			Details: fiber.Map{"reason": sup.Reason},
+		})
 	}
 
-	sub, err := CreateEmailSubscription(room, req.ParticipantName, req.Email)
+	sub, err := s.store.CreateEmailSubscription(ctx, room, req.ParticipantName, req.Email, req.IncludeRecording)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return respondError(c, 500, err.Error())
 	}
 
-	return c.JSON(fiber.Map{
-		"status": "subscribed",
-		"id":     sub.ID,
-		"email":  sub.Email,
+	return c.JSON(SubscribeEmailResponse{
+		Status:           "subscribed",
+		ID:               sub.ID,
+		Email:            sub.Email,
+		IncludeRecording: sub.IncludeRecording,
 	})
 }
 
-func getEmailSubscriptionsHandler(c *fiber.Ctx) error {
-	room := c.Params("room")
+// EmailSubscriptionsResponse lists a room's subscriptions, oldest first.
+// Count is the number on this page and Total the number in the room; Limit
+// and Offset are only set when a page was asked for. Invalid is only set
+// when ?validate=true asked for stored addresses to be re-checked.
+type EmailSubscriptionsResponse struct {
+	Subscriptions []EmailSubscription `json:"subscriptions"`
+	Count         int                 `json:"count"`
+	Total         int                 `json:"total"`
+	Limit         int                 `json:"limit,omitempty"`
+	Offset        int                 `json:"offset,omitempty"`
+	Invalid       *[]string           `json:"invalid,omitempty"`
+}
 
-	subs, err := GetEmailSubscriptionsByRoom(room)
-	if err != nil {
-		return c.JSON(fiber.Map{
-			"subscriptions": []EmailSubscription{},
-			"count":         0,
-		})
+// getEmailSubscriptionsHandler lists a room's subscriptions: all of them, or
+// one page when ?limit or ?offset is given
+func (s *server) getEmailSubscriptionsHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+
+	var errs fieldErrors
+	paged := c.Query("limit") != "" || c.Query("offset") != ""
+	limit := errs.queryInt(c, "limit", defaultEmailSubscriptionPageSize)
+	offset := errs.queryInt(c, "offset", 0)
+	if limit < 1 || limit > maxEmailSubscriptionPageSize {
+		errs.add("limit", fmt.Sprintf("limit must be between 1 and %d", maxEmailSubscriptionPageSize))
+	}
+	if offset < 0 {
+		errs.add("offset", "offset must not be negative")
+	}
+	if err := errs.err(); err != nil {
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"subscriptions": subs,
-		"count":         len(subs),
-	})
+	var subs []EmailSubscription
+	var total int
+	var err error
+	if paged {
+		subs, total, err = s.store.GetEmailSubscriptionsPage(ctx, room, limit, offset)
+	} else {
+		subs, err = s.store.GetEmailSubscriptionsByRoom(ctx, room)
+		total = len(subs)
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return respondError(c, 500, err.Error())
+	}
+	if subs == nil {
+		subs = []EmailSubscription{}
+	}
+
+	resp := EmailSubscriptionsResponse{Subscriptions: subs, Count: len(subs), Total: total}
+	if paged {
+		resp.Limit, resp.Offset = limit, offset
+	}
+	// ?validate=true re-checks stored addresses, which may predate validation
+	if c.QueryBool("validate") {
+		invalid := []string{}
+		for _, sub := range subs {
+			if !isValidEmail(sub.Email) {
+				invalid = append(invalid, sub.Email)
+			}
+		}
+		resp.Invalid = &invalid
+	}
+	return c.JSON(resp)
 }
 
 type UnsubscribeEmailRequest struct {
 	Email string `json:"email"`
 }
 
-func unsubscribeEmailHandler(c *fiber.Ctx) error {
-	room := c.Params("room")
+func (r *UnsubscribeEmailRequest) validate(errs *fieldErrors) {
+	errs.require("email", r.Email)
+	errs.email("email", r.Email)
+}
+
+type UnsubscribeEmailResponse struct {
+	Status  string `json:"status"` // unsubscribed
+	Removed int64  `json:"removed"`
+}
+
+func (s *server) unsubscribeEmailHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
 	var req UnsubscribeEmailRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	if err := DeleteEmailSubscription(room, req.Email); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	removed, err := s.store.DeleteEmailSubscription(ctx, room, req.Email)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if removed == 0 {
+		return respondError(c, 404, "Email is not subscribed")
 	}
 
-	return c.JSON(fiber.Map{"status": "unsubscribed"})
+	return c.JSON(UnsubscribeEmailResponse{Status: "unsubscribed", Removed: removed})
 }
@@ -0,0 +1,638 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/livekit/protocol/livekit"
+)
+
+// Tests share the package globals (config, db, the WebSocket registry), so
+// none of them call t.Parallel. A test that needs one installs a fresh value
+// with the helpers below, which put the previous one back when it ends.
+
+func TestMain(m *testing.M) {
+	// Handlers log every rejected request; keep test output readable
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// testAdminEmail is the seeded user tests treat as the admin
+const testAdminEmail = "justin@nevinstech.com"
+
+// testUserEmail is a seeded user without admin rights
+const testUserEmail = "burt@nevinstech.com"
+
+// testOtherUserEmail is a second seeded user without admin rights
+const testOtherUserEmail = "justinnevins@protonmail.com"
+
+// testInternalSecret is the INTERNAL_API_SECRET of the test configuration
+const testInternalSecret = "test-internal-secret"
+
+// internalSecret returns the header pair trusted services authenticate with
+func internalSecret() []string {
+	return []string{"X-Internal-Secret", testInternalSecret}
+}
+
+// useTestConfig installs a valid configuration with no external services
+func useTestConfig(t *testing.T) *Config {
+	t.Helper()
+	c := defaultConfig()
+	c.LiveKitURL = "ws://livekit.test"
+	c.LiveKitAPIKey = "test-key"
+	c.LiveKitAPISecret = "test-secret-test-secret-test-secret"
+	c.FrontendURL = "http://frontend.test"
+	c.CORSOrigins = []string{"http://frontend.test"}
+	c.AIServiceURL = ""
+	c.JWTSecret = "test-jwt-secret"
+	c.InternalAPISecret = testInternalSecret
+	c.AdminEmails = []string{testAdminEmail}
+
+	prev, prevAdmins := config, adminEmails
+	config = c
+	adminEmails = map[string]bool{testAdminEmail: true}
+	t.Cleanup(func() { config, adminEmails = prev, prevAdmins })
+	return c
+}
+
+// useTestDB gives the test its own in-memory database with the seeded users
+func useTestDB(t *testing.T) {
+	t.Helper()
+	useTestDBAt(t, memoryDatabasePath)
+}
+
+// useTestDBAt is useTestDB for a database at location, for tests that need
+// a file or another engine
+func useTestDBAt(t *testing.T, location string) {
+	t.Helper()
+	if err := initDB(location); err != nil {
+		t.Fatalf("initDB: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		db = nil
+	})
+	seedUsers(context.Background())
+}
+
+// countRows runs a COUNT query against the test database
+func countRows(t *testing.T, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRowContext(context.Background(), query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+// testUser returns a seeded user
+func testUser(t *testing.T, email string) *User {
+	t.Helper()
+	user, err := GetUserByEmail(context.Background(), email)
+	if err != nil {
+		t.Fatalf("GetUserByEmail(%q): %v", email, err)
+	}
+	return user
+}
+
+// testToken signs a session token for a seeded user
+func testToken(t *testing.T, email string) string {
+	t.Helper()
+	token, err := generateJWT(testUser(t, email))
+	if err != nil {
+		t.Fatalf("generateJWT: %v", err)
+	}
+	return token
+}
+
+// newTestServer returns a server backed by store and fake LiveKit clients
+func newTestServer(t *testing.T, store Store) *server {
+	t.Helper()
+	srv := newServer(store, newFakeRooms(), newFakeEgress())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.jobs.Stop(ctx)
+	})
+	return srv
+}
+
+// newTestApp mounts every route of srv on a fresh app
+func newTestApp(t *testing.T, srv *server) *fiber.App {
+	t.Helper()
+	cfg := fiberConfig(config)
+	cfg.DisableStartupMessage = true
+	app := fiber.New(cfg)
+	registerRoutes(app, srv)
+	return app
+}
+
+// serveTestApp serves app on a loopback port until the test ends and
+// returns its base URL, for tests that need a real connection
+func serveTestApp(t *testing.T, app *fiber.App) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+	return "http://" + ln.Addr().String()
+}
+
+// testResponse is a response read in full
+type testResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// decode unmarshals the body into v
+func (r *testResponse) decode(t *testing.T, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("decode %s: %v", r.Body, err)
+	}
+}
+
+// apiError returns the body's error envelope
+func (r *testResponse) apiError(t *testing.T) APIError {
+	t.Helper()
+	var body struct {
+		Error APIError `json:"error"`
+	}
+	r.decode(t, &body)
+	return body.Error
+}
+
+// doRequest sends a request to app. body is sent as JSON unless it is
+// already a string or []byte; header is a list of name, value pairs.
+func doRequest(t *testing.T, app *fiber.App, method, path string, body any, header ...string) *testResponse {
+	t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(b)
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read %s %s: %v", method, path, err)
+	}
+	return &testResponse{Status: resp.StatusCode, Header: resp.Header, Body: data}
+}
+
+// bearer returns the header pair authenticating as a seeded user
+func bearer(t *testing.T, email string) []string {
+	t.Helper()
+	return []string{"Authorization", "Bearer " + testToken(t, email)}
+}
+
+// The handler tests below run against fakeStore. The auth middleware and
+// audit log still read the package database, so signed-in tests also call
+// useTestDB for the seeded users.
+
+func TestCreateRoom(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	store := newFakeStore()
+	srv := newTestServer(t, store)
+	app := newTestApp(t, srv)
+
+	if resp := doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{Name: "standup"}); resp.Status != 401 {
+		t.Fatalf("without a token: got %d, want 401", resp.Status)
+	}
+
+	resp := doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{Name: "standup"}, bearer(t, testUserEmail)...)
+	if resp.Status != 200 {
+		t.Fatalf("got %d, want 200: %s", resp.Status, resp.Body)
+	}
+	var room CreateRoomResponse
+	resp.decode(t, &room)
+	if room.RoomName != "standup" || room.RoomID == "" {
+		t.Errorf("got %+v, want room standup with a SID", room)
+	}
+	meeting, err := store.GetMeetingByRoom(context.Background(), "standup")
+	if err != nil {
+		t.Fatalf("meeting was not recorded: %v", err)
+	}
+	if meeting.RoomSID != room.RoomID {
+		t.Errorf("meeting SID %q, want %q", meeting.RoomSID, room.RoomID)
+	}
+
+	resp = doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{Name: " Team Sync! "}, bearer(t, testUserEmail)...)
+	resp.decode(t, &room)
+	if resp.Status != 200 || room.RoomName != "team-sync" {
+		t.Errorf("unsafe name: got %d %q, want 200 team-sync", resp.Status, room.RoomName)
+	}
+	if resp := doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{Name: "!!"}, bearer(t, testUserEmail)...); resp.Status != 422 {
+		t.Errorf("name with nothing usable: got %d, want 422", resp.Status)
+	}
+}
+
+func TestCreateRoomIdempotency(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	store := newFakeStore()
+	srv := newTestServer(t, store)
+	rooms := srv.rooms.(*fakeRooms)
+	app := newTestApp(t, srv)
+
+	// The first attempt creates the LiveKit room but fails to record it
+	store.createMeetingErr = errors.New("database is locked")
+	resp := doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{Name: "retry-me"}, bearer(t, testUserEmail)...)
+	if resp.Status != 500 {
+		t.Fatalf("failed insert: got %d, want 500", resp.Status)
+	}
+	if listed, _ := rooms.ListRooms(context.Background(), &livekit.ListRoomsRequest{}); len(listed.Rooms) != 0 {
+		t.Errorf("empty room left open after the failed insert: %v", listed.Rooms)
+	}
+
+	// Retrying the same request succeeds, and so does a third call
+	var first, second CreateRoomResponse
+	resp = doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{Name: "retry-me"}, bearer(t, testUserEmail)...)
+	if resp.Status != 200 {
+		t.Fatalf("retry: got %d, want 200: %s", resp.Status, resp.Body)
+	}
+	resp.decode(t, &first)
+	resp = doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{Name: "retry-me"}, bearer(t, testUserEmail)...)
+	if resp.Status != 200 {
+		t.Fatalf("repeat: got %d, want 200: %s", resp.Status, resp.Body)
+	}
+	resp.decode(t, &second)
+	if first != second {
+		t.Errorf("repeat returned %+v, want %+v", second, first)
+	}
+	if len(store.meetings) != 1 {
+		t.Errorf("got %d meetings, want 1", len(store.meetings))
+	}
+}
+
+func TestSaveNotesHandler(t *testing.T) {
+	useTestConfig(t)
+	store := newFakeStore()
+	app := newTestApp(t, newTestServer(t, store))
+
+	resp := doRequest(t, app, "POST", "/api/meetings/retro/notes", SaveNotesRequest{Markdown: "# Retro", Model: "test-model", InputTokens: 10, OutputTokens: 5})
+	if resp.Status != 200 {
+		t.Fatalf("got %d, want 200: %s", resp.Status, resp.Body)
+	}
+	var saved SaveNotesResponse
+	resp.decode(t, &saved)
+
+	resp = doRequest(t, app, "GET", "/api/meetings/retro/notes", nil)
+	if resp.Status != 200 {
+		t.Fatalf("get notes: got %d, want 200: %s", resp.Status, resp.Body)
+	}
+	var notes MeetingNotes
+	resp.decode(t, &notes)
+	if notes.ID != saved.ID || notes.Markdown != "# Retro" {
+		t.Errorf("got notes %d %q, want %d %q", notes.ID, notes.Markdown, saved.ID, "# Retro")
+	}
+	if got, want := resp.Header.Get("ETag"), `"`+notesETag("# Retro")+`"`; got != want {
+		t.Errorf("ETag %s, want %s", got, want)
+	}
+
+	resp = doRequest(t, app, "POST", "/api/meetings/retro/notes", SaveNotesRequest{Markdown: "x", InputTokens: -1})
+	if resp.Status != 422 {
+		t.Errorf("negative token count: got %d, want 422", resp.Status)
+	}
+}
+
+func TestUpdateNotesSecondWriterGetsPreconditionFailed(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	app := newTestApp(t, newTestServer(t, sqlStore{}))
+	admin := bearer(t, testAdminEmail)
+
+	saved, err := SaveNotes(context.Background(), "retro", "# Retro", "test-model", 1, 1)
+	if err != nil {
+		t.Fatalf("SaveNotes: %v", err)
+	}
+	path := fmt.Sprintf("/api/meetings/retro/notes/%d", saved.ID)
+	edit := func(markdown string) *testResponse {
+		header := append([]string{"If-Match", `"` + saved.ETag + `"`}, admin...)
+		return doRequest(t, app, "PATCH", path, UpdateNotesRequest{Markdown: markdown}, header...)
+	}
+
+	// Both editors loaded the same revision; they save at once
+	edits := []string{"# Retro\n\nfirst", "# Retro\n\nsecond", "# Retro\n\nthird"}
+	results := make([]*testResponse, len(edits))
+	var wg sync.WaitGroup
+	for i, markdown := range edits {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = edit(markdown)
+		}()
+	}
+	wg.Wait()
+
+	winner := ""
+	for i, resp := range results {
+		switch resp.Status {
+		case 200:
+			if winner != "" {
+				t.Fatalf("two edits from the same etag succeeded")
+			}
+			winner = edits[i]
+		case 412:
+		default:
+			t.Fatalf("edit %d: got %d, want 200 or 412: %s", i, resp.Status, resp.Body)
+		}
+	}
+	if winner == "" {
+		t.Fatal("no edit succeeded")
+	}
+
+	// A writer still holding the old etag is told what changed
+	resp := edit("# Retro\n\nlate")
+	if resp.Status != 412 {
+		t.Fatalf("stale edit: got %d, want 412", resp.Status)
+	}
+	details := resp.apiError(t).Details
+	if details["etag"] != notesETag(winner) || details["markdown"] != winner {
+		t.Errorf("412 details: got %v, want the winning edit", details)
+	}
+	if got := resp.Header.Get("ETag"); got != `"`+notesETag(winner)+`"` {
+		t.Errorf("412 ETag: got %s, want the winning edit's", got)
+	}
+}
+
+func TestScheduledMeetingHandlers(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	store := newFakeStore()
+	app := newTestApp(t, newTestServer(t, store))
+	host, other := bearer(t, testUserEmail), bearer(t, testOtherUserEmail)
+
+	create := func(scheduledAt time.Time, slug string) *testResponse {
+		return doRequest(t, app, "POST", "/api/scheduled-meetings", CreateScheduledMeetingRequest{
+			ClientName:  "Client",
+			ClientEmail: "client@example.com",
+			ScheduledAt: scheduledAt.Format(time.RFC3339),
+			Slug:        slug,
+		}, host...)
+	}
+	later := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	sooner := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	resp := create(later, "Weekly-Sync")
+	if resp.Status != 200 {
+		t.Fatalf("create: got %d, want 200: %s", resp.Status, resp.Body)
+	}
+	var created ScheduledMeetingResponse
+	resp.decode(t, &created)
+	if created.Status != "scheduled" || created.Slug != "weekly-sync" || !strings.HasSuffix(created.InviteLinkSlug, "/weekly-sync") {
+		t.Errorf("got %+v", created)
+	}
+	if resp := create(sooner, "weekly-sync"); resp.Status != 409 || resp.apiError(t).Code != "slug_taken" {
+		t.Errorf("duplicate slug: got %d %s, want 409 slug_taken", resp.Status, resp.Body)
+	}
+	if resp := create(sooner, ""); resp.Status != 200 {
+		t.Fatalf("second create: got %d, want 200: %s", resp.Status, resp.Body)
+	}
+
+	var listed []ScheduledMeetingResponse
+	doRequest(t, app, "GET", "/api/scheduled-meetings", nil, host...).decode(t, &listed)
+	if len(listed) != 2 || !listed[0].ScheduledAt.Equal(sooner) {
+		t.Fatalf("list: got %+v, want two meetings, soonest first", listed)
+	}
+	var others []ScheduledMeetingResponse
+	doRequest(t, app, "GET", "/api/scheduled-meetings", nil, other...).decode(t, &others)
+	if len(others) != 0 {
+		t.Errorf("another user sees %d meetings, want 0", len(others))
+	}
+
+	path := fmt.Sprintf("/api/scheduled-meetings/%d", created.ID)
+	if resp := doRequest(t, app, "DELETE", path, nil, other...); resp.Status != 404 {
+		t.Errorf("cancel by another user: got %d, want 404", resp.Status)
+	}
+	if resp := doRequest(t, app, "DELETE", path, nil, host...); resp.Status != 200 {
+		t.Errorf("cancel: got %d, want 200: %s", resp.Status, resp.Body)
+	}
+	if resp := doRequest(t, app, "DELETE", path, nil, host...); resp.Status != 409 {
+		t.Errorf("cancel twice: got %d, want 409", resp.Status)
+	}
+	doRequest(t, app, "GET", "/api/scheduled-meetings", nil, host...).decode(t, &listed)
+	if len(listed) != 1 {
+		t.Errorf("after cancel: got %d meetings, want 1", len(listed))
+	}
+}
+
+func TestCanManageRoomUsesStore(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	store := newFakeStore()
+	app := newTestApp(t, newTestServer(t, store))
+
+	// The meeting exists only in the fake, so the check must ask the store
+	meeting, err := store.CreateScheduledMeeting(context.Background(), "client-call", testUser(t, testUserEmail).ID, "Client", "client@example.com", time.Now().Add(time.Hour), time.Now().Add(2*time.Hour), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/api/scheduled-meetings/%d/qr-code", meeting.ID)
+
+	for _, tc := range []struct {
+		email string
+		want  int
+	}{
+		{testUserEmail, 200},
+		{testAdminEmail, 200},
+		{testOtherUserEmail, 403},
+	} {
+		if resp := doRequest(t, app, "GET", path, nil, bearer(t, tc.email)...); resp.Status != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.email, resp.Status, tc.want)
+		}
+	}
+}
+
+func TestAdHocRoomHostCanManageRoom(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	app := newTestApp(t, newTestServer(t, sqlStore{}))
+	host, other := bearer(t, testUserEmail), bearer(t, testOtherUserEmail)
+
+	// No scheduled meeting names a host; only the meeting row records who
+	// created the room
+	resp := doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{Name: "huddle"}, host...)
+	if resp.Status != 200 {
+		t.Fatalf("create: got %d: %s", resp.Status, resp.Body)
+	}
+	var created CreateRoomResponse
+	resp.decode(t, &created)
+	path := "/api/meetings/" + created.RoomName + "/end"
+
+	if resp := doRequest(t, app, "POST", path, nil, other...); resp.Status != 403 {
+		t.Errorf("end by another user: got %d, want 403", resp.Status)
+	}
+	if resp := doRequest(t, app, "POST", path, nil, host...); resp.Status != 200 {
+		t.Errorf("end by the room's creator: got %d, want 200: %s", resp.Status, resp.Body)
+	}
+}
+
+func TestScheduledMeetingRoutesRejectInvalidID(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	routes := []struct{ method, path string }{
+		{"DELETE", "/api/scheduled-meetings/abc"},
+		{"POST", "/api/scheduled-meetings/abc/start"},
+		{"POST", "/api/scheduled-meetings/abc/transfer"},
+		{"PATCH", "/api/scheduled-meetings/abc"},
+	}
+	for _, r := range routes {
+		resp := doRequest(t, app, r.method, r.path, map[string]any{}, bearer(t, testUserEmail)...)
+		if resp.Status != 400 || resp.apiError(t).Message != "Invalid ID" {
+			t.Errorf("%s %s: got %d %s, want 400 Invalid ID", r.method, r.path, resp.Status, resp.Body)
+		}
+	}
+}
+
+func TestJoinInfoShowsHostNameOnly(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	app := newTestApp(t, newTestServer(t, sqlStore{}))
+	host := testUser(t, testUserEmail)
+	if host.Name == "" || host.PasswordHash == "" {
+		t.Fatalf("seeded host %q has no name or password hash", host.Email)
+	}
+	at := time.Now().Add(time.Hour)
+	if _, err := CreateScheduledMeeting(context.Background(), "client-call", host.ID, "Client", "client@example.com", at, at.Add(24*time.Hour), "client-call-x7", ""); err != nil {
+		t.Fatalf("CreateScheduledMeeting: %v", err)
+	}
+
+	for _, path := range []string{"/api/join/client-call", "/api/join/slug/client-call-x7"} {
+		resp := doRequest(t, app, http.MethodGet, path, nil)
+		if resp.Status != http.StatusOK {
+			t.Fatalf("%s: got %d, want 200: %s", path, resp.Status, resp.Body)
+		}
+		var info map[string]any
+		resp.decode(t, &info)
+		if info["hostName"] != host.Name {
+			t.Errorf("%s: hostName %v, want %q", path, info["hostName"], host.Name)
+		}
+		for _, key := range []string{"passwordHash", "password_hash", "hostEmail", "hostUserId"} {
+			if _, ok := info[key]; ok {
+				t.Errorf("%s: response has %s", path, key)
+			}
+		}
+		if body := string(resp.Body); strings.Contains(body, host.PasswordHash) || strings.Contains(body, host.Email) {
+			t.Errorf("%s: response leaks the host's password hash or email: %s", path, body)
+		}
+	}
+}
+
+func TestRecreatedRoomReopensEndedMeeting(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	app := newTestApp(t, newTestServer(t, sqlStore{}))
+	admin := bearer(t, testAdminEmail)
+	ctx := context.Background()
+
+	if resp := doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{Name: "standup"}, admin...); resp.Status != 200 {
+		t.Fatalf("create: got %d: %s", resp.Status, resp.Body)
+	}
+	first, err := GetMeetingByRoom(ctx, "standup")
+	if err != nil {
+		t.Fatalf("GetMeetingByRoom: %v", err)
+	}
+	if resp := doRequest(t, app, "POST", "/api/meetings/standup/end", nil, admin...); resp.Status != 200 {
+		t.Fatalf("end: got %d: %s", resp.Status, resp.Body)
+	}
+	if resp := doRequest(t, app, "POST", "/api/meetings/standup/start-recording", nil); resp.Status != 409 {
+		t.Fatalf("recording an ended meeting: got %d, want 409", resp.Status)
+	}
+
+	if resp := doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{Name: "standup"}, admin...); resp.Status != 200 {
+		t.Fatalf("recreate: got %d: %s", resp.Status, resp.Body)
+	}
+	again, err := GetMeetingByRoom(ctx, "standup")
+	if err != nil {
+		t.Fatalf("GetMeetingByRoom: %v", err)
+	}
+	if again.EndedAt != nil {
+		t.Errorf("recreated meeting still ended at %v", again.EndedAt)
+	}
+	if again.CreatedAt.Before(first.CreatedAt) {
+		t.Errorf("recreated meeting created at %v, before the first at %v", again.CreatedAt, first.CreatedAt)
+	}
+	if resp := doRequest(t, app, "POST", "/api/meetings/standup/start-recording", nil); resp.Status != 200 {
+		t.Errorf("recording the recreated room: got %d, want 200: %s", resp.Status, resp.Body)
+	}
+}
+
+func TestCreateRoomGeneratesUnusedName(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	store := newFakeStore()
+	app := newTestApp(t, newTestServer(t, store))
+
+	// Half the generated names belong to past meetings
+	used := map[string]bool{}
+	for i, verb := range verbs {
+		for j, noun := range nouns {
+			if (i+j)%2 == 0 {
+				used[verb+"-"+noun] = true
+				store.ensureMeeting(verb + "-" + noun)
+			}
+		}
+	}
+	for i := 0; i < 5; i++ {
+		var room CreateRoomResponse
+		resp := doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{}, bearer(t, testUserEmail)...)
+		if resp.Status != 200 {
+			t.Fatalf("got %d: %s", resp.Status, resp.Body)
+		}
+		resp.decode(t, &room)
+		if used[room.RoomName] {
+			t.Errorf("generated %q, which a past meeting already used", room.RoomName)
+		}
+		used[room.RoomName] = true
+	}
+
+	for _, verb := range verbs {
+		for _, noun := range nouns {
+			store.ensureMeeting(verb + "-" + noun)
+		}
+	}
+	if resp := doRequest(t, app, "POST", "/api/rooms", CreateRoomRequest{}, bearer(t, testUserEmail)...); resp.Status != 503 {
+		t.Errorf("with every name used: got %d, want 503", resp.Status)
+	}
+}
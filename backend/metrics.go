@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/livekit/protocol/livekit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus collectors, served on /metrics
+var (
+	liveKitRTT = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "boom_livekit_rtt_ms",
+		Help: "Round-trip time of the most recent LiveKit readiness check in milliseconds.",
+	})
+
+	dbBusyRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "boom_db_busy_retries_total",
+		Help: "Writes retried because SQLite was busy, by kind (write or tx).",
+	}, []string{"kind"})
+
+	dbBusyFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "boom_db_busy_failures_total",
+		Help: "Writes that were still busy after every retry, by kind (write or tx).",
+	}, []string{"kind"})
+
+	// The per-method error rate is
+	//   sum by (method) (rate(boom_ai_service_duration_seconds_count{status="error"}[5m]))
+	//     / sum by (method) (rate(boom_ai_service_duration_seconds_count[5m]))
+	aiServiceDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "boom_ai_service_duration_seconds",
+		Help:    "Latency of AI service calls, by method (join, leave, transcribe_recording, summarize, health) and status (success or error).",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"method", "status"})
+
+	walSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "boom_db_wal_size_bytes",
+		Help: "Size of the SQLite -wal file when last measured.",
+	})
+
+	walCheckpoints = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "boom_db_wal_checkpoints_total",
+		Help: "WAL checkpoints run, by result (ok, busy, or error).",
+	}, []string{"result"})
+
+	aiServiceInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "boom_ai_service_in_flight",
+		Help: "AI service calls currently waiting for a response.",
+	})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "boom_http_request_duration_seconds",
+		Help:    "Latency of HTTP requests, by method, route pattern, and status code. The _count series is the request count.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	wsConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "boom_ws_connections",
+		Help: "Open room WebSocket connections, by room.",
+	}, []string{"room"})
+
+	transcriptSegmentsIngested = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "boom_transcript_segments_ingested_total",
+		Help: "Final transcript segments stored.",
+	})
+
+	egressRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "boom_egress_requests_total",
+		Help: "Egress start and stop calls to LiveKit, by action (start or stop) and result (success or error).",
+	}, []string{"action", "result"})
+
+	egressEnded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "boom_egress_ended_total",
+		Help: "Recordings finalized by LiveKit's egress_ended webhook, by status (completed or failed).",
+	}, []string{"status"})
+
+	backgroundQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "boom_background_queue_depth",
+		Help: "Background tasks waiting for a worker.",
+	})
+
+	backgroundTasks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "boom_background_tasks_total",
+		Help: "Background jobs, by result (completed, retried, failed, panicked, or dropped).",
+	}, []string{"result"})
+
+	panicsRecovered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "boom_panics_total",
+		Help: "Panics recovered, by source (http, websocket, or background).",
+	}, []string{"source"})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "boom_rate_limited_total",
+		Help: "Requests refused with 429 by the rate limiter, by rule.",
+	}, []string{"rule"})
+
+	recordingWebhookRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "boom_recording_webhook_retries_total",
+		Help: "Recording-ready webhook deliveries retried after a failed attempt.",
+	})
+
+	dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "boom_db_query_duration_seconds",
+		Help:    "Latency of store statements, by statement kind (select, insert, update, delete, or other).",
+		Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 5},
+	}, []string{"statement"})
+)
+
+// serveMetrics serves /metrics on addr for the life of the process
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	slog.Info("Metrics listening", "addr", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		fatal("Metrics server error", "error", err)
+	}
+}
+
+// httpMetrics is Fiber middleware that records each request's latency by
+// route pattern, so path parameters do not multiply the series
+func httpMetrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// The error handler writes the status after this returns
+			status = errorStatus(err)
+		}
+		httpRequestDuration.WithLabelValues(c.Method(), c.Route().Path, strconv.Itoa(status)).
+			Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// setWSConnections records how many sockets a room has open; call with
+// transcriptLock held
+func setWSConnections(room string, n int) {
+	if n == 0 {
+		wsConnections.DeleteLabelValues(room)
+		return
+	}
+	wsConnections.WithLabelValues(room).Set(float64(n))
+}
+
+// observeQuery records a store statement's latency by its leading keyword
+func observeQuery(query string, start time.Time) {
+	dbQueryDuration.WithLabelValues(statementKind(query)).Observe(time.Since(start).Seconds())
+}
+
+func statementKind(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	switch kind := strings.ToLower(fields[0]); kind {
+	case "select", "insert", "update", "delete":
+		return kind
+	case "with":
+		return "select"
+	}
+	return "other"
+}
+
+// instrumentedEgress counts egress starts and stops on the wrapped service
+type instrumentedEgress struct {
+	EgressService
+}
+
+func (e instrumentedEgress) StartRoomCompositeEgress(ctx context.Context, req *livekit.RoomCompositeEgressRequest) (*livekit.EgressInfo, error) {
+	info, err := e.EgressService.StartRoomCompositeEgress(ctx, req)
+	egressRequests.WithLabelValues("start", resultLabel(err)).Inc()
+	return info, err
+}
+
+func (e instrumentedEgress) StopEgress(ctx context.Context, req *livekit.StopEgressRequest) (*livekit.EgressInfo, error) {
+	info, err := e.EgressService.StopEgress(ctx, req)
+	egressRequests.WithLabelValues("stop", resultLabel(err)).Inc()
+	return info, err
+}
+
+func resultLabel(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
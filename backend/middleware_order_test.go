@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The tests below pin the order registerRoutes applies middleware in:
+// request ID and CORS first, then rate limits, then auth, with the body
+// limit enforced by Fiber before any of them.
+
+const testOrigin = "http://frontend.test"
+
+func TestCORSHeadersOnUnauthorizedResponse(t *testing.T) {
+	useTestConfig(t)
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	resp := doRequest(t, app, http.MethodGet, "/api/auth/me", nil, "Origin", testOrigin)
+	if resp.Status != http.StatusUnauthorized {
+		t.Fatalf("got %d, want 401", resp.Status)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != testOrigin {
+		t.Errorf("Access-Control-Allow-Origin on 401: got %q, want %q", got, testOrigin)
+	}
+}
+
+func TestRequestIDOnErrorResponses(t *testing.T) {
+	useTestConfig(t)
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	requests := []struct {
+		method, path string
+		body         any
+		want         int
+	}{
+		{http.MethodGet, "/api/auth/me", nil, http.StatusUnauthorized},
+		{http.MethodGet, "/api/no-such-route", nil, http.StatusNotFound},
+		{http.MethodPost, "/api/auth/login", map[string]any{}, http.StatusUnprocessableEntity},
+	}
+	for _, r := range requests {
+		resp := doRequest(t, app, r.method, r.path, r.body)
+		if resp.Status != r.want {
+			t.Errorf("%s %s: got %d, want %d", r.method, r.path, resp.Status, r.want)
+		}
+		if resp.Header.Get(requestIDHeader) == "" {
+			t.Errorf("%s %s: no %s on a %d response", r.method, r.path, requestIDHeader, resp.Status)
+		}
+	}
+}
+
+func TestRateLimitRunsBeforeAuth(t *testing.T) {
+	useTestConfig(t)
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	resp := doRequest(t, app, http.MethodPost, "/api/rooms", map[string]any{})
+	if resp.Status != http.StatusUnauthorized {
+		t.Fatalf("got %d, want 401", resp.Status)
+	}
+	if resp.Header.Get("X-RateLimit-Limit") == "" {
+		t.Error("no rate limit headers on a 401, so auth ran before the limiter")
+	}
+
+	// Once the bucket is empty, unauthenticated requests are refused by the
+	// limiter rather than reaching auth
+	for i := 1; i < 10; i++ {
+		doRequest(t, app, http.MethodPost, "/api/rooms", map[string]any{})
+	}
+	if resp := doRequest(t, app, http.MethodPost, "/api/rooms", map[string]any{}); resp.Status != http.StatusTooManyRequests {
+		t.Errorf("request over the limit: got %d, want 429", resp.Status)
+	}
+}
+
+func TestBodyLimitRejectsBeforeHandler(t *testing.T) {
+	useTestConfig(t)
+	config.HTTPBodyLimit = 1024
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+	called := false
+	app.Post("/test/body-limit", func(c *fiber.Ctx) error {
+		called = true
+		return c.SendStatus(http.StatusNoContent)
+	})
+
+	// app.Test drops the connection instead of answering, so this one goes
+	// over a real socket
+	body := `{"text":"` + strings.Repeat("x", 2048) + `"}`
+	resp, err := http.Post(serveTestApp(t, app)+"/test/body-limit", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body: got %d, want 413", resp.StatusCode)
+	}
+	if called {
+		t.Error("handler ran for an oversized body")
+	}
+}
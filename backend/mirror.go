@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListMirrorTargets returns the rooms that directly mirror a room's transcript
+func ListMirrorTargets(ctx context.Context, sourceRoom string) ([]string, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT target_room FROM transcript_mirrors WHERE source_room = ? ORDER BY target_room",
+		sourceRoom,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []string
+	for rows.Next() {
+		var target string
+		if err := rows.Scan(&target); err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, rows.Err()
+}
+
+// resolveMirrorTargets returns every room reachable from sourceRoom through
+// mirror relationships, excluding sourceRoom itself
+func resolveMirrorTargets(ctx context.Context, sourceRoom string) ([]string, error) {
+	seen := map[string]bool{sourceRoom: true}
+	queue := []string{sourceRoom}
+	var targets []string
+	for len(queue) > 0 {
+		room := queue[0]
+		queue = queue[1:]
+
+		direct, err := ListMirrorTargets(ctx, room)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range direct {
+			if seen[target] {
+				continue
+			}
+			seen[target] = true
+			targets = append(targets, target)
+			queue = append(queue, target)
+		}
+	}
+	return targets, nil
+}
+
+// AddTranscriptMirror makes targetRoom receive sourceRoom's transcript. It
+// returns false without writing if the mirror would create a cycle.
+func AddTranscriptMirror(ctx context.Context, sourceRoom, targetRoom string, createdBy int64) (bool, error) {
+	if sourceRoom == targetRoom {
+		return false, nil
+	}
+	downstream, err := resolveMirrorTargets(ctx, targetRoom)
+	if err != nil {
+		return false, err
+	}
+	for _, room := range downstream {
+		if room == sourceRoom {
+			return false, nil
+		}
+	}
+
+	_, err = execWrite(ctx,
+		"INSERT INTO transcript_mirrors (source_room, target_room, created_by) VALUES (?, ?, ?) ON CONFLICT(source_room, target_room) DO NOTHING",
+		sourceRoom, targetRoom, createdBy,
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveTranscriptMirror stops targetRoom from receiving sourceRoom's transcript
+func RemoveTranscriptMirror(ctx context.Context, sourceRoom, targetRoom string) (bool, error) {
+	result, err := execWrite(ctx,
+		"DELETE FROM transcript_mirrors WHERE source_room = ? AND target_room = ?",
+		sourceRoom, targetRoom,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// broadcastToMirrors forwards an already-encoded transcript to every room
+// mirroring the given room
+func broadcastToMirrors(room string, envelope, legacy []byte) {
+	ctx, cancel := withStatementTimeout(context.Background())
+	defer cancel()
+
+	targets, err := resolveMirrorTargets(ctx, room)
+	if err != nil {
+		slog.Error("Failed to look up transcript mirrors", "room", room, "error", err)
+		return
+	}
+	for _, target := range targets {
+		broadcastToRoom(target, envelope, legacy)
+	}
+}
+
+// Transcript mirror handlers
+
+type TranscriptMirrorRequest struct {
+	TargetRooms []string `json:"targetRooms"`
+}
+
+func (r *TranscriptMirrorRequest) validate(errs *fieldErrors) {
+	if len(r.TargetRooms) == 0 {
+		errs.add("targetRooms", "targetRooms is required")
+	}
+	for i, target := range r.TargetRooms {
+		if strings.TrimSpace(target) == "" {
+			errs.add(fmt.Sprintf("targetRooms[%d]", i), "Target room names must not be empty")
+		}
+	}
+}
+
+type TranscriptMirrorResponse struct {
+	Room        string   `json:"room"`
+	TargetRooms []string `json:"targetRooms"`
+}
+
+func (s *server) addTranscriptMirrorHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	var req TranscriptMirrorRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	userID, _ := c.Locals("userID").(int64)
+	for _, target := range req.TargetRooms {
+		target = strings.TrimSpace(target)
+		added, err := AddTranscriptMirror(ctx, room, target, userID)
+		if err != nil {
+			return respondError(c, 500, err.Error())
+		}
+		if !added {
+			return respondAPIError(c, 400, APIError{
+				Message: "Mirror would create a cycle",
+				Details: fiber.Map{"target": target},
+			})
+		}
+		recordAudit(c, "transcript.mirror_add", room, target)
+	}
+
+	targets, err := ListMirrorTargets(ctx, room)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	return c.JSON(TranscriptMirrorResponse{Room: room, TargetRooms: targets})
+}
+
+func (s *server) removeTranscriptMirrorHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	target := pathParam(c, "target")
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	removed, err := RemoveTranscriptMirror(ctx, room, target)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if !removed {
+		return respondError(c, 404, "Mirror not found")
+	}
+
+	recordAudit(c, "transcript.mirror_remove", room, target)
+	return c.JSON(StatusResponse{Status: "removed"})
+}
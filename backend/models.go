@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"math"
+	"strings"
+)
+
+// modelTokenLimits are the context windows, in tokens, of the models the AI
+// service may report. Notes from a model not listed here are not checked.
+var modelTokenLimits = map[string]int{
+	"claude-sonnet-4-20250514": 200_000,
+	"claude-opus-4-6":          200_000,
+	"gpt-4o":                   128_000,
+	"gpt-4o-mini":              128_000,
+}
+
+// modelPrice is what a model charges per million tokens, in US dollars
+type modelPrice struct {
+	InputPerMTok  float64
+	OutputPerMTok float64
+}
+
+// modelPrices are the list prices of the models in modelTokenLimits
+var modelPrices = map[string]modelPrice{
+	"claude-sonnet-4-20250514": {InputPerMTok: 3, OutputPerMTok: 15},
+	"claude-opus-4-6":          {InputPerMTok: 5, OutputPerMTok: 25},
+	"gpt-4o":                   {InputPerMTok: 2.5, OutputPerMTok: 10},
+	"gpt-4o-mini":              {InputPerMTok: 0.15, OutputPerMTok: 0.6},
+}
+
+// notesCostUSD prices a generation, rounded to a hundredth of a cent. ok is
+// false for models without a price.
+func notesCostUSD(model string, inputTokens, outputTokens int) (cost float64, ok bool) {
+	price, ok := modelPrices[model]
+	if !ok {
+		return 0, false
+	}
+	cost = (float64(inputTokens)*price.InputPerMTok + float64(outputTokens)*price.OutputPerMTok) / 1e6
+	return math.Round(cost*1e4) / 1e4, true
+}
+
+// backfillNotesCosts prices notes saved before total_cost_usd existed
+func backfillNotesCosts(ctx context.Context) error {
+	models := make([]any, 0, len(modelPrices))
+	for model := range modelPrices {
+		models = append(models, model)
+	}
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, model_used, COALESCE(input_tokens, 0), COALESCE(output_tokens, 0) FROM meeting_notes WHERE total_cost_usd IS NULL AND model_used IN (?"+strings.Repeat(", ?", len(models)-1)+")",
+		models...,
+	)
+	if err != nil {
+		return err
+	}
+	pending := map[int64]float64{}
+	for rows.Next() {
+		var id int64
+		var model string
+		var inputTokens, outputTokens int
+		if err := rows.Scan(&id, &model, &inputTokens, &outputTokens); err != nil {
+			rows.Close()
+			return err
+		}
+		pending[id], _ = notesCostUSD(model, inputTokens, outputTokens)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, cost := range pending {
+		if _, err := execWrite(ctx, "UPDATE meeting_notes SET total_cost_usd = ? WHERE id = ?", cost, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Room names are plain ("flying-falcon") unless the host has a namespace, in
+// which case they are qualified as "<namespace>/<name>" so hosts in different
+// tenants can reuse the same friendly name. The qualified form is what is
+// stored, sent to LiveKit, and (URL-escaped) used in paths.
+
+// defaultRoomNameMaxLength is the longest room name accepted, including any namespace
+const defaultRoomNameMaxLength = 64
+
+// roomNamespaceSeparator joins a namespace to a room name
+const roomNamespaceSeparator = "/"
+
+var namespacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// qualifyRoomName prefixes name with namespace, if there is one
+func qualifyRoomName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + roomNamespaceSeparator + name
+}
+
+// validateNamespace checks a host namespace is a short lowercase slug
+func validateNamespace(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("namespace must be 1-32 lowercase letters, digits, or dashes")
+	}
+	return nil
+}
+
+// minRoomNameLength is the shortest room name left after sanitizing
+const minRoomNameLength = 3
+
+var (
+	roomNameDisallowed = regexp.MustCompile(`[^a-z0-9-]+`)
+	roomNameDashes     = regexp.MustCompile(`-{2,}`)
+)
+
+// sanitizeRoomName turns a requested room name into one that is safe in URL
+// paths such as /ws/transcription/:room: lowercase letters, digits, and
+// single dashes, with spaces becoming dashes and anything else dropped
+func sanitizeRoomName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Join(strings.Fields(name), "-")
+	name = roomNameDisallowed.ReplaceAllString(name, "")
+	name = roomNameDashes.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "-")
+	if len(name) < minRoomNameLength {
+		return "", fmt.Errorf("room name must have at least %d letters, digits, or dashes", minRoomNameLength)
+	}
+	return name, nil
+}
+
+// validateRoomName checks a requested room name before it is qualified. The
+// separator is reserved so a name cannot claim another host's namespace.
+func validateRoomName(namespace, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("room name must not be empty")
+	}
+	if strings.Contains(name, roomNamespaceSeparator) {
+		return fmt.Errorf("room name must not contain %q", roomNamespaceSeparator)
+	}
+	if max := config.RoomNameMaxLength; len(qualifyRoomName(namespace, name)) > max {
+		return fmt.Errorf("room name must be at most %d characters", max)
+	}
+	return nil
+}
+
+// paramGetter is satisfied by both fiber and websocket contexts
+type paramGetter interface {
+	Params(key string, defaultValue ...string) string
+}
+
+// roomParam returns the :room path parameter. Qualified names arrive with
+// their separator escaped, since it would otherwise split the path.
+func roomParam(c paramGetter) string {
+	return pathParam(c, "room")
+}
+
+func pathParam(c paramGetter, key string) string {
+	raw := c.Params(key)
+	if v, err := url.PathUnescape(raw); err == nil {
+		return v
+	}
+	return raw
+}
+
+// GetUserNamespace returns the room namespace of a host, or "" if it has none
+func GetUserNamespace(ctx context.Context, userID int64) (string, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var namespace sql.NullString
+	err := db.QueryRowContext(ctx, "SELECT namespace FROM users WHERE id = ?", userID).Scan(&namespace)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return namespace.String, err
+}
+
+// SetUserNamespace sets or, with "", clears a host's room namespace. It
+// reports false if the user does not exist.
+func SetUserNamespace(ctx context.Context, userID int64, namespace string) (bool, error) {
+	var value interface{}
+	if namespace != "" {
+		value = namespace
+	}
+	result, err := execWrite(ctx, "UPDATE users SET namespace = ? WHERE id = ?", value, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// hostNamespace returns the namespace for rooms created by the signed-in user
+func (s *server) hostNamespace(c *fiber.Ctx) (string, error) {
+	userID, ok := c.Locals("userID").(int64)
+	if !ok {
+		return "", nil
+	}
+	return s.store.GetUserNamespace(c.UserContext(), userID)
+}
+
+type SetUserNamespaceRequest struct {
+	Namespace string `json:"namespace"` // empty clears it
+}
+
+// validate normalizes the namespace before checking it
+func (r *SetUserNamespaceRequest) validate(errs *fieldErrors) {
+	r.Namespace = strings.ToLower(strings.TrimSpace(r.Namespace))
+	if r.Namespace == "" {
+		return
+	}
+	if err := validateNamespace(r.Namespace); err != nil {
+		errs.add("namespace", err.Error())
+	}
+}
+
+type SetUserNamespaceResponse struct {
+	ID        int64  `json:"id"`
+	Namespace string `json:"namespace"`
+}
+
+func (s *server) setUserNamespaceHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	var id int64
+	if _, err := fmt.Sscanf(c.Params("id"), "%d", &id); err != nil {
+		return respondError(c, 400, "Invalid ID")
+	}
+
+	var req SetUserNamespaceRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	found, err := s.store.SetUserNamespace(ctx, id, req.Namespace)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if !found {
+		return respondError(c, 404, "User not found")
+	}
+
+	recordAudit(c, "user.namespace", fmt.Sprintf("user:%d", id), req.Namespace)
+
+	return c.JSON(SetUserNamespaceResponse{ID: id, Namespace: req.Namespace})
+}
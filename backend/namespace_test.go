@@ -0,0 +1,62 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSanitizeRoomName(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"Standup", "standup"},
+		{"  Weekly   Sync  ", "weekly-sync"},
+		{"team/../admin", "teamadmin"},
+		{"Q3 — Planning!", "q3-planning"},
+		{"--a--b--", "a-b"},
+		{"café crème", "caf-crme"},
+	} {
+		got, err := sanitizeRoomName(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("sanitizeRoomName(%q): got %q, %v, want %q", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"", "  ", "ab", "!!!", "a/b", "---", "日本語"} {
+		if got, err := sanitizeRoomName(in); err == nil {
+			t.Errorf("sanitizeRoomName(%q): got %q, want an error", in, got)
+		}
+	}
+}
+
+var sanitizedRoomName = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func FuzzSanitizeRoomName(f *testing.F) {
+	for _, seed := range []string{
+		"Standup",
+		"  Weekly   Sync  ",
+		"team/../admin",
+		"room?name=x#frag",
+		"%2e%2e%2f",
+		"a\tb\nc\r\x00d",
+		"--a--b--",
+		"café crème",
+		"日本語の会議",
+		"\u00a0nbsp\u2003em",
+		"ab",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		got, err := sanitizeRoomName(in)
+		if err != nil {
+			return
+		}
+		if len(got) < minRoomNameLength {
+			t.Errorf("sanitizeRoomName(%q) = %q, shorter than %d", in, got, minRoomNameLength)
+		}
+		if !sanitizedRoomName.MatchString(got) {
+			t.Errorf("sanitizeRoomName(%q) = %q, not lowercase letters, digits, and single dashes", in, got)
+		}
+		if again, err := sanitizeRoomName(got); err != nil || again != got {
+			t.Errorf("sanitizeRoomName(%q) = %q, %v, want it unchanged", got, again, err)
+		}
+	})
+}
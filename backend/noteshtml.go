@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Notes are also served as HTML, rendered once with goldmark and cached in
+// meeting_notes.notes_html whenever the markdown is written. Raw HTML in the
+// markdown is dropped rather than passed through, since the AI service
+// writes it from what participants said.
+
+// notesHTMLBackfillBatch is how many notes backfillNotesHTML renders per query
+const notesHTMLBackfillBatch = 100
+
+// notesMarkdown renders GitHub flavoured markdown: tables, task lists, and
+// strikethrough all appear in generated notes
+var notesMarkdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+func renderNotesHTML(markdown string) (string, error) {
+	var buf bytes.Buffer
+	if err := notesMarkdown.Convert([]byte(markdown), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// GetNotesHTML returns the rendered HTML of a notes revision, rendering and
+// caching it if it was saved before the cache existed
+func GetNotesHTML(ctx context.Context, notes *MeetingNotes) (string, error) {
+	var cached sql.NullString
+	qctx, cancel := withStatementTimeout(ctx)
+	err := db.QueryRowContext(qctx, "SELECT notes_html FROM meeting_notes WHERE id = ?", notes.ID).Scan(&cached)
+	cancel()
+	if err != nil {
+		return "", notFound(err)
+	}
+	if cached.Valid {
+		return cached.String, nil
+	}
+
+	html, err := renderNotesHTML(notes.Markdown)
+	if err != nil {
+		return "", err
+	}
+	// Only fill an empty cache, so an edit saved meanwhile keeps its own HTML
+	if _, err := execWrite(ctx,
+		"UPDATE meeting_notes SET notes_html = ? WHERE id = ? AND notes_html IS NULL AND etag = ?",
+		html, notes.ID, notes.ETag,
+	); err != nil {
+		ctxLogger(ctx).Warn("Failed to cache notes HTML", "notes_id", notes.ID, "error", err)
+	}
+	return html, nil
+}
+
+// backfillNotesHTML renders and caches HTML for notes saved before the
+// column existed, a batch at a time so a large table is never held in memory
+func backfillNotesHTML(ctx context.Context) error {
+	var lastID int64
+	for {
+		rows, err := db.QueryContext(ctx,
+			"SELECT id, notes_markdown FROM meeting_notes WHERE notes_html IS NULL AND id > ? ORDER BY id LIMIT ?",
+			lastID, notesHTMLBackfillBatch,
+		)
+		if err != nil {
+			return err
+		}
+		pending := map[int64]string{}
+		for rows.Next() {
+			var id int64
+			var markdown string
+			if err := rows.Scan(&id, &markdown); err != nil {
+				rows.Close()
+				return err
+			}
+			pending[id] = markdown
+			lastID = max(lastID, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for id, markdown := range pending {
+			html, err := renderNotesHTML(markdown)
+			if err != nil {
+				return err
+			}
+			if _, err := execWrite(ctx, "UPDATE meeting_notes SET notes_html = ? WHERE id = ?", html, id); err != nil {
+				return err
+			}
+		}
+		if len(pending) < notesHTMLBackfillBatch {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"math"
+	"strings"
+)
+
+// readingWordsPerMinute is the average adult silent reading speed
+const readingWordsPerMinute = 238
+
+// notesStats are the figures derived from a notes document for list and detail views
+type notesStats struct {
+	WordCount      int
+	ReadingTimeS   int
+	CodeBlockCount int
+	HeadingCount   int
+}
+
+func computeNotesStats(markdown string) notesStats {
+	stats := notesStats{
+		WordCount:      len(strings.Fields(markdown)),
+		CodeBlockCount: strings.Count(markdown, "```") / 2,
+	}
+	stats.ReadingTimeS = int(math.Round(float64(stats.WordCount) * 60 / readingWordsPerMinute))
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			stats.HeadingCount++
+		}
+	}
+	return stats
+}
+
+// readingTimeMinutes converts a stored reading time to minutes, to two decimal places
+func readingTimeMinutes(seconds int) float64 {
+	return math.Round(float64(seconds)/0.6) / 100
+}
+
+// applyNotesStats fills the computed fields of notes from its markdown
+func applyNotesStats(notes *MeetingNotes) {
+	stats := computeNotesStats(notes.Markdown)
+	notes.WordCount = stats.WordCount
+	notes.ReadingTimeMinutes = readingTimeMinutes(stats.ReadingTimeS)
+	notes.CodeBlockCount = stats.CodeBlockCount
+	notes.HeadingCount = stats.HeadingCount
+}
+
+// backfillNotesStats caches word counts and reading times for notes saved
+// before the columns existed
+func backfillNotesStats(ctx context.Context) error {
+	rows, err := db.QueryContext(ctx, "SELECT id, notes_markdown FROM meeting_notes WHERE word_count IS NULL")
+	if err != nil {
+		return err
+	}
+	pending := map[int64]notesStats{}
+	for rows.Next() {
+		var id int64
+		var markdown string
+		if err := rows.Scan(&id, &markdown); err != nil {
+			rows.Close()
+			return err
+		}
+		pending[id] = computeNotesStats(markdown)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, stats := range pending {
+		if _, err := execWrite(ctx,
+			"UPDATE meeting_notes SET word_count = ?, reading_time_s = ? WHERE id = ?",
+			stats.WordCount, stats.ReadingTimeS, id,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,577 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The OpenAPI document is generated at startup from apiOperations and the Go
+// types the handlers decode and encode, so field names and types cannot drift
+// from the code. Every route registered on the app must have an entry in
+// apiOperations: a missing one is logged at startup, and
+// `nexus-backend openapi` (which prints the document) exits non-zero, so CI
+// can run it as a check.
+
+// API_DOCS values
+const (
+	apiDocsPublic = "public"
+	apiDocsAuth   = "auth"
+	apiDocsOff    = "off"
+)
+
+// apiOperation documents one route
+type apiOperation struct {
+	Method   string
+	Path     string // Fiber pattern, as registered
+	Tag      string
+	Summary  string
+	Security []map[string][]string // nil for open routes
+	Query    []apiQueryParam
+	Request  any    // JSON request body, nil for none
+	Response any    // JSON success body
+	Produces string // content type of a non-JSON success body
+	// WebSocket marks an upgrade endpoint, described by the x-websocket
+	// extension since OpenAPI has no way to express one
+	WebSocket bool
+}
+
+// apiQueryParam documents a query string parameter
+type apiQueryParam struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// Security requirements. Alternatives are ORed, and an empty requirement
+// makes the credential optional.
+var (
+	secUser        = []map[string][]string{{"bearerAuth": {}}}
+	secUserOrKey   = []map[string][]string{{"bearerAuth": {}}, {"roomApiKey": {}}}
+	secOptionalKey = []map[string][]string{{}, {"roomApiKey": {}}}
+	secOptional    = []map[string][]string{{}, {"bearerAuth": {}}, {"roomApiKey": {}}}
+	secInternal    = []map[string][]string{{"internalSecret": {}}}
+	secN8N         = []map[string][]string{{"n8nSignature": {}}}
+	secLiveKit     = []map[string][]string{{"liveKitWebhook": {}}}
+	secDebug       = []map[string][]string{{"bearerAuth": {}}, {"debugToken": {}}}
+)
+
+// apiOperations lists every route the backend registers
+var apiOperations = []apiOperation{
+	// Health
+	{Method: "GET", Path: "/health", Tag: "health", Summary: "Liveness check", Response: HealthResponse{}},
+	{Method: "GET", Path: "/api/version", Tag: "health", Summary: "Build and schema version", Response: VersionResponse{}},
+	{Method: "GET", Path: "/healthz/ready", Tag: "health", Summary: "Readiness of the database and LiveKit; 503 with the same body when either is down", Response: ReadyResponse{}},
+	{Method: "GET", Path: "/metrics", Tag: "health", Summary: "Prometheus metrics, unless METRICS_ADDR serves them elsewhere", Produces: "text/plain"},
+	{Method: "GET", Path: "/debug/vars", Tag: "health", Summary: "Runtime and hub counters (admin); the Go profiler is under /debug/pprof/ when DEBUG_ENDPOINTS is set", Security: secDebug, Response: DebugVars{}},
+	{Method: "GET", Path: "/api/openapi.json", Tag: "health", Summary: "This document; open to everyone when API_DOCS=public", Security: secUser, Produces: "application/json"},
+	{Method: "GET", Path: "/api/docs", Tag: "health", Summary: "Interactive API reference", Produces: "text/html"},
+
+	// Auth
+	{Method: "POST", Path: "/api/auth/login", Tag: "auth", Summary: "Sign in", Request: LoginRequest{}, Response: LoginResponse{}},
+	{Method: "GET", Path: "/api/auth/me", Tag: "auth", Summary: "Current user", Security: secUser, Response: UserSummary{}},
+	{Method: "POST", Path: "/api/auth/verify", Tag: "auth", Summary: "Check a session token for a trusted service", Security: secInternal, Request: VerifyTokenRequest{}, Response: VerifyTokenResponse{}},
+
+	// Rooms
+	{Method: "POST", Path: "/api/rooms", Tag: "rooms", Summary: "Create a room", Security: secUser, Request: CreateRoomRequest{}, Response: CreateRoomResponse{}},
+	{Method: "POST", Path: "/api/token", Tag: "rooms", Summary: "LiveKit access token for joining a room; hosts skip the join PIN", Security: secOptional, Request: TokenRequest{}, Response: TokenResponse{}},
+	{Method: "GET", Path: "/api/languages", Tag: "rooms", Summary: "Supported transcription languages", Response: LanguagesResponse{}},
+	{Method: "GET", Path: "/api/rooms/suggest-name", Tag: "rooms", Summary: "Suggest unused room names", Security: secUser,
+		Query: []apiQueryParam{{"count", "integer", "How many names to suggest"}}, Response: RoomNameSuggestions{}},
+	{Method: "GET", Path: "/api/rooms/:id", Tag: "rooms", Summary: "Live room details", Response: RoomInfo{}},
+	{Method: "GET", Path: "/api/join/:room", Tag: "rooms", Summary: "What a guest needs to join a room", Response: JoinInfo{}},
+	{Method: "GET", Path: "/api/join/slug/:slug", Tag: "rooms", Summary: "What a guest needs to join a room, found by its vanity slug", Response: JoinInfo{}},
+
+	// Scheduled meetings
+	{Method: "POST", Path: "/api/scheduled-meetings", Tag: "scheduled-meetings", Summary: "Schedule a meeting", Security: secUser, Request: CreateScheduledMeetingRequest{}, Response: ScheduledMeetingResponse{}},
+	{Method: "GET", Path: "/api/scheduled-meetings", Tag: "scheduled-meetings", Summary: "The signed-in host's scheduled meetings", Security: secUser, Response: []ScheduledMeetingResponse{}},
+	{Method: "PATCH", Path: "/api/scheduled-meetings/:id", Tag: "scheduled-meetings", Summary: "Reschedule a meeting", Security: secUser, Request: RescheduleMeetingRequest{}, Response: ScheduledMeetingResponse{}},
+	{Method: "DELETE", Path: "/api/scheduled-meetings/:id", Tag: "scheduled-meetings", Summary: "Cancel a meeting", Security: secUser, Response: StatusResponse{}},
+	{Method: "POST", Path: "/api/scheduled-meetings/bulk-cancel", Tag: "scheduled-meetings", Summary: "Cancel the host's scheduled meetings in a date range; dryRun previews them", Security: secUser, Request: BulkCancelScheduledMeetingsRequest{}, Response: BulkCancelScheduledMeetingsResponse{}},
+	{Method: "POST", Path: "/api/scheduled-meetings/:id/start", Tag: "scheduled-meetings", Summary: "Open the meeting's room", Security: secUser, Response: StartScheduledMeetingResponse{}},
+	{Method: "POST", Path: "/api/scheduled-meetings/:id/transfer", Tag: "scheduled-meetings", Summary: "Hand the meeting to another host", Security: secUser, Request: TransferScheduledMeetingRequest{}, Response: TransferScheduledMeetingResponse{}},
+	{Method: "GET", Path: "/api/scheduled-meetings/:id/qr-code", Tag: "scheduled-meetings", Summary: "QR code of the join link", Security: secUser,
+		Query: []apiQueryParam{{"size", "integer", "Image width and height in pixels"}}, Produces: "image/png"},
+
+	// Meetings
+	{Method: "GET", Path: "/api/meetings", Tag: "meetings", Summary: "Meetings that have notes", Query: []apiQueryParam{
+		{"sort", "string", "generatedAt, createdAt, or roomName"},
+		{"order", "string", "asc or desc (default)"},
+		{"limit", "integer", "Most meetings to return"},
+	}, Response: []MeetingListItem{}},
+	{Method: "POST", Path: "/api/meetings/:room/end", Tag: "meetings", Summary: "End the meeting for everyone", Security: secUserOrKey, Response: EndMeetingResponse{}},
+	{Method: "GET", Path: "/api/meetings/:room/participants", Tag: "meetings", Summary: "Attendance", Security: secUserOrKey, Response: ParticipantsResponse{}},
+	{Method: "GET", Path: "/api/meetings/:room/stats", Tag: "meetings", Summary: "Talk time and transcript statistics", Security: secUserOrKey, Response: MeetingStats{}},
+	{Method: "GET", Path: "/api/stats/overview", Tag: "meetings", Summary: "Totals across a host's meetings", Security: secUser,
+		Query: []apiQueryParam{{"host", "integer", "Another host's user ID (admins only)"}}, Response: HostOverview{}},
+	{Method: "POST", Path: "/api/meetings/:room/breakout", Tag: "meetings", Summary: "Split participants into breakout rooms", Security: secUserOrKey, Request: StartBreakoutRequest{}, Response: StartBreakoutResponse{}},
+	{Method: "POST", Path: "/api/meetings/:room/breakout/end", Tag: "meetings", Summary: "Bring everyone back from breakout rooms", Security: secUserOrKey, Response: EndBreakoutResponse{}},
+	{Method: "GET", Path: "/api/meetings/:room/export.zip", Tag: "meetings", Summary: "Notes and transcript as a ZIP archive", Security: secUserOrKey, Produces: "application/zip"},
+	{Method: "GET", Path: "/api/meetings/:room/export", Tag: "meetings", Summary: "Full meeting bundle as a ZIP archive", Security: secUserOrKey,
+		Query: []apiQueryParam{{"includeAudio", "boolean", "Include the recording"}}, Produces: "application/zip"},
+	{Method: "POST", Path: "/api/meetings/:room/consent", Tag: "meetings", Summary: "Record a participant's recording consent", Request: ConsentRequest{}, Response: ConsentResponse{}},
+	{Method: "POST", Path: "/api/meetings/:room/acknowledge", Tag: "meetings", Summary: "Acknowledge the recording notice", Request: AcknowledgeRequest{}, Response: AcknowledgeResponse{}},
+	{Method: "GET", Path: "/api/meetings/:room/acknowledgements", Tag: "meetings", Summary: "Who acknowledged the recording notice", Security: secUserOrKey, Response: AcknowledgementsResponse{}},
+
+	// Notes
+	{Method: "POST", Path: "/api/meetings/:room/notes", Tag: "notes", Summary: "Store generated notes; 422 if inputTokens exceed the model's context window", Request: SaveNotesRequest{}, Response: SaveNotesResponse{}},
+	{Method: "GET", Path: "/api/meetings/:room/notes", Tag: "notes", Summary: "Latest notes", Security: secOptionalKey,
+		Query: []apiQueryParam{{"format", "string", "raw (default) for the notes as JSON, or html for the rendered markdown as text/html"}}, Response: MeetingNotes{}},
+	{Method: "PATCH", Path: "/api/meetings/:room/notes/:id", Tag: "notes", Summary: "Edit notes; send If-Match with the ETag to avoid overwriting", Security: secUser, Request: UpdateNotesRequest{}, Response: MeetingNotes{}},
+	{Method: "GET", Path: "/api/meetings/:room/notes/draft", Tag: "notes", Summary: "Notes drafted while the meeting runs", Security: secOptionalKey, Response: NotesDraft{}},
+	{Method: "POST", Path: "/api/meetings/:room/auto-summary/enable", Tag: "notes", Summary: "Email the summary when the meeting ends", Security: secUserOrKey, Response: AutoSummaryResponse{}},
+	{Method: "POST", Path: "/api/meetings/:room/auto-summary/disable", Tag: "notes", Summary: "Stop emailing the summary automatically", Security: secUserOrKey, Response: AutoSummaryResponse{}},
+
+	// Email
+	{Method: "POST", Path: "/api/meetings/:room/subscribe-email", Tag: "email", Summary: "Subscribe to the meeting summary", Request: SubscribeEmailRequest{}, Response: SubscribeEmailResponse{}},
+	{Method: "GET", Path: "/api/meetings/:room/email-subscriptions", Tag: "email", Summary: "Summary subscribers, oldest first", Query: []apiQueryParam{
+		{"validate", "boolean", "Also list addresses that fail validation"},
+		{"limit", "integer", "Page size; all subscribers when neither limit nor offset is given"},
+		{"offset", "integer", "Subscribers to skip"},
+	}, Response: EmailSubscriptionsResponse{}},
+	{Method: "DELETE", Path: "/api/meetings/:room/unsubscribe-email", Tag: "email", Summary: "Unsubscribe from the meeting summary", Request: UnsubscribeEmailRequest{}, Response: UnsubscribeEmailResponse{}},
+	{Method: "GET", Path: "/api/subscriptions/:email/history", Tag: "email", Summary: "An address's subscriptions across all meetings, newest first; your own unless you are an admin", Security: secUser, Query: []apiQueryParam{
+		{"limit", "integer", "Page size"},
+		{"cursor", "string", "nextCursor from the previous page"},
+	}, Response: SubscriptionHistoryPage{}},
+	{Method: "DELETE", Path: "/api/subscriptions/:email", Tag: "email", Summary: "Remove an address's subscriptions to every meeting", Security: secUser, Response: DeleteSubscriptionsResponse{}},
+	{Method: "GET", Path: "/api/meetings/:room/email-log", Tag: "email", Summary: "Summary email delivery attempts", Security: secUserOrKey,
+		Query: []apiQueryParam{{"email", "string", "Only this recipient"}}, Response: EmailLogResponse{}},
+	{Method: "POST", Path: "/api/internal/email-bounce", Tag: "email", Summary: "Report a bounced address", Security: secInternal, Request: EmailBounceRequest{}, Response: BounceResponse{}},
+	{Method: "POST", Path: "/api/webhooks/n8n/callback", Tag: "email", Summary: "Delivery result from the n8n workflow", Security: secN8N, Request: N8NCallbackPayload{}, Response: BounceResponse{}},
+
+	// Transcription
+	{Method: "POST", Path: "/api/meetings/:room/start-transcription", Tag: "transcription", Summary: "Start the transcription agent; 503 while the AI service is down", Security: secOptionalKey, Request: StartTranscriptionRequest{}, Response: TranscriptionResponse{}},
+	{Method: "POST", Path: "/api/meetings/:room/end-transcription", Tag: "transcription", Summary: "Stop transcribing and generate notes", Security: secOptionalKey, Response: TranscriptionResponse{}},
+	{Method: "POST", Path: "/api/internal/transcript", Tag: "transcription", Summary: "Transcript segment from the AI service", Security: secInternal, Request: TranscriptMessage{}, Response: StatusResponse{}},
+	{Method: "POST", Path: "/api/internal/speakers", Tag: "transcription", Summary: "Active speakers from the AI service", Security: secInternal, Request: SpeakersMessage{}, Response: StatusResponse{}},
+	{Method: "POST", Path: "/api/meetings/:room/transcript/mirror", Tag: "transcription", Summary: "Mirror the live transcript into other rooms", Security: secUser, Request: TranscriptMirrorRequest{}, Response: TranscriptMirrorResponse{}},
+	{Method: "DELETE", Path: "/api/meetings/:room/transcript/mirror/:target", Tag: "transcription", Summary: "Stop mirroring into a room", Security: secUser, Response: StatusResponse{}},
+	{Method: "GET", Path: "/api/meetings/:room/speaker-map", Tag: "transcription", Summary: "Names given to the AI service's speaker labels", Security: secUserOrKey, Response: SpeakerMapResponse{}},
+	{Method: "POST", Path: "/api/meetings/:room/speaker-map", Tag: "transcription", Summary: "Name speaker labels", Security: secUserOrKey, Request: SpeakerMapRequest{}, Response: SpeakerMapResponse{}},
+	{Method: "GET", Path: "/ws/transcription/:room", Tag: "transcription", Summary: "Live transcript and room events", WebSocket: true,
+		Query: []apiQueryParam{{"v", "integer", "2 for enveloped events; omit for bare transcript messages"}}},
+
+	// Recording
+	{Method: "POST", Path: "/api/meetings/:room/start-recording", Tag: "recording", Summary: "Start recording", Security: secOptionalKey, Response: StartRecordingResponse{}},
+	{Method: "POST", Path: "/api/meetings/:room/start-capture", Tag: "recording", Summary: "Start recording and transcription together", Security: secOptionalKey, Request: StartTranscriptionRequest{}, Response: StartCaptureResponse{}},
+	{Method: "POST", Path: "/api/meetings/:room/stop-recording", Tag: "recording", Summary: "Stop recording", Security: secOptionalKey, Response: RecordingStatusResponse{}},
+	{Method: "POST", Path: "/api/meetings/:room/pause-recording", Tag: "recording", Summary: "Pause recording; ends the current egress", Security: secOptionalKey, Response: PauseRecordingResponse{}},
+	{Method: "POST", Path: "/api/meetings/:room/resume-recording", Tag: "recording", Summary: "Resume a paused recording in a new egress", Security: secOptionalKey, Response: ResumeRecordingResponse{}},
+	{Method: "GET", Path: "/api/meetings/:room/recording-status", Tag: "recording", Summary: "Recording state", Security: secOptionalKey,
+		Query: []apiQueryParam{{"live", "boolean", "Also ask LiveKit and reconcile"}}, Response: RecordingStatusResponse{}},
+	{Method: "GET", Path: "/api/meetings/:room/capture-status", Tag: "recording", Summary: "Recording and transcription state", Security: secOptionalKey, Response: CaptureStatus{}},
+	{Method: "POST", Path: "/api/meetings/:room/recording-lock/enable", Tag: "recording", Summary: "Keep participants from stopping the recording", Security: secUserOrKey, Response: RecordingLockResponse{}},
+	{Method: "POST", Path: "/api/meetings/:room/recording-lock/disable", Tag: "recording", Summary: "Let participants stop the recording", Security: secUserOrKey, Response: RecordingLockResponse{}},
+	{Method: "GET", Path: "/api/recordings/:id/audio", Tag: "recording", Summary: "Recording audio behind a signed link; may redirect", Query: []apiQueryParam{
+		{"expires", "integer", "Link expiry, Unix seconds"},
+		{"sig", "string", "Link signature"},
+	}, Produces: "audio/ogg"},
+	{Method: "POST", Path: "/api/webhooks/livekit", Tag: "recording", Summary: "LiveKit server events", Security: secLiveKit, Response: StatusResponse{}},
+
+	// Admin
+	{Method: "GET", Path: "/api/admin/users", Tag: "admin", Summary: "All users", Security: secUser, Response: []User{}},
+	{Method: "POST", Path: "/api/admin/users/:id/active", Tag: "admin", Summary: "Enable or disable a user", Security: secUser, Request: SetUserActiveRequest{}, Response: SetUserActiveResponse{}},
+	{Method: "POST", Path: "/api/admin/users/:id/namespace", Tag: "admin", Summary: "Set a user's room name prefix", Security: secUser, Request: SetUserNamespaceRequest{}, Response: SetUserNamespaceResponse{}},
+	{Method: "GET", Path: "/api/admin/data-export/:email", Tag: "admin", Summary: "Everything stored against an email address", Security: secUser, Response: DataExport{}},
+	{Method: "DELETE", Path: "/api/admin/data/:email", Tag: "admin", Summary: "Erase an email address's data", Security: secUser, Response: DataErasure{}},
+	{Method: "GET", Path: "/api/admin/suppressions", Tag: "admin", Summary: "Suppressed email addresses", Security: secUser, Response: SuppressionsResponse{}},
+	{Method: "DELETE", Path: "/api/admin/suppressions/:email", Tag: "admin", Summary: "Lift a suppression", Security: secUser, Response: StatusResponse{}},
+	{Method: "POST", Path: "/api/admin/n8n/test", Tag: "admin", Summary: "Render the n8n payload with sample data", Security: secUser, Response: N8NPayloadTestResponse{}},
+	{Method: "GET", Path: "/api/admin/audit", Tag: "admin", Summary: "Audit log", Security: secUser, Query: []apiQueryParam{
+		{"action", "string", "Only this action"},
+		{"target", "string", "Only this target"},
+		{"actor", "integer", "Only this user ID"},
+		{"since", "string", "RFC 3339 lower bound"},
+		{"until", "string", "RFC 3339 upper bound"},
+		{"limit", "integer", "Page size"},
+		{"offset", "integer", "Entries to skip"},
+	}, Response: AuditPage{}},
+	{Method: "GET", Path: "/api/admin/config", Tag: "admin", Summary: "Effective configuration, secrets redacted", Security: secUser, Response: AdminConfigResponse{}},
+	{Method: "POST", Path: "/api/admin/retention/run", Tag: "admin", Summary: "Purge data past its retention period", Security: secUser,
+		Query: []apiQueryParam{
+			{"dryRun", "boolean", "Only count what would be purged (default true)"},
+			{"confirm", "boolean", "Must be true to purge with dryRun=false"},
+		}, Response: RetentionRun{}},
+	{Method: "POST", Path: "/api/admin/backup", Tag: "admin", Summary: "Back up the database now", Security: secUser, Response: BackupResult{}},
+	{Method: "GET", Path: "/api/admin/overview", Tag: "admin", Summary: "Rooms, transcriptions, recordings, queues, and recent failures at a glance", Security: secUser, Response: AdminOverviewResponse{}},
+	{Method: "GET", Path: "/api/admin/egress", Tag: "admin", Summary: "Active egresses and unfinished recordings, with orphans flagged", Security: secUser, Response: EgressListResponse{}},
+	{Method: "POST", Path: "/api/admin/egress/reconcile", Tag: "admin", Summary: "Settle orphaned recordings and, optionally, untracked egresses", Security: secUser, Request: ReconcileEgressRequest{}, Response: ReconcileEgressResponse{}},
+	{Method: "POST", Path: "/api/admin/meetings/:room/legal-hold", Tag: "admin", Summary: "Exempt a meeting from retention", Security: secUser, Response: LegalHoldResponse{}},
+	{Method: "DELETE", Path: "/api/admin/meetings/:room/legal-hold", Tag: "admin", Summary: "Release a legal hold", Security: secUser, Response: LegalHoldResponse{}},
+	{Method: "POST", Path: "/api/admin/meetings/:room/api-key", Tag: "admin", Summary: "Issue a room API key", Security: secUser, Request: CreateRoomAPIKeyRequest{}, Response: CreateRoomAPIKeyResponse{}},
+	{Method: "GET", Path: "/api/ai-service/health", Tag: "admin", Summary: "The AI service's health, checked at most every 10 seconds; 503 with the same body when it is down", Security: secUser, Response: AIServiceHealthResponse{}},
+}
+
+// fiberParam matches a Fiber path parameter
+var fiberParam = regexp.MustCompile(`:(\w+)`)
+
+// openAPIPath converts a Fiber pattern to an OpenAPI path template
+func openAPIPath(path string) string {
+	return fiberParam.ReplaceAllString(path, "{$1}")
+}
+
+// schemaBuilder derives JSON schemas from Go types, collecting named structs
+// under components/schemas
+type schemaBuilder struct {
+	components map[string]any
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schema returns the schema encoding/json produces for t
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawJSONType:
+		return map[string]any{}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		if _, ok := b.components[t.Name()]; !ok {
+			b.components[t.Name()] = map[string]any{} // placeholder while recursing
+			b.components[t.Name()] = b.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	// Interfaces and anything else: any value
+	return map[string]any{}
+}
+
+// object returns the schema of struct t. Fields without omitempty are always
+// present in the encoded object, so they are listed as required.
+func (b *schemaBuilder) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	b.fields(t, properties, &required)
+	s := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func (b *schemaBuilder) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			b.fields(f.Type, properties, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = b.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// buildOpenAPISpec assembles the OpenAPI document
+func buildOpenAPISpec() map[string]any {
+	b := &schemaBuilder{components: map[string]any{}}
+	errorSchema := b.schema(reflect.TypeOf(ErrorResponse{}))
+
+	paths := map[string]map[string]any{}
+	for _, op := range apiOperations {
+		path := openAPIPath(op.Path)
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(op.Method)] = b.operation(op)
+	}
+
+	spec := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Boom backend API",
+			"version": "1.0",
+			"description": "Errors are returned as {\"error\": {\"code\", \"message\"}} with a 4xx or 5xx status. " +
+				"A request with invalid fields gets a 422 with code validation_failed and a fields list. " +
+				"Requests are rate limited per client IP; a 429 carries Retry-After in seconds.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": b.components,
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "Error",
+					"content": map[string]any{
+						"application/json": map[string]any{"schema": errorSchema},
+					},
+				},
+			},
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type": "http", "scheme": "bearer", "bearerFormat": "JWT",
+					"description": "Session token from /api/auth/login. Admin routes also need an address in BOOM_ADMIN_EMAILS.",
+				},
+				"roomApiKey": map[string]any{
+					"type": "apiKey", "in": "header", "name": roomAPIKeyHeader,
+					"description": "Room API key, limited to its room and permissions",
+				},
+				"internalSecret": map[string]any{
+					"type": "apiKey", "in": "header", "name": "X-Internal-Secret",
+					"description": "INTERNAL_API_SECRET",
+				},
+				"n8nSignature": map[string]any{
+					"type": "apiKey", "in": "header", "name": "X-N8N-Signature",
+					"description": "sha256= followed by the hex HMAC-SHA256 of the body, keyed with N8N_CALLBACK_SECRET",
+				},
+				"liveKitWebhook": map[string]any{
+					"type": "apiKey", "in": "header", "name": "Authorization",
+					"description": "Token LiveKit signs each webhook with",
+				},
+				"debugToken": map[string]any{
+					"type": "apiKey", "in": "header", "name": debugTokenHeader,
+					"description": "DEBUG_TOKEN",
+				},
+			},
+		},
+	}
+	if config.PublicBackendURL != "" {
+		spec["servers"] = []map[string]any{{"url": config.PublicBackendURL}}
+	}
+	return spec
+}
+
+// operation returns the OpenAPI operation object for op
+func (b *schemaBuilder) operation(op apiOperation) map[string]any {
+	var params []map[string]any
+	for _, m := range fiberParam.FindAllStringSubmatch(op.Path, -1) {
+		typ := "string"
+		if m[1] == "id" {
+			typ = "integer"
+		}
+		params = append(params, map[string]any{
+			"name": m[1], "in": "path", "required": true,
+			"schema": map[string]any{"type": typ},
+		})
+	}
+	for _, q := range op.Query {
+		params = append(params, map[string]any{
+			"name": q.Name, "in": "query", "description": q.Description,
+			"schema": map[string]any{"type": q.Type},
+		})
+	}
+
+	responses := map[string]any{
+		"default": map[string]any{"$ref": "#/components/responses/Error"},
+	}
+	switch {
+	case op.WebSocket:
+		responses["101"] = map[string]any{"description": "Switching Protocols"}
+	case op.Produces != "":
+		responses["200"] = map[string]any{
+			"description": "OK",
+			"content": map[string]any{
+				op.Produces: map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}},
+			},
+		}
+	default:
+		responses["200"] = map[string]any{
+			"description": "OK",
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": b.schema(reflect.TypeOf(op.Response))},
+			},
+		}
+	}
+
+	o := map[string]any{
+		"summary":   op.Summary,
+		"tags":      []string{op.Tag},
+		"responses": responses,
+	}
+	if op.Security != nil {
+		o["security"] = op.Security
+	}
+	if params != nil {
+		o["parameters"] = params
+	}
+	if op.Request != nil {
+		o["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": b.schema(reflect.TypeOf(op.Request))},
+			},
+		}
+	}
+	if op.WebSocket {
+		o["x-websocket"] = b.webSocketExtension()
+	}
+	return o
+}
+
+// webSocketExtension describes the transcription WebSocket's messages
+func (b *schemaBuilder) webSocketExtension() map[string]any {
+	return map[string]any{
+		"description": "Server-to-client only. With v=2 every message is an envelope whose type names the payload; " +
+			"without it, clients receive bare transcript payloads and no other events.",
+		"envelope": b.schema(reflect.TypeOf(WSEnvelope{})),
+		"events": map[string]any{
+			EventTranscript:    b.schema(reflect.TypeOf(TranscriptEvent{})),
+			EventStatus:        b.schema(reflect.TypeOf(StatusEvent{})),
+			EventSpeakerActive: b.schema(reflect.TypeOf(SpeakerActiveEvent{})),
+			EventParticipant:   b.schema(reflect.TypeOf(ParticipantEvent{})),
+		},
+	}
+}
+
+// undocumentedRoutes lists routes registered on app that apiOperations does
+// not cover. HEAD routes Fiber adds for each GET are ignored.
+func undocumentedRoutes(app *fiber.App) []string {
+	documented := make(map[string]bool, len(apiOperations))
+	for _, op := range apiOperations {
+		documented[op.Method+" "+op.Path] = true
+	}
+	var missing []string
+	for _, r := range app.GetRoutes(true) {
+		key := r.Method + " " + r.Path
+		if r.Method != fiber.MethodHead && !documented[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// openAPICommand writes the document to out for `nexus-backend openapi`. It
+// registers every route, including optional ones, and fails when any of them
+// is undocumented.
+func openAPICommand(out io.Writer) int {
+	config.DebugEndpoints = true
+	config.APIDocs = apiDocsPublic
+	app := fiber.New()
+	registerRoutes(app, newServer(sqlStore{}, nil, nil))
+	if missing := undocumentedRoutes(app); len(missing) > 0 {
+		fmt.Fprintln(os.Stderr, "Routes missing from the OpenAPI spec:")
+		for _, route := range missing {
+			fmt.Fprintln(os.Stderr, "  "+route)
+		}
+		return 1
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(buildOpenAPISpec()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// registerAPIDocsRoutes serves the document and a Swagger UI page for it.
+// With API_DOCS=auth the document needs a session token; the page itself
+// holds nothing and asks for one before loading it.
+func registerAPIDocsRoutes(app *fiber.App) {
+	if config.APIDocs == apiDocsOff {
+		return
+	}
+	spec, err := json.Marshal(buildOpenAPISpec())
+	if err != nil {
+		fatal("Failed to build OpenAPI spec", "error", err)
+	}
+	needsAuth := config.APIDocs == apiDocsAuth
+
+	handlers := []fiber.Handler{}
+	if needsAuth {
+		handlers = append(handlers, authRequired())
+	}
+	handlers = append(handlers, func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(spec)
+	})
+	app.Get("/api/openapi.json", handlers...)
+
+	page := fmt.Sprintf(apiDocsPage, needsAuth)
+	app.Get("/api/docs", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(page)
+	})
+}
+
+// apiDocsPage loads Swagger UI from a CDN. %t is whether the document needs
+// a session token, which is kept for the browser tab.
+const apiDocsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Boom API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+const needsAuth = %t;
+function docsToken() {
+  let token = sessionStorage.getItem("boom_docs_token");
+  if (!token && needsAuth) {
+    token = prompt("Session token (the token field from POST /api/auth/login)") || "";
+    sessionStorage.setItem("boom_docs_token", token);
+  }
+  return token;
+}
+SwaggerUIBundle({
+  url: "/api/openapi.json",
+  dom_id: "#swagger-ui",
+  persistAuthorization: true,
+  requestInterceptor: (req) => {
+    const token = docsToken();
+    if (token && req.url.endsWith("/api/openapi.json")) {
+      req.headers.Authorization = "Bearer " + token;
+    }
+    return req;
+  },
+  responseInterceptor: (res) => {
+    if (res.status === 401 && res.url.endsWith("/api/openapi.json")) {
+      sessionStorage.removeItem("boom_docs_token");
+    }
+    return res;
+  },
+});
+</script>
+</body>
+</html>
+`
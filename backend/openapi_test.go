@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestRoutesMatchOpenAPISpec registers every route, including optional
+// ones, and checks each is documented and each documented operation exists
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	useTestConfig(t)
+	config.DebugEndpoints = true
+	config.APIDocs = apiDocsPublic
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	for _, route := range undocumentedRoutes(app) {
+		t.Errorf("route missing from apiOperations: %s", route)
+	}
+
+	registered := make(map[string]bool)
+	for _, r := range app.GetRoutes(true) {
+		registered[r.Method+" "+r.Path] = true
+	}
+	for _, op := range apiOperations {
+		if key := op.Method + " " + op.Path; !registered[key] {
+			t.Errorf("apiOperations documents a route that is not registered: %s", key)
+		}
+	}
+}
+
+func TestUndocumentedRoutesIgnoresHead(t *testing.T) {
+	app := fiber.New()
+	app.Get("/api/not-in-spec", func(c *fiber.Ctx) error { return nil })
+
+	missing := undocumentedRoutes(app)
+	if len(missing) != 1 || missing[0] != "GET /api/not-in-spec" {
+		t.Errorf("got %v, want only the GET route", missing)
+	}
+}
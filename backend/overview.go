@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/livekit/protocol/livekit"
+)
+
+// GET /api/admin/overview answers "is the system healthy and busy" in one
+// call. It must answer quickly while LiveKit is struggling, so the LiveKit
+// room list is cached briefly and, when a refresh fails or times out, the
+// last good list is served flagged stale. Sections that cannot be read are
+// named in errors and the rest is still returned.
+
+const (
+	// overviewTimeout bounds the whole overview
+	overviewTimeout = 3 * time.Second
+	// overviewLiveKitTimeout bounds the LiveKit refresh within it
+	overviewLiveKitTimeout = 2 * time.Second
+	// liveRoomsMaxAge is how long a LiveKit room list is reused
+	liveRoomsMaxAge = 10 * time.Second
+	// failedEmailWindow is how far back failed emails are counted
+	failedEmailWindow = 24 * time.Hour
+)
+
+// liveRoomCache holds the last room list fetched from LiveKit
+type liveRoomCache struct {
+	mu          sync.Mutex
+	rooms       []*livekit.Room
+	fetchedAt   time.Time // of rooms
+	attemptedAt time.Time // of the last refresh, successful or not
+	err         error     // from the last refresh
+}
+
+// liveRooms returns LiveKit's rooms, refreshing them at most once per
+// liveRoomsMaxAge so a slow LiveKit only delays one overview in that time.
+// After a failed refresh it returns the last good list, which may be empty,
+// together with the error.
+func (s *server) liveRooms(ctx context.Context) ([]*livekit.Room, time.Time, error) {
+	cache := s.liveRoomCache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if time.Since(cache.attemptedAt) < liveRoomsMaxAge {
+		return cache.rooms, cache.fetchedAt, cache.err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, overviewLiveKitTimeout)
+	defer cancel()
+	cache.attemptedAt = time.Now()
+	resp, err := s.rooms.ListRooms(ctx, &livekit.ListRoomsRequest{})
+	cache.err = err
+	if err == nil {
+		cache.rooms, cache.fetchedAt = resp.Rooms, time.Now()
+	}
+	return cache.rooms, cache.fetchedAt, cache.err
+}
+
+// Webhook sources whose last delivery the overview reports
+var webhookSources = []string{"livekit", "n8n-callback", "email-bounce"}
+
+var webhookReceipts = struct {
+	sync.Mutex
+	last map[string]time.Time
+}{last: map[string]time.Time{}}
+
+// noteWebhookReceipt is middleware recording when source last delivered a
+// webhook that was accepted
+func noteWebhookReceipt(source string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err == nil && c.Response().StatusCode() < 400 {
+			webhookReceipts.Lock()
+			webhookReceipts.last[source] = time.Now()
+			webhookReceipts.Unlock()
+		}
+		return err
+	}
+}
+
+// OverviewRoom is a LiveKit room in the overview
+type OverviewRoom struct {
+	Name         string    `json:"name"`
+	Participants uint32    `json:"participants"`
+	Publishers   uint32    `json:"publishers"`
+	Recording    bool      `json:"recording"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type AdminOverviewResponse struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	LiveKit     struct {
+		FetchedAt *time.Time `json:"fetchedAt,omitempty"`
+		Stale     bool       `json:"stale"` // the refresh failed; rooms are from fetchedAt
+		Error     string     `json:"error,omitempty"`
+	} `json:"livekit"`
+	ActiveRooms      []OverviewRoom         `json:"activeRooms"`
+	Transcriptions   []TranscriptionSession `json:"transcriptions"`
+	ActiveRecordings []UnfinishedRecording  `json:"activeRecordings"`
+	WebSockets       struct {
+		Connections int `json:"connections"`
+		Rooms       int `json:"rooms"`
+	} `json:"websockets"`
+	// BackgroundQueue is the work waiting for a worker: summary emails,
+	// outbound webhooks, and batch transcription
+	BackgroundQueue struct {
+		Queued   int `json:"queued"`
+		Capacity int `json:"capacity"`
+	} `json:"backgroundQueue"`
+	FailedTasks     []TaskFailure         `json:"failedTasks"` // newest first, since the last restart
+	FailedEmails24h int                   `json:"failedEmails24h"`
+	LastWebhooks    map[string]*time.Time `json:"lastWebhooks"`     // by source; null if none since the last restart
+	Errors          []string              `json:"errors,omitempty"` // sections that could not be read
+}
+
+func (s *server) adminOverviewHandler(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), overviewTimeout)
+	defer cancel()
+
+	var resp AdminOverviewResponse
+	resp.GeneratedAt = time.Now()
+	fail := func(section string, err error) {
+		ctxLogger(ctx).Warn("Overview section unavailable", "section", section, "error", err)
+		resp.Errors = append(resp.Errors, section+": "+err.Error())
+	}
+
+	rooms, fetchedAt, err := s.liveRooms(ctx)
+	if err != nil {
+		resp.LiveKit.Stale = true
+		resp.LiveKit.Error = err.Error()
+	}
+	if !fetchedAt.IsZero() {
+		resp.LiveKit.FetchedAt = &fetchedAt
+	}
+	resp.ActiveRooms = make([]OverviewRoom, len(rooms))
+	for i, r := range rooms {
+		resp.ActiveRooms[i] = OverviewRoom{
+			Name:         r.GetName(),
+			Participants: r.GetNumParticipants(),
+			Publishers:   r.GetNumPublishers(),
+			Recording:    r.GetActiveRecording(),
+			CreatedAt:    time.Unix(r.GetCreationTime(), 0).UTC(),
+		}
+	}
+
+	if resp.Transcriptions, err = s.store.ListTranscriptionSessions(ctx); err != nil {
+		fail("transcriptions", err)
+	}
+	if resp.ActiveRecordings, err = s.store.ListUnfinishedRecordings(ctx); err != nil {
+		fail("activeRecordings", err)
+	}
+	if resp.FailedEmails24h, err = s.store.CountFailedEmailsSince(ctx, time.Now().Add(-failedEmailWindow)); err != nil {
+		fail("failedEmails24h", err)
+	}
+	if resp.Transcriptions == nil {
+		resp.Transcriptions = []TranscriptionSession{}
+	}
+	if resp.ActiveRecordings == nil {
+		resp.ActiveRecordings = []UnfinishedRecording{}
+	}
+
+	transcriptLock.RLock()
+	resp.WebSockets.Rooms = len(transcriptWS)
+	for _, clients := range transcriptWS {
+		resp.WebSockets.Connections += len(clients)
+	}
+	transcriptLock.RUnlock()
+
+	resp.BackgroundQueue.Queued = len(s.jobs.jobs)
+	resp.BackgroundQueue.Capacity = cap(s.jobs.jobs)
+	resp.FailedTasks = s.jobs.recentFailures()
+
+	resp.LastWebhooks = map[string]*time.Time{}
+	webhookReceipts.Lock()
+	for _, source := range webhookSources {
+		if t, ok := webhookReceipts.last[source]; ok {
+			resp.LastWebhooks[source] = &t
+		} else {
+			resp.LastWebhooks[source] = nil
+		}
+	}
+	webhookReceipts.Unlock()
+
+	return c.JSON(resp)
+}
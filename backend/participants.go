@@ -0,0 +1,541 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+)
+
+// participantReconcileInterval is how often open attendance rows are checked
+// against LiveKit to catch missed join/leave webhooks
+const participantReconcileInterval = time.Minute
+
+// participantLeaveGrace is how long a participant_left webhook is held before
+// it is recorded. A participant who reconnects within it, as LiveKit clients
+// do after a brief network drop, is treated as never having left.
+const participantLeaveGrace = 15 * time.Second
+
+// Participant is one stretch of time someone spent in a meeting
+type Participant struct {
+	ID         int64      `json:"id"`
+	Identity   string     `json:"identity"`
+	Name       string     `json:"name,omitempty"`
+	Metadata   string     `json:"metadata,omitempty"`
+	JoinedAt   time.Time  `json:"joinedAt"`
+	LeftAt     *time.Time `json:"leftAt,omitempty"`
+	DurationMS int64      `json:"durationMs"` // so far, if still in the meeting
+}
+
+// isBotParticipant reports whether a LiveKit participant is a service rather
+// than a person: egress, agents, or the transcription bot
+func isBotParticipant(p *livekit.ParticipantInfo) bool {
+	switch p.GetKind() {
+	case livekit.ParticipantInfo_EGRESS, livekit.ParticipantInfo_AGENT:
+		return true
+	}
+	return strings.HasPrefix(p.GetIdentity(), "transcriber-")
+}
+
+// meeting_events types
+const (
+	meetingEventJoined = "participant_joined"
+	meetingEventLeft   = "participant_left"
+)
+
+// insertMeetingEvent adds an attendance change to a meeting's timeline
+func insertMeetingEvent(ctx context.Context, tx *storeTx, meetingID int64, eventType, identity, name string, at time.Time) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO meeting_events (meeting_id, event_type, identity, name, occurred_at) VALUES (?, ?, ?, ?, ?)",
+		meetingID, eventType, identity, name, at.UTC(),
+	)
+	return err
+}
+
+// RecordParticipantJoined opens an attendance row for identity and reports
+// whether it did. A join for someone who already has an open row is a
+// redelivery or a reconnect and is ignored.
+func RecordParticipantJoined(ctx context.Context, roomName, identity, name, metadata string, joinedAt time.Time) (bool, error) {
+	var opened bool
+	err := withTx(ctx, func(tx *storeTx) error {
+		opened = false
+		meeting, err := GetOrCreateMeeting(ctx, tx, roomName)
+		if err != nil {
+			return err
+		}
+
+		var open int
+		if err := tx.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM participants WHERE meeting_id = ? AND identity = ? AND left_at IS NULL",
+			meeting.ID, identity,
+		).Scan(&open); err != nil {
+			return err
+		}
+		if open > 0 {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO participants (meeting_id, identity, name, metadata, joined_at) VALUES (?, ?, ?, ?, ?)",
+			meeting.ID, identity, name, metadata, joinedAt.UTC(),
+		); err != nil {
+			return err
+		}
+		opened = true
+		return insertMeetingEvent(ctx, tx, meeting.ID, meetingEventJoined, identity, name, joinedAt)
+	})
+	return opened, err
+}
+
+// openParticipant is an attendance row that has not been closed
+type openParticipant struct {
+	id, meetingID  int64
+	identity, name string
+}
+
+// closeParticipants closes the open attendance rows selected by where, adding
+// a leave to the timeline for each, and returns how many it closed
+func closeParticipants(ctx context.Context, tx *storeTx, leftAt time.Time, where string, args ...any) (int, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT p.id, p.meeting_id, p.identity, p.name
+		 FROM participants p JOIN meetings m ON m.id = p.meeting_id
+		 WHERE p.left_at IS NULL AND `+where,
+		args...,
+	)
+	if err != nil {
+		return 0, err
+	}
+	var open []openParticipant
+	for rows.Next() {
+		var p openParticipant
+		var name sql.NullString
+		if err := rows.Scan(&p.id, &p.meetingID, &p.identity, &name); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		p.name = name.String
+		open = append(open, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, p := range open {
+		if _, err := tx.ExecContext(ctx, "UPDATE participants SET left_at = ? WHERE id = ?", leftAt.UTC(), p.id); err != nil {
+			return 0, err
+		}
+		if err := insertMeetingEvent(ctx, tx, p.meetingID, meetingEventLeft, p.identity, p.name, leftAt); err != nil {
+			return 0, err
+		}
+	}
+	return len(open), nil
+}
+
+// RecordParticipantLeft closes identity's open attendance row, if any, and
+// reports whether there was one
+func RecordParticipantLeft(ctx context.Context, roomName, identity string, leftAt time.Time) (bool, error) {
+	var closed int
+	err := withTx(ctx, func(tx *storeTx) error {
+		var err error
+		closed, err = closeParticipants(ctx, tx, leftAt, "m.room_name = ? AND p.identity = ?", roomName, identity)
+		return err
+	})
+	return closed > 0, err
+}
+
+// CloseOpenParticipants marks everyone still recorded as present in a room as
+// having left, e.g. when the room finishes
+func CloseOpenParticipants(ctx context.Context, roomName string, leftAt time.Time) error {
+	return withTx(ctx, func(tx *storeTx) error {
+		_, err := closeParticipants(ctx, tx, leftAt, "m.room_name = ?", roomName)
+		return err
+	})
+}
+
+// ListMeetingEvents returns a meeting's attendance changes in order
+func ListMeetingEvents(ctx context.Context, meetingID int64) ([]TimelineEvent, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT event_type, identity, name, occurred_at FROM meeting_events WHERE meeting_id = ? ORDER BY occurred_at, id",
+		meetingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []TimelineEvent
+	for rows.Next() {
+		var e TimelineEvent
+		var identity string
+		var name sql.NullString
+		if err := rows.Scan(&e.Type, &identity, &name, &e.Time); err != nil {
+			return nil, err
+		}
+		e.Detail = identity
+		if name.String != "" && name.String != identity {
+			e.Detail = name.String + " (" + identity + ")"
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// ListParticipants returns every attendance row for a meeting in join order
+func ListParticipants(ctx context.Context, roomName string) ([]Participant, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT p.id, p.identity, p.name, p.metadata, p.joined_at, p.left_at
+		 FROM participants p JOIN meetings m ON m.id = p.meeting_id
+		 WHERE m.room_name = ?
+		 ORDER BY p.joined_at, p.id`,
+		roomName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	participants := []Participant{}
+	for rows.Next() {
+		var p Participant
+		var name, metadata sql.NullString
+		var leftAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.Identity, &name, &metadata, &p.JoinedAt, &leftAt); err != nil {
+			return nil, err
+		}
+		p.Name = name.String
+		p.Metadata = metadata.String
+		end := now
+		if leftAt.Valid {
+			p.LeftAt = &leftAt.Time
+			end = leftAt.Time
+		}
+		p.DurationMS = end.Sub(p.JoinedAt).Milliseconds()
+		participants = append(participants, p)
+	}
+	return participants, rows.Err()
+}
+
+// ListOpenParticipantRooms returns the rooms that have someone recorded as present
+func ListOpenParticipantRooms(ctx context.Context) ([]string, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT DISTINCT m.room_name FROM participants p JOIN meetings m ON m.id = p.meeting_id WHERE p.left_at IS NULL",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []string
+	for rows.Next() {
+		var room string
+		if err := rows.Scan(&room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, rows.Err()
+}
+
+// recordParticipantEvent applies a participant_joined or participant_left
+// webhook. Leaves are held for participantLeaveGrace, and a rejoin within it
+// cancels the leave, so reconnects do not show up as attendance changes.
+func (s *server) recordParticipantEvent(ctx context.Context, event *livekit.WebhookEvent) error {
+	p := event.GetParticipant()
+	if p == nil || isBotParticipant(p) {
+		return nil
+	}
+	room := event.GetRoom().GetName()
+	identity := p.GetIdentity()
+	at := time.Unix(event.GetCreatedAt(), 0)
+	if event.GetCreatedAt() == 0 {
+		at = time.Now()
+	}
+
+	if event.GetEvent() == webhook.EventParticipantJoined {
+		if s.leaves.cancel(room, identity) {
+			ctxLogger(ctx).Debug("Participant reconnected", "identity", identity)
+		}
+		if p.GetJoinedAt() > 0 {
+			at = time.Unix(p.GetJoinedAt(), 0)
+		}
+		return s.participantJoined(ctx, room, identity, p.GetName(), p.GetMetadata(), at)
+	}
+
+	s.leaves.hold(room, identity, func() {
+		ctx, cancel := detachedContext(ctx)
+		defer cancel()
+		if err := s.participantLeft(ctx, room, identity, at); err != nil {
+			ctxLogger(ctx).Error("Failed to record participant leaving", "identity", identity, "error", err)
+		}
+	})
+	return nil
+}
+
+// participantJoined records a join and, if it changed who is present,
+// broadcasts the room's roster
+func (s *server) participantJoined(ctx context.Context, room, identity, name, metadata string, at time.Time) error {
+	opened, err := s.store.RecordParticipantJoined(ctx, room, identity, name, metadata, at)
+	if err != nil || !opened {
+		return err
+	}
+	s.broadcastRoster(ctx, room, ParticipantEvent{Action: "joined", Identity: identity, Name: name})
+	return nil
+}
+
+// participantLeft records a leave and, if it changed who is present,
+// broadcasts the room's roster
+func (s *server) participantLeft(ctx context.Context, room, identity string, at time.Time) error {
+	closed, err := s.store.RecordParticipantLeft(ctx, room, identity, at)
+	if err != nil || !closed {
+		return err
+	}
+	s.broadcastRoster(ctx, room, ParticipantEvent{Action: "left", Identity: identity})
+	return nil
+}
+
+// ParticipantEvent is the payload of a participant event: who joined or
+// left, and everyone present afterwards
+type ParticipantEvent struct {
+	Action   string        `json:"action"` // joined, left, or roster for the snapshot sent on connect
+	Identity string        `json:"identity"`
+	Name     string        `json:"name,omitempty"`
+	Roster   []RosterEntry `json:"roster"`
+}
+
+// RosterEntry is someone currently in a room
+type RosterEntry struct {
+	Identity string    `json:"identity"`
+	Name     string    `json:"name,omitempty"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+// openRoster returns the participants still present
+func openRoster(participants []Participant) []RosterEntry {
+	roster := []RosterEntry{}
+	for _, p := range participants {
+		if p.LeftAt == nil {
+			roster = append(roster, RosterEntry{Identity: p.Identity, Name: p.Name, JoinedAt: p.JoinedAt})
+		}
+	}
+	return roster
+}
+
+// broadcastRoster sends event, with the current roster, to a room's WebSocket clients
+func (s *server) broadcastRoster(ctx context.Context, room string, event ParticipantEvent) {
+	participants, err := s.store.ListParticipants(ctx, room)
+	if err != nil {
+		ctxLogger(ctx).Warn("Failed to load roster", "error", err)
+		return
+	}
+	event.Roster = openRoster(participants)
+	broadcastEvent(room, EventParticipant, event)
+}
+
+// currentRosterEvent returns the encoded roster for a client joining a room's
+// WebSocket, or nil if nobody is present
+func currentRosterEvent(room string) []byte {
+	ctx, cancel := backgroundContext()
+	defer cancel()
+	participants, err := ListParticipants(ctx, room)
+	if err != nil {
+		return nil
+	}
+	roster := openRoster(participants)
+	if len(roster) == 0 {
+		return nil
+	}
+	envelope, err := json.Marshal(WSEnvelope{
+		Version: wsProtocolEnvelope,
+		Type:    EventParticipant,
+		Payload: ParticipantEvent{Action: "roster", Roster: roster},
+	})
+	if err != nil {
+		return nil
+	}
+	return envelope
+}
+
+// leaveDebouncer holds participant leaves for participantLeaveGrace so a
+// quick reconnect can cancel them
+type leaveDebouncer struct {
+	mu      sync.Mutex
+	pending map[string]*time.Timer // room + "\x00" + identity
+}
+
+func newLeaveDebouncer() *leaveDebouncer {
+	return &leaveDebouncer{pending: map[string]*time.Timer{}}
+}
+
+// hold runs fn after participantLeaveGrace unless cancel is called first
+func (d *leaveDebouncer) hold(room, identity string, fn func()) {
+	key := room + "\x00" + identity
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if t := d.pending[key]; t != nil {
+		t.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(participantLeaveGrace, func() {
+		defer recoverPanic(context.Background(), "background", "participant leave")
+		d.mu.Lock()
+		current := d.pending[key] == t
+		if current {
+			delete(d.pending, key)
+		}
+		d.mu.Unlock()
+		if current {
+			fn()
+		}
+	})
+	d.pending[key] = t
+}
+
+// cancel drops a held leave, reporting whether there was one
+func (d *leaveDebouncer) cancel(room, identity string) bool {
+	key := room + "\x00" + identity
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t := d.pending[key]
+	if t == nil {
+		return false
+	}
+	t.Stop()
+	delete(d.pending, key)
+	return true
+}
+
+// cancelRoom drops every held leave in a room
+func (d *leaveDebouncer) cancelRoom(room string) {
+	prefix := room + "\x00"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, t := range d.pending {
+		if strings.HasPrefix(key, prefix) {
+			t.Stop()
+			delete(d.pending, key)
+		}
+	}
+}
+
+// reconcileParticipants compares the attendance table with who LiveKit says
+// is in each active room, opening rows for people we missed joining and
+// closing rows for people we missed leaving
+func (s *server) reconcileParticipants(ctx context.Context) error {
+	live, err := s.rooms.ListRooms(ctx, &livekit.ListRoomsRequest{})
+	if err != nil {
+		return err
+	}
+	active := map[string]bool{}
+	for _, r := range live.Rooms {
+		active[r.Name] = true
+	}
+
+	now := time.Now()
+	open, err := s.store.ListOpenParticipantRooms(ctx)
+	if err != nil {
+		return err
+	}
+	for _, room := range open {
+		if !active[room] {
+			// The room has finished; nobody is left in it
+			if _, err := s.finishMeeting(withLogAttrs(ctx, "room", room), room, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	for room := range active {
+		resp, err := s.rooms.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: room})
+		if err != nil {
+			return err
+		}
+
+		present := map[string]bool{}
+		for _, p := range resp.Participants {
+			if isBotParticipant(p) {
+				continue
+			}
+			present[p.Identity] = true
+			joinedAt := now
+			if p.JoinedAt > 0 {
+				joinedAt = time.Unix(p.JoinedAt, 0)
+			}
+			if err := s.participantJoined(ctx, room, p.Identity, p.Name, p.Metadata, joinedAt); err != nil {
+				return err
+			}
+		}
+
+		recorded, err := s.store.ListParticipants(ctx, room)
+		if err != nil {
+			return err
+		}
+		for _, p := range recorded {
+			if p.LeftAt == nil && !present[p.Identity] {
+				if err := s.participantLeft(ctx, room, p.Identity, now); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// runParticipantReconciler periodically reconciles attendance with LiveKit.
+// It runs for the life of the process.
+func (s *server) runParticipantReconciler() {
+	ticker := time.NewTicker(participantReconcileInterval)
+	defer ticker.Stop()
+
+	for nextTick(ticker) {
+		ctx, cancel := backgroundContext()
+		if err := s.reconcileParticipants(ctx); err != nil {
+			slog.Error("Failed to reconcile participants", "error", err)
+		}
+		cancel()
+	}
+}
+
+type ParticipantsResponse struct {
+	Participants []Participant `json:"participants"`
+	Count        int           `json:"count"`
+}
+
+func (s *server) listParticipantsHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	if _, err := s.store.GetMeetingByRoom(ctx, room); errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	participants, err := s.store.ListParticipants(ctx, room)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	return c.JSON(ParticipantsResponse{Participants: participants, Count: len(participants)})
+}
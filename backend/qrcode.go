@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/skip2/go-qrcode"
+)
+
+// QR code image size bounds in pixels
+const (
+	qrCodeMinSize     = 64
+	qrCodeMaxSize     = 1024
+	qrCodeDefaultSize = 256
+)
+
+// scheduledMeetingQRCodeHandler renders a scheduled meeting's invite link as a PNG QR code
+func (s *server) scheduledMeetingQRCodeHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	var id int64
+	if _, err := fmt.Sscanf(c.Params("id"), "%d", &id); err != nil {
+		return respondError(c, 400, "Invalid ID")
+	}
+
+	var errs fieldErrors
+	size := errs.queryInt(c, "size", qrCodeDefaultSize)
+	if size < qrCodeMinSize || size > qrCodeMaxSize {
+		errs.add("size", fmt.Sprintf("size must be between %d and %d", qrCodeMinSize, qrCodeMaxSize))
+	}
+	if err := errs.err(); err != nil {
+		return err
+	}
+
+	meeting, err := s.store.GetScheduledMeetingByID(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Scheduled meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if !s.canManageRoom(c, meeting.RoomName) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	png, err := qrcode.Encode(inviteLink(meeting.RoomName), qrcode.Medium, size)
+	if err != nil {
+		return respondError(c, 500, "Failed to generate QR code")
+	}
+
+	c.Set("Content-Type", "image/png")
+	c.Set("Content-Disposition", `inline; filename="meeting-qr.png"`)
+	return c.Send(png)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// qrModules samples a rendered QR code back into its grid of modules,
+// quiet zone included. The top edge of the top-left finder pattern is seven
+// modules wide, which gives the module size; modules need not be a whole
+// number of pixels.
+func qrModules(t *testing.T, img image.Image) [][]bool {
+	t.Helper()
+	dark := func(x, y int) bool {
+		r, g, b, _ := img.At(x, y).RGBA()
+		return r+g+b < 3*0x8000
+	}
+	bounds := img.Bounds()
+
+	x0, y0 := -1, -1
+	for y := bounds.Min.Y; y < bounds.Max.Y && x0 < 0; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if dark(x, y) {
+				x0, y0 = x, y
+				break
+			}
+		}
+	}
+	if x0 < 0 {
+		t.Fatal("image has no dark pixels")
+	}
+	run := 0
+	for x := x0; x < bounds.Max.X && dark(x, y0); x++ {
+		run++
+	}
+	module := float64(run) / 7
+	n := int(math.Round(float64(bounds.Dx()) / module))
+
+	grid := make([][]bool, n)
+	for row := range grid {
+		grid[row] = make([]bool, n)
+		for col := range grid[row] {
+			x := bounds.Min.X + int((float64(col)+0.5)*float64(bounds.Dx())/float64(n))
+			y := bounds.Min.Y + int((float64(row)+0.5)*float64(bounds.Dy())/float64(n))
+			grid[row][col] = dark(x, y)
+		}
+	}
+	return grid
+}
+
+func TestScheduledMeetingQRCodeEncodesInviteLink(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	store := newFakeStore()
+	app := newTestApp(t, newTestServer(t, store))
+	host := bearer(t, testUserEmail)
+
+	meeting, err := store.CreateScheduledMeeting(context.Background(), "client-call", testUser(t, testUserEmail).ID, "Client", "client@example.com", time.Now().Add(time.Hour), time.Now().Add(2*time.Hour), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/api/scheduled-meetings/%d/qr-code", meeting.ID)
+
+	resp := doRequest(t, app, "GET", path+"?size=300", nil, host...)
+	if resp.Status != 200 {
+		t.Fatalf("got %d, want 200: %s", resp.Status, resp.Body)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type: got %q, want image/png", got)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != `inline; filename="meeting-qr.png"` {
+		t.Errorf("Content-Disposition: got %q", got)
+	}
+	img, err := png.Decode(bytes.NewReader(resp.Body))
+	if err != nil {
+		t.Fatalf("decode PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 300 || b.Dy() != 300 {
+		t.Errorf("image is %dx%d, want 300x300", b.Dx(), b.Dy())
+	}
+
+	want := "http://frontend.test/join/client-call"
+	code, err := qrcode.New(want, qrcode.Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := code.Bitmap()
+	got := qrModules(t, img)
+	if len(got) != len(expected) {
+		t.Fatalf("QR code is %d modules across, want %d for %s", len(got), len(expected), want)
+	}
+	for row := range expected {
+		for col := range expected[row] {
+			if got[row][col] != expected[row][col] {
+				t.Fatalf("module (%d, %d) differs from the encoding of %s", row, col, want)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  int
+	}{
+		{"?size=63", 422},
+		{"?size=1025", 422},
+		{"?size=big", 422},
+		{"", 200},
+	} {
+		if resp := doRequest(t, app, "GET", path+tc.query, nil, host...); resp.Status != tc.want {
+			t.Errorf("size %q: got %d, want %d", tc.query, resp.Status, tc.want)
+		}
+	}
+	if resp := doRequest(t, app, "GET", "/api/scheduled-meetings/9999/qr-code", nil, host...); resp.Status != 404 {
+		t.Errorf("missing meeting: got %d, want 404", resp.Status)
+	}
+}
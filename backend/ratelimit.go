@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/redis/go-redis/v9"
+)
+
+// Every request is counted against a per-IP, per-minute bucket chosen by the
+// first rule in rateLimitRules that matches it. Cheap-to-abuse routes get
+// tighter buckets of their own; service-to-service and health routes are
+// exempt; everything else shares the RATE_LIMIT bucket. RATE_LIMIT_OVERRIDES
+// changes any rule's limit by name. Counters live in memory, or in Redis when
+// RATE_LIMIT_REDIS_URL is set so every instance shares them.
+
+const (
+	// defaultTokenRateLimit is how many tokens one IP may request per minute
+	defaultTokenRateLimit = 20
+	// defaultRateLimit is the per-IP limit for routes without a rule of their own
+	defaultRateLimit = 300
+)
+
+// rateLimitRule sets the limit for requests matching Method and Pattern
+type rateLimitRule struct {
+	Name    string // rules sharing a name share a bucket
+	Method  string // empty matches any method
+	Pattern string // Fiber-style path; :param matches a segment, a trailing /* anything below
+	Limit   int    // requests per IP per minute; 0 exempts the route
+}
+
+// rateLimitRules returns the rules in match order, with c's limits and
+// overrides applied
+func rateLimitRules(c *Config) []rateLimitRule {
+	rules := []rateLimitRule{
+		{Name: "health", Method: "GET", Pattern: "/health"},
+		{Name: "health", Method: "GET", Pattern: "/healthz/ready"},
+		{Name: "health", Method: "GET", Pattern: "/metrics"},
+		{Name: "internal", Pattern: "/api/internal/*"},
+		{Name: "internal", Pattern: "/api/webhooks/*"},
+		{Name: "internal", Method: "POST", Pattern: "/api/auth/verify"},
+		{Name: "login", Method: "POST", Pattern: "/api/auth/login", Limit: 10},
+		{Name: "token", Method: "POST", Pattern: "/api/token", Limit: c.TokenRateLimit},
+		{Name: "rooms", Method: "POST", Pattern: "/api/rooms", Limit: 10},
+		{Name: "subscribe-email", Method: "POST", Pattern: "/api/meetings/:room/subscribe-email", Limit: 10},
+		{Name: "subscribe-email", Method: "DELETE", Pattern: "/api/meetings/:room/unsubscribe-email", Limit: 10},
+		{Name: "default", Pattern: "/*", Limit: c.RateLimit},
+	}
+	for i := range rules {
+		if limit, ok := c.RateLimitOverrides[rules[i].Name]; ok {
+			rules[i].Limit = limit
+		}
+	}
+	return rules
+}
+
+// parseRateLimitOverrides parses RATE_LIMIT_OVERRIDES, a comma-separated
+// list of rule=limit pairs
+func parseRateLimitOverrides(v string) (map[string]int, error) {
+	known := map[string]bool{}
+	for _, r := range rateLimitRules(defaultConfig()) {
+		known[r.Name] = true
+	}
+	overrides := map[string]int{}
+	for _, pair := range strings.Split(v, ",") {
+		name, limit, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not rule=limit", pair)
+		}
+		name = strings.TrimSpace(name)
+		if !known[name] {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("limit for %s must be a whole number, got %q", name, limit)
+		}
+		overrides[name] = n
+	}
+	return overrides, nil
+}
+
+// matchRoutePattern reports whether path fits pattern. Like Fiber's default
+// routing it ignores case and a trailing slash.
+func matchRoutePattern(pattern, path string) bool {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return len(path) >= len(prefix) && strings.EqualFold(path[:len(prefix)], prefix) &&
+			(len(path) == len(prefix) || path[len(prefix)] == '/')
+	}
+	want, got := strings.Split(pattern, "/"), strings.Split(path, "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if strings.HasPrefix(want[i], ":") {
+			if got[i] == "" {
+				return false
+			}
+		} else if !strings.EqualFold(want[i], got[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// rateLimiter is Fiber middleware applying rateLimitRules to every request
+func rateLimiter() fiber.Handler {
+	rules := rateLimitRules(config)
+	storage := rateLimitStorage(config)
+
+	limiters := map[string]fiber.Handler{}
+	for _, r := range rules {
+		if r.Limit == 0 || limiters[r.Name] != nil {
+			continue
+		}
+		name := r.Name
+		limiters[name] = limiter.New(limiter.Config{
+			Max:        r.Limit,
+			Expiration: time.Minute,
+			Storage:    storage,
+			KeyGenerator: func(c *fiber.Ctx) string {
+				return "ratelimit:" + name + ":" + c.IP()
+			},
+			LimitReached: func(c *fiber.Ctx) error {
+				rateLimited.WithLabelValues(name).Inc()
+				ctxLogger(c.UserContext()).Warn("Rate limit exceeded", "rule", name, "ip", c.IP(), "path", c.Path())
+				return respondError(c, 429, "Too many requests, try again later")
+			},
+		})
+	}
+
+	return func(c *fiber.Ctx) error {
+		for _, r := range rules {
+			if (r.Method == "" || r.Method == c.Method()) && matchRoutePattern(r.Pattern, c.Path()) {
+				if r.Limit == 0 {
+					return c.Next()
+				}
+				return limiters[r.Name](c)
+			}
+		}
+		return c.Next()
+	}
+}
+
+// rateLimitStorage returns the shared counter store, or nil for Fiber's
+// in-memory one
+func rateLimitStorage(c *Config) fiber.Storage {
+	if c.RateLimitRedisURL == "" {
+		return nil
+	}
+	opts, err := redis.ParseURL(c.RateLimitRedisURL)
+	if err != nil {
+		// loadConfig has already validated it
+		fatal("Invalid RATE_LIMIT_REDIS_URL", "error", err)
+	}
+	// A slow Redis must not stall every request; the limiter lets requests
+	// through when the store fails
+	opts.DialTimeout = 250 * time.Millisecond
+	opts.ReadTimeout = 250 * time.Millisecond
+	opts.WriteTimeout = 250 * time.Millisecond
+	return redisStorage{redis.NewClient(opts)}
+}
+
+// redisStorage adapts a Redis client to fiber.Storage. Fiber's limiter reads
+// and writes a counter without a transaction, so instances racing on the
+// same key can undercount by a request or two; that is close enough for
+// abuse protection.
+type redisStorage struct {
+	client *redis.Client
+}
+
+func (s redisStorage) Get(key string) ([]byte, error) {
+	val, err := s.client.Get(context.Background(), key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return val, err
+}
+
+func (s redisStorage) Set(key string, val []byte, exp time.Duration) error {
+	return s.client.Set(context.Background(), key, val, exp).Err()
+}
+
+func (s redisStorage) Delete(key string) error {
+	return s.client.Del(context.Background(), key).Err()
+}
+
+// Reset removes the rate limit counters, leaving other keys alone
+func (s redisStorage) Reset() error {
+	ctx := context.Background()
+	iter := s.client.Scan(ctx, 0, "ratelimit:*", 0).Iterator()
+	for iter.Next(ctx) {
+		if err := s.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+func (s redisStorage) Close() error {
+	return s.client.Close()
+}
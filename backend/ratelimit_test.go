@@ -0,0 +1,184 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRateLimitBoundary(t *testing.T) {
+	useTestConfig(t)
+	config.TokenRateLimit = 3
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+	throttledBefore := testutil.ToFloat64(rateLimited.WithLabelValues("token"))
+
+	for i := 1; i <= 3; i++ {
+		resp := doRequest(t, app, http.MethodPost, "/api/token", map[string]any{})
+		if resp.Status == http.StatusTooManyRequests {
+			t.Fatalf("request %d of 3: got 429", i)
+		}
+		if got, want := resp.Header.Get("X-RateLimit-Remaining"), strconv.Itoa(3-i); got != want {
+			t.Errorf("request %d: X-RateLimit-Remaining %q, want %q", i, got, want)
+		}
+	}
+
+	resp := doRequest(t, app, http.MethodPost, "/api/token", map[string]any{})
+	if resp.Status != http.StatusTooManyRequests {
+		t.Fatalf("request 4 of 3: got %d, want 429", resp.Status)
+	}
+	retry, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || retry < 1 || retry > 60 {
+		t.Errorf("Retry-After: got %q, want 1-60 seconds", resp.Header.Get("Retry-After"))
+	}
+	if e := resp.apiError(t); e.Code != "too_many_requests" || e.Message == "" {
+		t.Errorf("429 body: got %+v, want the standard error envelope", e)
+	}
+	if got := testutil.ToFloat64(rateLimited.WithLabelValues("token")) - throttledBefore; got != 1 {
+		t.Errorf("throttled requests counted: got %v, want 1", got)
+	}
+}
+
+func TestRateLimitBuckets(t *testing.T) {
+	useTestConfig(t)
+	config.RateLimit = 2
+	config.RateLimitOverrides = map[string]int{"subscribe-email": 2, "rooms": 0}
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	// Subscribing and unsubscribing share one bucket
+	doRequest(t, app, http.MethodPost, "/api/meetings/standup/subscribe-email", map[string]any{})
+	doRequest(t, app, http.MethodDelete, "/api/meetings/standup/unsubscribe-email", map[string]any{})
+	if resp := doRequest(t, app, http.MethodPost, "/api/meetings/other/subscribe-email", map[string]any{}); resp.Status != http.StatusTooManyRequests {
+		t.Errorf("third request to the subscribe-email bucket: got %d, want 429", resp.Status)
+	}
+
+	// An empty bucket leaves the others alone
+	for i := 1; i <= 2; i++ {
+		if resp := doRequest(t, app, http.MethodGet, "/api/version", nil); resp.Status == http.StatusTooManyRequests {
+			t.Errorf("default bucket request %d of 2 after subscribe-email ran out: got 429", i)
+		}
+	}
+
+	// Exempt routes, and rules overridden to 0, are never limited
+	for i := 0; i < 5; i++ {
+		for _, r := range []struct{ method, path string }{
+			{http.MethodGet, "/health"},
+			{http.MethodPost, "/api/internal/transcript"},
+			{http.MethodPost, "/api/rooms"},
+		} {
+			if resp := doRequest(t, app, r.method, r.path, map[string]any{}); resp.Status == http.StatusTooManyRequests {
+				t.Fatalf("%s %s request %d: got 429, want it exempt", r.method, r.path, i+1)
+			}
+		}
+	}
+}
+
+func TestRateLimitPerIP(t *testing.T) {
+	useTestConfig(t)
+	config.TokenRateLimit = 1
+	base := serveTestApp(t, newTestApp(t, newTestServer(t, newFakeStore())))
+
+	// Each client connects from its own loopback address
+	post := func(ip string) int {
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: (&net.Dialer{LocalAddr: &net.TCPAddr{IP: net.ParseIP(ip)}}).DialContext,
+		}}
+		resp, err := client.Post(base+"/api/token", "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatalf("POST from %s: %v", ip, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := post("127.0.0.2"); status == http.StatusTooManyRequests {
+		t.Fatal("first request from 127.0.0.2: got 429")
+	}
+	if status := post("127.0.0.2"); status != http.StatusTooManyRequests {
+		t.Errorf("second request from 127.0.0.2: got %d, want 429", status)
+	}
+	if status := post("127.0.0.3"); status == http.StatusTooManyRequests {
+		t.Error("first request from 127.0.0.3: got 429, want its own bucket")
+	}
+}
+
+func TestMatchRoutePattern(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/api/token", "/api/token", true},
+		{"/api/token", "/api/token/", true},
+		{"/api/token", "/API/Token", true},
+		{"/api/token", "/api/tokens", false},
+		{"/api/meetings/:room/subscribe-email", "/api/meetings/standup/subscribe-email", true},
+		{"/api/meetings/:room/subscribe-email", "/api/meetings//subscribe-email", false},
+		{"/api/meetings/:room/subscribe-email", "/api/meetings/a/b/subscribe-email", false},
+		{"/api/internal/*", "/api/internal", true},
+		{"/api/internal/*", "/api/internal/transcript", true},
+		{"/api/internal/*", "/api/internalx", false},
+		{"/*", "/", true},
+	} {
+		if got := matchRoutePattern(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("matchRoutePattern(%q, %q): got %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestParseRateLimitOverrides(t *testing.T) {
+	got, err := parseRateLimitOverrides("token=5, rooms = 0")
+	if err != nil || len(got) != 2 || got["token"] != 5 || got["rooms"] != 0 {
+		t.Errorf("valid overrides: got %v, %v", got, err)
+	}
+	for _, bad := range []string{"token", "nope=5", "token=-1", "token=lots", "token=5,"} {
+		if _, err := parseRateLimitOverrides(bad); err == nil {
+			t.Errorf("%q: got nil, want an error", bad)
+		}
+	}
+}
+
+func TestRateLimitPerForwardedIP(t *testing.T) {
+	useTestConfig(t)
+	config.TokenRateLimit = 1
+	config.ProxyHeader = "X-Forwarded-For"
+	config.TrustedProxies = []string{"127.0.0.1"}
+	base := serveTestApp(t, newTestApp(t, newTestServer(t, newFakeStore())))
+
+	// The trusted proxy connects from 127.0.0.1 and names the client in
+	// X-Forwarded-For; anyone else's header is ignored
+	post := func(from, client string) int {
+		c := &http.Client{Transport: &http.Transport{
+			DialContext: (&net.Dialer{LocalAddr: &net.TCPAddr{IP: net.ParseIP(from)}}).DialContext,
+		}}
+		req, _ := http.NewRequest(http.MethodPost, base+"/api/token", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", client)
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("POST from %s for %s: %v", from, client, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := post("127.0.0.1", "203.0.113.1"); status == http.StatusTooManyRequests {
+		t.Fatal("first request for 203.0.113.1: got 429")
+	}
+	if status := post("127.0.0.1", "203.0.113.2"); status == http.StatusTooManyRequests {
+		t.Error("first request for 203.0.113.2: got 429, want its own bucket")
+	}
+	if status := post("127.0.0.1", "203.0.113.1"); status != http.StatusTooManyRequests {
+		t.Errorf("second request for 203.0.113.1: got %d, want 429", status)
+	}
+
+	// An untrusted peer can't dodge its bucket by changing the header
+	if status := post("127.0.0.2", "198.51.100.1"); status == http.StatusTooManyRequests {
+		t.Fatal("first request from untrusted 127.0.0.2: got 429")
+	}
+	if status := post("127.0.0.2", "198.51.100.2"); status != http.StatusTooManyRequests {
+		t.Errorf("second request from untrusted 127.0.0.2 with a new forwarded IP: got %d, want 429", status)
+	}
+}
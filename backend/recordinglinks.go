@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Summary emails can carry a link to the meeting's recording for subscribers
+// who asked for one. The link points at this backend, is signed with
+// JWT_SECRET, and stops working after RECORDING_LINK_TTL, so it can be
+// followed without signing in but is useless once it leaks past that.
+// Links are only issued when PUBLIC_BACKEND_URL says where the backend is
+// reachable from outside.
+const defaultRecordingLinkTTL = 7 * 24 * time.Hour
+
+// recordingLinkSignature signs a link to one recording that expires at expires
+func recordingLinkSignature(recordingID, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(config.JWTSecret))
+	fmt.Fprintf(mac, "recording:%d:%d", recordingID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signedRecordingLink returns a link to a recording's audio that works
+// until the returned time
+func signedRecordingLink(recordingID int64, now time.Time) (string, time.Time) {
+	expiresAt := now.Add(config.RecordingLinkTTL).Truncate(time.Second)
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	q.Set("sig", recordingLinkSignature(recordingID, expiresAt.Unix()))
+	return fmt.Sprintf("%s/api/recordings/%d/audio?%s", config.PublicBackendURL, recordingID, q.Encode()), expiresAt
+}
+
+// summaryRecordingLink returns a signed link to a meeting's latest completed
+// recording, or "" when links are not configured or no recording is ready
+func summaryRecordingLink(ctx context.Context, meetingID int64) (string, time.Time, error) {
+	if config.PublicBackendURL == "" {
+		return "", time.Time{}, nil
+	}
+	recordings, err := ListRecordingsByMeeting(ctx, meetingID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	for i := len(recordings) - 1; i >= 0; i-- {
+		if r := recordings[i]; r.Status == "completed" && r.AudioURL != "" {
+			link, expiresAt := signedRecordingLink(r.ID, time.Now())
+			return link, expiresAt, nil
+		}
+	}
+	return "", time.Time{}, nil
+}
+
+// recordingAudioHandler serves the audio behind a signed recording link:
+// the file itself when it is stored locally, otherwise a redirect to where
+// LiveKit uploaded it
+func (s *server) recordingAudioHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return respondError(c, 404, "Recording not found")
+	}
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return respondError(c, 403, "Invalid or expired link")
+	}
+	if !hmac.Equal([]byte(strings.ToLower(c.Query("sig"))), []byte(recordingLinkSignature(id, expires))) {
+		return respondError(c, 403, "Invalid or expired link")
+	}
+
+	rec, err := s.store.GetRecordingByID(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Recording not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if rec.Status != "completed" || rec.AudioURL == "" {
+		return respondError(c, 404, "Recording is not available")
+	}
+
+	if path := localRecordingPath(*rec); path != "" {
+		return c.SendFile(path)
+	}
+	if strings.HasPrefix(rec.AudioURL, "https://") || strings.HasPrefix(rec.AudioURL, "http://") {
+		return c.Redirect(rec.AudioURL, fiber.StatusFound)
+	}
+	return respondError(c, 404, "Recording is not stored where it can be served")
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LiveKit egress cannot be suspended, so pausing a recording stops its egress
+// and resuming starts a new one. Each pause is kept in recording_pauses,
+// linking the recording it ended to the one that resumed it; the audio from
+// before and after a pause is therefore in separate recordings, and no
+// recording's duration includes paused time. A pause still open when the
+// meeting ends is simply left open.
+
+// RecordingPause is one interval in which a meeting's recording was paused
+type RecordingPause struct {
+	ID                 int64      `json:"id"`
+	MeetingID          int64      `json:"meetingId"`
+	RecordingID        int64      `json:"recordingId"`
+	ResumedRecordingID *int64     `json:"resumedRecordingId,omitempty"` // unset if stopped while paused
+	PausedAt           time.Time  `json:"pausedAt"`
+	ResumedAt          *time.Time `json:"resumedAt,omitempty"`
+}
+
+const recordingPauseColumns = "id, meeting_id, recording_id, resumed_recording_id, paused_at, resumed_at"
+
+func scanRecordingPause(scan func(dest ...any) error) (*RecordingPause, error) {
+	var p RecordingPause
+	var resumedID sql.NullInt64
+	var resumedAt sql.NullTime
+	if err := scan(&p.ID, &p.MeetingID, &p.RecordingID, &resumedID, &p.PausedAt, &resumedAt); err != nil {
+		return nil, err
+	}
+	if resumedID.Valid {
+		p.ResumedRecordingID = &resumedID.Int64
+	}
+	if resumedAt.Valid {
+		p.ResumedAt = &resumedAt.Time
+	}
+	return &p, nil
+}
+
+// CreateRecordingPause records that recordingID was stopped to pause its meeting's recording
+func CreateRecordingPause(ctx context.Context, meetingID, recordingID int64, pausedAt time.Time) (*RecordingPause, error) {
+	id, err := insertReturningID(ctx,
+		"INSERT INTO recording_pauses (meeting_id, recording_id, paused_at) VALUES (?, ?, ?)",
+		meetingID, recordingID, pausedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordingPause{ID: id, MeetingID: meetingID, RecordingID: recordingID, PausedAt: pausedAt}, nil
+}
+
+// GetOpenRecordingPause returns the meeting's pause that has not ended yet
+func GetOpenRecordingPause(ctx context.Context, meetingID int64) (*RecordingPause, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	p, err := scanRecordingPause(db.QueryRowContext(ctx,
+		"SELECT "+recordingPauseColumns+" FROM recording_pauses WHERE meeting_id = ? AND resumed_at IS NULL ORDER BY paused_at DESC, id DESC LIMIT 1",
+		meetingID,
+	).Scan)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return p, nil
+}
+
+// EndRecordingPause closes a pause. resumedRecordingID is the recording that
+// resumed it, or 0 if the recording was stopped instead.
+func EndRecordingPause(ctx context.Context, id, resumedRecordingID int64, resumedAt time.Time) error {
+	_, err := execWrite(ctx,
+		"UPDATE recording_pauses SET resumed_at = ?, resumed_recording_id = ? WHERE id = ? AND resumed_at IS NULL",
+		resumedAt, sql.NullInt64{Int64: resumedRecordingID, Valid: resumedRecordingID != 0}, id,
+	)
+	return err
+}
+
+// ListRecordingPauses returns a meeting's pauses, oldest first
+func ListRecordingPauses(ctx context.Context, meetingID int64) ([]RecordingPause, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT "+recordingPauseColumns+" FROM recording_pauses WHERE meeting_id = ? ORDER BY paused_at, id",
+		meetingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pauses := []RecordingPause{}
+	for rows.Next() {
+		p, err := scanRecordingPause(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		pauses = append(pauses, *p)
+	}
+	return pauses, rows.Err()
+}
+
+// pausedTime returns the pause still open, if any, and the total time the
+// pauses have lasted up to now
+func pausedTime(pauses []RecordingPause, now time.Time) (open *RecordingPause, totalMS int64) {
+	for i := range pauses {
+		end := now
+		if pauses[i].ResumedAt != nil {
+			end = *pauses[i].ResumedAt
+		} else {
+			open = &pauses[i]
+		}
+		totalMS += end.Sub(pauses[i].PausedAt).Milliseconds()
+	}
+	return open, totalMS
+}
+
+type PauseRecordingResponse struct {
+	Status   string    `json:"status"` // paused
+	PausedAt time.Time `json:"pausedAt"`
+	// Segment is the recording that was stopped, now being processed like
+	// any stopped recording
+	Segment RecordingStatusResponse `json:"segment"`
+}
+
+func (s *server) pauseRecordingHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	roomName := roomParam(c)
+	if !roomScopeAllows(c, roomName, roomPermRecording) {
+		return roomScopeDenied(c)
+	}
+
+	meeting, err := s.store.GetMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	rec, err := s.store.GetActiveRecordingByMeeting(ctx, meeting.ID)
+	if errors.Is(err, ErrNotFound) {
+		if _, err := s.store.GetOpenRecordingPause(ctx, meeting.ID); err == nil {
+			return respondAPIError(c, 409, APIError{Code: "already_paused", Message: "Recording is already paused"})
+		} else if !errors.Is(err, ErrNotFound) {
+			return respondError(c, 500, err.Error())
+		}
+		return respondAPIError(c, 409, APIError{Code: "no_active_recording", Message: "No active recording"})
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	segment, cerr := s.stopRecording(ctx, roomName, rec)
+	if cerr != nil {
+		return cerr.respond(c)
+	}
+	pause, err := s.store.CreateRecordingPause(ctx, meeting.ID, rec.ID, time.Now().UTC())
+	if err != nil {
+		ctxLogger(ctx).Error("Failed to save recording pause", "egress_id", rec.EgressID, "error", err)
+		return respondError(c, 500, "Recording stopped but the pause could not be saved")
+	}
+
+	recordAudit(c, "recording.pause", roomName, rec.EgressID)
+	broadcastEvent(roomName, EventStatus, StatusEvent{Kind: "recording", State: "paused"})
+
+	return c.JSON(PauseRecordingResponse{Status: "paused", PausedAt: pause.PausedAt, Segment: segment})
+}
+
+type ResumeRecordingResponse struct {
+	Status      string `json:"status"` // recording
+	EgressID    string `json:"egressId"`
+	RecordingID int64  `json:"recordingId"`
+	PausedMS    int64  `json:"pausedMs"` // how long this pause lasted
+}
+
+func (s *server) resumeRecordingHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	roomName := roomParam(c)
+	if !roomScopeAllows(c, roomName, roomPermRecording) {
+		return roomScopeDenied(c)
+	}
+
+	meeting, err := s.store.GetMeetingByRoom(ctx, roomName)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	pause, err := s.store.GetOpenRecordingPause(ctx, meeting.ID)
+	if errors.Is(err, ErrNotFound) {
+		return respondAPIError(c, 409, APIError{Code: "not_paused", Message: "Recording is not paused"})
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	rec, started, cerr := s.startRecording(ctx, roomName)
+	if cerr != nil {
+		return cerr.respond(c)
+	}
+	if !started {
+		return respondAPIError(c, 409, APIError{
+			Code:    "already_recording",
+			Message: "A recording is already running",
+			Details: fiber.Map{"egressId": rec.EgressID},
+		})
+	}
+
+	// The new recording is running either way, so a failure here is only logged
+	resumedAt := time.Now().UTC()
+	if err := s.store.EndRecordingPause(ctx, pause.ID, rec.ID, resumedAt); err != nil {
+		ctxLogger(ctx).Error("Failed to end recording pause", "pause_id", pause.ID, "error", err)
+	}
+
+	recordAudit(c, "recording.resume", roomName, rec.EgressID)
+	broadcastEvent(roomName, EventStatus, StatusEvent{Kind: "recording", State: "resumed"})
+
+	return c.JSON(ResumeRecordingResponse{
+		Status:      "recording",
+		EgressID:    rec.EgressID,
+		RecordingID: rec.ID,
+		PausedMS:    resumedAt.Sub(pause.PausedAt).Milliseconds(),
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// A panic in a handler is answered with a 500 whose errorId the user can
+// quote, logged with its stack and request ID, counted in boom_panics_total,
+// and posted to ERROR_WEBHOOK_URL when that is set. Goroutines outside the
+// request path (timers, periodic jobs, WebSockets, the worker pool) recover
+// the same way so one bad payload cannot take the process down.
+
+const (
+	// panicRestartDelay spaces out restarts of a periodic job that panics
+	panicRestartDelay = 5 * time.Second
+	// maxErrorWebhookPosts caps reports in flight, so a panic on every
+	// request cannot pile up goroutines waiting on a slow webhook
+	maxErrorWebhookPosts = 4
+)
+
+var errorWebhookSlots = make(chan struct{}, maxErrorWebhookPosts)
+
+// PanicReport is the body posted to ERROR_WEBHOOK_URL
+type PanicReport struct {
+	ErrorID   string    `json:"errorId"`
+	Source    string    `json:"source"`         // http, websocket, or background
+	Task      string    `json:"task,omitempty"` // what was running outside a request
+	RequestID string    `json:"requestId,omitempty"`
+	Method    string    `json:"method,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	Panic     string    `json:"panic"`
+	Stack     string    `json:"stack"`
+	Time      time.Time `json:"time"`
+	// Text is a one-line summary, the field Slack incoming webhooks display
+	Text string `json:"text"`
+}
+
+// reportPanic logs, counts, and forwards a recovered panic, returning the
+// error ID it was filed under
+func reportPanic(ctx context.Context, report PanicReport, recovered any, stack []byte) string {
+	report.ErrorID = newRequestID()
+	report.RequestID = requestIDFrom(ctx)
+	report.Panic = fmt.Sprint(recovered)
+	report.Stack = string(stack)
+	report.Time = time.Now().UTC()
+	where := report.Task
+	if report.Path != "" {
+		where = report.Method + " " + report.Path
+	}
+	report.Text = fmt.Sprintf("Panic in %s (%s): %s [errorId %s]", report.Source, where, report.Panic, report.ErrorID)
+
+	panicsRecovered.WithLabelValues(report.Source).Inc()
+	ctxLogger(ctx).Error("Recovered from panic",
+		"error_id", report.ErrorID, "source", report.Source, "task", report.Task,
+		"panic", report.Panic, "stack", report.Stack)
+
+	if config.ErrorWebhookURL != "" {
+		select {
+		case errorWebhookSlots <- struct{}{}:
+			go postPanicReport(ctx, report)
+		default:
+			slog.Warn("Panic report not sent: error webhook busy", "error_id", report.ErrorID)
+		}
+	}
+	return report.ErrorID
+}
+
+func postPanicReport(ctx context.Context, report PanicReport) {
+	defer func() { <-errorWebhookSlots }()
+	body, err := json.Marshal(report)
+	if err != nil {
+		slog.Error("Failed to encode panic report", "error_id", report.ErrorID, "error", err)
+		return
+	}
+	ctx, cancel := detachedContext(ctx)
+	defer cancel()
+	if err := postRecordingWebhook(ctx, config.ErrorWebhookURL, config.ErrorWebhookSecret, body); err != nil {
+		slog.Warn("Failed to send panic report", "error_id", report.ErrorID, "error", err)
+	}
+}
+
+// recoverPanics is Fiber middleware that turns a handler panic into a 500
+// carrying the errorId it was reported under
+func recoverPanics() fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				id := reportPanic(c.UserContext(), PanicReport{
+					Source: "http",
+					Method: c.Method(),
+					Path:   c.Path(),
+				}, r, debug.Stack())
+				err = respondAPIError(c, 500, APIError{
+					Message: "Something went wrong. Quote error ID " + id + " if you report it.",
+					Details: fiber.Map{"errorId": id},
+				})
+			}
+		}()
+		return c.Next()
+	}
+}
+
+// recoverPanic reports a panic in the goroutine it is deferred in and lets
+// the goroutine end. It must be deferred directly: defer recoverPanic(...).
+func recoverPanic(ctx context.Context, source, task string) {
+	if r := recover(); r != nil {
+		reportPanic(ctx, PanicReport{Source: source, Task: task}, r, debug.Stack())
+	}
+}
+
+// runRecovered runs fn, reporting false if it panicked
+func runRecovered(ctx context.Context, task string, fn func()) (ok bool) {
+	defer recoverPanic(ctx, "background", task)
+	fn()
+	return true
+}
+
+// goPeriodic runs a periodic job on its own goroutine, restarting it after
+// a panic so one bad run does not stop it for good. Jobs wait with nextTick
+// so they return when shutdown begins.
+func goPeriodic(task string, job func()) {
+	startPeriodicJob(task)
+	go func() {
+		defer finishPeriodicJob(task)
+		for !runRecovered(context.Background(), task, job) && !shuttingDown() {
+			time.Sleep(panicRestartDelay)
+		}
+	}()
+}
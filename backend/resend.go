@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"time"
+)
+
+const resendAPIURL = "https://api.resend.com/emails"
+
+// ResendEmailSender delivers email through the Resend REST API
+type ResendEmailSender struct {
+	APIKey  string
+	From    string
+	ReplyTo string
+	URL     string
+	Client  *http.Client
+}
+
+// newResendEmailSender returns a sender configured from RESEND_API_KEY and
+// the EMAIL_ settings, or nil when Resend is not configured
+func newResendEmailSender() *ResendEmailSender {
+	if config.ResendAPIKey == "" {
+		return nil
+	}
+	return &ResendEmailSender{
+		APIKey:  config.ResendAPIKey,
+		From:    config.emailSender(),
+		ReplyTo: config.EmailReplyTo,
+		URL:     resendAPIURL,
+		Client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type resendRequest struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	ReplyTo string   `json:"reply_to,omitempty"`
+	Subject string   `json:"subject"`
+	HTML    string   `json:"html,omitempty"`
+	Text    string   `json:"text,omitempty"`
+}
+
+// Send posts one email and returns the message ID Resend assigned to it
+func (r *ResendEmailSender) Send(ctx context.Context, to []string, subject, htmlBody, textBody string) (string, error) {
+	if r.From == "" {
+		return "", fmt.Errorf("EMAIL_FROM must be set to send through Resend")
+	}
+
+	body, err := json.Marshal(resendRequest{
+		From:    r.From,
+		To:      to,
+		ReplyTo: r.ReplyTo,
+		Subject: subject,
+		HTML:    htmlBody,
+		Text:    textBody,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+r.APIKey)
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Message != "" {
+			return "", fmt.Errorf("resend returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return "", fmt.Errorf("resend returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("decode resend response: %w", err)
+	}
+	return result.ID, nil
+}
+
+// summaryEmail renders the subject and bodies of a meeting summary email,
+// with a link to the recording when recordingURL is set
+func summaryEmail(roomName, notes, recordingURL string) (subject, htmlBody, textBody string) {
+	subject = "Meeting notes: " + roomName
+	htmlBody = `<pre style="font-family: inherit; white-space: pre-wrap">` + html.EscapeString(notes) + `</pre>`
+	textBody = notes
+	if recordingURL != "" {
+		htmlBody += `<p><a href="` + html.EscapeString(recordingURL) + `">Listen to the recording</a></p>`
+		textBody += "\n\nListen to the recording: " + recordingURL
+	}
+	return subject, htmlBody, textBody
+}
+
+// sendSummaryViaResend mails the summary to each recipient separately so
+// addresses are not shared and every delivery gets its own message ID. It
+// fails only if every recipient that could be mailed failed.
+func sendSummaryViaResend(ctx context.Context, sender *ResendEmailSender, roomName, notes, recordingURL string, recipients []EmailSubscription) error {
+	var lastErr error
+	sent, failed := 0, 0
+	for _, r := range recipients {
+		link := ""
+		if r.IncludeRecording {
+			link = recordingURL
+		}
+		subject, htmlBody, textBody := summaryEmail(roomName, notes, link)
+		err := deliverEmail(ctx, r.MeetingID, []string{r.Email}, func(to []string) error {
+			messageID, err := sender.Send(ctx, to, subject, htmlBody, textBody)
+			if err != nil {
+				ctxLogger(ctx).Error("Failed to send summary through Resend", "email", r.Email, "error", err)
+				LogEmailDelivery(ctx, r.MeetingID, r.Email, "failed", err.Error(), "")
+				return err
+			}
+			LogEmailDelivery(ctx, r.MeetingID, r.Email, "sent", "", messageID)
+			sent++
+			return nil
+		})
+		if err != nil {
+			lastErr = err
+			failed++
+		}
+	}
+
+	ctxLogger(ctx).Info("Sent summary through Resend", "sent", sent, "recipients", len(recipients))
+	if sent == 0 && failed > 0 {
+		return lastErr
+	}
+	return nil
+}
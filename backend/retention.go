@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Retention purge settings. Batches keep each delete short so the purge does
+// not hold the SQLite write lock for long.
+const (
+	retentionInterval         = 24 * time.Hour
+	defaultRetentionBatchSize = 500
+	defaultRetentionRunLimit  = 5000
+)
+
+// retentionPolicy is how long one class of meeting data is kept
+type retentionPolicy struct {
+	Class      string // transcripts, notes, recordings, email_logs
+	Table      string
+	TimeColumn string
+	EnvVar     string
+	Days       int // 0 keeps the data forever
+}
+
+// retentionPolicies returns the policy for every data class, with each
+// window from its RETENTION_*_DAYS variable. Drafts follow the notes policy.
+func retentionPolicies() []retentionPolicy {
+	return []retentionPolicy{
+		{Class: "transcripts", Table: "transcript_segments", TimeColumn: "created_at", EnvVar: "RETENTION_TRANSCRIPTS_DAYS", Days: config.RetentionTranscriptsDays},
+		{Class: "notes", Table: "meeting_notes", TimeColumn: "generated_at", EnvVar: "RETENTION_NOTES_DAYS", Days: config.RetentionNotesDays},
+		{Class: "notes", Table: "meeting_note_drafts", TimeColumn: "generated_at", EnvVar: "RETENTION_NOTES_DAYS", Days: config.RetentionNotesDays},
+		{Class: "recordings", Table: "recordings", TimeColumn: "created_at", EnvVar: "RETENTION_RECORDINGS_DAYS", Days: config.RetentionRecordingsDays},
+		{Class: "email_logs", Table: "email_delivery_log", TimeColumn: "created_at", EnvVar: "RETENTION_EMAIL_LOGS_DAYS", Days: config.RetentionEmailLogsDays},
+	}
+}
+
+// RetentionResult reports what one policy purged, or would purge in a dry run
+type RetentionResult struct {
+	Class        string    `json:"class"`
+	Table        string    `json:"table"`
+	Days         int       `json:"days"`
+	Cutoff       time.Time `json:"cutoff"`
+	Rows         int       `json:"rows"`
+	FilesRemoved int       `json:"filesRemoved,omitempty"`
+}
+
+// RetentionRun summarizes a purge across all policies
+type RetentionRun struct {
+	DryRun    bool              `json:"dryRun"`
+	Limit     int               `json:"limit"`
+	Total     int               `json:"total"`
+	Truncated bool              `json:"truncated"` // the run limit was reached
+	Results   []RetentionResult `json:"results"`
+}
+
+// SetMeetingLegalHold places or lifts a legal hold, which exempts a meeting's
+// data from retention purges. It reports false if the meeting does not exist.
+func SetMeetingLegalHold(ctx context.Context, roomName string, hold bool) (bool, error) {
+	result, err := execWrite(ctx, "UPDATE meetings SET legal_hold = ? WHERE room_name = ?", hold, roomName)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// expiredRowsQuery selects rows of p older than the cutoff whose meeting is
+// not on legal hold
+func expiredRowsQuery(p retentionPolicy, columns string) string {
+	return fmt.Sprintf(
+		`SELECT %s FROM %s t JOIN meetings m ON m.id = t.meeting_id
+		 WHERE t.%s < ? AND NOT m.legal_hold ORDER BY t.id LIMIT ?`,
+		columns, p.Table, p.TimeColumn,
+	)
+}
+
+// CountExpiredRows reports how many rows of p a purge would remove, up to limit
+func CountExpiredRows(ctx context.Context, p retentionPolicy, cutoff time.Time, limit int) (int, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var n int
+	err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM ("+expiredRowsQuery(p, "t.id")+") expired",
+		cutoff.UTC(), limit,
+	).Scan(&n)
+	return n, err
+}
+
+// PurgeExpiredBatch deletes up to limit expired rows of p. For recordings it
+// also returns the audio locations of the deleted rows.
+func PurgeExpiredBatch(ctx context.Context, p retentionPolicy, cutoff time.Time, limit int) (int, []string, error) {
+	columns := "t.id, NULL"
+	if p.Table == "recordings" {
+		columns = "t.id, t.audio_url"
+	}
+
+	var ids []interface{}
+	var files []string
+	err := func() error {
+		ctx, cancel := withStatementTimeout(ctx)
+		defer cancel()
+
+		rows, err := db.QueryContext(ctx, expiredRowsQuery(p, columns), cutoff.UTC(), limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id int64
+			var file sql.NullString
+			if err := rows.Scan(&id, &file); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+			if file.String != "" {
+				files = append(files, file.String)
+			}
+		}
+		return rows.Err()
+	}()
+	if err != nil || len(ids) == 0 {
+		return 0, nil, err
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	if _, err := execWrite(ctx, "DELETE FROM "+p.Table+" WHERE id IN ("+placeholders+")", ids...); err != nil {
+		return 0, nil, err
+	}
+	return len(ids), files, nil
+}
+
+// removeRecordingFiles deletes the stored audio for purged recordings. Files
+// kept elsewhere, such as on the egress host, are left to that storage.
+func removeRecordingFiles(ctx context.Context, locations []string) int {
+	removed := 0
+	for _, location := range locations {
+		path := localRecordingPath(Recording{AudioURL: location})
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			ctxLogger(ctx).Error("Retention: failed to remove recording file", "path", path, "error", err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+// PurgeExpiredData applies every retention policy, purging at most
+// retentionRunLimit rows in batches of retentionBatchSize. With dryRun set it
+// only counts what would be purged. Each policy that matched anything is
+// recorded in the audit log.
+func PurgeExpiredData(ctx context.Context, store Store, now time.Time, dryRun bool) (*RetentionRun, error) {
+	// RETENTION_MAX_PER_RUN caps how many rows one run may purge across all
+	// classes; anything left over is picked up by the next run
+	run := &RetentionRun{DryRun: dryRun, Limit: config.RetentionMaxPerRun, Results: []RetentionResult{}}
+	batchSize := config.RetentionBatchSize
+	remaining := run.Limit
+
+	for _, p := range retentionPolicies() {
+		if p.Days == 0 {
+			continue
+		}
+		if remaining == 0 {
+			run.Truncated = true
+			break
+		}
+		result := RetentionResult{
+			Class:  p.Class,
+			Table:  p.Table,
+			Days:   p.Days,
+			Cutoff: now.AddDate(0, 0, -p.Days),
+		}
+
+		if dryRun {
+			n, err := store.CountExpiredRows(ctx, p, result.Cutoff, remaining)
+			if err != nil {
+				return run, fmt.Errorf("count %s: %w", p.Table, err)
+			}
+			result.Rows = n
+			remaining -= n
+		} else {
+			for remaining > 0 {
+				limit := batchSize
+				if remaining < limit {
+					limit = remaining
+				}
+				n, files, err := store.PurgeExpiredBatch(ctx, p, result.Cutoff, limit)
+				if err != nil {
+					return run, fmt.Errorf("purge %s: %w", p.Table, err)
+				}
+				result.Rows += n
+				result.FilesRemoved += removeRecordingFiles(ctx, files)
+				remaining -= n
+				if n < limit {
+					break
+				}
+			}
+		}
+		run.Total += result.Rows
+		run.Results = append(run.Results, result)
+
+		if result.Rows > 0 {
+			action := "retention.purge"
+			if dryRun {
+				action = "retention.dry_run"
+			}
+			details := fmt.Sprintf("%d %s rows older than %s (policy %s=%d days)",
+				result.Rows, p.Table, result.Cutoff.Format(time.RFC3339), p.EnvVar, p.Days)
+			if result.FilesRemoved > 0 {
+				details += fmt.Sprintf(", %d recording files removed", result.FilesRemoved)
+			}
+			if err := store.LogAudit(ctx, 0, action, p.Class, details, ""); err != nil {
+				ctxLogger(ctx).Error("Failed to write retention audit entry", "error", err)
+			}
+		}
+	}
+	if remaining == 0 {
+		run.Truncated = true
+	}
+	return run, nil
+}
+
+// runRetentionPurge applies the retention policies once a day. It runs for
+// the life of the process.
+func (s *server) runRetentionPurge() {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+
+	for nextTick(ticker) {
+		ctx, cancel := backgroundContext()
+		run, err := PurgeExpiredData(ctx, s.store, time.Now(), config.RetentionDryRun)
+		cancel()
+		if err != nil {
+			slog.Error("Retention purge failed", "error", err)
+			continue
+		}
+		logRetentionRun(context.Background(), run)
+	}
+}
+
+func logRetentionRun(ctx context.Context, run *RetentionRun) {
+	verb := "Purged"
+	if run.DryRun {
+		verb = "Would purge"
+	}
+	var parts []string
+	for _, r := range run.Results {
+		parts = append(parts, fmt.Sprintf("%s=%d", r.Table, r.Rows))
+	}
+	summary := strings.Join(parts, ", ")
+	if summary == "" {
+		summary = "no policies enabled"
+	}
+	ctxLogger(ctx).Info("Retention: "+verb, "rows", run.Total, "tables", summary)
+	if run.Truncated {
+		ctxLogger(ctx).Warn("Retention: run limit reached; the rest will be purged next run", "limit", run.Limit)
+	}
+}
+
+// Retention handlers
+
+// retentionRunHandler counts what the retention policies would purge. Purging
+// for real deletes data for good, so it takes dryRun=false and confirm=true.
+func (s *server) retentionRunHandler(c *fiber.Ctx) error {
+	dryRun := c.QueryBool("dryRun", true)
+	if !dryRun && !c.QueryBool("confirm", false) {
+		var errs fieldErrors
+		errs.add("confirm", "confirm=true is required to purge data; omit dryRun to see what would be purged")
+		return errs.err()
+	}
+
+	ctx, cancel := detachedContext(c.UserContext())
+	defer cancel()
+
+	run, err := PurgeExpiredData(ctx, s.store, time.Now(), dryRun)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	logRetentionRun(ctx, run)
+
+	return c.JSON(run)
+}
+
+type LegalHoldResponse struct {
+	RoomName  string `json:"roomName"`
+	LegalHold bool   `json:"legalHold"`
+}
+
+func (s *server) legalHoldHandler(hold bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		room := roomParam(c)
+
+		found, err := s.store.SetMeetingLegalHold(ctx, room, hold)
+		if err != nil {
+			return respondError(c, 500, err.Error())
+		}
+		if !found {
+			return respondError(c, 404, "Meeting not found")
+		}
+
+		action := "meeting.legal_hold_release"
+		if hold {
+			action = "meeting.legal_hold"
+		}
+		recordAudit(c, action, room, "")
+
+		return c.JSON(LegalHoldResponse{RoomName: room, LegalHold: hold})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// useRetentionConfig keeps transcripts and notes for 30 days and purges at
+// most maxPerRun rows a run in batches of batchSize
+func useRetentionConfig(t *testing.T, batchSize, maxPerRun int) {
+	t.Helper()
+	c := useTestConfig(t)
+	c.RetentionTranscriptsDays = 30
+	c.RetentionNotesDays = 30
+	c.RetentionBatchSize = batchSize
+	c.RetentionMaxPerRun = maxPerRun
+}
+
+func TestPurgeExpiredDataStaysWithinRunLimit(t *testing.T) {
+	useRetentionConfig(t, 100, 250)
+	store := newFakeStore()
+	store.expired["transcript_segments"] = 180
+	store.expired["meeting_notes"] = 120
+
+	run, err := PurgeExpiredData(context.Background(), store, time.Now(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Total != 250 || !run.Truncated {
+		t.Errorf("purged %d rows, truncated %v; want 250, true", run.Total, run.Truncated)
+	}
+	for i, limit := range store.batches {
+		if limit > 100 {
+			t.Errorf("batch %d asked for %d rows, over the batch size of 100", i, limit)
+		}
+	}
+	// 100 + 80 transcripts, then 70 notes to reach the limit
+	if want := []int{100, 100, 70}; !slices.Equal(store.batches, want) {
+		t.Errorf("batches %v, want %v", store.batches, want)
+	}
+	if store.expired["meeting_notes"] != 50 {
+		t.Errorf("%d notes left, want 50 for the next run", store.expired["meeting_notes"])
+	}
+	if len(store.audit) != 2 {
+		t.Errorf("got %d audit entries, want one per purged table", len(store.audit))
+	}
+}
+
+func TestPurgeExpiredDataDryRunDeletesNothing(t *testing.T) {
+	useRetentionConfig(t, 100, 250)
+	store := newFakeStore()
+	store.expired["transcript_segments"] = 400
+
+	run, err := PurgeExpiredData(context.Background(), store, time.Now(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Total != 250 || !run.Truncated || len(store.batches) != 0 {
+		t.Errorf("dry run counted %d, truncated %v, with %d batches; want 250, true, 0", run.Total, run.Truncated, len(store.batches))
+	}
+	if store.expired["transcript_segments"] != 400 {
+		t.Errorf("dry run deleted rows")
+	}
+}
+
+func TestPurgeExpiredBatchHonorsLimit(t *testing.T) {
+	useRetentionConfig(t, 100, 250)
+	useTestDB(t)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if _, err := SaveTranscriptSegment(ctx, "old-room", "Ada", "hello", time.Now().Format(time.RFC3339Nano)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := retentionPolicies()[0]
+	future := time.Now().Add(time.Hour)
+
+	n, _, err := PurgeExpiredBatch(ctx, p, future, 3)
+	if err != nil || n != 3 {
+		t.Fatalf("first batch deleted %d (%v), want 3", n, err)
+	}
+	n, _, err = PurgeExpiredBatch(ctx, p, future, 3)
+	if err != nil || n != 2 {
+		t.Fatalf("second batch deleted %d (%v), want 2", n, err)
+	}
+}
+
+func TestRetentionRunRequiresConfirmation(t *testing.T) {
+	useRetentionConfig(t, 100, 250)
+	useTestDB(t)
+	store := newFakeStore()
+	store.expired["transcript_segments"] = 10
+	app := newTestApp(t, newTestServer(t, store))
+	admin := bearer(t, testAdminEmail)
+
+	resp := doRequest(t, app, "POST", "/api/admin/retention/run", nil, admin...)
+	var run RetentionRun
+	resp.decode(t, &run)
+	if resp.Status != 200 || !run.DryRun || run.Total != 10 {
+		t.Errorf("default run: got %d %+v, want a dry run counting 10", resp.Status, run)
+	}
+
+	resp = doRequest(t, app, "POST", "/api/admin/retention/run?dryRun=false", nil, admin...)
+	if resp.Status != 422 || resp.apiError(t).Fields[0].Field != "confirm" {
+		t.Errorf("unconfirmed purge: got %d %s, want 422 on confirm", resp.Status, resp.Body)
+	}
+	if store.expired["transcript_segments"] != 10 {
+		t.Fatal("unconfirmed purge deleted rows")
+	}
+
+	resp = doRequest(t, app, "POST", "/api/admin/retention/run?dryRun=false&confirm=true", nil, admin...)
+	resp.decode(t, &run)
+	if resp.Status != 200 || run.DryRun || run.Total != 10 || store.expired["transcript_segments"] != 0 {
+		t.Errorf("confirmed purge: got %d %+v", resp.Status, run)
+	}
+
+	if resp := doRequest(t, app, "POST", "/api/admin/retention/run", nil, bearer(t, testUserEmail)...); resp.Status != 403 {
+		t.Errorf("non-admin: got %d, want 403", resp.Status)
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Room API keys let kiosks and embedded clients, which cannot hold a user
+// session, act on a single room. The key is shown once when created; only its
+// SHA-256 hash is stored.
+
+const (
+	roomAPIKeyHeader     = "X-API-Key"
+	roomAPIKeyPrefix     = "brk_"
+	defaultRoomKeyExpiry = 30 * 24 * time.Hour
+)
+
+// Room API key permissions
+const (
+	roomPermJoin          = "join"          // request LiveKit tokens
+	roomPermTranscription = "transcription" // start and end transcription
+	roomPermRecording     = "recording"     // start, stop, and check recordings
+	roomPermNotes         = "notes"         // read notes and drafts
+	roomPermManage        = "manage"        // host-only room endpoints
+)
+
+var roomPermissions = map[string]bool{
+	roomPermJoin:          true,
+	roomPermTranscription: true,
+	roomPermRecording:     true,
+	roomPermNotes:         true,
+	roomPermManage:        true,
+}
+
+// defaultRoomKeyPermissions covers what a kiosk needs to run a meeting
+var defaultRoomKeyPermissions = []string{roomPermJoin, roomPermTranscription, roomPermRecording, roomPermNotes}
+
+// RoomAPIKey is a stored room-scoped key
+type RoomAPIKey struct {
+	ID          int64     `json:"id"`
+	RoomName    string    `json:"roomName"`
+	Permissions []string  `json:"permissions"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func hashRoomAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateRoomAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return roomAPIKeyPrefix + hex.EncodeToString(b), nil
+}
+
+// CreateRoomAPIKey stores the hash of a new room key
+func CreateRoomAPIKey(ctx context.Context, keyHash, roomName string, permissions []string, expiresAt time.Time, createdBy int64) (*RoomAPIKey, error) {
+	perms, err := json.Marshal(permissions)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	id, err := insertReturningID(ctx,
+		"INSERT INTO room_api_keys (key_hash, room_name, permissions, expires_at, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		keyHash, roomName, string(perms), expiresAt.UTC(), createdBy, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &RoomAPIKey{ID: id, RoomName: roomName, Permissions: permissions, ExpiresAt: expiresAt.UTC(), CreatedAt: now}, nil
+}
+
+// GetRoomAPIKeyByHash returns the unexpired key with the given hash, or nil
+func GetRoomAPIKeyByHash(ctx context.Context, keyHash string) (*RoomAPIKey, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var k RoomAPIKey
+	var perms string
+	err := db.QueryRowContext(ctx,
+		"SELECT id, room_name, permissions, expires_at, created_at FROM room_api_keys WHERE key_hash = ? AND expires_at > ?",
+		keyHash, time.Now().UTC(),
+	).Scan(&k.ID, &k.RoomName, &perms, &k.ExpiresAt, &k.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(perms), &k.Permissions); err != nil {
+		return nil, fmt.Errorf("room key %d permissions: %w", k.ID, err)
+	}
+	return &k, nil
+}
+
+// resolveRoomAPIKey checks the X-API-Key header and, for a valid key, scopes
+// the request to its room. It reports false after writing an error response.
+func resolveRoomAPIKey(c *fiber.Ctx) (bool, error) {
+	key, err := GetRoomAPIKeyByHash(c.UserContext(), hashRoomAPIKey(c.Get(roomAPIKeyHeader)))
+	if err != nil {
+		return false, respondError(c, 500, "Failed to check API key")
+	}
+	if key == nil {
+		return false, respondError(c, 401, "Invalid or expired API key")
+	}
+	c.Locals("room_scope", key.RoomName)
+	c.Locals("room_permissions", key.Permissions)
+	return true, nil
+}
+
+// apiKeyRequired is Fiber middleware that accepts either a user JWT or a
+// room API key. Handlers behind it must check the room scope, which
+// canManageRoom does.
+func apiKeyRequired() fiber.Handler {
+	userAuth := authRequired()
+	return func(c *fiber.Ctx) error {
+		if c.Get(roomAPIKeyHeader) == "" {
+			return userAuth(c)
+		}
+		if ok, err := resolveRoomAPIKey(c); !ok {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// apiKeyOptional is Fiber middleware for open room endpoints: requests
+// without a key pass through, while a presented key must be valid and then
+// limits the request to its room
+func apiKeyOptional() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get(roomAPIKeyHeader) == "" {
+			return c.Next()
+		}
+		if ok, err := resolveRoomAPIKey(c); !ok {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// roomScopeAllows reports whether a request may act on roomName. Requests
+// without a room key are unrestricted here; room keys must match the room and
+// carry the permission.
+func roomScopeAllows(c *fiber.Ctx, roomName, permission string) bool {
+	scope, ok := c.Locals("room_scope").(string)
+	if !ok {
+		return true
+	}
+	if scope != roomName {
+		return false
+	}
+	perms, _ := c.Locals("room_permissions").([]string)
+	for _, p := range perms {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// roomScopeDenied is the response for a room key used outside its scope
+func roomScopeDenied(c *fiber.Ctx) error {
+	return respondError(c, 403, "API key does not grant access to this room")
+}
+
+// Room API key handlers
+
+type CreateRoomAPIKeyRequest struct {
+	Permissions []string `json:"permissions"` // defaults to join, transcription, recording, notes
+	ExpiresAt   string   `json:"expiresAt"`   // ISO 8601; defaults to 30 days from now
+
+	// Normalized by validate
+	permissions []string
+	expiresAt   time.Time
+}
+
+func (r *CreateRoomAPIKeyRequest) validate(errs *fieldErrors) {
+	r.permissions = defaultRoomKeyPermissions
+	if len(r.Permissions) > 0 {
+		seen := map[string]bool{}
+		r.permissions = nil
+		for _, p := range r.Permissions {
+			p = strings.ToLower(strings.TrimSpace(p))
+			if !roomPermissions[p] {
+				errs.add("permissions", fmt.Sprintf("Unknown permission %q", p))
+			}
+			if !seen[p] {
+				seen[p] = true
+				r.permissions = append(r.permissions, p)
+			}
+		}
+	}
+
+	r.expiresAt = time.Now().Add(defaultRoomKeyExpiry)
+	if t := errs.timestamp("expiresAt", r.ExpiresAt); !t.IsZero() {
+		if !t.After(time.Now()) {
+			errs.add("expiresAt", "expiresAt must be in the future")
+		}
+		r.expiresAt = t
+	}
+}
+
+// CreateRoomAPIKeyResponse carries the new key, which is only ever returned
+// here; the backend keeps just its hash
+type CreateRoomAPIKeyResponse struct {
+	ID          int64     `json:"id"`
+	Key         string    `json:"key"`
+	RoomName    string    `json:"roomName"`
+	Permissions []string  `json:"permissions"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+func (s *server) createRoomAPIKeyHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+
+	var req CreateRoomAPIKeyRequest
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, &req); err != nil {
+			return err
+		}
+	} else if err := validateRequest(&req); err != nil {
+		return err
+	}
+	permissions, expiresAt := req.permissions, req.expiresAt
+
+	key, err := generateRoomAPIKey()
+	if err != nil {
+		return respondError(c, 500, "Failed to generate key")
+	}
+	userID, _ := c.Locals("userID").(int64)
+	stored, err := s.store.CreateRoomAPIKey(ctx, hashRoomAPIKey(key), room, permissions, expiresAt, userID)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	recordAudit(c, "room.api_key_create", room, fmt.Sprintf("key %d: %s until %s",
+		stored.ID, strings.Join(permissions, ","), stored.ExpiresAt.Format(time.RFC3339)))
+
+	return c.JSON(CreateRoomAPIKeyResponse{
+		ID:          stored.ID,
+		Key:         key,
+		RoomName:    stored.RoomName,
+		Permissions: stored.Permissions,
+		ExpiresAt:   stored.ExpiresAt,
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MAX_ACTIVE_ROOMS_PER_HOST caps how many rooms a host can have open at once,
+// so a runaway script cannot exhaust LiveKit. A host's rooms are the ad-hoc
+// rooms they created and their scheduled meetings that have been started;
+// a room stops counting once it has ended. Admins are not limited.
+
+// CountActiveHostRooms returns how many unended rooms a host has open, not
+// counting exceptRoom, so re-creating an open room is not refused
+func CountActiveHostRooms(ctx context.Context, hostUserID int64, exceptRoom string) (int, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var n int
+	err := db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM (
+			SELECT room_name FROM meetings WHERE host_user_id = ? AND ended_at IS NULL
+			UNION
+			SELECT sm.room_name FROM scheduled_meetings sm
+			LEFT JOIN meetings m ON m.room_name = sm.room_name
+			WHERE sm.host_user_id = ? AND sm.status = 'active' AND m.ended_at IS NULL
+		) rooms WHERE room_name != ?`,
+		hostUserID, hostUserID, exceptRoom,
+	).Scan(&n)
+	return n, err
+}
+
+// checkActiveRoomLimit refuses to open roomName when the signed-in host
+// already has MAX_ACTIVE_ROOMS_PER_HOST other rooms open
+func (s *server) checkActiveRoomLimit(c *fiber.Ctx, roomName string) *requestError {
+	limit := config.MaxActiveRoomsPerHost
+	if limit == 0 {
+		return nil
+	}
+	if email, _ := c.Locals("userEmail").(string); isAdmin(email) {
+		return nil
+	}
+	userID, _ := c.Locals("userID").(int64)
+	n, err := s.store.CountActiveHostRooms(c.UserContext(), userID, roomName)
+	if err != nil {
+		return &requestError{500, APIError{Message: err.Error()}}
+	}
+	if n >= limit {
+		return &requestError{429, APIError{
+			Message: fmt.Sprintf("You can have at most %d active rooms at once; end one before opening another", limit),
+			Details: fiber.Map{"activeRooms": n, "limit": limit},
+		}}
+	}
+	return nil
+}
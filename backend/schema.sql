@@ -18,7 +18,7 @@ CREATE TABLE IF NOT EXISTS meeting_notes (
     model_used TEXT DEFAULT 'claude-sonnet-4-20250514',
     input_tokens INTEGER,
     output_tokens INTEGER,
-    FOREIGN KEY (meeting_id) REFERENCES meetings(id)
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
 );
 
 -- recordings table (for batch transcription pivot)
@@ -31,12 +31,14 @@ CREATE TABLE IF NOT EXISTS recordings (
     duration_ms INTEGER,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     completed_at DATETIME,
-    FOREIGN KEY (meeting_id) REFERENCES meetings(id)
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
 );
 
 -- Indexes
 CREATE INDEX IF NOT EXISTS idx_meetings_room_name ON meetings(room_name);
 CREATE INDEX IF NOT EXISTS idx_notes_meeting ON meeting_notes(meeting_id);
+CREATE INDEX IF NOT EXISTS idx_notes_generated ON meeting_notes(generated_at);
+CREATE INDEX IF NOT EXISTS idx_meetings_created ON meetings(created_at);
 CREATE INDEX IF NOT EXISTS idx_recordings_meeting ON recordings(meeting_id);
 CREATE INDEX IF NOT EXISTS idx_recordings_egress ON recordings(egress_id);
 
@@ -47,7 +49,7 @@ CREATE TABLE IF NOT EXISTS email_subscriptions (
     participant_name TEXT NOT NULL,
     email TEXT NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (meeting_id) REFERENCES meetings(id),
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE,
     UNIQUE(meeting_id, email)
 );
 
@@ -72,8 +74,218 @@ CREATE TABLE IF NOT EXISTS scheduled_meetings (
     scheduled_at DATETIME NOT NULL,
     status TEXT DEFAULT 'scheduled',
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (host_user_id) REFERENCES users(id)
+    FOREIGN KEY (host_user_id) REFERENCES users(id) ON DELETE RESTRICT
 );
 
 CREATE INDEX IF NOT EXISTS idx_scheduled_host ON scheduled_meetings(host_user_id);
 CREATE INDEX IF NOT EXISTS idx_scheduled_room ON scheduled_meetings(room_name);
+
+-- email_delivery_log table (one row per summary email per recipient)
+CREATE TABLE IF NOT EXISTS email_delivery_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    meeting_id INTEGER NOT NULL,
+    email TEXT NOT NULL,
+    status TEXT NOT NULL, -- sent, failed, suppressed, bounced, complained
+    bounce_type TEXT, -- hard, soft, complaint
+    reason TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_delivery_meeting ON email_delivery_log(meeting_id);
+CREATE INDEX IF NOT EXISTS idx_email_delivery_email ON email_delivery_log(email);
+
+-- email_bounces table (bounce and complaint history per address)
+CREATE TABLE IF NOT EXISTS email_bounces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL,
+    bounce_type TEXT NOT NULL, -- hard, soft, complaint
+    reason TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_bounces_email ON email_bounces(email);
+
+-- email_suppressions table (addresses that must never be mailed)
+CREATE TABLE IF NOT EXISTS email_suppressions (
+    email TEXT PRIMARY KEY,
+    reason TEXT NOT NULL,
+    bounce_type TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- audit_log table (sensitive actions, kept for compliance)
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_user_id INTEGER, -- NULL for system actions
+    action TEXT NOT NULL,
+    target TEXT,
+    details TEXT,
+    ip_address TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_created ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_action ON audit_log(action);
+CREATE INDEX IF NOT EXISTS idx_audit_target ON audit_log(target);
+
+-- transcript_segments table (final transcript lines from the AI service)
+CREATE TABLE IF NOT EXISTS transcript_segments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    meeting_id INTEGER NOT NULL,
+    speaker TEXT,
+    text TEXT NOT NULL,
+    segment_ts TEXT, -- timestamp reported by the AI service
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_meeting ON transcript_segments(meeting_id);
+
+-- transcript_mirrors table (rooms that receive another room's transcript stream)
+CREATE TABLE IF NOT EXISTS transcript_mirrors (
+    source_room TEXT NOT NULL,
+    target_room TEXT NOT NULL,
+    created_by INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source_room, target_room)
+);
+
+-- transcript_segment_hashes table (recently received segments, for dropping retried duplicates)
+CREATE TABLE IF NOT EXISTS transcript_segment_hashes (
+    hash TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_hashes_created ON transcript_segment_hashes(created_at);
+
+-- recording_consents table (latest recording consent answer per participant)
+CREATE TABLE IF NOT EXISTS recording_consents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    meeting_id INTEGER NOT NULL,
+    participant_identity TEXT NOT NULL,
+    consent_given BOOLEAN NOT NULL,
+    consent_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE,
+    UNIQUE(meeting_id, participant_identity)
+);
+
+-- participants table (one row per join; rejoining starts a new row)
+CREATE TABLE IF NOT EXISTS participants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    meeting_id INTEGER NOT NULL,
+    identity TEXT NOT NULL,
+    name TEXT,
+    metadata TEXT,
+    joined_at DATETIME NOT NULL,
+    left_at DATETIME,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_participants_meeting ON participants(meeting_id, identity);
+CREATE INDEX IF NOT EXISTS idx_participants_open ON participants(left_at);
+
+-- meeting_events table (attendance changes for the meeting timeline)
+CREATE TABLE IF NOT EXISTS meeting_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    meeting_id INTEGER NOT NULL,
+    event_type TEXT NOT NULL, -- participant_joined, participant_left
+    identity TEXT NOT NULL,
+    name TEXT,
+    occurred_at DATETIME NOT NULL,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_meeting_events_meeting ON meeting_events(meeting_id, occurred_at);
+
+-- meeting_note_drafts table (interim summaries generated while a meeting is running)
+CREATE TABLE IF NOT EXISTS meeting_note_drafts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    meeting_id INTEGER NOT NULL,
+    notes_markdown TEXT NOT NULL,
+    model_used TEXT,
+    input_tokens INTEGER,
+    output_tokens INTEGER,
+    segment_count INTEGER NOT NULL, -- transcript segments the draft covers
+    generated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_drafts_meeting ON meeting_note_drafts(meeting_id);
+
+-- acknowledgements table (participants who acknowledged the recording notice before joining)
+CREATE TABLE IF NOT EXISTS acknowledgements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_name TEXT NOT NULL,
+    participant_identity TEXT NOT NULL,
+    acknowledged_at DATETIME NOT NULL,
+    ip_address TEXT,
+    UNIQUE(room_name, participant_identity)
+);
+
+-- breakout_rooms table (child rooms split off from a meeting; removed when the breakout ends)
+CREATE TABLE IF NOT EXISTS breakout_rooms (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    parent_room TEXT NOT NULL,
+    child_room TEXT UNIQUE NOT NULL,
+    name TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_breakout_parent ON breakout_rooms(parent_room);
+
+-- room_api_keys table (room-scoped keys for kiosks and embeds; only the hash is stored)
+CREATE TABLE IF NOT EXISTS room_api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    key_hash TEXT UNIQUE NOT NULL,
+    room_name TEXT NOT NULL,
+    permissions TEXT NOT NULL, -- JSON array, e.g. ["join","transcription"]
+    expires_at DATETIME NOT NULL,
+    created_by INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_api_keys_room ON room_api_keys(room_name);
+
+-- speaker_names table (host-assigned names for the AI service's speaker labels)
+CREATE TABLE IF NOT EXISTS speaker_names (
+    meeting_id INTEGER NOT NULL,
+    speaker_label TEXT NOT NULL,
+    display_name TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (meeting_id, speaker_label),
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+-- recording_pauses table (pausing ends the recording's egress; resuming starts a new recording)
+CREATE TABLE IF NOT EXISTS recording_pauses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    meeting_id INTEGER NOT NULL,
+    recording_id INTEGER NOT NULL, -- the recording that was paused
+    resumed_recording_id INTEGER, -- the recording that resumed it
+    paused_at DATETIME NOT NULL,
+    resumed_at DATETIME,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE,
+    FOREIGN KEY (recording_id) REFERENCES recordings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_recording_pauses_meeting ON recording_pauses(meeting_id);
+
+-- schema_version table (the newest schema any binary has migrated this database to)
+CREATE TABLE IF NOT EXISTS schema_version (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    version INTEGER NOT NULL,
+    binary_version TEXT, -- of the binary that applied it
+    applied_at DATETIME NOT NULL
+);
+
+-- orphaned_rows table (rows set aside because their parent no longer existed when foreign keys were enforced)
+CREATE TABLE IF NOT EXISTS orphaned_rows (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    table_name TEXT NOT NULL,
+    row_id INTEGER NOT NULL,
+    row_data TEXT NOT NULL, -- the row as a JSON object
+    quarantined_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,314 @@
+-- Boom Prototype Database Schema (Postgres)
+-- Keep in sync with schema.sql; columns added there through columnMigrations
+-- are declared inline here.
+
+-- meetings table
+CREATE TABLE IF NOT EXISTS meetings (
+    id BIGSERIAL PRIMARY KEY,
+    room_name TEXT UNIQUE NOT NULL,
+    room_sid TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMPTZ,
+    language TEXT,
+    auto_send_summary BOOLEAN NOT NULL DEFAULT TRUE,
+    stats_json TEXT, -- cached /stats response once the meeting has ended
+    recording_locked BOOLEAN NOT NULL DEFAULT FALSE,
+    legal_hold BOOLEAN NOT NULL DEFAULT FALSE, -- exempt from retention purges
+    transcribing_since TIMESTAMPTZ, -- set while the AI service is in the room
+    host_user_id BIGINT -- user who created the room; NULL for scheduled and webhook-created rooms
+);
+
+-- meeting_notes table
+CREATE TABLE IF NOT EXISTS meeting_notes (
+    id BIGSERIAL PRIMARY KEY,
+    meeting_id BIGINT NOT NULL,
+    notes_markdown TEXT NOT NULL,
+    generated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    model_used TEXT DEFAULT 'claude-sonnet-4-20250514',
+    input_tokens INTEGER,
+    output_tokens INTEGER,
+    etag TEXT,
+    word_count INTEGER,
+    reading_time_s INTEGER,
+    total_cost_usd DOUBLE PRECISION,
+    notes_html TEXT,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+-- recordings table (for batch transcription pivot)
+CREATE TABLE IF NOT EXISTS recordings (
+    id BIGSERIAL PRIMARY KEY,
+    meeting_id BIGINT NOT NULL,
+    egress_id TEXT UNIQUE NOT NULL,
+    status TEXT DEFAULT 'recording', -- recording, processing, completed, failed
+    audio_url TEXT,
+    duration_ms INTEGER,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_meetings_room_name ON meetings(room_name);
+CREATE INDEX IF NOT EXISTS idx_notes_meeting ON meeting_notes(meeting_id);
+CREATE INDEX IF NOT EXISTS idx_notes_generated ON meeting_notes(generated_at);
+CREATE INDEX IF NOT EXISTS idx_meetings_created ON meetings(created_at);
+CREATE INDEX IF NOT EXISTS idx_recordings_meeting ON recordings(meeting_id);
+CREATE INDEX IF NOT EXISTS idx_recordings_egress ON recordings(egress_id);
+
+-- email_subscriptions table (for meeting summary emails)
+CREATE TABLE IF NOT EXISTS email_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    meeting_id BIGINT NOT NULL,
+    participant_name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    include_recording BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE,
+    UNIQUE(meeting_id, email)
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_subs_meeting ON email_subscriptions(meeting_id);
+
+-- users table (seeded, no registration)
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    email TEXT UNIQUE NOT NULL,
+    password_hash TEXT NOT NULL,
+    name TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    namespace TEXT, -- prefix for hosted room names in multi-tenant setups
+    deleted_at TIMESTAMPTZ, -- set when the user's data was erased
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- scheduled_meetings table
+CREATE TABLE IF NOT EXISTS scheduled_meetings (
+    id BIGSERIAL PRIMARY KEY,
+    room_name TEXT UNIQUE NOT NULL,
+    host_user_id BIGINT NOT NULL,
+    client_name TEXT,
+    client_email TEXT,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    status TEXT DEFAULT 'scheduled',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    link_expires_at TIMESTAMPTZ, -- NULL means the default window after scheduled_at
+    slug TEXT, -- vanity invite link; unique via idx_scheduled_slug
+    join_pin_hash TEXT, -- bcrypt hash of the PIN guests must give
+    FOREIGN KEY (host_user_id) REFERENCES users(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_host ON scheduled_meetings(host_user_id);
+CREATE INDEX IF NOT EXISTS idx_scheduled_room ON scheduled_meetings(room_name);
+
+-- email_delivery_log table (one row per summary email per recipient)
+CREATE TABLE IF NOT EXISTS email_delivery_log (
+    id BIGSERIAL PRIMARY KEY,
+    meeting_id BIGINT NOT NULL,
+    email TEXT NOT NULL,
+    status TEXT NOT NULL, -- sent, failed, suppressed, bounced, complained
+    bounce_type TEXT, -- hard, soft, complaint
+    reason TEXT,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    provider_message_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_delivery_meeting ON email_delivery_log(meeting_id);
+CREATE INDEX IF NOT EXISTS idx_email_delivery_email ON email_delivery_log(email);
+
+-- email_bounces table (bounce and complaint history per address)
+CREATE TABLE IF NOT EXISTS email_bounces (
+    id BIGSERIAL PRIMARY KEY,
+    email TEXT NOT NULL,
+    bounce_type TEXT NOT NULL, -- hard, soft, complaint
+    reason TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_bounces_email ON email_bounces(email);
+
+-- email_suppressions table (addresses that must never be mailed)
+CREATE TABLE IF NOT EXISTS email_suppressions (
+    email TEXT PRIMARY KEY,
+    reason TEXT NOT NULL,
+    bounce_type TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- audit_log table (sensitive actions, kept for compliance)
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_user_id BIGINT, -- NULL for system actions
+    action TEXT NOT NULL,
+    target TEXT,
+    details TEXT,
+    ip_address TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_created ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_action ON audit_log(action);
+CREATE INDEX IF NOT EXISTS idx_audit_target ON audit_log(target);
+
+-- transcript_segments table (final transcript lines from the AI service)
+CREATE TABLE IF NOT EXISTS transcript_segments (
+    id BIGSERIAL PRIMARY KEY,
+    meeting_id BIGINT NOT NULL,
+    speaker TEXT,
+    text TEXT NOT NULL,
+    segment_ts TEXT, -- timestamp reported by the AI service
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_meeting ON transcript_segments(meeting_id);
+
+-- transcript_mirrors table (rooms that receive another room's transcript stream)
+CREATE TABLE IF NOT EXISTS transcript_mirrors (
+    source_room TEXT NOT NULL,
+    target_room TEXT NOT NULL,
+    created_by BIGINT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source_room, target_room)
+);
+
+-- transcript_segment_hashes table (recently received segments, for dropping retried duplicates)
+CREATE TABLE IF NOT EXISTS transcript_segment_hashes (
+    hash TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_hashes_created ON transcript_segment_hashes(created_at);
+
+-- recording_consents table (latest recording consent answer per participant)
+CREATE TABLE IF NOT EXISTS recording_consents (
+    id BIGSERIAL PRIMARY KEY,
+    meeting_id BIGINT NOT NULL,
+    participant_identity TEXT NOT NULL,
+    consent_given BOOLEAN NOT NULL,
+    consent_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE,
+    UNIQUE(meeting_id, participant_identity)
+);
+
+-- participants table (one row per join; rejoining starts a new row)
+CREATE TABLE IF NOT EXISTS participants (
+    id BIGSERIAL PRIMARY KEY,
+    meeting_id BIGINT NOT NULL,
+    identity TEXT NOT NULL,
+    name TEXT,
+    metadata TEXT,
+    joined_at TIMESTAMPTZ NOT NULL,
+    left_at TIMESTAMPTZ,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_participants_meeting ON participants(meeting_id, identity);
+CREATE INDEX IF NOT EXISTS idx_participants_open ON participants(left_at);
+
+-- meeting_events table (attendance changes for the meeting timeline)
+CREATE TABLE IF NOT EXISTS meeting_events (
+    id BIGSERIAL PRIMARY KEY,
+    meeting_id BIGINT NOT NULL,
+    event_type TEXT NOT NULL, -- participant_joined, participant_left
+    identity TEXT NOT NULL,
+    name TEXT,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_meeting_events_meeting ON meeting_events(meeting_id, occurred_at);
+
+-- meeting_note_drafts table (interim summaries generated while a meeting is running)
+CREATE TABLE IF NOT EXISTS meeting_note_drafts (
+    id BIGSERIAL PRIMARY KEY,
+    meeting_id BIGINT NOT NULL,
+    notes_markdown TEXT NOT NULL,
+    model_used TEXT,
+    input_tokens INTEGER,
+    output_tokens INTEGER,
+    segment_count INTEGER NOT NULL, -- transcript segments the draft covers
+    generated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_drafts_meeting ON meeting_note_drafts(meeting_id);
+
+-- acknowledgements table (participants who acknowledged the recording notice before joining)
+CREATE TABLE IF NOT EXISTS acknowledgements (
+    id BIGSERIAL PRIMARY KEY,
+    room_name TEXT NOT NULL,
+    participant_identity TEXT NOT NULL,
+    acknowledged_at TIMESTAMPTZ NOT NULL,
+    ip_address TEXT,
+    UNIQUE(room_name, participant_identity)
+);
+
+-- breakout_rooms table (child rooms split off from a meeting; removed when the breakout ends)
+CREATE TABLE IF NOT EXISTS breakout_rooms (
+    id BIGSERIAL PRIMARY KEY,
+    parent_room TEXT NOT NULL,
+    child_room TEXT UNIQUE NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_breakout_parent ON breakout_rooms(parent_room);
+
+-- room_api_keys table (room-scoped keys for kiosks and embeds; only the hash is stored)
+CREATE TABLE IF NOT EXISTS room_api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    key_hash TEXT UNIQUE NOT NULL,
+    room_name TEXT NOT NULL,
+    permissions TEXT NOT NULL, -- JSON array, e.g. ["join","transcription"]
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_by BIGINT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_api_keys_room ON room_api_keys(room_name);
+
+-- speaker_names table (host-assigned names for the AI service's speaker labels)
+CREATE TABLE IF NOT EXISTS speaker_names (
+    meeting_id BIGINT NOT NULL,
+    speaker_label TEXT NOT NULL,
+    display_name TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (meeting_id, speaker_label),
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE
+);
+
+-- recording_pauses table (pausing ends the recording's egress; resuming starts a new recording)
+CREATE TABLE IF NOT EXISTS recording_pauses (
+    id BIGSERIAL PRIMARY KEY,
+    meeting_id BIGINT NOT NULL,
+    recording_id BIGINT NOT NULL, -- the recording that was paused
+    resumed_recording_id BIGINT, -- the recording that resumed it
+    paused_at TIMESTAMPTZ NOT NULL,
+    resumed_at TIMESTAMPTZ,
+    FOREIGN KEY (meeting_id) REFERENCES meetings(id) ON DELETE CASCADE,
+    FOREIGN KEY (recording_id) REFERENCES recordings(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_recording_pauses_meeting ON recording_pauses(meeting_id);
+
+-- schema_version table (the newest schema any binary has migrated this database to)
+CREATE TABLE IF NOT EXISTS schema_version (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    version INTEGER NOT NULL,
+    binary_version TEXT, -- of the binary that applied it
+    applied_at TIMESTAMPTZ NOT NULL
+);
+
+-- orphaned_rows table (rows set aside because their parent no longer existed when foreign keys were enforced)
+CREATE TABLE IF NOT EXISTS orphaned_rows (
+    id BIGSERIAL PRIMARY KEY,
+    table_name TEXT NOT NULL,
+    row_id BIGINT NOT NULL,
+    row_data TEXT NOT NULL, -- the row as a JSON object
+    quarantined_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
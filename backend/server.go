@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// RoomService is the subset of the LiveKit room API the handlers use.
+// *lksdk.RoomServiceClient satisfies it.
+type RoomService interface {
+	CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error)
+	ListRooms(ctx context.Context, req *livekit.ListRoomsRequest) (*livekit.ListRoomsResponse, error)
+	ListParticipants(ctx context.Context, req *livekit.ListParticipantsRequest) (*livekit.ListParticipantsResponse, error)
+	DeleteRoom(ctx context.Context, req *livekit.DeleteRoomRequest) (*livekit.DeleteRoomResponse, error)
+	SendData(ctx context.Context, req *livekit.SendDataRequest) (*livekit.SendDataResponse, error)
+}
+
+// EgressService is the subset of the LiveKit egress API the handlers use.
+// *lksdk.EgressClient satisfies it.
+type EgressService interface {
+	StartRoomCompositeEgress(ctx context.Context, req *livekit.RoomCompositeEgressRequest) (*livekit.EgressInfo, error)
+	StopEgress(ctx context.Context, req *livekit.StopEgressRequest) (*livekit.EgressInfo, error)
+	ListEgress(ctx context.Context, req *livekit.ListEgressRequest) (*livekit.ListEgressResponse, error)
+}
+
+// server holds the dependencies shared by the HTTP handlers
+type server struct {
+	store  Store
+	rooms  RoomService
+	egress EgressService
+
+	drafts *draftRuns
+	leaves *leaveDebouncer
+	jobs   *JobQueue // background work started by requests
+
+	liveRoomCache *liveRoomCache   // for the admin overview
+	pinAttempts   *joinPinAttempts // wrong join PINs per room
+	aiHealth      *aiHealthCache
+}
+
+func newServer(store Store, rooms RoomService, egress EgressService) *server {
+	return &server{
+		store:  store,
+		rooms:  rooms,
+		egress: egress,
+		drafts: newDraftRuns(),
+		leaves: newLeaveDebouncer(),
+		jobs:   newJobQueue(config.JobQueueWorkers, config.JobQueueSize),
+
+		liveRoomCache: &liveRoomCache{},
+		pinAttempts:   newJoinPinAttempts(),
+		aiHealth:      &aiHealthCache{},
+	}
+}
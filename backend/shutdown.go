@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+// Shutdown drains in order: new WebSocket upgrades are refused and connected
+// clients are sent a going_away close, in-flight HTTP requests finish,
+// periodic jobs finish the run they are in, and queued background jobs run.
+// All of it shares one SHUTDOWN_TIMEOUT budget; whatever is still running
+// when it is spent is abandoned and named in the summary. The WAL is
+// checkpointed last, as the database closes.
+
+// wsCloseGrace is the longest shutdown waits for clients to answer the
+// going_away close before moving on
+const wsCloseGrace = 5 * time.Second
+
+var (
+	shutdownStarted = make(chan struct{})
+	shutdownOnce    sync.Once
+)
+
+// beginShutdown tells WebSocket upgrades and periodic jobs to stop
+func beginShutdown() {
+	shutdownOnce.Do(func() { close(shutdownStarted) })
+}
+
+func shuttingDown() bool {
+	select {
+	case <-shutdownStarted:
+		return true
+	default:
+		return false
+	}
+}
+
+// nextTick waits for ticker's next tick, reporting false once shutdown has
+// begun so a periodic job returns between runs instead of starting another
+func nextTick(ticker *time.Ticker) bool {
+	select {
+	case <-shutdownStarted:
+		return false
+	case <-ticker.C:
+		return !shuttingDown()
+	}
+}
+
+// periodicJobs tracks the jobs started with goPeriodic
+var periodicJobs = struct {
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}{running: map[string]bool{}}
+
+func startPeriodicJob(task string) {
+	periodicJobs.mu.Lock()
+	defer periodicJobs.mu.Unlock()
+	periodicJobs.running[task] = true
+	periodicJobs.wg.Add(1)
+}
+
+func finishPeriodicJob(task string) {
+	periodicJobs.mu.Lock()
+	defer periodicJobs.mu.Unlock()
+	delete(periodicJobs.running, task)
+	periodicJobs.wg.Done()
+}
+
+// waitPeriodicJobs waits until timeout for periodic jobs to return, and
+// names the ones still running when it gave up
+func waitPeriodicJobs(timeout time.Duration) (abandoned []string) {
+	done := make(chan struct{})
+	go func() {
+		periodicJobs.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	periodicJobs.mu.Lock()
+	defer periodicJobs.mu.Unlock()
+	for task := range periodicJobs.running {
+		abandoned = append(abandoned, task)
+	}
+	sort.Strings(abandoned)
+	return abandoned
+}
+
+// refuseWSDuringShutdown answers WebSocket upgrades with 503 once shutdown
+// has begun
+func refuseWSDuringShutdown(c *fiber.Ctx) error {
+	if shuttingDown() {
+		c.Set(fiber.HeaderRetryAfter, "5")
+		return respondAPIError(c, 503, APIError{Code: "shutting_down", Message: "Server is shutting down"})
+	}
+	return c.Next()
+}
+
+// openWebSockets counts the registered WebSocket clients
+func openWebSockets() int {
+	transcriptLock.RLock()
+	defer transcriptLock.RUnlock()
+	n := 0
+	for _, conns := range transcriptWS {
+		n += len(conns)
+	}
+	return n
+}
+
+// closeWebSockets sends every connected client a going_away close and waits
+// up to timeout for them to disconnect. It reports how many were connected
+// and how many were still connected when it gave up.
+func closeWebSockets(timeout time.Duration) (connected, abandoned int) {
+	transcriptLock.RLock()
+	var clients []*wsClient
+	for _, conns := range transcriptWS {
+		for _, client := range conns {
+			clients = append(clients, client)
+		}
+	}
+	transcriptLock.RUnlock()
+	if len(clients) == 0 {
+		return 0, 0
+	}
+
+	deadline := time.Now().Add(timeout)
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, client := range clients {
+		client.writeLock.Lock()
+		if !client.closed {
+			if err := client.conn.WriteControl(websocket.CloseMessage, msg, deadline); err != nil {
+				slog.Debug("Failed to send WebSocket close", "error", err)
+			}
+		}
+		client.writeLock.Unlock()
+	}
+
+	// Each client's handler deregisters it once the client answers the close
+	for time.Now().Before(deadline) {
+		if openWebSockets() == 0 {
+			return len(clients), 0
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return len(clients), openWebSockets()
+}
+
+// shutdown drains the server within config.ShutdownTimeout and closes the database
+func shutdown(app *fiber.App, srv *server) {
+	start := time.Now()
+	deadline := start.Add(config.ShutdownTimeout)
+	slog.Info("Shutting down", "timeout", config.ShutdownTimeout.String())
+	beginShutdown()
+
+	wsConnected, wsAbandoned := closeWebSockets(min(wsCloseGrace, time.Until(deadline)))
+
+	// Connections still open after the budget, including WebSockets that
+	// ignored the close, would otherwise hold shutdown up indefinitely
+	httpDrained := true
+	if err := app.ShutdownWithTimeout(time.Until(deadline)); err != nil {
+		slog.Warn("Connections still open at shutdown timeout", "error", err)
+		httpDrained = false
+	}
+
+	periodicAbandoned := waitPeriodicJobs(time.Until(deadline))
+
+	jobsPending := srv.jobs.Pending()
+	jobsAbandoned := 0
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	var abandoned *abandonedJobsError
+	if err := srv.jobs.Stop(ctx); errors.As(err, &abandoned) {
+		jobsAbandoned = abandoned.count
+	}
+	cancel()
+
+	attrs := []any{
+		"elapsed", time.Since(start).Round(time.Millisecond).String(),
+		"websockets_closed", wsConnected - wsAbandoned,
+		"websockets_abandoned", wsAbandoned,
+		"http_drained", httpDrained,
+		"periodic_abandoned", periodicAbandoned,
+		"jobs_drained", max(jobsPending-jobsAbandoned, 0),
+		"jobs_abandoned", jobsAbandoned,
+	}
+	if wsAbandoned > 0 || !httpDrained || len(periodicAbandoned) > 0 || jobsAbandoned > 0 {
+		slog.Warn("Shutdown drain incomplete", attrs...)
+	} else {
+		slog.Info("Shutdown drain complete", attrs...)
+	}
+	closeDB()
+}
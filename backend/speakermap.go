@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The AI service labels transcript lines with diarization ids such as
+// "Speaker 1". Hosts can map those labels to participant names; the mapping
+// is applied whenever a stored transcript is read (exports, stats, draft
+// notes) and to live transcript lines as they are broadcast.
+
+const (
+	maxSpeakerMapEntries = 100
+	maxSpeakerNameLength = 100
+)
+
+// SpeakerName maps one speaker label in a meeting to a display name
+type SpeakerName struct {
+	Label     string    `json:"label"`
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SetSpeakerNames assigns display names to a meeting's speaker labels. An
+// empty name removes the label's mapping. The meeting's cached stats are
+// cleared so they are recomputed with the new names.
+func SetSpeakerNames(ctx context.Context, meetingID int64, names map[string]string) error {
+	return withTx(ctx, func(tx *storeTx) error {
+		for label, name := range names {
+			var err error
+			if name == "" {
+				_, err = tx.ExecContext(ctx, "DELETE FROM speaker_names WHERE meeting_id = ? AND speaker_label = ?", meetingID, label)
+			} else {
+				_, err = tx.ExecContext(ctx,
+					`INSERT INTO speaker_names (meeting_id, speaker_label, display_name) VALUES (?, ?, ?)
+					 ON CONFLICT(meeting_id, speaker_label) DO UPDATE SET display_name = excluded.display_name, updated_at = CURRENT_TIMESTAMP`,
+					meetingID, label, name,
+				)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE meetings SET stats_json = NULL WHERE id = ?", meetingID)
+		return err
+	})
+}
+
+// ListSpeakerNames returns a meeting's speaker mappings ordered by label
+func ListSpeakerNames(ctx context.Context, meetingID int64) ([]SpeakerName, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT speaker_label, display_name, updated_at FROM speaker_names WHERE meeting_id = ? ORDER BY speaker_label",
+		meetingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []SpeakerName{}
+	for rows.Next() {
+		var n SpeakerName
+		if err := rows.Scan(&n.Label, &n.Name, &n.UpdatedAt); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+	return names, rows.Err()
+}
+
+// ListSpeakerLabels returns the distinct speaker labels in a meeting's
+// transcript, so hosts can see which ones still need a name
+func ListSpeakerLabels(ctx context.Context, meetingID int64) ([]string, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT DISTINCT speaker FROM transcript_segments WHERE meeting_id = ? AND speaker IS NOT NULL AND speaker != '' ORDER BY speaker",
+		meetingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := []string{}
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+// SpeakerDisplayName returns the name a host assigned to a speaker label in
+// a room's meeting, or the label itself if there is none
+func SpeakerDisplayName(ctx context.Context, roomName, label string) (string, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var name string
+	err := db.QueryRowContext(ctx,
+		`SELECT sn.display_name FROM speaker_names sn
+		 JOIN meetings m ON m.id = sn.meeting_id
+		 WHERE m.room_name = ? AND sn.speaker_label = ?`,
+		roomName, label,
+	).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return label, nil
+	}
+	if err != nil {
+		return label, err
+	}
+	return name, nil
+}
+
+// Speaker map handlers
+
+func (s *server) getSpeakerMapHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	meeting, err := s.store.GetMeetingByRoom(ctx, room)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	return s.speakerMapResponse(c, meeting)
+}
+
+type SpeakerMapRequest struct {
+	// Speakers maps speaker labels to display names; "" removes a mapping
+	Speakers map[string]string `json:"speakers"`
+}
+
+func (r *SpeakerMapRequest) validate(errs *fieldErrors) {
+	if len(r.Speakers) == 0 {
+		errs.add("speakers", "speakers is required")
+	}
+	if len(r.Speakers) > maxSpeakerMapEntries {
+		errs.add("speakers", fmt.Sprintf("at most %d speakers per request", maxSpeakerMapEntries))
+		return
+	}
+	labels := make([]string, 0, len(r.Speakers))
+	for label := range r.Speakers {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		if strings.TrimSpace(label) == "" {
+			errs.add("speakers", "speaker labels cannot be empty")
+		} else if len(strings.TrimSpace(r.Speakers[label])) > maxSpeakerNameLength {
+			errs.add("speakers."+label, fmt.Sprintf("names must be at most %d characters", maxSpeakerNameLength))
+		}
+	}
+}
+
+func (s *server) setSpeakerMapHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	var req SpeakerMapRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	names := make(map[string]string, len(req.Speakers))
+	for label, name := range req.Speakers {
+		names[label] = strings.TrimSpace(name)
+	}
+
+	meeting, err := s.store.GetMeetingByRoom(ctx, room)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if err := s.store.SetSpeakerNames(ctx, meeting.ID, names); err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	recordAudit(c, "meeting.speaker_map", room, fmt.Sprintf("%d speakers", len(names)))
+
+	return s.speakerMapResponse(c, meeting)
+}
+
+// SpeakerMapResponse lists a meeting's mappings alongside the labels its
+// transcript uses
+type SpeakerMapResponse struct {
+	RoomName string        `json:"roomName"`
+	Speakers []SpeakerName `json:"speakers"`
+	Labels   []string      `json:"labels"`
+}
+
+func (s *server) speakerMapResponse(c *fiber.Ctx, meeting *Meeting) error {
+	ctx := c.UserContext()
+	names, err := s.store.ListSpeakerNames(ctx, meeting.ID)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	labels, err := s.store.ListSpeakerLabels(ctx, meeting.ID)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	return c.JSON(SpeakerMapResponse{RoomName: meeting.RoomName, Speakers: names, Labels: labels})
+}
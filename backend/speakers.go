@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The AI service sits in every transcribed room, so it relays LiveKit's
+// active-speaker updates here. The latest list is kept per room and only
+// changes are broadcast; an empty list means nobody is speaking.
+
+// ActiveSpeaker is one participant LiveKit reports as speaking
+type ActiveSpeaker struct {
+	Identity string `json:"identity"`
+	Name     string `json:"name"`
+}
+
+// SpeakerActiveEvent is the payload of a speaker-active event, loudest first
+type SpeakerActiveEvent struct {
+	Speakers []ActiveSpeaker `json:"speakers"`
+}
+
+var (
+	activeSpeakers     = make(map[string][]ActiveSpeaker)
+	activeSpeakersLock sync.Mutex
+)
+
+// setActiveSpeakers records the current speakers of a room and reports
+// whether they differ from the last update
+func setActiveSpeakers(room string, speakers []ActiveSpeaker) bool {
+	activeSpeakersLock.Lock()
+	defer activeSpeakersLock.Unlock()
+
+	current := activeSpeakers[room]
+	if len(current) == len(speakers) {
+		same := true
+		for i := range speakers {
+			if current[i] != speakers[i] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return false
+		}
+	}
+	if len(speakers) == 0 {
+		delete(activeSpeakers, room)
+	} else {
+		activeSpeakers[room] = speakers
+	}
+	return true
+}
+
+// currentSpeakerEvent returns the encoded speaker-active event for a room, or
+// nil if nobody is speaking
+func currentSpeakerEvent(room string) []byte {
+	activeSpeakersLock.Lock()
+	speakers := activeSpeakers[room]
+	activeSpeakersLock.Unlock()
+	if len(speakers) == 0 {
+		return nil
+	}
+
+	envelope, err := json.Marshal(WSEnvelope{
+		Version: wsProtocolEnvelope,
+		Type:    EventSpeakerActive,
+		Payload: SpeakerActiveEvent{Speakers: speakers},
+	})
+	if err != nil {
+		return nil
+	}
+	return envelope
+}
+
+// clearActiveSpeakers forgets a room's speakers once transcription stops,
+// telling clients that still show someone speaking
+func clearActiveSpeakers(room string) {
+	if setActiveSpeakers(room, nil) {
+		broadcastEvent(room, EventSpeakerActive, SpeakerActiveEvent{Speakers: []ActiveSpeaker{}})
+	}
+}
+
+// SpeakersMessage is an active-speaker update from the AI service
+type SpeakersMessage struct {
+	RoomName string          `json:"room_name"`
+	Speakers []ActiveSpeaker `json:"speakers"`
+}
+
+func (m *SpeakersMessage) validate(errs *fieldErrors) {
+	errs.require("room_name", m.RoomName)
+}
+
+func (s *server) receiveSpeakersHandler(c *fiber.Ctx) error {
+	var msg SpeakersMessage
+	if err := parseBody(c, &msg); err != nil {
+		return err
+	}
+
+	// Speaker state is only kept for meetings the store knows about, so a
+	// stray update cannot add rooms to the map
+	if _, err := s.store.GetMeetingByRoom(c.UserContext(), msg.RoomName); errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if msg.Speakers == nil {
+		msg.Speakers = []ActiveSpeaker{}
+	}
+
+	if !setActiveSpeakers(msg.RoomName, msg.Speakers) {
+		return c.JSON(StatusResponse{Status: "unchanged"})
+	}
+	broadcastEvent(msg.RoomName, EventSpeakerActive, SpeakerActiveEvent{Speakers: msg.Speakers})
+
+	return c.JSON(StatusResponse{Status: "broadcast"})
+}
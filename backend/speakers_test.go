@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReceiveSpeakersHandler(t *testing.T) {
+	useTestConfig(t)
+	store := newFakeStore()
+	app := newTestApp(t, newTestServer(t, store))
+	if _, err := store.EnsureMeeting(context.Background(), "talk"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { setActiveSpeakers("talk", nil) })
+
+	update := SpeakersMessage{RoomName: "talk", Speakers: []ActiveSpeaker{{Identity: "ada", Name: "Ada"}}}
+	for _, want := range []string{"broadcast", "unchanged"} {
+		resp := doRequest(t, app, "POST", "/api/internal/speakers", update, internalSecret()...)
+		var status StatusResponse
+		resp.decode(t, &status)
+		if resp.Status != 200 || status.Status != want {
+			t.Errorf("got %d %q, want 200 %q", resp.Status, status.Status, want)
+		}
+	}
+
+	resp := doRequest(t, app, "POST", "/api/internal/speakers", SpeakersMessage{RoomName: "no-such-room"}, internalSecret()...)
+	if resp.Status != 404 {
+		t.Errorf("unknown room: got %d, want 404", resp.Status)
+	}
+	if event := currentSpeakerEvent("no-such-room"); event != nil {
+		t.Errorf("unknown room has speaker state %s", event)
+	}
+	if resp := doRequest(t, app, "POST", "/api/internal/speakers", SpeakersMessage{}, internalSecret()...); resp.Status != 422 {
+		t.Errorf("missing room_name: got %d, want 422", resp.Status)
+	}
+}
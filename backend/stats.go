@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// speakingWordsPerMinute is the conversational speaking rate used to estimate
+// talk time, since transcript segments carry no duration
+const speakingWordsPerMinute = 150
+
+// defaultOverviewRange is how far back the host overview looks without a since date
+const defaultOverviewRange = 30 * 24 * time.Hour
+
+// SpeakerStats is one speaker's share of a meeting's transcript
+type SpeakerStats struct {
+	Speaker    string `json:"speaker"`
+	Segments   int    `json:"segments"`
+	WordCount  int    `json:"wordCount"`
+	TalkTimeMS int64  `json:"talkTimeMs"` // estimated from word count
+}
+
+// MeetingStats summarizes a single meeting for the dashboard
+type MeetingStats struct {
+	RoomName            string         `json:"roomName"`
+	Ended               bool           `json:"ended"`
+	DurationMS          int64          `json:"durationMs"`
+	ParticipantCount    int            `json:"participantCount"`
+	Speakers            []SpeakerStats `json:"speakers"`
+	TranscriptWordCount int            `json:"transcriptWordCount"`
+	HasNotes            bool           `json:"hasNotes"`
+	RecordingCount      int            `json:"recordingCount"`
+	HasRecording        bool           `json:"hasRecording"` // at least one completed recording
+	InputTokens         int            `json:"inputTokens"`
+	OutputTokens        int            `json:"outputTokens"`
+	ComputedAt          time.Time      `json:"computedAt"`
+}
+
+// HostOverview aggregates a host's meetings over a date range
+type HostOverview struct {
+	HostUserID     int64     `json:"hostUserId"`
+	Since          time.Time `json:"since"`
+	Until          time.Time `json:"until"`
+	MeetingsHeld   int       `json:"meetingsHeld"`
+	TotalHours     float64   `json:"totalHours"`
+	NotesGenerated int       `json:"notesGenerated"`
+}
+
+// meetingSpan returns how long a meeting ran: from the first join to its end,
+// the last leave, or now if someone is still present
+func meetingSpan(endedAt *time.Time, participants []Participant) time.Duration {
+	if len(participants) == 0 {
+		return 0
+	}
+	start := participants[0].JoinedAt
+	var end time.Time
+	for _, p := range participants {
+		if p.JoinedAt.Before(start) {
+			start = p.JoinedAt
+		}
+		left := time.Now()
+		if p.LeftAt != nil {
+			left = *p.LeftAt
+		}
+		if left.After(end) {
+			end = left
+		}
+	}
+	if endedAt != nil {
+		end = *endedAt
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// GetCachedMeetingStats returns the stats stored on a meeting once it ended, or nil
+func GetCachedMeetingStats(ctx context.Context, meetingID int64) (*MeetingStats, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var raw sql.NullString
+	if err := db.QueryRowContext(ctx, "SELECT stats_json FROM meetings WHERE id = ?", meetingID).Scan(&raw); err != nil {
+		return nil, err
+	}
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+	var stats MeetingStats
+	if err := json.Unmarshal([]byte(raw.String), &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// CacheMeetingStats stores computed stats on a meeting
+func CacheMeetingStats(ctx context.Context, meetingID int64, stats *MeetingStats) error {
+	raw, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	_, err = execWrite(ctx, "UPDATE meetings SET stats_json = ? WHERE id = ?", string(raw), meetingID)
+	return err
+}
+
+// meetingTokenUsage totals the tokens spent on a meeting's notes and drafts
+func meetingTokenUsage(ctx context.Context, meetingID int64) (input, output int, notes int, err error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	err = db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0)
+		 FROM meeting_notes WHERE meeting_id = ?`,
+		meetingID,
+	).Scan(&notes, &input, &output)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	var draftInput, draftOutput int
+	err = db.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0) FROM meeting_note_drafts WHERE meeting_id = ?",
+		meetingID,
+	).Scan(&draftInput, &draftOutput)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return input + draftInput, output + draftOutput, notes, nil
+}
+
+// HostMeetingOverview aggregates the meetings a host scheduled that were
+// created within [since, until)
+func HostMeetingOverview(ctx context.Context, hostUserID int64, since, until time.Time) (*HostOverview, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	overview := &HostOverview{HostUserID: hostUserID, Since: since, Until: until}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT m.id, m.ended_at,
+		        (SELECT COUNT(*) FROM meeting_notes n WHERE n.meeting_id = m.id)
+		 FROM meetings m JOIN scheduled_meetings s ON s.room_name = m.room_name
+		 WHERE s.host_user_id = ? AND m.created_at >= ? AND m.created_at < ?`,
+		hostUserID, since.UTC(), until.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	endedAt := map[int64]*time.Time{}
+	for rows.Next() {
+		var id int64
+		var ended sql.NullTime
+		var notes int
+		if err := rows.Scan(&id, &ended, &notes); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if ended.Valid {
+			endedAt[id] = &ended.Time
+		} else {
+			endedAt[id] = nil
+		}
+		overview.NotesGenerated += notes
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(endedAt) == 0 {
+		return overview, nil
+	}
+
+	rows, err = db.QueryContext(ctx,
+		`SELECT p.meeting_id, p.joined_at, p.left_at
+		 FROM participants p
+		 JOIN meetings m ON m.id = p.meeting_id
+		 JOIN scheduled_meetings s ON s.room_name = m.room_name
+		 WHERE s.host_user_id = ? AND m.created_at >= ? AND m.created_at < ?`,
+		hostUserID, since.UTC(), until.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byMeeting := map[int64][]Participant{}
+	for rows.Next() {
+		var meetingID int64
+		var p Participant
+		var leftAt sql.NullTime
+		if err := rows.Scan(&meetingID, &p.JoinedAt, &leftAt); err != nil {
+			return nil, err
+		}
+		if leftAt.Valid {
+			p.LeftAt = &leftAt.Time
+		}
+		byMeeting[meetingID] = append(byMeeting[meetingID], p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var total time.Duration
+	for id, ended := range endedAt {
+		// A meeting counts as held once someone actually joined it
+		if len(byMeeting[id]) == 0 {
+			continue
+		}
+		overview.MeetingsHeld++
+		total += meetingSpan(ended, byMeeting[id])
+	}
+	overview.TotalHours = float64(total.Round(time.Minute)) / float64(time.Hour)
+	return overview, nil
+}
+
+// computeMeetingStats builds stats for a meeting from its attendance,
+// transcript, notes, and recordings
+func (s *server) computeMeetingStats(ctx context.Context, meeting *Meeting) (*MeetingStats, error) {
+	stats := &MeetingStats{
+		RoomName:   meeting.RoomName,
+		Ended:      meeting.EndedAt != nil,
+		Speakers:   []SpeakerStats{},
+		ComputedAt: time.Now(),
+	}
+
+	participants, err := s.store.ListParticipants(ctx, meeting.RoomName)
+	if err != nil {
+		return nil, err
+	}
+	stats.DurationMS = meetingSpan(meeting.EndedAt, participants).Milliseconds()
+	identities := map[string]bool{}
+	for _, p := range participants {
+		identities[p.Identity] = true
+	}
+	stats.ParticipantCount = len(identities)
+
+	segments, err := s.store.GetTranscriptByMeeting(ctx, meeting.ID)
+	if err != nil {
+		return nil, err
+	}
+	bySpeaker := map[string]*SpeakerStats{}
+	for _, seg := range segments {
+		speaker := strings.TrimSpace(seg.Speaker)
+		if speaker == "" {
+			speaker = "Unknown"
+		}
+		sp, ok := bySpeaker[speaker]
+		if !ok {
+			sp = &SpeakerStats{Speaker: speaker}
+			bySpeaker[speaker] = sp
+		}
+		words := len(strings.Fields(seg.Text))
+		sp.Segments++
+		sp.WordCount += words
+		stats.TranscriptWordCount += words
+	}
+	for _, sp := range bySpeaker {
+		sp.TalkTimeMS = int64(sp.WordCount) * 60000 / speakingWordsPerMinute
+		stats.Speakers = append(stats.Speakers, *sp)
+	}
+	sort.Slice(stats.Speakers, func(i, j int) bool {
+		if stats.Speakers[i].WordCount != stats.Speakers[j].WordCount {
+			return stats.Speakers[i].WordCount > stats.Speakers[j].WordCount
+		}
+		return stats.Speakers[i].Speaker < stats.Speakers[j].Speaker
+	})
+
+	input, output, notes, err := meetingTokenUsage(ctx, meeting.ID)
+	if err != nil {
+		return nil, err
+	}
+	stats.HasNotes = notes > 0
+	stats.InputTokens = input
+	stats.OutputTokens = output
+
+	recordings, err := s.store.ListRecordingsByMeeting(ctx, meeting.ID)
+	if err != nil {
+		return nil, err
+	}
+	stats.RecordingCount = len(recordings)
+	for _, r := range recordings {
+		if r.Status == "completed" {
+			stats.HasRecording = true
+		}
+	}
+	return stats, nil
+}
+
+// Stats handlers
+
+func (s *server) meetingStatsHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	room := roomParam(c)
+	if !s.canManageRoom(c, room) {
+		return respondError(c, 403, "Not your meeting")
+	}
+
+	meeting, err := s.store.GetMeetingByRoom(ctx, room)
+	if errors.Is(err, ErrNotFound) {
+		return respondError(c, 404, "Meeting not found")
+	} else if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	if meeting.EndedAt != nil {
+		cached, err := s.store.GetCachedMeetingStats(ctx, meeting.ID)
+		if err != nil {
+			return respondError(c, 500, err.Error())
+		}
+		if cached != nil {
+			return c.JSON(cached)
+		}
+	}
+
+	stats, err := s.computeMeetingStats(ctx, meeting)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	// Nothing more can happen to an ended meeting, so later views reuse this
+	if meeting.EndedAt != nil {
+		if err := s.store.CacheMeetingStats(ctx, meeting.ID, stats); err != nil {
+			return respondError(c, 500, err.Error())
+		}
+	}
+	return c.JSON(stats)
+}
+
+func (s *server) statsOverviewHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	hostID, _ := c.Locals("userID").(int64)
+	var errs fieldErrors
+	if v := c.Query("host"); v != "" {
+		email, _ := c.Locals("userEmail").(string)
+		if !isAdmin(email) {
+			return respondError(c, 403, "Admin access required")
+		}
+		if _, err := fmt.Sscanf(v, "%d", &hostID); err != nil {
+			errs.add("host", "host must be a user ID")
+		}
+	}
+
+	until := time.Now()
+	since := until.Add(-defaultOverviewRange)
+	if t := errs.timestamp("since", c.Query("since")); !t.IsZero() {
+		since = t
+	}
+	if t := errs.timestamp("until", c.Query("until")); !t.IsZero() {
+		until = t
+	}
+	if len(errs) == 0 && !since.Before(until) {
+		errs.add("since", "since must be before until")
+	}
+	if err := errs.err(); err != nil {
+		return err
+	}
+
+	overview, err := s.store.HostMeetingOverview(ctx, hostID, since, until)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	return c.JSON(overview)
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// Store is the persistence layer used by the HTTP handlers. sqlStore is the
+// production implementation backed by the package database.
+type Store interface {
+	// Meetings
+	CreateMeeting(ctx context.Context, roomName, roomSID string, hostUserID int64) (*Meeting, error)
+	CountActiveHostRooms(ctx context.Context, hostUserID int64, exceptRoom string) (int, error)
+	GetMeetingByRoom(ctx context.Context, roomName string) (*Meeting, error)
+	EnsureMeeting(ctx context.Context, roomName string) (*Meeting, error)
+	SetMeetingLanguage(ctx context.Context, roomName, language string) error
+	EndMeeting(ctx context.Context, roomName string, at time.Time) (bool, error)
+	ReopenMeeting(ctx context.Context, roomName string) error
+	SetMeetingTranscribing(ctx context.Context, roomName string, active bool) error
+	ListTranscriptionSessions(ctx context.Context) ([]TranscriptionSession, error)
+	SetMeetingAutoSummary(ctx context.Context, roomName string, enabled bool) (bool, error)
+	SetMeetingLegalHold(ctx context.Context, roomName string, hold bool) (bool, error)
+	RoomNameInUse(ctx context.Context, roomName string) (bool, error)
+	ListMeetingsWithNotes(ctx context.Context, opts MeetingListOptions) ([]MeetingListItem, error)
+
+	// Notes
+	SaveNotes(ctx context.Context, roomName, markdown, model string, inputTokens, outputTokens int) (*MeetingNotes, error)
+	GetNotesByRoom(ctx context.Context, roomName string) (*MeetingNotes, error)
+	GetNotesByID(ctx context.Context, roomName string, id int64) (*MeetingNotes, error)
+	UpdateNotes(ctx context.Context, roomName string, id int64, markdown, ifMatch string) (*MeetingNotes, bool, error)
+	GetNotesHTML(ctx context.Context, notes *MeetingNotes) (string, error)
+	SaveNotesDraft(ctx context.Context, meetingID int64, markdown, model string, inputTokens, outputTokens, segmentCount int) (*NotesDraft, error)
+	GetLatestNotesDraft(ctx context.Context, roomName string) (*NotesDraft, error)
+	ForEachNotesVersion(ctx context.Context, meetingID int64, fn func(*MeetingNotes) error) error
+
+	// Transcripts
+	SaveTranscriptSegment(ctx context.Context, roomName, speaker, text, segmentTS string) (*TranscriptSegment, error)
+	SaveFinalTranscriptSegment(ctx context.Context, roomName, speaker, text, segmentTS string) (bool, error)
+	GetTranscriptByMeeting(ctx context.Context, meetingID int64) ([]TranscriptSegment, error)
+	ForEachTranscriptSegment(ctx context.Context, meetingID int64, fn func(*TranscriptSegment) error) error
+
+	// Recordings
+	CreateRecording(ctx context.Context, meetingID int64, egressID string) (*Recording, error)
+	GetRecordingByEgressID(ctx context.Context, egressID string) (*Recording, error)
+	GetRecordingByID(ctx context.Context, id int64) (*Recording, error)
+	GetActiveRecordingByMeeting(ctx context.Context, meetingID int64) (*Recording, error)
+	ListRecordingsByMeeting(ctx context.Context, meetingID int64) ([]Recording, error)
+	ListUnfinishedRecordings(ctx context.Context) ([]UnfinishedRecording, error)
+	UpdateRecordingStatus(ctx context.Context, egressID, status, audioURL string, durationMS int64) error
+	CreateRecordingPause(ctx context.Context, meetingID, recordingID int64, pausedAt time.Time) (*RecordingPause, error)
+	GetOpenRecordingPause(ctx context.Context, meetingID int64) (*RecordingPause, error)
+	EndRecordingPause(ctx context.Context, id, resumedRecordingID int64, resumedAt time.Time) error
+	ListRecordingPauses(ctx context.Context, meetingID int64) ([]RecordingPause, error)
+
+	// Participants
+	RecordParticipantJoined(ctx context.Context, roomName, identity, name, metadata string, joinedAt time.Time) (bool, error)
+	RecordParticipantLeft(ctx context.Context, roomName, identity string, leftAt time.Time) (bool, error)
+	CloseOpenParticipants(ctx context.Context, roomName string, leftAt time.Time) error
+	ListParticipants(ctx context.Context, roomName string) ([]Participant, error)
+	ListOpenParticipantRooms(ctx context.Context) ([]string, error)
+	ListMeetingEvents(ctx context.Context, meetingID int64) ([]TimelineEvent, error)
+
+	// Stats
+	GetCachedMeetingStats(ctx context.Context, meetingID int64) (*MeetingStats, error)
+	CacheMeetingStats(ctx context.Context, meetingID int64, stats *MeetingStats) error
+	HostMeetingOverview(ctx context.Context, hostUserID int64, since, until time.Time) (*HostOverview, error)
+
+	// Recording consent
+	RecordConsent(ctx context.Context, roomName, identity string, given bool, at time.Time) error
+	ListConsentedIdentities(ctx context.Context, roomName string) (map[string]bool, error)
+
+	// Breakout rooms
+	CreateBreakoutRooms(ctx context.Context, rooms []BreakoutRoom) error
+	ListBreakoutRooms(ctx context.Context, parentRoom string) ([]BreakoutRoom, error)
+	DeleteBreakoutRooms(ctx context.Context, parentRoom string) error
+
+	// Recording acknowledgements
+	SetMeetingRecordingLocked(ctx context.Context, roomName string, locked bool) (bool, error)
+	RecordAcknowledgement(ctx context.Context, roomName, identity, ipAddress string) error
+	HasAcknowledged(ctx context.Context, roomName, identity string) (bool, error)
+	ListAcknowledgements(ctx context.Context, roomName string) ([]Acknowledgement, error)
+
+	// Speaker names
+	SetSpeakerNames(ctx context.Context, meetingID int64, names map[string]string) error
+	ListSpeakerNames(ctx context.Context, meetingID int64) ([]SpeakerName, error)
+	ListSpeakerLabels(ctx context.Context, meetingID int64) ([]string, error)
+	SpeakerDisplayName(ctx context.Context, roomName, label string) (string, error)
+
+	// Room API keys
+	CreateRoomAPIKey(ctx context.Context, keyHash, roomName string, permissions []string, expiresAt time.Time, createdBy int64) (*RoomAPIKey, error)
+
+	// Email subscriptions
+	CreateEmailSubscription(ctx context.Context, roomName, participantName, email string, includeRecording bool) (*EmailSubscription, error)
+	GetEmailSubscriptionsByRoom(ctx context.Context, roomName string) ([]EmailSubscription, error)
+	GetEmailSubscriptionsPage(ctx context.Context, roomName string, limit, offset int) ([]EmailSubscription, int, error)
+	DeleteEmailSubscription(ctx context.Context, roomName, email string) (int64, error)
+	CountFailedEmailsSince(ctx context.Context, since time.Time) (int, error)
+	ListSubscriptionHistory(ctx context.Context, email string, after subscriptionCursor, limit int) ([]SubscriptionHistoryEntry, bool, error)
+	DeleteSubscriptionsByEmail(ctx context.Context, email string) (int64, error)
+	GetEmailActivity(ctx context.Context, meetingID int64, email string) ([]EmailLogEntry, error)
+
+	// Scheduled meetings
+	CreateScheduledMeeting(ctx context.Context, roomName string, hostUserID int64, clientName, clientEmail string, scheduledAt, linkExpiresAt time.Time, slug, joinPinHash string) (*ScheduledMeeting, error)
+	ScheduledMeetingSlugInUse(ctx context.Context, slug string) (bool, error)
+	GetScheduledMeetingByRoom(ctx context.Context, roomName string) (*ScheduledMeeting, error)
+	GetScheduledMeetingBySlug(ctx context.Context, slug string) (*ScheduledMeeting, error)
+	GetScheduledMeetingByID(ctx context.Context, id int64) (*ScheduledMeeting, error)
+	ListScheduledMeetingsByHost(ctx context.Context, hostUserID int64) ([]ScheduledMeeting, error)
+	UpdateScheduledMeetingStatus(ctx context.Context, id int64, status string) error
+	CancelScheduledMeeting(ctx context.Context, id, hostUserID int64) error
+	BulkCancelScheduledMeetings(ctx context.Context, hostUserID int64, from, to time.Time, dryRun bool) ([]ScheduledMeeting, error)
+	TransferScheduledMeeting(ctx context.Context, id, fromUserID, toUserID int64) error
+	RescheduleMeeting(ctx context.Context, id, hostUserID int64, scheduledAt, linkExpiresAt time.Time) error
+
+	// Users
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	SetUserActive(ctx context.Context, id int64, active bool) (bool, error)
+	ExportEmailData(ctx context.Context, email string) (*DataExport, error)
+	EraseEmailData(ctx context.Context, email string) (*DataErasure, error)
+	GetUserNamespace(ctx context.Context, userID int64) (string, error)
+	SetUserNamespace(ctx context.Context, userID int64, namespace string) (bool, error)
+
+	// Audit log
+	LogAudit(ctx context.Context, actorUserID int64, action, target, details, ip string) error
+	ListAuditByTarget(ctx context.Context, target string) ([]AuditEntry, error)
+
+	// Retention
+	CountExpiredRows(ctx context.Context, p retentionPolicy, cutoff time.Time, limit int) (int, error)
+	PurgeExpiredBatch(ctx context.Context, p retentionPolicy, cutoff time.Time, limit int) (int, []string, error)
+
+	// Schema and backups
+	GetAppliedSchema(ctx context.Context) (*AppliedSchema, error)
+	BackupDatabase(ctx context.Context) (*BackupResult, error)
+}
+
+// sqlStore implements Store with the SQL functions in this package
+type sqlStore struct{}
+
+var _ Store = sqlStore{}
+
+func (sqlStore) CreateMeeting(ctx context.Context, roomName, roomSID string, hostUserID int64) (*Meeting, error) {
+	return CreateMeeting(ctx, roomName, roomSID, hostUserID)
+}
+
+func (sqlStore) CountActiveHostRooms(ctx context.Context, hostUserID int64, exceptRoom string) (int, error) {
+	return CountActiveHostRooms(ctx, hostUserID, exceptRoom)
+}
+
+func (sqlStore) GetMeetingByRoom(ctx context.Context, roomName string) (*Meeting, error) {
+	return GetMeetingByRoom(ctx, roomName)
+}
+
+func (sqlStore) EnsureMeeting(ctx context.Context, roomName string) (*Meeting, error) {
+	return EnsureMeeting(ctx, roomName)
+}
+
+func (sqlStore) SetMeetingLanguage(ctx context.Context, roomName, language string) error {
+	return SetMeetingLanguage(ctx, roomName, language)
+}
+
+func (sqlStore) EndMeeting(ctx context.Context, roomName string, at time.Time) (bool, error) {
+	return EndMeeting(ctx, roomName, at)
+}
+
+func (sqlStore) ReopenMeeting(ctx context.Context, roomName string) error {
+	return ReopenMeeting(ctx, roomName)
+}
+
+func (sqlStore) SetMeetingTranscribing(ctx context.Context, roomName string, active bool) error {
+	return SetMeetingTranscribing(ctx, roomName, active)
+}
+
+func (sqlStore) ListTranscriptionSessions(ctx context.Context) ([]TranscriptionSession, error) {
+	return ListTranscriptionSessions(ctx)
+}
+
+func (sqlStore) SetMeetingAutoSummary(ctx context.Context, roomName string, enabled bool) (bool, error) {
+	return SetMeetingAutoSummary(ctx, roomName, enabled)
+}
+
+func (sqlStore) SetMeetingLegalHold(ctx context.Context, roomName string, hold bool) (bool, error) {
+	return SetMeetingLegalHold(ctx, roomName, hold)
+}
+
+func (sqlStore) RoomNameInUse(ctx context.Context, roomName string) (bool, error) {
+	return RoomNameInUse(ctx, roomName)
+}
+
+func (sqlStore) ListMeetingsWithNotes(ctx context.Context, opts MeetingListOptions) ([]MeetingListItem, error) {
+	return ListMeetingsWithNotes(ctx, opts)
+}
+
+func (sqlStore) SaveNotes(ctx context.Context, roomName, markdown, model string, inputTokens, outputTokens int) (*MeetingNotes, error) {
+	return SaveNotes(ctx, roomName, markdown, model, inputTokens, outputTokens)
+}
+
+func (sqlStore) GetNotesByRoom(ctx context.Context, roomName string) (*MeetingNotes, error) {
+	return GetNotesByRoom(ctx, roomName)
+}
+
+func (sqlStore) GetNotesByID(ctx context.Context, roomName string, id int64) (*MeetingNotes, error) {
+	return GetNotesByID(ctx, roomName, id)
+}
+
+func (sqlStore) UpdateNotes(ctx context.Context, roomName string, id int64, markdown, ifMatch string) (*MeetingNotes, bool, error) {
+	return UpdateNotes(ctx, roomName, id, markdown, ifMatch)
+}
+
+func (sqlStore) GetNotesHTML(ctx context.Context, notes *MeetingNotes) (string, error) {
+	return GetNotesHTML(ctx, notes)
+}
+
+func (sqlStore) SaveNotesDraft(ctx context.Context, meetingID int64, markdown, model string, inputTokens, outputTokens, segmentCount int) (*NotesDraft, error) {
+	return SaveNotesDraft(ctx, meetingID, markdown, model, inputTokens, outputTokens, segmentCount)
+}
+
+func (sqlStore) GetLatestNotesDraft(ctx context.Context, roomName string) (*NotesDraft, error) {
+	return GetLatestNotesDraft(ctx, roomName)
+}
+
+func (sqlStore) ForEachNotesVersion(ctx context.Context, meetingID int64, fn func(*MeetingNotes) error) error {
+	return ForEachNotesVersion(ctx, meetingID, fn)
+}
+
+func (sqlStore) SaveTranscriptSegment(ctx context.Context, roomName, speaker, text, segmentTS string) (*TranscriptSegment, error) {
+	return SaveTranscriptSegment(ctx, roomName, speaker, text, segmentTS)
+}
+
+func (sqlStore) SaveFinalTranscriptSegment(ctx context.Context, roomName, speaker, text, segmentTS string) (bool, error) {
+	return SaveFinalTranscriptSegment(ctx, roomName, speaker, text, segmentTS)
+}
+
+func (sqlStore) GetTranscriptByMeeting(ctx context.Context, meetingID int64) ([]TranscriptSegment, error) {
+	return GetTranscriptByMeeting(ctx, meetingID)
+}
+
+func (sqlStore) ForEachTranscriptSegment(ctx context.Context, meetingID int64, fn func(*TranscriptSegment) error) error {
+	return ForEachTranscriptSegment(ctx, meetingID, fn)
+}
+
+func (sqlStore) CreateRecording(ctx context.Context, meetingID int64, egressID string) (*Recording, error) {
+	return CreateRecording(ctx, meetingID, egressID)
+}
+
+func (sqlStore) GetRecordingByEgressID(ctx context.Context, egressID string) (*Recording, error) {
+	return GetRecordingByEgressID(ctx, egressID)
+}
+
+func (sqlStore) GetRecordingByID(ctx context.Context, id int64) (*Recording, error) {
+	return GetRecordingByID(ctx, id)
+}
+
+func (sqlStore) GetActiveRecordingByMeeting(ctx context.Context, meetingID int64) (*Recording, error) {
+	return GetActiveRecordingByMeeting(ctx, meetingID)
+}
+
+func (sqlStore) ListRecordingsByMeeting(ctx context.Context, meetingID int64) ([]Recording, error) {
+	return ListRecordingsByMeeting(ctx, meetingID)
+}
+
+func (sqlStore) ListUnfinishedRecordings(ctx context.Context) ([]UnfinishedRecording, error) {
+	return ListUnfinishedRecordings(ctx)
+}
+
+func (sqlStore) UpdateRecordingStatus(ctx context.Context, egressID, status, audioURL string, durationMS int64) error {
+	return UpdateRecordingStatus(ctx, egressID, status, audioURL, durationMS)
+}
+
+func (sqlStore) CreateRecordingPause(ctx context.Context, meetingID, recordingID int64, pausedAt time.Time) (*RecordingPause, error) {
+	return CreateRecordingPause(ctx, meetingID, recordingID, pausedAt)
+}
+
+func (sqlStore) GetOpenRecordingPause(ctx context.Context, meetingID int64) (*RecordingPause, error) {
+	return GetOpenRecordingPause(ctx, meetingID)
+}
+
+func (sqlStore) EndRecordingPause(ctx context.Context, id, resumedRecordingID int64, resumedAt time.Time) error {
+	return EndRecordingPause(ctx, id, resumedRecordingID, resumedAt)
+}
+
+func (sqlStore) ListRecordingPauses(ctx context.Context, meetingID int64) ([]RecordingPause, error) {
+	return ListRecordingPauses(ctx, meetingID)
+}
+
+func (sqlStore) RecordParticipantJoined(ctx context.Context, roomName, identity, name, metadata string, joinedAt time.Time) (bool, error) {
+	return RecordParticipantJoined(ctx, roomName, identity, name, metadata, joinedAt)
+}
+
+func (sqlStore) RecordParticipantLeft(ctx context.Context, roomName, identity string, leftAt time.Time) (bool, error) {
+	return RecordParticipantLeft(ctx, roomName, identity, leftAt)
+}
+
+func (sqlStore) CloseOpenParticipants(ctx context.Context, roomName string, leftAt time.Time) error {
+	return CloseOpenParticipants(ctx, roomName, leftAt)
+}
+
+func (sqlStore) ListParticipants(ctx context.Context, roomName string) ([]Participant, error) {
+	return ListParticipants(ctx, roomName)
+}
+
+func (sqlStore) ListOpenParticipantRooms(ctx context.Context) ([]string, error) {
+	return ListOpenParticipantRooms(ctx)
+}
+
+func (sqlStore) ListMeetingEvents(ctx context.Context, meetingID int64) ([]TimelineEvent, error) {
+	return ListMeetingEvents(ctx, meetingID)
+}
+
+func (sqlStore) GetCachedMeetingStats(ctx context.Context, meetingID int64) (*MeetingStats, error) {
+	return GetCachedMeetingStats(ctx, meetingID)
+}
+
+func (sqlStore) CacheMeetingStats(ctx context.Context, meetingID int64, stats *MeetingStats) error {
+	return CacheMeetingStats(ctx, meetingID, stats)
+}
+
+func (sqlStore) HostMeetingOverview(ctx context.Context, hostUserID int64, since, until time.Time) (*HostOverview, error) {
+	return HostMeetingOverview(ctx, hostUserID, since, until)
+}
+
+func (sqlStore) RecordConsent(ctx context.Context, roomName, identity string, given bool, at time.Time) error {
+	return RecordConsent(ctx, roomName, identity, given, at)
+}
+
+func (sqlStore) ListConsentedIdentities(ctx context.Context, roomName string) (map[string]bool, error) {
+	return ListConsentedIdentities(ctx, roomName)
+}
+
+func (sqlStore) CreateBreakoutRooms(ctx context.Context, rooms []BreakoutRoom) error {
+	return CreateBreakoutRooms(ctx, rooms)
+}
+
+func (sqlStore) ListBreakoutRooms(ctx context.Context, parentRoom string) ([]BreakoutRoom, error) {
+	return ListBreakoutRooms(ctx, parentRoom)
+}
+
+func (sqlStore) DeleteBreakoutRooms(ctx context.Context, parentRoom string) error {
+	return DeleteBreakoutRooms(ctx, parentRoom)
+}
+
+func (sqlStore) SetMeetingRecordingLocked(ctx context.Context, roomName string, locked bool) (bool, error) {
+	return SetMeetingRecordingLocked(ctx, roomName, locked)
+}
+
+func (sqlStore) RecordAcknowledgement(ctx context.Context, roomName, identity, ipAddress string) error {
+	return RecordAcknowledgement(ctx, roomName, identity, ipAddress)
+}
+
+func (sqlStore) HasAcknowledged(ctx context.Context, roomName, identity string) (bool, error) {
+	return HasAcknowledged(ctx, roomName, identity)
+}
+
+func (sqlStore) ListAcknowledgements(ctx context.Context, roomName string) ([]Acknowledgement, error) {
+	return ListAcknowledgements(ctx, roomName)
+}
+
+func (sqlStore) SetSpeakerNames(ctx context.Context, meetingID int64, names map[string]string) error {
+	return SetSpeakerNames(ctx, meetingID, names)
+}
+
+func (sqlStore) ListSpeakerNames(ctx context.Context, meetingID int64) ([]SpeakerName, error) {
+	return ListSpeakerNames(ctx, meetingID)
+}
+
+func (sqlStore) ListSpeakerLabels(ctx context.Context, meetingID int64) ([]string, error) {
+	return ListSpeakerLabels(ctx, meetingID)
+}
+
+func (sqlStore) SpeakerDisplayName(ctx context.Context, roomName, label string) (string, error) {
+	return SpeakerDisplayName(ctx, roomName, label)
+}
+
+func (sqlStore) CreateRoomAPIKey(ctx context.Context, keyHash, roomName string, permissions []string, expiresAt time.Time, createdBy int64) (*RoomAPIKey, error) {
+	return CreateRoomAPIKey(ctx, keyHash, roomName, permissions, expiresAt, createdBy)
+}
+
+func (sqlStore) CreateEmailSubscription(ctx context.Context, roomName, participantName, email string, includeRecording bool) (*EmailSubscription, error) {
+	return CreateEmailSubscription(ctx, roomName, participantName, email, includeRecording)
+}
+
+func (sqlStore) GetEmailSubscriptionsByRoom(ctx context.Context, roomName string) ([]EmailSubscription, error) {
+	return GetEmailSubscriptionsByRoom(ctx, roomName)
+}
+
+func (sqlStore) GetEmailSubscriptionsPage(ctx context.Context, roomName string, limit, offset int) ([]EmailSubscription, int, error) {
+	return GetEmailSubscriptionsPage(ctx, roomName, limit, offset)
+}
+
+func (sqlStore) DeleteEmailSubscription(ctx context.Context, roomName, email string) (int64, error) {
+	return DeleteEmailSubscription(ctx, roomName, email)
+}
+
+func (sqlStore) CountFailedEmailsSince(ctx context.Context, since time.Time) (int, error) {
+	return CountFailedEmailsSince(ctx, since)
+}
+
+func (sqlStore) ListSubscriptionHistory(ctx context.Context, email string, after subscriptionCursor, limit int) ([]SubscriptionHistoryEntry, bool, error) {
+	return ListSubscriptionHistory(ctx, email, after, limit)
+}
+
+func (sqlStore) DeleteSubscriptionsByEmail(ctx context.Context, email string) (int64, error) {
+	return DeleteSubscriptionsByEmail(ctx, email)
+}
+
+func (sqlStore) GetEmailActivity(ctx context.Context, meetingID int64, email string) ([]EmailLogEntry, error) {
+	return GetEmailActivity(ctx, meetingID, email)
+}
+
+func (sqlStore) CreateScheduledMeeting(ctx context.Context, roomName string, hostUserID int64, clientName, clientEmail string, scheduledAt, linkExpiresAt time.Time, slug, joinPinHash string) (*ScheduledMeeting, error) {
+	return CreateScheduledMeeting(ctx, roomName, hostUserID, clientName, clientEmail, scheduledAt, linkExpiresAt, slug, joinPinHash)
+}
+
+func (sqlStore) ScheduledMeetingSlugInUse(ctx context.Context, slug string) (bool, error) {
+	return ScheduledMeetingSlugInUse(ctx, slug)
+}
+
+func (sqlStore) GetScheduledMeetingByRoom(ctx context.Context, roomName string) (*ScheduledMeeting, error) {
+	return GetScheduledMeetingByRoom(ctx, roomName)
+}
+
+func (sqlStore) GetScheduledMeetingBySlug(ctx context.Context, slug string) (*ScheduledMeeting, error) {
+	return GetScheduledMeetingBySlug(ctx, slug)
+}
+
+func (sqlStore) GetScheduledMeetingByID(ctx context.Context, id int64) (*ScheduledMeeting, error) {
+	return GetScheduledMeetingByID(ctx, id)
+}
+
+func (sqlStore) ListScheduledMeetingsByHost(ctx context.Context, hostUserID int64) ([]ScheduledMeeting, error) {
+	return ListScheduledMeetingsByHost(ctx, hostUserID)
+}
+
+func (sqlStore) UpdateScheduledMeetingStatus(ctx context.Context, id int64, status string) error {
+	return UpdateScheduledMeetingStatus(ctx, id, status)
+}
+
+func (sqlStore) CancelScheduledMeeting(ctx context.Context, id, hostUserID int64) error {
+	return CancelScheduledMeeting(ctx, id, hostUserID)
+}
+
+func (sqlStore) BulkCancelScheduledMeetings(ctx context.Context, hostUserID int64, from, to time.Time, dryRun bool) ([]ScheduledMeeting, error) {
+	return BulkCancelScheduledMeetings(ctx, hostUserID, from, to, dryRun)
+}
+
+func (sqlStore) TransferScheduledMeeting(ctx context.Context, id, fromUserID, toUserID int64) error {
+	return TransferScheduledMeeting(ctx, id, fromUserID, toUserID)
+}
+
+func (sqlStore) RescheduleMeeting(ctx context.Context, id, hostUserID int64, scheduledAt, linkExpiresAt time.Time) error {
+	return RescheduleMeeting(ctx, id, hostUserID, scheduledAt, linkExpiresAt)
+}
+
+func (sqlStore) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	return GetUserByEmail(ctx, email)
+}
+
+func (sqlStore) ListUsers(ctx context.Context) ([]User, error) {
+	return ListUsers(ctx)
+}
+
+func (sqlStore) SetUserActive(ctx context.Context, id int64, active bool) (bool, error) {
+	return SetUserActive(ctx, id, active)
+}
+
+func (sqlStore) ExportEmailData(ctx context.Context, email string) (*DataExport, error) {
+	return ExportEmailData(ctx, email)
+}
+
+func (sqlStore) EraseEmailData(ctx context.Context, email string) (*DataErasure, error) {
+	return EraseEmailData(ctx, email)
+}
+
+func (sqlStore) GetUserNamespace(ctx context.Context, userID int64) (string, error) {
+	return GetUserNamespace(ctx, userID)
+}
+
+func (sqlStore) SetUserNamespace(ctx context.Context, userID int64, namespace string) (bool, error) {
+	return SetUserNamespace(ctx, userID, namespace)
+}
+
+func (sqlStore) LogAudit(ctx context.Context, actorUserID int64, action, target, details, ip string) error {
+	return LogAudit(ctx, actorUserID, action, target, details, ip)
+}
+
+func (sqlStore) ListAuditByTarget(ctx context.Context, target string) ([]AuditEntry, error) {
+	return ListAuditByTarget(ctx, target)
+}
+
+func (sqlStore) CountExpiredRows(ctx context.Context, p retentionPolicy, cutoff time.Time, limit int) (int, error) {
+	return CountExpiredRows(ctx, p, cutoff, limit)
+}
+
+func (sqlStore) PurgeExpiredBatch(ctx context.Context, p retentionPolicy, cutoff time.Time, limit int) (int, []string, error) {
+	return PurgeExpiredBatch(ctx, p, cutoff, limit)
+}
+
+func (sqlStore) GetAppliedSchema(ctx context.Context) (*AppliedSchema, error) {
+	return GetAppliedSchema(ctx)
+}
+
+func (sqlStore) BackupDatabase(ctx context.Context) (*BackupResult, error) {
+	return BackupDatabase(ctx)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// An address's subscriptions across every meeting, for answering data
+// access and erasure requests. Addresses match case-insensitively, since
+// they are stored as each participant typed them.
+
+const (
+	defaultSubscriptionHistoryPageSize = 50
+	maxSubscriptionHistoryPageSize     = 200
+)
+
+// SubscriptionHistoryEntry is one meeting an address subscribed to
+type SubscriptionHistoryEntry struct {
+	ID               int64      `json:"id"`
+	RoomName         string     `json:"roomName"`
+	ParticipantName  string     `json:"participantName"`
+	Email            string     `json:"email"` // as entered for this meeting
+	IncludeRecording bool       `json:"includeRecording"`
+	SubscribedAt     time.Time  `json:"subscribedAt"`
+	MeetingCreatedAt time.Time  `json:"createdAt"`
+	NotesGeneratedAt *time.Time `json:"generatedAt,omitempty"` // of the meeting's latest notes
+	SummarySent      bool       `json:"summarySent"`
+}
+
+// subscriptionCursor is the position after the last entry of a page. The
+// zero value starts at the newest subscription.
+type subscriptionCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+var errInvalidCursor = errors.New("cursor must be a nextCursor from an earlier page")
+
+// encode returns the cursor as an opaque string for nextCursor
+func (c subscriptionCursor) encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "," + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// parseSubscriptionCursor reverses encode
+func parseSubscriptionCursor(s string) (subscriptionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return subscriptionCursor{}, errInvalidCursor
+	}
+	createdAt, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return subscriptionCursor{}, errInvalidCursor
+	}
+	var c subscriptionCursor
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return subscriptionCursor{}, errInvalidCursor
+	}
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil || c.ID < 1 {
+		return subscriptionCursor{}, errInvalidCursor
+	}
+	return c, nil
+}
+
+// ListSubscriptionHistory returns up to limit of email's subscriptions,
+// newest first, starting after the cursor. more reports whether another page
+// follows.
+func ListSubscriptionHistory(ctx context.Context, email string, after subscriptionCursor, limit int) (entries []SubscriptionHistoryEntry, more bool, err error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	// Keyset pagination on (created_at, id), so subscriptions added or
+	// removed while a client pages through do not shift later pages
+	where := "LOWER(es.email) = LOWER(?)"
+	args := []any{email}
+	if after.ID != 0 {
+		createdAt := db.timestampArg(after.CreatedAt)
+		where += " AND (es.created_at < ? OR (es.created_at = ? AND es.id < ?))"
+		args = append(args, createdAt, createdAt, after.ID)
+	}
+	rows, err := db.QueryContext(ctx,
+		`SELECT es.id, m.room_name, es.participant_name, es.email, es.include_recording, es.created_at, m.created_at, mn.generated_at,
+		 EXISTS (SELECT 1 FROM email_delivery_log dl WHERE dl.meeting_id = es.meeting_id AND LOWER(dl.email) = LOWER(es.email) AND dl.status = 'sent')
+		 FROM email_subscriptions es
+		 JOIN meetings m ON es.meeting_id = m.id
+		 LEFT JOIN meeting_notes mn ON mn.id = (SELECT id FROM meeting_notes WHERE meeting_id = es.meeting_id ORDER BY generated_at DESC, id DESC LIMIT 1)
+		 WHERE `+where+`
+		 ORDER BY es.created_at DESC, es.id DESC
+		 LIMIT ?`,
+		append(args, limit+1)...,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	entries = []SubscriptionHistoryEntry{}
+	for rows.Next() {
+		var e SubscriptionHistoryEntry
+		var generatedAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.RoomName, &e.ParticipantName, &e.Email, &e.IncludeRecording,
+			&e.SubscribedAt, &e.MeetingCreatedAt, &generatedAt, &e.SummarySent); err != nil {
+			return nil, false, err
+		}
+		if generatedAt.Valid {
+			e.NotesGeneratedAt = &generatedAt.Time
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	if len(entries) > limit {
+		return entries[:limit], true, nil
+	}
+	return entries, false, nil
+}
+
+// DeleteSubscriptionsByEmail removes email's subscriptions to every meeting
+func DeleteSubscriptionsByEmail(ctx context.Context, email string) (int64, error) {
+	result, err := execWrite(ctx, "DELETE FROM email_subscriptions WHERE LOWER(email) = LOWER(?)", email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// SubscriptionHistoryPage is one page of an address's subscriptions
+type SubscriptionHistoryPage struct {
+	Email         string                     `json:"email"`
+	Subscriptions []SubscriptionHistoryEntry `json:"subscriptions"`
+	NextCursor    string                     `json:"nextCursor,omitempty"` // pass as ?cursor= for the next page
+}
+
+// emailParam reads and checks the :email path parameter
+func emailParam(c *fiber.Ctx) (string, error) {
+	email := strings.TrimSpace(pathParam(c, "email"))
+	var errs fieldErrors
+	errs.require("email", email)
+	errs.email("email", email)
+	return email, errs.err()
+}
+
+// subscriptionHistoryHandler lists an address's subscriptions. Users may see
+// their own; admins may see anyone's.
+func (s *server) subscriptionHistoryHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	email, err := emailParam(c)
+	if err != nil {
+		return err
+	}
+	caller, _ := c.Locals("userEmail").(string)
+	if !strings.EqualFold(caller, email) && !isAdmin(caller) {
+		return respondError(c, 403, "You can only view your own subscriptions")
+	}
+
+	var errs fieldErrors
+	limit := errs.queryInt(c, "limit", defaultSubscriptionHistoryPageSize)
+	if limit < 1 || limit > maxSubscriptionHistoryPageSize {
+		errs.add("limit", fmt.Sprintf("limit must be between 1 and %d", maxSubscriptionHistoryPageSize))
+	}
+	var after subscriptionCursor
+	if cursor := c.Query("cursor"); cursor != "" {
+		if after, err = parseSubscriptionCursor(cursor); err != nil {
+			errs.add("cursor", err.Error())
+		}
+	}
+	if err := errs.err(); err != nil {
+		return err
+	}
+
+	entries, more, err := s.store.ListSubscriptionHistory(ctx, email, after, limit)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	page := SubscriptionHistoryPage{Email: email, Subscriptions: entries}
+	if more {
+		last := entries[len(entries)-1]
+		page.NextCursor = subscriptionCursor{CreatedAt: last.SubscribedAt, ID: last.ID}.encode()
+	}
+	return c.JSON(page)
+}
+
+type DeleteSubscriptionsResponse struct {
+	Email   string `json:"email"`
+	Deleted int64  `json:"deleted"`
+}
+
+func (s *server) deleteSubscriptionsHandler(c *fiber.Ctx) error {
+	email, err := emailParam(c)
+	if err != nil {
+		return err
+	}
+
+	n, err := s.store.DeleteSubscriptionsByEmail(c.UserContext(), email)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	recordAudit(c, "subscriptions.delete_all", email, fmt.Sprintf("%d subscriptions", n))
+
+	return c.JSON(DeleteSubscriptionsResponse{Email: email, Deleted: n})
+}
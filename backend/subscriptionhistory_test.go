@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionHistoryPagination(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx := context.Background()
+	app := newTestApp(t, newTestServer(t, sqlStore{}))
+
+	// Five subscriptions created within the same second, then one moved an
+	// hour into the past; the cursor has to order ties by ID
+	for i := 1; i <= 5; i++ {
+		if _, err := CreateEmailSubscription(ctx, fmt.Sprintf("room-%d", i), "Burt", testUserEmail, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, "UPDATE email_subscriptions SET created_at = datetime(created_at, '-1 hour') WHERE id = 5"); err != nil {
+		t.Fatal(err)
+	}
+
+	var rooms []string
+	path := "/api/subscriptions/" + testUserEmail + "/history?limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination does not end")
+		}
+		resp := doRequest(t, app, "GET", path, nil, bearer(t, testUserEmail)...)
+		if resp.Status != 200 {
+			t.Fatalf("got %d: %s", resp.Status, resp.Body)
+		}
+		var page SubscriptionHistoryPage
+		resp.decode(t, &page)
+		for _, e := range page.Subscriptions {
+			rooms = append(rooms, e.RoomName)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		path = "/api/subscriptions/" + testUserEmail + "/history?limit=2&cursor=" + page.NextCursor
+	}
+	if got, want := fmt.Sprint(rooms), "[room-4 room-3 room-2 room-1 room-5]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	resp := doRequest(t, app, "GET", "/api/subscriptions/"+testUserEmail+"/history?cursor=42", nil, bearer(t, testUserEmail)...)
+	if resp.Status != 422 || resp.apiError(t).Fields[0].Field != "cursor" {
+		t.Errorf("bad cursor: got %d %s, want 422 on cursor", resp.Status, resp.Body)
+	}
+}
+
+func TestSubscriptionCursorRoundTrip(t *testing.T) {
+	c, err := parseSubscriptionCursor(subscriptionCursor{CreatedAt: mustParseTime(t, "2026-05-01T10:00:00.123456Z"), ID: 7}.encode())
+	if err != nil || c.ID != 7 || !c.CreatedAt.Equal(mustParseTime(t, "2026-05-01T10:00:00.123456Z")) {
+		t.Errorf("round trip: got %+v, %v", c, err)
+	}
+	for _, bad := range []string{"", "7", "!!", subscriptionCursor{ID: 0}.encode()} {
+		if _, err := parseSubscriptionCursor(bad); err == nil {
+			t.Errorf("parseSubscriptionCursor(%q) accepted", bad)
+		}
+	}
+}
+
+func TestSubscriptionHistoryIsScopedToCaller(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	app := newTestApp(t, newTestServer(t, sqlStore{}))
+
+	for _, tc := range []struct {
+		caller, email string
+		want          int
+	}{
+		{testUserEmail, testUserEmail, 200},
+		{testUserEmail, "BURT@nevinstech.com", 200},
+		{testUserEmail, testOtherUserEmail, 403},
+		{testAdminEmail, testOtherUserEmail, 200},
+	} {
+		resp := doRequest(t, app, "GET", "/api/subscriptions/"+tc.email+"/history", nil, bearer(t, tc.caller)...)
+		if resp.Status != tc.want {
+			t.Errorf("%s reading %s: got %d, want %d", tc.caller, tc.email, resp.Status, tc.want)
+		}
+	}
+
+	if resp := doRequest(t, app, "DELETE", "/api/subscriptions/"+testUserEmail, nil, bearer(t, testUserEmail)...); resp.Status != 403 {
+		t.Errorf("delete by a non-admin: got %d, want 403", resp.Status)
+	}
+}
+
+func mustParseTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Bounce classifications reported by the mail provider
+const (
+	BounceHard      = "hard"
+	BounceSoft      = "soft"
+	BounceComplaint = "complaint"
+)
+
+// defaultSoftBounceLimit is how many soft bounces an address may collect before it is suppressed
+const defaultSoftBounceLimit = 3
+
+// EmailSuppression represents an address that summary emails must not be sent to
+type EmailSuppression struct {
+	Email      string    `json:"email"`
+	Reason     string    `json:"reason"`
+	BounceType string    `json:"bounceType,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// isValidEmail reports whether email is a bare address (no display name or
+// angle brackets) within the RFC 5321 length limits
+func isValidEmail(email string) bool {
+	if len(email) > 254 {
+		return false
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return false
+	}
+	at := strings.LastIndex(email, "@")
+	return at > 0 && at <= 64 && at < len(email)-1
+}
+
+// GetSuppression returns the suppression entry for an address, or nil if it is not suppressed
+func GetSuppression(ctx context.Context, email string) (*EmailSuppression, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var s EmailSuppression
+	var bounceType sql.NullString
+	err := db.QueryRowContext(ctx,
+		"SELECT email, reason, bounce_type, created_at FROM email_suppressions WHERE email = ?",
+		normalizeEmail(email),
+	).Scan(&s.Email, &s.Reason, &bounceType, &s.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.BounceType = bounceType.String
+	return &s, nil
+}
+
+// ListSuppressions returns all suppressed addresses, newest first
+func ListSuppressions(ctx context.Context) ([]EmailSuppression, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "SELECT email, reason, bounce_type, created_at FROM email_suppressions ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []EmailSuppression
+	for rows.Next() {
+		var s EmailSuppression
+		var bounceType sql.NullString
+		if err := rows.Scan(&s.Email, &s.Reason, &bounceType, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.BounceType = bounceType.String
+		results = append(results, s)
+	}
+	return results, rows.Err()
+}
+
+// SuppressEmail adds an address to the suppression list
+func SuppressEmail(ctx context.Context, email, bounceType, reason string) error {
+	_, err := execWrite(ctx,
+		"INSERT INTO email_suppressions (email, reason, bounce_type) VALUES (?, ?, ?) ON CONFLICT(email) DO UPDATE SET reason = ?, bounce_type = ?",
+		normalizeEmail(email), reason, bounceType, reason, bounceType,
+	)
+	return err
+}
+
+// RemoveSuppression lifts a suppression and clears the bounce history so the
+// address starts over with a clean soft-bounce count
+func RemoveSuppression(ctx context.Context, email string) (bool, error) {
+	email = normalizeEmail(email)
+	result, err := execWrite(ctx, "DELETE FROM email_suppressions WHERE email = ?", email)
+	if err != nil {
+		return false, err
+	}
+	if _, err := execWrite(ctx, "DELETE FROM email_bounces WHERE email = ?", email); err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// RecordEmailBounce stores a bounce or complaint for an address, marks its most
+// recent delivery accordingly, and suppresses the address if needed
+func RecordEmailBounce(ctx context.Context, email, bounceType, reason string) (bool, error) {
+	status := "bounced"
+	if bounceType == BounceComplaint {
+		status = "complained"
+	}
+	if err := MarkLatestDelivery(ctx, email, status, bounceType, reason); err != nil {
+		return false, err
+	}
+	return recordBounce(ctx, email, bounceType, reason)
+}
+
+// recordBounce adds to an address's bounce history and suppresses it after one
+// hard bounce or complaint, or once it reaches the soft-bounce limit
+func recordBounce(ctx context.Context, email, bounceType, reason string) (bool, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	email = normalizeEmail(email)
+
+	if _, err := execWrite(ctx,
+		"INSERT INTO email_bounces (email, bounce_type, reason) VALUES (?, ?, ?)",
+		email, bounceType, reason,
+	); err != nil {
+		return false, err
+	}
+
+	suppressReason := ""
+	switch bounceType {
+	case BounceHard:
+		suppressReason = "hard bounce"
+	case BounceComplaint:
+		suppressReason = "spam complaint"
+	case BounceSoft:
+		var count int
+		if err := db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM email_bounces WHERE email = ? AND bounce_type = ?",
+			email, BounceSoft,
+		).Scan(&count); err != nil {
+			return false, err
+		}
+		if count >= config.EmailSoftBounceLimit {
+			suppressReason = strconv.Itoa(count) + " soft bounces"
+		}
+	}
+	if suppressReason == "" {
+		return false, nil
+	}
+	if reason != "" {
+		suppressReason += ": " + reason
+	}
+
+	if err := SuppressEmail(ctx, email, bounceType, suppressReason); err != nil {
+		return false, err
+	}
+	ctxLogger(ctx).Info("Suppressed address", "email", email, "reason", suppressReason)
+	return true, nil
+}
+
+// Bounce and suppression handlers
+
+type EmailBounceRequest struct {
+	Email  string `json:"email"`
+	Type   string `json:"type"` // hard, soft, complaint
+	Reason string `json:"reason"`
+}
+
+func (r *EmailBounceRequest) validate(errs *fieldErrors) {
+	errs.require("email", r.Email)
+	if r.Type != BounceHard && r.Type != BounceSoft && r.Type != BounceComplaint {
+		errs.add("type", "type must be hard, soft, or complaint")
+	}
+}
+
+// BounceResponse reports a recorded bounce or delivery update and whether
+// the address is now suppressed
+type BounceResponse struct {
+	Status     string `json:"status"`
+	Suppressed bool   `json:"suppressed"`
+}
+
+func receiveEmailBounceHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	var req EmailBounceRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	suppressed, err := RecordEmailBounce(ctx, req.Email, req.Type, req.Reason)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+
+	return c.JSON(BounceResponse{Status: "recorded", Suppressed: suppressed})
+}
+
+type SuppressionsResponse struct {
+	Suppressions []EmailSuppression `json:"suppressions"`
+	Count        int                `json:"count"`
+}
+
+func listSuppressionsHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	suppressions, err := ListSuppressions(ctx)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if suppressions == nil {
+		suppressions = []EmailSuppression{}
+	}
+
+	return c.JSON(SuppressionsResponse{Suppressions: suppressions, Count: len(suppressions)})
+}
+
+func removeSuppressionHandler(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	email, err := url.PathUnescape(c.Params("email"))
+	if err != nil {
+		return respondError(c, 400, "Invalid email")
+	}
+
+	removed, err := RemoveSuppression(ctx, email)
+	if err != nil {
+		return respondError(c, 500, err.Error())
+	}
+	if !removed {
+		return respondError(c, 404, "Email is not suppressed")
+	}
+
+	recordAudit(c, "suppression.remove", normalizeEmail(email), "")
+
+	return c.JSON(StatusResponse{Status: "removed"})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestIsValidEmail(t *testing.T) {
+	// Addresses at the RFC 5321 limits: a 64-octet local part and a
+	// 254-octet path
+	longLocal := strings.Repeat("a", 64) + "@example.com"
+	longAddress := "a@" + strings.Repeat("b", 63) + "." + strings.Repeat("c", 63) + "." + strings.Repeat("d", 63) + "." + strings.Repeat("e", 56) + ".com"
+
+	for _, email := range []string{
+		"ada@example.com",
+		"ada.lovelace@example.com",
+		"ada+boom@example.com",
+		"ada_l-ove@mail.example.co.uk",
+		"o'brien@example.ie",
+		"1234567890@example.com",
+		"x@example.com",
+		"ada@localhost",
+		longLocal,
+		longAddress,
+	} {
+		if !isValidEmail(email) {
+			t.Errorf("%q (%d octets): got invalid, want valid", email, len(email))
+		}
+	}
+
+	for _, email := range []string{
+		"",
+		"ada",
+		"ada@",
+		"@example.com",
+		"ada@@example.com",
+		"ada@exa mple.com",
+		"ada lovelace@example.com",
+		".ada@example.com",
+		"ada.@example.com",
+		"ada..lovelace@example.com",
+		"Ada <ada@example.com>",
+		"<ada@example.com>",
+		"ada@example.com, bob@example.com",
+		" ada@example.com",
+		"ada@example.com\n",
+		"a" + longLocal,
+		longAddress + "m",
+	} {
+		if isValidEmail(email) {
+			t.Errorf("%q (%d octets): got valid, want invalid", email, len(email))
+		}
+	}
+}
+
+func TestSubscribeRejectsInvalidEmail(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	app := newTestApp(t, newTestServer(t, newFakeStore()))
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/api/meetings/standup/subscribe-email"},
+		{http.MethodDelete, "/api/meetings/standup/unsubscribe-email"},
+	} {
+		resp := doRequest(t, app, route.method, route.path, map[string]string{"email": "Ada <ada@example.com>"})
+		if resp.Status != http.StatusUnprocessableEntity {
+			t.Errorf("%s %s: got %d, want 422: %s", route.method, route.path, resp.Status, resp.Body)
+			continue
+		}
+		if fields := resp.apiError(t).Fields; len(fields) != 1 || fields[0].Field != "email" {
+			t.Errorf("%s %s: fields %+v, want email", route.method, route.path, fields)
+		}
+	}
+}
+
+func TestEmailSubscriptionsValidateListsInvalidAddresses(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	store := newFakeStore()
+	app := newTestApp(t, newTestServer(t, store))
+	// Rows stored before addresses were validated
+	for _, email := range []string{"ada@example.com", "not an address", "bob@example.com", "carol@"} {
+		if _, err := store.CreateEmailSubscription(context.Background(), "standup", "", email, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var body EmailSubscriptionsResponse
+	doRequest(t, app, http.MethodGet, "/api/meetings/standup/email-subscriptions?validate=true", nil).decode(t, &body)
+	if body.Invalid == nil {
+		t.Fatal("invalid: missing, want the malformed addresses")
+	}
+	if got := strings.Join(*body.Invalid, ","); got != "not an address,carol@" {
+		t.Errorf("invalid: got %q, want the two malformed addresses", got)
+	}
+
+	body = EmailSubscriptionsResponse{}
+	doRequest(t, app, http.MethodGet, "/api/meetings/standup/email-subscriptions", nil).decode(t, &body)
+	if body.Invalid != nil {
+		t.Errorf("invalid without ?validate: got %v, want omitted", *body.Invalid)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log/slog"
+	"time"
+)
+
+// Duplicate detection window for transcript segments. Hashes older than
+// transcriptHashTTL are purged every transcriptHashCleanupInterval.
+const (
+	transcriptHashTTL             = 24 * time.Hour
+	transcriptHashCleanupInterval = time.Hour
+)
+
+// TranscriptSegment is one finalized line of a meeting transcript
+type TranscriptSegment struct {
+	ID        int64     `json:"id"`
+	MeetingID int64     `json:"meetingId"`
+	Speaker   string    `json:"speaker"`
+	Text      string    `json:"text"`
+	SegmentTS string    `json:"timestamp"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// SpeakerLabel is the AI service's label when a host has mapped it to
+	// the name in Speaker
+	SpeakerLabel string `json:"speakerLabel,omitempty"`
+}
+
+// SaveTranscriptSegment stores a final transcript line for a room
+func SaveTranscriptSegment(ctx context.Context, roomName, speaker, text, segmentTS string) (*TranscriptSegment, error) {
+	var segment *TranscriptSegment
+	err := withTx(ctx, func(tx *storeTx) error {
+		var err error
+		segment, err = insertTranscriptSegment(ctx, tx, roomName, speaker, text, segmentTS)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	transcriptSegmentsIngested.Inc()
+	return segment, nil
+}
+
+// SaveFinalTranscriptSegment records a final segment's hash and, if the
+// segment is new and has text, stores it, all in one transaction so a
+// failed save does not leave the hash behind to reject the retry. It
+// returns false for a segment already received within the deduplication
+// window.
+func SaveFinalTranscriptSegment(ctx context.Context, roomName, speaker, text, segmentTS string) (bool, error) {
+	var isNew, saved bool
+	err := withTx(ctx, func(tx *storeTx) error {
+		result, err := tx.ExecContext(ctx,
+			"INSERT INTO transcript_segment_hashes (hash) VALUES (?) ON CONFLICT(hash) DO NOTHING",
+			transcriptSegmentHash(roomName, speaker, segmentTS),
+		)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if isNew = n > 0; !isNew || text == "" {
+			return nil
+		}
+		_, err = insertTranscriptSegment(ctx, tx, roomName, speaker, text, segmentTS)
+		saved = err == nil
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if saved {
+		transcriptSegmentsIngested.Inc()
+	}
+	return isNew, nil
+}
+
+func insertTranscriptSegment(ctx context.Context, tx *storeTx, roomName, speaker, text, segmentTS string) (*TranscriptSegment, error) {
+	meeting, err := GetOrCreateMeeting(ctx, tx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	id, err := tx.insertReturningID(ctx,
+		"INSERT INTO transcript_segments (meeting_id, speaker, text, segment_ts) VALUES (?, ?, ?, ?)",
+		meeting.ID, speaker, text, segmentTS,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &TranscriptSegment{
+		ID:        id,
+		MeetingID: meeting.ID,
+		Speaker:   speaker,
+		Text:      text,
+		SegmentTS: segmentTS,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// GetTranscriptByMeeting returns a meeting's transcript in the order it was spoken
+func GetTranscriptByMeeting(ctx context.Context, meetingID int64) ([]TranscriptSegment, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var segments []TranscriptSegment
+	err := ForEachTranscriptSegment(ctx, meetingID, func(s *TranscriptSegment) error {
+		segments = append(segments, *s)
+		return nil
+	})
+	return segments, err
+}
+
+// ForEachTranscriptSegment calls fn for each of a meeting's segments in order
+// without holding the whole transcript in memory, with speaker labels
+// replaced by any names the host assigned. It applies no statement timeout
+// of its own, so long transcripts are bounded only by ctx.
+func ForEachTranscriptSegment(ctx context.Context, meetingID int64, fn func(*TranscriptSegment) error) error {
+	rows, err := db.QueryContext(ctx,
+		`SELECT t.id, t.meeting_id, t.speaker, sn.display_name, t.text, t.segment_ts, t.created_at
+		 FROM transcript_segments t
+		 LEFT JOIN speaker_names sn ON sn.meeting_id = t.meeting_id AND sn.speaker_label = t.speaker
+		 WHERE t.meeting_id = ? ORDER BY t.created_at, t.id`,
+		meetingID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s TranscriptSegment
+		var speaker, name, segmentTS sql.NullString
+		if err := rows.Scan(&s.ID, &s.MeetingID, &speaker, &name, &s.Text, &segmentTS, &s.CreatedAt); err != nil {
+			return err
+		}
+		s.Speaker = speaker.String
+		if name.Valid {
+			s.Speaker, s.SpeakerLabel = name.String, speaker.String
+		}
+		s.SegmentTS = segmentTS.String
+		if err := fn(&s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// transcriptSegmentHash identifies a segment by room, speaker, and the AI
+// service's timestamp, which stay the same when the service retries a send
+func transcriptSegmentHash(roomName, speaker, segmentTS string) string {
+	sum := sha256.Sum256([]byte(roomName + "\x00" + speaker + "\x00" + segmentTS))
+	return hex.EncodeToString(sum[:])
+}
+
+// PurgeTranscriptHashes deletes segment hashes recorded before cutoff
+func PurgeTranscriptHashes(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := execWrite(ctx, "DELETE FROM transcript_segment_hashes WHERE created_at < ?", cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// runTranscriptHashCleanup periodically purges expired segment hashes. It
+// runs for the life of the process.
+func runTranscriptHashCleanup() {
+	ticker := time.NewTicker(transcriptHashCleanupInterval)
+	defer ticker.Stop()
+
+	for nextTick(ticker) {
+		ctx, cancel := backgroundContext()
+		n, err := PurgeTranscriptHashes(ctx, time.Now().Add(-transcriptHashTTL))
+		cancel()
+		if err != nil {
+			slog.Error("Failed to purge transcript hashes", "error", err)
+		} else if n > 0 {
+			slog.Info("Purged expired transcript hashes", "count", n)
+		}
+	}
+}
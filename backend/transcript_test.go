@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestReceiveTranscriptStoresRetriedSegmentOnce(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	app := newTestApp(t, newTestServer(t, sqlStore{}))
+
+	msg := TranscriptMessage{RoomName: "standup", Speaker: "Ada", Text: "hello", IsFinal: true, Timestamp: "2026-10-16T10:00:00Z"}
+	var first, second StatusResponse
+	doRequest(t, app, http.MethodPost, "/api/internal/transcript", msg, internalSecret()...).decode(t, &first)
+	doRequest(t, app, http.MethodPost, "/api/internal/transcript", msg, internalSecret()...).decode(t, &second)
+
+	if first.Status != "broadcast" || second.Status != "duplicate" {
+		t.Errorf("statuses: got %q then %q, want broadcast then duplicate", first.Status, second.Status)
+	}
+	if n := countRows(t, "SELECT COUNT(*) FROM transcript_segments"); n != 1 {
+		t.Errorf("transcript rows: got %d, want 1", n)
+	}
+}
+
+func TestSaveFinalTranscriptSegmentRollsBackHashOnFailure(t *testing.T) {
+	useTestConfig(t)
+	useTestDB(t)
+	ctx := context.Background()
+
+	breakSchema(t, "DROP TABLE transcript_segments")
+	if _, err := SaveFinalTranscriptSegment(ctx, "standup", "Ada", "hello", "1"); err == nil {
+		t.Fatal("SaveFinalTranscriptSegment: got nil, want an error")
+	}
+	if n := countRows(t, "SELECT COUNT(*) FROM transcript_segment_hashes"); n != 0 {
+		t.Errorf("hashes left after a failed save: got %d, want 0", n)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Build information is set at build time with
+//
+//	go build -ldflags "-X main.version=1.4.0 -X main.gitSHA=$(git rev-parse HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// When the flags are missing, the commit and time Go records for builds
+// inside a git checkout are used instead. The database records the newest
+// schema version any binary has migrated it to, so a binary running against
+// a database from a newer release shows up as drift.
+
+var (
+	version   = "dev"
+	gitSHA    = ""
+	buildTime = ""
+)
+
+// versionHeader carries the backend build on every response, so HAR
+// captures identify it
+const versionHeader = "X-Boom-Version"
+
+// schemaVersion is the schema this binary migrates the database to. Bump it
+// with every change to schema.sql, schema_postgres.sql, or the migration lists.
+const schemaVersion = 1
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	for _, s := range info.Settings {
+		switch {
+		case s.Key == "vcs.revision" && gitSHA == "":
+			gitSHA = s.Value
+		case s.Key == "vcs.time" && buildTime == "":
+			buildTime = s.Value
+		}
+	}
+}
+
+// buildLabel identifies the build in one string: the version and short SHA
+func buildLabel() string {
+	if len(gitSHA) >= 12 {
+		return version + " (" + gitSHA[:12] + ")"
+	} else if gitSHA != "" {
+		return version + " (" + gitSHA + ")"
+	}
+	return version
+}
+
+// versionHeaders is middleware adding X-Boom-Version to every response
+func versionHeaders() fiber.Handler {
+	label := buildLabel()
+	return func(c *fiber.Ctx) error {
+		c.Set(versionHeader, label)
+		return c.Next()
+	}
+}
+
+// recordSchemaVersion notes that the database has been migrated to this
+// binary's schema. An older binary leaves a newer version in place.
+func recordSchemaVersion(ctx context.Context) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO schema_version (id, version, binary_version, applied_at) VALUES (1, ?, ?, ?)
+		 ON CONFLICT(id) DO UPDATE SET version = excluded.version, binary_version = excluded.binary_version, applied_at = excluded.applied_at
+		 WHERE schema_version.version < excluded.version`,
+		schemaVersion, buildLabel(), time.Now().UTC(),
+	)
+	return err
+}
+
+// AppliedSchema is the schema version recorded in the database
+type AppliedSchema struct {
+	Version       int       `json:"version"`
+	BinaryVersion string    `json:"binaryVersion,omitempty"` // of the binary that applied it
+	AppliedAt     time.Time `json:"appliedAt"`
+}
+
+// GetAppliedSchema returns the schema version recorded in the database
+func GetAppliedSchema(ctx context.Context) (*AppliedSchema, error) {
+	ctx, cancel := withStatementTimeout(ctx)
+	defer cancel()
+
+	var s AppliedSchema
+	var binaryVersion sql.NullString
+	err := db.QueryRowContext(ctx,
+		"SELECT version, binary_version, applied_at FROM schema_version WHERE id = 1",
+	).Scan(&s.Version, &binaryVersion, &s.AppliedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	s.BinaryVersion = binaryVersion.String
+	return &s, nil
+}
+
+type VersionResponse struct {
+	Version   string `json:"version"`
+	GitSHA    string `json:"gitSha,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Schema    struct {
+		Expected int            `json:"expected"` // what this binary migrates to
+		Applied  *AppliedSchema `json:"applied,omitempty"`
+		Drift    bool           `json:"drift"` // applied differs from expected
+		Error    string         `json:"error,omitempty"`
+	} `json:"schema"`
+}
+
+func (s *server) versionHandler(c *fiber.Ctx) error {
+	resp := VersionResponse{
+		Version:   version,
+		GitSHA:    gitSHA,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	}
+	resp.Schema.Expected = schemaVersion
+	applied, err := s.store.GetAppliedSchema(c.UserContext())
+	if err != nil {
+		resp.Schema.Error = err.Error()
+	} else {
+		resp.Schema.Applied = applied
+		resp.Schema.Drift = applied.Version != schemaVersion
+	}
+	return c.JSON(resp)
+}
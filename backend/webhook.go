@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+)
+
+// Outbound recording-ready webhook delivery
+const (
+	recordingWebhookAttempts  = 5
+	recordingWebhookBaseDelay = 2 * time.Second
+)
+
+// RecordingReadyPayload is posted to RECORDING_READY_WEBHOOK_URL once a
+// recording has finished uploading
+type RecordingReadyPayload struct {
+	Event       string    `json:"event"`
+	RoomName    string    `json:"roomName"`
+	EgressID    string    `json:"egressId"`
+	DurationMS  int64     `json:"durationMs"`
+	DownloadURL string    `json:"downloadUrl"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// signWebhookBody returns the X-Boom-Signature value for body, in the same
+// sha256=<hex> form the n8n callback uses
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// notifyRecordingReady posts a signed recording-ready event, retrying with
+// exponential backoff on network errors, 429s, and 5xx responses
+func notifyRecordingReady(ctx context.Context, payload RecordingReadyPayload) error {
+	webhookURL := config.RecordingReadyWebhookURL
+	if webhookURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	secret := config.RecordingReadyWebhookSecret
+
+	delay := recordingWebhookBaseDelay
+	for attempt := 1; ; attempt++ {
+		err = postRecordingWebhook(ctx, webhookURL, secret, body)
+		if err == nil || attempt == recordingWebhookAttempts {
+			return err
+		}
+		if _, permanent := err.(permanentWebhookError); permanent {
+			return err
+		}
+		recordingWebhookRetries.Inc()
+		ctxLogger(ctx).Warn("Recording webhook attempt failed, retrying", "attempt", attempt, "egress_id", payload.EgressID, "error", err, "retry_in", delay.String())
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// permanentWebhookError is a rejection that retrying will not fix
+type permanentWebhookError struct{ status int }
+
+func (e permanentWebhookError) Error() string {
+	return fmt.Sprintf("webhook rejected with status %d", e.status)
+}
+
+func postRecordingWebhook(ctx context.Context, webhookURL, secret string, body []byte) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(attemptCtx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setRequestIDHeader(ctx, req)
+	if secret != "" {
+		req.Header.Set("X-Boom-Signature", signWebhookBody(secret, body))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	default:
+		return permanentWebhookError{resp.StatusCode}
+	}
+}
+
+// LiveKit webhook receiver
+
+// liveKitWebhookHandler receives signed events from the LiveKit server. It
+// records attendance, finalizes recordings when their egress ends, and
+// schedules the summary email when a room finishes.
+func (s *server) liveKitWebhookHandler(c *fiber.Ctx) error {
+	r, err := adaptor.ConvertRequest(c, false)
+	if err != nil {
+		return respondError(c, 400, "Invalid request")
+	}
+	event, err := webhook.ReceiveWebhookEvent(r, auth.NewSimpleKeyProvider(config.LiveKitAPIKey, config.LiveKitAPISecret))
+	if err != nil {
+		ctxLogger(c.UserContext()).Warn("Rejected LiveKit webhook", "error", err)
+		return respondError(c, 401, "Invalid webhook signature")
+	}
+
+	room := event.GetRoom().GetName()
+	if room == "" {
+		room = event.GetEgressInfo().GetRoomName()
+	}
+	ctx := withLogAttrs(c.UserContext(), "room", room, "event", event.GetEvent())
+
+	switch event.GetEvent() {
+	case webhook.EventEgressEnded:
+		if err := s.handleEgressEnded(ctx, event.GetEgressInfo()); err != nil {
+			ctxLogger(ctx).Error("Failed to handle webhook", "error", err)
+			return respondError(c, 500, err.Error())
+		}
+	case webhook.EventParticipantJoined, webhook.EventParticipantLeft:
+		if err := s.recordParticipantEvent(ctx, event); err != nil {
+			ctxLogger(ctx).Error("Failed to handle webhook", "error", err)
+			return respondError(c, 500, err.Error())
+		}
+	case webhook.EventRoomFinished:
+		ended, err := s.finishMeeting(ctx, room, time.Now())
+		if err != nil {
+			ctxLogger(ctx).Error("Failed to end meeting", "error", err)
+		}
+		// Redelivered events and meetings ended by their host were already handled
+		if ended {
+			broadcastEvent(room, EventStatus, StatusEvent{Kind: "meeting", State: "ended"})
+			s.scheduleAutoSummary(ctx, room)
+		}
+	}
+
+	return c.JSON(StatusResponse{Status: "received"})
+}
+
+// handleEgressEnded records the final state of a recording and, when it
+// completed successfully, notifies RECORDING_READY_WEBHOOK_URL
+func (s *server) handleEgressEnded(ctx context.Context, info *livekit.EgressInfo) error {
+	rec, err := s.store.GetRecordingByEgressID(ctx, info.GetEgressId())
+	if errors.Is(err, ErrNotFound) {
+		// Not one of ours
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if rec.Status == "completed" || rec.Status == "failed" {
+		// LiveKit redelivers webhooks; only the first one counts
+		return nil
+	}
+
+	var audioURL string
+	var durationMS int64
+	file := info.GetFile()
+	if results := info.GetFileResults(); len(results) > 0 {
+		file = results[0]
+	}
+	if file != nil {
+		audioURL = file.GetLocation()
+		durationMS = convertEgressDuration(file.GetDuration())
+	}
+
+	status := "failed"
+	if info.GetStatus() == livekit.EgressStatus_EGRESS_COMPLETE {
+		status = "completed"
+	}
+	if err := s.store.UpdateRecordingStatus(ctx, rec.EgressID, status, audioURL, durationMS); err != nil {
+		return err
+	}
+	egressEnded.WithLabelValues(status).Inc()
+	ctxLogger(ctx).Info("Recording finished", "egress_id", rec.EgressID, "status", status)
+
+	if status == "completed" {
+		s.recordingReady(ctx, info.GetRoomName(), rec.EgressID, audioURL, durationMS)
+	}
+	return nil
+}
+
+// recordingReady notifies RECORDING_READY_WEBHOOK_URL in the background that
+// a recording completed
+func (s *server) recordingReady(ctx context.Context, roomName, egressID, audioURL string, durationMS int64) {
+	payload := RecordingReadyPayload{
+		Event:       "recording.ready",
+		RoomName:    roomName,
+		EgressID:    egressID,
+		DurationMS:  durationMS,
+		DownloadURL: audioURL,
+		CompletedAt: time.Now(),
+	}
+	// notifyRecordingReady retries on its own, so the job does not
+	s.jobs.Submit(Job{Name: "recording webhook " + payload.EgressID, Origin: ctx, Timeout: 5 * time.Minute, Execute: func(ctx context.Context) error {
+		if err := notifyRecordingReady(ctx, payload); err != nil {
+			return fmt.Errorf("deliver recording webhook for %s: %w", payload.EgressID, err)
+		}
+		return nil
+	}})
+}
@@ -30,7 +30,11 @@ deploy_backend() {
         rm -rf boom-prototype
         git clone "$REPO"
         cd boom-prototype/backend
-        docker build -t boom-backend:latest .
+        docker build -t boom-backend:latest \
+            --build-arg VERSION="\$(git describe --tags --always)" \
+            --build-arg GIT_SHA="\$(git rev-parse HEAD)" \
+            --build-arg BUILD_TIME="\$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+            .
         docker stop boom-backend 2>/dev/null || true
         docker rm boom-backend 2>/dev/null || true
         docker run -d --name boom-backend \
@@ -14,6 +14,7 @@ export default function App() {
           <Route path="/" element={<Home />} />
           <Route path="/login" element={<Login />} />
           <Route path="/join/:roomName" element={<Join />} />
+          <Route path="/join/slug/:slug" element={<Join />} />
           <Route path="/room/:roomName" element={<Room />} />
         </Routes>
       </BrowserRouter>
@@ -25,21 +25,22 @@ export default function EmailSubscription({ roomName, participantName }: EmailSu
     setError('');
 
     try {
-      const res = await fetch(`${BACKEND_URL}/api/meetings/${roomName}/subscribe-email`, {
+      const res = await fetch(`${BACKEND_URL}/api/meetings/${encodeURIComponent(roomName)}/subscribe-email`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ email, participantName }),
       });
 
       if (!res.ok) {
-        throw new Error('Failed to subscribe');
+        const data = await res.json().catch(() => ({}));
+        throw new Error(data.error?.message || 'Failed to subscribe. Please try again.');
       }
 
       setIsSubscribed(true);
       setSubscribedEmail(email);
       setEmail('');
     } catch (err) {
-      setError('Failed to subscribe. Please try again.');
+      setError(err instanceof Error ? err.message : 'Failed to subscribe. Please try again.');
     } finally {
       setLoading(false);
     }
@@ -48,7 +49,7 @@ export default function EmailSubscription({ roomName, participantName }: EmailSu
   const handleUnsubscribe = async () => {
     setLoading(true);
     try {
-      await fetch(`${BACKEND_URL}/api/meetings/${roomName}/unsubscribe-email`, {
+      await fetch(`${BACKEND_URL}/api/meetings/${encodeURIComponent(roomName)}/unsubscribe-email`, {
         method: 'DELETE',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ email: subscribedEmail }),
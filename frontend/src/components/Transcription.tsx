@@ -26,7 +26,7 @@ export default function Transcription({ roomName }: { roomName: string }) {
     }
 
     const wsUrl = BACKEND_URL.replace('http', 'ws').replace('https', 'wss');
-    const ws = new WebSocket(`${wsUrl}/ws/transcription/${roomName}`);
+    const ws = new WebSocket(`${wsUrl}/ws/transcription/${encodeURIComponent(roomName)}?v=2`);
     wsRef.current = ws;
 
     ws.onopen = () => {
@@ -38,7 +38,9 @@ export default function Transcription({ roomName }: { roomName: string }) {
 
     ws.onmessage = (event) => {
       try {
-        const data = JSON.parse(event.data);
+        const message = JSON.parse(event.data);
+        if (message.type !== 'transcript') return;
+        const data = message.payload;
         const entry: TranscriptEntry = {
           id: crypto.randomUUID(),
           speaker: data.speaker || 'Unknown',
@@ -32,6 +32,24 @@ export function AuthProvider({ children }: { children: ReactNode }) {
     }
   }, []);
 
+  // The backend renews idle-limited sessions by returning a fresh token on
+  // any authenticated response
+  useEffect(() => {
+    const originalFetch = window.fetch;
+    window.fetch = async (...args) => {
+      const res = await originalFetch(...args);
+      const refreshed = res.headers.get('X-Refreshed-Token');
+      if (refreshed) {
+        localStorage.setItem('boom_token', refreshed);
+        setToken(refreshed);
+      }
+      return res;
+    };
+    return () => {
+      window.fetch = originalFetch;
+    };
+  }, []);
+
   const checkAuth = async (t: string) => {
     try {
       const res = await fetch(`${BACKEND_URL}/api/auth/me`, {
@@ -61,7 +79,7 @@ export function AuthProvider({ children }: { children: ReactNode }) {
 
     if (!res.ok) {
       const data = await res.json();
-      throw new Error(data.error || 'Login failed');
+      throw new Error(data.error?.message || 'Login failed');
     }
 
     const data = await res.json();
@@ -74,7 +74,7 @@ export default function Home() {
       if (!res.ok) throw new Error('Failed to create room');
       const data = await res.json();
       sessionStorage.setItem('participantName', name || user?.name || 'Guest');
-      navigate(`/room/${data.roomName}`);
+      navigate(`/room/${encodeURIComponent(data.roomName)}`);
     } catch (err) {
       console.error('Failed to create room:', err);
       setError('Failed to create meeting. Please try again.');
@@ -86,7 +86,7 @@ export default function Home() {
   const joinMeeting = () => {
     if (!joinCode.trim()) return;
     sessionStorage.setItem('participantName', name || 'Guest');
-    navigate(`/room/${joinCode.trim()}`);
+    navigate(`/room/${encodeURIComponent(joinCode.trim())}`);
   };
 
   const scheduleMeeting = async (e: React.FormEvent) => {
@@ -139,7 +139,7 @@ export default function Home() {
       });
       if (!res.ok) throw new Error('Failed to start meeting');
       sessionStorage.setItem('participantName', user?.name || 'Host');
-      navigate(`/room/${meeting.roomName}`);
+      navigate(`/room/${encodeURIComponent(meeting.roomName)}`);
     } catch (err) {
       console.error('Failed to start meeting:', err);
     }
@@ -9,19 +9,25 @@ interface MeetingInfo {
   clientName: string;
   scheduledAt: string;
   status: string;
+  pinRequired: boolean;
 }
 
 export default function Join() {
-  const { roomName } = useParams<{ roomName: string }>();
+  const { roomName, slug } = useParams<{ roomName: string; slug: string }>();
+  // Vanity links look the meeting up by slug; the room comes from the response
+  const infoUrl = slug
+    ? `${BACKEND_URL}/api/join/slug/${encodeURIComponent(slug)}`
+    : `${BACKEND_URL}/api/join/${encodeURIComponent(roomName!)}`;
   const navigate = useNavigate();
   const [meeting, setMeeting] = useState<MeetingInfo | null>(null);
   const [name, setName] = useState('');
+  const [pin, setPin] = useState('');
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState('');
 
   useEffect(() => {
     fetchMeetingInfo();
-  }, [roomName]);
+  }, [infoUrl]);
 
   // Poll for status changes when meeting is scheduled (not yet active)
   useEffect(() => {
@@ -30,7 +36,7 @@ export default function Join() {
 
     const interval = setInterval(async () => {
       try {
-        const res = await fetch(`${BACKEND_URL}/api/join/${roomName}`);
+        const res = await fetch(infoUrl);
         if (res.ok) {
           const data = await res.json();
           setMeeting(data);
@@ -41,11 +47,11 @@ export default function Join() {
     }, 5000);
 
     return () => clearInterval(interval);
-  }, [meeting?.status, roomName]);
+  }, [meeting?.status, infoUrl]);
 
   const fetchMeetingInfo = async () => {
     try {
-      const res = await fetch(`${BACKEND_URL}/api/join/${roomName}`);
+      const res = await fetch(infoUrl);
       if (!res.ok) {
         setError('Meeting not found');
         return;
@@ -62,10 +68,17 @@ export default function Join() {
     }
   };
 
+  const canJoin = name.trim() !== '' && (!meeting?.pinRequired || pin.trim() !== '');
+
   const joinRoom = () => {
-    if (!name.trim()) return;
+    if (!canJoin) return;
     sessionStorage.setItem('participantName', name.trim());
-    navigate(`/room/${roomName}`);
+    if (meeting?.pinRequired) {
+      sessionStorage.setItem('joinPin', pin.trim());
+    } else {
+      sessionStorage.removeItem('joinPin');
+    }
+    navigate(`/room/${encodeURIComponent(meeting?.roomName ?? roomName!)}`);
   };
 
   if (loading) {
@@ -157,11 +170,31 @@ export default function Join() {
             />
           </div>
 
+          {/* PIN input */}
+          {meeting?.pinRequired && (
+            <div className="mb-4">
+              <label className="block text-sm font-medium text-slate-300 mb-1.5">
+                Meeting PIN <span className="text-[#D93D1A]">*</span>
+              </label>
+              <input
+                type="password"
+                inputMode="numeric"
+                autoComplete="off"
+                required
+                value={pin}
+                onChange={(e) => setPin(e.target.value)}
+                placeholder="Enter the PIN from your invitation"
+                className="w-full px-4 py-2.5 bg-slate-700 border border-slate-600 rounded-lg text-white placeholder-slate-400 focus:outline-none focus:ring-2 focus:ring-[#2B88D9] focus:border-transparent"
+                onKeyDown={(e) => e.key === 'Enter' && isActive && joinRoom()}
+              />
+            </div>
+          )}
+
           {/* Join button */}
           {isActive ? (
             <button
               onClick={joinRoom}
-              disabled={!name.trim()}
+              disabled={!canJoin}
               className="w-full py-2.5 px-4 bg-[#0396A6] hover:bg-[#027d8a] text-white font-medium rounded-lg transition-colors disabled:opacity-50 disabled:cursor-not-allowed"
             >
               Join Meeting
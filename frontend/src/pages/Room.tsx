@@ -12,6 +12,7 @@ import ErrorBoundary from '../components/ErrorBoundary';
 import BackgroundToggle from '../components/BackgroundToggle';
 import NotesModal from '../components/NotesModal';
 import EmailSubscription from '../components/EmailSubscription';
+import { useAuth } from '../context/AuthContext';
 
 const BACKEND_URL = import.meta.env.VITE_BACKEND_URL || 'http://localhost:8080';
 const LIVEKIT_URL = import.meta.env.VITE_LIVEKIT_URL;
@@ -50,17 +51,25 @@ export default function Room() {
   const [token, setToken] = useState<string>('');
   const [error, setError] = useState<string>('');
   const [wasConnected, setWasConnected] = useState(false);
+  const { token: authToken, isAuthenticated, loading: authLoading } = useAuth();
 
   useEffect(() => {
+    // Wait for the session check so a stale login is not sent
+    if (authLoading) return;
     const participantName = sessionStorage.getItem('participantName') || 'Guest';
+    const joinPin = sessionStorage.getItem('joinPin') || undefined;
 
-    // Get token from backend
+    // Get token from backend. Signed-in hosts skip the meeting PIN.
     fetch(`${BACKEND_URL}/api/token`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: {
+        'Content-Type': 'application/json',
+        ...(isAuthenticated && authToken ? { Authorization: `Bearer ${authToken}` } : {}),
+      },
       body: JSON.stringify({
         roomName,
         participantName,
+        joinPin,
       }),
     })
       .then((res) => res.json())
@@ -68,14 +77,14 @@ export default function Room() {
         if (data.token) {
           setToken(data.token);
         } else {
-          setError('Failed to get access token');
+          setError(data.error?.message || 'Failed to get access token');
         }
       })
       .catch((err) => {
         console.error('Token error:', err);
         setError('Failed to connect to server');
       });
-  }, [roomName]);
+  }, [roomName, authLoading]);
 
   const handleDisconnect = (reason?: DisconnectReason) => {
     console.log('LiveKit disconnected, reason:', reason, 'wasConnected:', wasConnected);
@@ -149,7 +158,7 @@ function RoomContent({ roomName, onLeave }: { roomName: string; onLeave: () => v
 
   const startTranscription = async () => {
     try {
-      const res = await fetch(`${BACKEND_URL}/api/meetings/${roomName}/start-transcription`, {
+      const res = await fetch(`${BACKEND_URL}/api/meetings/${encodeURIComponent(roomName)}/start-transcription`, {
         method: 'POST',
       });
       const data = await res.json();
@@ -172,7 +181,7 @@ function RoomContent({ roomName, onLeave }: { roomName: string; onLeave: () => v
 
     try {
       // End transcription and generate notes
-      const res = await fetch(`${BACKEND_URL}/api/meetings/${roomName}/end-transcription`, {
+      const res = await fetch(`${BACKEND_URL}/api/meetings/${encodeURIComponent(roomName)}/end-transcription`, {
         method: 'POST',
       });
       const data = await res.json();
@@ -199,7 +208,7 @@ function RoomContent({ roomName, onLeave }: { roomName: string; onLeave: () => v
 
     const poll = async () => {
       try {
-        const res = await fetch(`${BACKEND_URL}/api/meetings/${roomName}/notes`);
+        const res = await fetch(`${BACKEND_URL}/api/meetings/${encodeURIComponent(roomName)}/notes`);
 
         if (res.ok) {
           const data = await res.json();
@@ -333,7 +342,7 @@ function CopyLinkButton({ roomName }: { roomName: string }) {
   const [copied, setCopied] = useState(false);
 
   const copyLink = () => {
-    const url = `${window.location.origin}/room/${roomName}`;
+    const url = `${window.location.origin}/room/${encodeURIComponent(roomName)}`;
     navigator.clipboard.writeText(url);
     setCopied(true);
     setTimeout(() => setCopied(false), 2000);